@@ -0,0 +1,298 @@
+// Пакет client предоставляет типизированный клиент api электронной библиотеки.
+//
+// Клиент входит в систему методом [Client.Login] и хранит пару токенов сессии.
+// Когда срок действия токена доступа истекает, клиент сам обновляет пару токенов
+// запросом PUT /auth/session и повторяет исходный запрос.
+//
+// Ответы с ошибкой по RFC 7807 возвращаются как [*ProblemError], который с помощью [errors.Is]
+// сравнивается с ошибками пакета errs (например, [ErrNotFound] или [ErrVersionMismatch]).
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+)
+
+// Client представляет клиент api электронной библиотеки. Клиент безопасен для одновременного использования.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+
+	mu           sync.Mutex
+	accessToken  string // jwt токен доступа
+	refreshToken string // токен обновления
+}
+
+// NewClient возвращает новый экземпляр [Client] для сервера по адресу baseURL (например, http://localhost:8080).
+// Если httpClient равен nil, то используется [http.DefaultClient].
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// Tokens возвращает текущие токен доступа и токен обновления, например для сохранения между запусками.
+func (c *Client) Tokens() (accessToken, refreshToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.accessToken, c.refreshToken
+}
+
+// SetTokens задает токен доступа и токен обновления, полученные ранее.
+func (c *Client) SetTokens(accessToken, refreshToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.accessToken, c.refreshToken = accessToken, refreshToken
+}
+
+// tokenPair представляет пару токенов сессии в ответе сервера.
+type tokenPair struct {
+	AccessToken  string `json:"accessToken"`  // jwt токен доступа
+	RefreshToken string `json:"refreshToken"` // токен обновления
+}
+
+// Login входит в систему с именем пользователя username и паролем password и сохраняет пару токенов.
+// Если логин или пароль неверны, то возвращается ошибка, сравнимая с [ErrInvalidLogin] и [ErrInvalidPassword].
+func (c *Client) Login(ctx context.Context, username, password string) error {
+	var tokens tokenPair
+	if err := c.json(ctx, http.MethodPost, "/auth/session", nil, model.Credentials{Username: username, Password: password}, &tokens); err != nil {
+		return fmt.Errorf("login: %w", err)
+	}
+	c.SetTokens(tokens.AccessToken, tokens.RefreshToken)
+	return nil
+}
+
+// Logout завершает сессию и забывает пару токенов.
+func (c *Client) Logout(ctx context.Context) error {
+	_, refreshToken := c.Tokens()
+	if err := c.json(ctx, http.MethodDelete, "/auth/session", nil, refreshTokenBody(refreshToken), nil); err != nil {
+		return fmt.Errorf("logout: %w", err)
+	}
+	c.SetTokens("", "")
+	return nil
+}
+
+// refresh обновляет пару токенов, если токен доступа все еще равен stale.
+// Если другой запрос уже обновил токены, то ничего не делается.
+func (c *Client) refresh(ctx context.Context, stale string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.accessToken != stale {
+		return nil
+	}
+	body, err := json.Marshal(refreshTokenBody(c.refreshToken))
+	if err != nil {
+		return fmt.Errorf("encode refresh token: %w", err)
+	}
+	resp, err := c.send(ctx, request{method: http.MethodPut, path: "/auth/session", body: body, contentType: "application/json"}, "")
+	if err != nil {
+		return fmt.Errorf("refresh session: %w", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		return fmt.Errorf("refresh session: %w", newProblemError(resp))
+	}
+	var tokens tokenPair
+	if err := decodeJSON(resp, &tokens); err != nil {
+		return fmt.Errorf("refresh session: %w", err)
+	}
+	c.accessToken, c.refreshToken = tokens.AccessToken, tokens.RefreshToken
+	return nil
+}
+
+// refreshTokenBody оборачивает токен обновления в тело запроса группы /auth.
+func refreshTokenBody(refreshToken string) map[string]string {
+	return map[string]string{"refreshToken": refreshToken}
+}
+
+// request описывает запрос к api.
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	body        []byte
+	contentType string
+	version     int   // версия для заголовка If-Match (0 — без проверки версии)
+	accept      []int // коды ответа с ошибкой, тело которых возвращается как успешный ответ
+}
+
+// do выполняет запрос и возвращает ответ или ошибку. Тело ответа закрывает вызывающий.
+// Если запрос к группе /api отклонен с кодом 401 и у клиента есть токен обновления,
+// то пара токенов обновляется и запрос повторяется один раз.
+func (c *Client) do(ctx context.Context, req request) (*http.Response, error) {
+	accessToken, refreshToken := c.Tokens()
+	resp, err := c.send(ctx, req, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && strings.HasPrefix(req.path, "/api/") && refreshToken != "" {
+		resp.Body.Close()
+		if err := c.refresh(ctx, accessToken); err != nil {
+			return nil, err
+		}
+		accessToken, _ = c.Tokens()
+		if resp, err = c.send(ctx, req, accessToken); err != nil {
+			return nil, err
+		}
+	}
+	if resp.StatusCode >= http.StatusBadRequest && !slices.Contains(req.accept, resp.StatusCode) {
+		defer resp.Body.Close()
+		return nil, newProblemError(resp)
+	}
+	return resp, nil
+}
+
+// send отправляет запрос с токеном доступа accessToken, если он не пуст.
+func (c *Client) send(ctx context.Context, req request, accessToken string) (*http.Response, error) {
+	target := c.baseURL + req.path
+	if len(req.query) > 0 {
+		target += "?" + req.query.Encode()
+	}
+	var body io.Reader
+	if req.body != nil {
+		body = bytes.NewReader(req.body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("new request %v %v: %w", req.method, req.path, err)
+	}
+	if req.contentType != "" {
+		httpReq.Header.Set("Content-Type", req.contentType)
+	}
+	if accessToken != "" && strings.HasPrefix(req.path, "/api/") {
+		httpReq.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	if req.version != 0 {
+		httpReq.Header.Set("If-Match", strconv.Quote(strconv.Itoa(req.version)))
+	}
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("%v %v: %w", req.method, req.path, err)
+	}
+	return resp, nil
+}
+
+// json выполняет запрос с телом in в формате json (если оно не nil)
+// и декодирует тело ответа в out (если он не nil).
+func (c *Client) json(ctx context.Context, method, path string, query url.Values, in, out any) error {
+	req := request{method: method, path: path, query: query}
+	if in != nil {
+		body, err := jsonBody(in)
+		if err != nil {
+			return err
+		}
+		req.body, req.contentType = body, "application/json"
+	}
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return err
+	}
+	return decodeJSON(resp, out)
+}
+
+// jsonBody кодирует тело запроса in в формате json.
+func jsonBody(in any) ([]byte, error) {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return nil, fmt.Errorf("encode %T: %w", in, err)
+	}
+	return body, nil
+}
+
+// decodeJSON декодирует тело ответа resp в формате json в out и закрывает его.
+// Если out равен nil, то тело ответа пропускается.
+func decodeJSON(resp *http.Response, out any) error {
+	defer resp.Body.Close()
+	if out == nil {
+		_, err := io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %T: %w", out, err)
+	}
+	return nil
+}
+
+// patchBody кодирует патч из пакета model в формате JSON Merge Patch:
+// в тело попадают только указанные поля, а удаляемые значения передаются как null.
+func patchBody(patch any) ([]byte, error) {
+	v := reflect.Indirect(reflect.ValueOf(patch))
+	fields := make(map[string]any)
+	for i := range v.NumField() {
+		field, ok := v.Field(i).Interface().(model.PatchField)
+		if !ok || !field.IsSet() {
+			continue
+		}
+		name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+		fields[name] = field.PatchValue()
+	}
+	return json.Marshal(fields)
+}
+
+// queryValues возвращает параметры запроса из полей фильтра с тегом query.
+// Пустые указатели и нулевые значения пропускаются.
+func queryValues(filter any) url.Values {
+	values := make(url.Values)
+	v := reflect.ValueOf(filter)
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return values
+		}
+		v = v.Elem()
+	}
+	for i := range v.NumField() {
+		name := v.Type().Field(i).Tag.Get("query")
+		field := reflect.Indirect(v.Field(i))
+		if name == "" || !field.IsValid() || field.IsZero() {
+			continue
+		}
+		switch value := field.Interface().(type) {
+		case time.Time:
+			values.Set(name, value.Format(time.RFC3339Nano))
+		default:
+			values.Set(name, fmt.Sprint(value))
+		}
+	}
+	return values
+}
+
+// multipartBody возвращает тело формы multipart/form-data с полями fields
+// и файлом fileName из content в поле fileField, а также тип содержимого формы.
+func multipartBody(fields map[string]string, fileField, fileName string, content io.Reader) ([]byte, string, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if err := form.WriteField(name, value); err != nil {
+			return nil, "", fmt.Errorf("write field %v: %w", name, err)
+		}
+	}
+	file, err := form.CreateFormFile(fileField, fileName)
+	if err != nil {
+		return nil, "", fmt.Errorf("create form file %v: %w", fileField, err)
+	}
+	if _, err := io.Copy(file, content); err != nil {
+		return nil, "", fmt.Errorf("copy %v: %w", fileName, err)
+	}
+	if err := form.Close(); err != nil {
+		return nil, "", fmt.Errorf("close form: %w", err)
+	}
+	return body.Bytes(), form.FormDataContentType(), nil
+}
@@ -0,0 +1,293 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/foreverd34d/aumsu-elib/internal/app"
+	"github.com/foreverd34d/aumsu-elib/internal/handler"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+	"github.com/foreverd34d/aumsu-elib/internal/repo/memory"
+	"github.com/foreverd34d/aumsu-elib/internal/service"
+	"github.com/foreverd34d/aumsu-elib/internal/storage"
+)
+
+const testSigningKey = "test-signing-key"
+
+// newTestClient запускает сервер, собранный из [app.NewApp] с репозиториями из пакета memory,
+// и возвращает клиент, вошедший в систему администратором, и хранилище сервера.
+func newTestClient(t *testing.T) (*Client, *memory.Store) {
+	t.Helper()
+	store := memory.NewStore()
+	files := storage.NewMemory()
+	auditRepo := memory.NewAuditRepo(store)
+	userRepo := memory.NewUserRepo(store)
+	groupRepo := memory.NewGroupRepo(store)
+	specialtyRepo := memory.NewSpecialtyRepo(store)
+	materialRepo := memory.NewMaterialRepo(store)
+	sessions := service.NewSessionService(userRepo, memory.NewSessionRepo(store), auditRepo, []byte(testSigningKey), service.DefaultSessionPolicy())
+	trash := service.NewTrashService(memory.NewTrashRepo(store), auditRepo, time.Hour)
+	jobs := service.NewJobService(memory.NewJobRepo(store), memory.NewLocker(store))
+	jobs.Add(service.Job{Name: "expired-tokens", Description: "Удаление истекших токенов", Interval: time.Hour, Run: sessions.DeleteExpiredTokens})
+	jobs.Add(service.Job{Name: "trash-purge", Description: "Очистка корзины", Interval: time.Hour, Run: trash.Purge})
+	h := &handler.Handler{
+		User:         service.NewUserService(userRepo, auditRepo, files),
+		Session:      sessions,
+		Group:        service.NewGroupService(groupRepo, auditRepo),
+		Specialty:    service.NewSpecialtyService(specialtyRepo, auditRepo),
+		Department:   service.NewDepartmentService(memory.NewDepartmentRepo(store), auditRepo),
+		Discipline:   service.NewDisciplineService(memory.NewDisciplineRepo(store), auditRepo),
+		Audit:        service.NewAuditService(auditRepo),
+		Import:       service.NewImportService(userRepo, groupRepo, auditRepo),
+		Rollover:     service.NewRolloverService(groupRepo, userRepo, specialtyRepo, auditRepo),
+		MaterialType: service.NewMaterialTypeService(memory.NewMaterialTypeRepo(store), auditRepo),
+		Material:     service.NewMaterialService(materialRepo, auditRepo, files),
+		Lesson:       service.NewLessonService(memory.NewLessonRepo(store), materialRepo),
+		Catalog:      service.NewCatalogService(memory.NewCatalogRepo(store)),
+		Trash:        trash,
+		Job:          jobs,
+	}
+	e := app.NewApp(h, testSigningKey)
+	e.Logger.SetOutput(io.Discard)
+	server := httptest.NewServer(e)
+	t.Cleanup(server.Close)
+
+	hash := sha256.Sum256([]byte("password"))
+	_, err := userRepo.Create(context.Background(), &model.NewUser{
+		Name:     "Иван",
+		Surname:  "Иванов",
+		Login:    "admin",
+		Password: hex.EncodeToString(hash[:]),
+		RoleID:   4,
+	})
+	if err != nil {
+		t.Fatalf("create admin: %v", err)
+	}
+
+	c := NewClient(server.URL, server.Client())
+	if err := c.Login(context.Background(), "admin", "password"); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	return c, store
+}
+
+func TestLogin(t *testing.T) {
+	c, _ := newTestClient(t)
+	ctx := context.Background()
+
+	err := c.Login(ctx, "admin", "wrong")
+	if !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("login with a wrong password: got %v, want %v", err, ErrInvalidPassword)
+	}
+	var problem *ProblemError
+	if !errors.As(err, &problem) || problem.Status != 401 || problem.Code != "invalid_credentials" {
+		t.Errorf("login with a wrong password: got problem %+v", problem)
+	}
+
+	profile, err := c.Me().Get(ctx)
+	if err != nil {
+		t.Fatalf("get profile: %v", err)
+	}
+	if profile.RoleName != "admin" {
+		t.Errorf("profile role: got %q, want admin", profile.RoleName)
+	}
+
+	if err := c.Logout(ctx); err != nil {
+		t.Fatalf("logout: %v", err)
+	}
+	if _, err := c.Me().Get(ctx); err == nil {
+		t.Error("get profile after logout: got no error")
+	}
+}
+
+func TestTokenRefresh(t *testing.T) {
+	c, _ := newTestClient(t)
+	ctx := context.Background()
+	_, refreshToken := c.Tokens()
+
+	// Недействительный токен доступа ведет себя так же, как истекший.
+	c.SetTokens("expired", refreshToken)
+	if _, err := c.Departments().List(ctx); err != nil {
+		t.Fatalf("list departments with an expired token: %v", err)
+	}
+	accessToken, newRefreshToken := c.Tokens()
+	if accessToken == "expired" || newRefreshToken == refreshToken {
+		t.Error("tokens were not refreshed")
+	}
+
+	c.SetTokens("expired", refreshToken)
+	if _, err := c.Departments().List(ctx); !errors.Is(err, ErrNotFound) {
+		t.Errorf("list departments with a used refresh token: got %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestReferenceData(t *testing.T) {
+	c, _ := newTestClient(t)
+	ctx := context.Background()
+
+	departmentID, err := c.Departments().Create(ctx, &NewDepartment{Name: "Кафедра"})
+	if err != nil {
+		t.Fatalf("create department: %v", err)
+	}
+	specialtyID, err := c.Specialties().Create(ctx, &NewSpecialty{Name: "Связь", DepartmentID: departmentID})
+	if err != nil {
+		t.Fatalf("create specialty: %v", err)
+	}
+	if _, err := c.Specialties().Create(ctx, &NewSpecialty{Name: "Связь", DepartmentID: departmentID + 100}); !errors.Is(err, ErrInvalidReference) {
+		t.Errorf("create specialty with a missing department: got %v, want %v", err, ErrInvalidReference)
+	}
+
+	specialty, err := c.Specialties().Get(ctx, specialtyID)
+	if err != nil {
+		t.Fatalf("get specialty: %v", err)
+	}
+	if err := c.Specialties().Patch(ctx, specialtyID, specialty.Version, &SpecialtyPatch{Name: Set("Радиотехника")}); err != nil {
+		t.Fatalf("patch specialty: %v", err)
+	}
+	update := &NewSpecialty{Name: "Связь", DepartmentID: departmentID}
+	if err := c.Specialties().Update(ctx, specialtyID, specialty.Version, update); !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("update stale specialty: got %v, want %v", err, ErrVersionMismatch)
+	}
+	specialties, err := c.Specialties().List(ctx)
+	if err != nil {
+		t.Fatalf("list specialties: %v", err)
+	}
+	if len(specialties) != 1 || specialties[0].Name != "Радиотехника" {
+		t.Errorf("list specialties: got %+v", specialties)
+	}
+
+	if err := c.Departments().Delete(ctx, departmentID, 0); !errors.Is(err, ErrReferencedBy) {
+		t.Errorf("delete referenced department: got %v, want %v", err, ErrReferencedBy)
+	}
+	if err := c.Specialties().Delete(ctx, specialtyID, 0); err != nil {
+		t.Fatalf("delete specialty: %v", err)
+	}
+	if _, err := c.Specialties().Get(ctx, specialtyID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("get deleted specialty: got %v, want %v", err, ErrNotFound)
+	}
+	if err := c.Trash().Restore(ctx, TrashSpecialty, specialtyID); err != nil {
+		t.Fatalf("restore specialty: %v", err)
+	}
+
+	tree, err := c.CatalogTree(ctx, &CatalogFilter{Depth: 2})
+	if err != nil {
+		t.Fatalf("catalog tree: %v", err)
+	}
+	if len(tree) != 1 || len(tree[0].Specialties) != 1 {
+		t.Errorf("catalog tree: got %+v", tree)
+	}
+}
+
+func TestUsers(t *testing.T) {
+	c, _ := newTestClient(t)
+	ctx := context.Background()
+
+	userID, err := c.Users().Create(ctx, &NewUser{Name: "Петр", Surname: "Петров", Login: "petrov", Password: "secret", RoleID: 2})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if _, err := c.Users().Create(ctx, &NewUser{Name: "Петр", Surname: "Петров", Login: "petrov", Password: "secret", RoleID: 2}); !errors.Is(err, ErrConflict) {
+		t.Errorf("create user with a taken login: got %v, want %v", err, ErrConflict)
+	}
+
+	patch := &UserPatch{Rank: SetNullable("майор"), Patronymic: Null[string]()}
+	if err := c.Users().Patch(ctx, userID, 0, patch); err != nil {
+		t.Fatalf("patch user: %v", err)
+	}
+	roleID := 2
+	users, err := c.Users().List(ctx, &UserFilter{RoleID: &roleID})
+	if err != nil {
+		t.Fatalf("list users: %v", err)
+	}
+	if len(users) != 1 || users[0].Rank == nil || *users[0].Rank != "майор" {
+		t.Errorf("list teachers: got %+v", users)
+	}
+
+	events, err := c.AuditEvents(ctx, &AuditFilter{EntityID: &userID})
+	if err != nil {
+		t.Fatalf("list audit events: %v", err)
+	}
+	if len(events) != 2 {
+		t.Errorf("audit events of the user: got %+v, want 2", events)
+	}
+}
+
+func TestMaterials(t *testing.T) {
+	c, store := newTestClient(t)
+	ctx := context.Background()
+	lesson := store.AddLesson(model.Lesson{ChapterID: 1, TypeID: 1})
+
+	typeID, err := c.MaterialTypes().Create(ctx, &NewMaterialType{Name: "Статья"})
+	if err != nil {
+		t.Fatalf("create material type: %v", err)
+	}
+	materialID, err := c.Materials().Create(ctx, &NewMaterial{Name: "Статья о связи", TypeID: typeID}, "article.pdf", strings.NewReader("%PDF-1.4"))
+	if err != nil {
+		t.Fatalf("create material: %v", err)
+	}
+
+	file, contentType, err := c.Materials().File(ctx, materialID)
+	if err != nil {
+		t.Fatalf("get material file: %v", err)
+	}
+	content, _ := io.ReadAll(file)
+	file.Close()
+	if string(content) != "%PDF-1.4" || contentType != "application/pdf" {
+		t.Errorf("material file: got %q of type %q", content, contentType)
+	}
+
+	if err := c.Lessons().AttachMaterial(ctx, lesson.ID, materialID); err != nil {
+		t.Fatalf("attach material: %v", err)
+	}
+	got, err := c.Lessons().Get(ctx, lesson.ID)
+	if err != nil {
+		t.Fatalf("get lesson: %v", err)
+	}
+	if len(got.Materials) != 1 || got.Materials[0].ID != materialID {
+		t.Errorf("lesson materials: got %+v", got.Materials)
+	}
+	if err := c.Lessons().DetachMaterial(ctx, lesson.ID, materialID); err != nil {
+		t.Fatalf("detach material: %v", err)
+	}
+	if err := c.Lessons().DetachMaterial(ctx, lesson.ID, materialID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("detach detached material: got %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestJobs(t *testing.T) {
+	c, _ := newTestClient(t)
+	ctx := context.Background()
+
+	run, err := c.Jobs().Run(ctx, "expired-tokens")
+	if err != nil {
+		t.Fatalf("run job: %v", err)
+	}
+	if run.Status != model.JobSucceeded {
+		t.Errorf("run job: got %+v", run)
+	}
+	if _, err := c.Jobs().Run(ctx, "unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("run unknown job: got %v, want %v", err, ErrNotFound)
+	}
+	jobs, err := c.Jobs().List(ctx)
+	if err != nil {
+		t.Fatalf("list jobs: %v", err)
+	}
+	if len(jobs) != 2 || jobs[0].LastRun == nil || jobs[0].LastRun.ID != run.ID {
+		t.Errorf("list jobs: got %+v", jobs)
+	}
+	job := "expired-tokens"
+	runs, err := c.Jobs().Runs(ctx, &JobRunFilter{Job: &job})
+	if err != nil {
+		t.Fatalf("job runs: %v", err)
+	}
+	if len(runs) != 1 || runs[0].ID != run.ID {
+		t.Errorf("job runs: got %+v", runs)
+	}
+}
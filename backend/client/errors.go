@@ -0,0 +1,74 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+)
+
+// Ошибки пакета errs, с которыми сравнивается [*ProblemError].
+var (
+	ErrNotFound               = errs.NotFound               // ресурс не найден
+	ErrInternal               = errs.Internal               // внутренняя ошибка сервера
+	ErrInvalidPassword        = errs.InvalidPassword        // неверный логин или пароль
+	ErrInvalidLogin           = errs.InvalidLogin           // неверный логин или пароль
+	ErrRefreshExpired         = errs.RefreshExpired         // сессия истекла, нужно войти заново
+	ErrUnsupportedMedia       = errs.UnsupportedMedia       // формат файла не поддерживается
+	ErrDeactivated            = errs.Deactivated            // учетная запись отключена
+	ErrConflict               = errs.Conflict               // значение должно быть уникальным, но уже занято
+	ErrReferencedBy           = errs.ReferencedBy           // на ресурс ссылаются другие ресурсы
+	ErrInvalidReference       = errs.InvalidReference       // ресурс ссылается на несуществующий ресурс
+	ErrVersionMismatch        = errs.VersionMismatch        // ресурс изменился с тех пор, как его получил клиент
+	ErrPasswordChangeRequired = errs.PasswordChangeRequired // нужно сменить одноразовый пароль и обновить токены
+)
+
+// problemErrors сопоставляет коды ошибок в ответах сервера с ошибками пакета errs.
+// Сервер не различает неверный логин и неверный пароль, поэтому их код соответствует обеим ошибкам.
+var problemErrors = map[string][]error{
+	"not_found":                {errs.NotFound},
+	"internal":                 {errs.Internal},
+	"invalid_credentials":      {errs.InvalidLogin, errs.InvalidPassword},
+	"refresh_expired":          {errs.RefreshExpired},
+	"unsupported_media_type":   {errs.UnsupportedMedia},
+	"account_deactivated":      {errs.Deactivated},
+	"conflict":                 {errs.Conflict},
+	"referenced":               {errs.ReferencedBy},
+	"invalid_reference":        {errs.InvalidReference},
+	"version_mismatch":         {errs.VersionMismatch},
+	"password_change_required": {errs.PasswordChangeRequired},
+}
+
+// ProblemError представляет ответ сервера с ошибкой по RFC 7807.
+// С помощью [errors.Is] ошибка сравнивается с ошибками пакета errs по коду ошибки.
+type ProblemError struct {
+	model.Problem
+}
+
+func (pe *ProblemError) Error() string {
+	msg := fmt.Sprintf("%v %v: %v", pe.Status, pe.Code, pe.Title)
+	if pe.Detail != "" {
+		msg += ": " + pe.Detail
+	}
+	return msg
+}
+
+func (pe *ProblemError) Is(target error) bool {
+	return slices.Contains(problemErrors[pe.Code], target)
+}
+
+// newProblemError возвращает описание ошибки из ответа resp.
+// Если тело ответа не является описанием ошибки, то описание строится по коду ответа.
+func newProblemError(resp *http.Response) *ProblemError {
+	pe := new(ProblemError)
+	body, _ := io.ReadAll(resp.Body)
+	if err := json.Unmarshal(body, &pe.Problem); err != nil || pe.Code == "" {
+		pe.Problem = model.Problem{Title: http.StatusText(resp.StatusCode), Detail: string(body), Code: "error"}
+	}
+	pe.Status = resp.StatusCode
+	return pe
+}
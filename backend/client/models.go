@@ -0,0 +1,68 @@
+package client
+
+import "github.com/foreverd34d/aumsu-elib/internal/model"
+
+// Модели api. Псевдонимы позволяют пользоваться моделями за пределами модуля сервера.
+type (
+	User              = model.User              // пользователь
+	NewUser           = model.NewUser           // данные нового пользователя
+	UserPatch         = model.UserPatch         // патч пользователя
+	UserFilter        = model.UserFilter        // условия отбора пользователей
+	Profile           = model.Profile           // профиль текущего пользователя
+	ProfileUpdate     = model.ProfileUpdate     // изменение профиля
+	Group             = model.Group             // взвод
+	NewGroup          = model.NewGroup          // данные нового взвода
+	GroupPatch        = model.GroupPatch        // патч взвода
+	GroupFilter       = model.GroupFilter       // условия отбора взводов
+	Specialty         = model.Specialty         // специальность
+	NewSpecialty      = model.NewSpecialty      // данные новой специальности
+	SpecialtyPatch    = model.SpecialtyPatch    // патч специальности
+	Department        = model.Department        // кафедра
+	NewDepartment     = model.NewDepartment     // данные новой кафедры
+	DepartmentPatch   = model.DepartmentPatch   // патч кафедры
+	Discipline        = model.Discipline        // предмет
+	NewDiscipline     = model.NewDiscipline     // данные нового предмета
+	DisciplinePatch   = model.DisciplinePatch   // патч предмета
+	MaterialType      = model.MaterialType      // вид материала
+	NewMaterialType   = model.NewMaterialType   // данные нового вида материала
+	MaterialTypePatch = model.MaterialTypePatch // патч вида материала
+	Material          = model.Material          // дополнительный материал
+	NewMaterial       = model.NewMaterial       // данные нового материала
+	MaterialPatch     = model.MaterialPatch     // патч материала
+	Lesson            = model.Lesson            // занятие
+	CatalogDepartment = model.CatalogDepartment // кафедра в дереве каталога
+	CatalogFilter     = model.CatalogFilter     // параметры дерева каталога
+	Rollover          = model.Rollover          // изменения взводов при переходе на новый учебный год
+	RolloverPlan      = model.RolloverPlan      // план перехода на новый учебный год
+	ImportReport      = model.ImportReport      // отчет об импорте студентов
+	AuditEvent        = model.AuditEvent        // запись журнала аудита
+	AuditFilter       = model.AuditFilter       // условия отбора записей журнала аудита
+	TrashItem         = model.TrashItem         // запись в корзине
+	Job               = model.Job               // задача обслуживания
+	JobRun            = model.JobRun            // запись истории запусков задачи обслуживания
+	JobRunFilter      = model.JobRunFilter      // условия отбора записей истории запусков
+	Problem           = model.Problem           // описание ошибки по RFC 7807
+)
+
+// Названия сущностей в корзине для [Trash.Restore].
+const (
+	TrashDepartment = model.TrashDepartment // кафедра
+	TrashSpecialty  = model.TrashSpecialty  // специальность
+	TrashDiscipline = model.TrashDiscipline // предмет
+	TrashUser       = model.TrashUser       // пользователь
+)
+
+// Set возвращает поле патча, которое заменяет значение на value.
+func Set[T any](value T) model.Optional[T] {
+	return model.Optional[T]{Set: true, Value: value}
+}
+
+// SetNullable возвращает поле патча, допускающее null, которое заменяет значение на value.
+func SetNullable[T any](value T) model.Nullable[T] {
+	return model.Nullable[T]{Set: true, Value: &value}
+}
+
+// Null возвращает поле патча, которое удаляет значение.
+func Null[T any]() model.Nullable[T] {
+	return model.Nullable[T]{Set: true}
+}
@@ -0,0 +1,482 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+)
+
+// createdID представляет ответ сервера на создание сущности.
+type createdID struct {
+	ID int `json:"ID"` // номер созданной сущности
+}
+
+// crud реализует общие методы справочника: получение, создание, изменение и удаление сущностей.
+// T — сущность, N — данные новой сущности, P — патч сущности.
+type crud[T, N, P any] struct {
+	c    *Client
+	path string // путь справочника, например /api/departments
+}
+
+// itemPath возвращает путь сущности с номером ID.
+func (r crud[T, N, P]) itemPath(ID int) string {
+	return r.path + "/" + strconv.Itoa(ID)
+}
+
+// List возвращает все сущности справочника.
+func (r crud[T, N, P]) List(ctx context.Context) ([]T, error) {
+	var items []T
+	if err := r.c.json(ctx, http.MethodGet, r.path, nil, nil, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Get возвращает сущность по номеру. Текущая версия сущности находится в ее поле Version.
+// Если сущность не нашлась, то возвращается ошибка, сравнимая с [ErrNotFound].
+func (r crud[T, N, P]) Get(ctx context.Context, ID int) (*T, error) {
+	item := new(T)
+	if err := r.c.json(ctx, http.MethodGet, r.itemPath(ID), nil, nil, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// Create создает сущность и возвращает ее номер.
+func (r crud[T, N, P]) Create(ctx context.Context, input *N) (int, error) {
+	var created createdID
+	if err := r.c.json(ctx, http.MethodPost, r.path, nil, input, &created); err != nil {
+		return 0, err
+	}
+	return created.ID, nil
+}
+
+// Update заменяет сущность с номером ID.
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка, сравнимая с [ErrVersionMismatch].
+func (r crud[T, N, P]) Update(ctx context.Context, ID, version int, input *N) error {
+	body, err := jsonBody(input)
+	if err != nil {
+		return err
+	}
+	return r.c.exec(ctx, request{method: http.MethodPut, path: r.itemPath(ID), body: body, contentType: "application/json", version: version})
+}
+
+// Patch изменяет указанные в патче поля сущности с номером ID.
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка, сравнимая с [ErrVersionMismatch].
+func (r crud[T, N, P]) Patch(ctx context.Context, ID, version int, patch *P) error {
+	body, err := patchBody(patch)
+	if err != nil {
+		return fmt.Errorf("encode %T: %w", patch, err)
+	}
+	return r.c.exec(ctx, request{method: http.MethodPatch, path: r.itemPath(ID), body: body, contentType: model.MergePatchContentType, version: version})
+}
+
+// Delete удаляет сущность с номером ID.
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка, сравнимая с [ErrVersionMismatch].
+func (r crud[T, N, P]) Delete(ctx context.Context, ID, version int) error {
+	return r.c.exec(ctx, request{method: http.MethodDelete, path: r.itemPath(ID), version: version})
+}
+
+// exec выполняет запрос, ответ на который не содержит тела, и возвращает ошибку, если она есть.
+func (c *Client) exec(ctx context.Context, req request) error {
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return err
+	}
+	return decodeJSON(resp, nil)
+}
+
+// download выполняет запрос файла и возвращает тело ответа и его тип содержимого.
+func (c *Client) download(ctx context.Context, path string) (io.ReadCloser, string, error) {
+	resp, err := c.do(ctx, request{method: http.MethodGet, path: path})
+	if err != nil {
+		return nil, "", err
+	}
+	return resp.Body, resp.Header.Get("Content-Type"), nil
+}
+
+// upload отправляет файл fileName из content в поле fileField формы вместе с полями fields
+// и декодирует ответ в out, если он не nil.
+func (c *Client) upload(ctx context.Context, method, path string, fields map[string]string, fileField, fileName string, content io.Reader, out any) error {
+	body, contentType, err := multipartBody(fields, fileField, fileName, content)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(ctx, request{method: method, path: path, body: body, contentType: contentType})
+	if err != nil {
+		return err
+	}
+	return decodeJSON(resp, out)
+}
+
+// Departments возвращает методы справочника кафедр.
+func (c *Client) Departments() *Departments {
+	return &Departments{crud[Department, NewDepartment, DepartmentPatch]{c, "/api/departments"}}
+}
+
+// Departments предоставляет методы справочника кафедр.
+type Departments struct {
+	crud[Department, NewDepartment, DepartmentPatch]
+}
+
+// Specialties возвращает методы справочника специальностей.
+func (c *Client) Specialties() *Specialties {
+	return &Specialties{crud[Specialty, NewSpecialty, SpecialtyPatch]{c, "/api/specialties"}}
+}
+
+// Specialties предоставляет методы справочника специальностей.
+type Specialties struct {
+	crud[Specialty, NewSpecialty, SpecialtyPatch]
+}
+
+// Disciplines возвращает методы справочника предметов.
+func (c *Client) Disciplines() *Disciplines {
+	return &Disciplines{crud[Discipline, NewDiscipline, DisciplinePatch]{c, "/api/disciplines"}}
+}
+
+// Disciplines предоставляет методы справочника предметов.
+type Disciplines struct {
+	crud[Discipline, NewDiscipline, DisciplinePatch]
+}
+
+// MaterialTypes возвращает методы справочника видов материалов.
+func (c *Client) MaterialTypes() *MaterialTypes {
+	return &MaterialTypes{crud[MaterialType, NewMaterialType, MaterialTypePatch]{c, "/api/material-types"}}
+}
+
+// MaterialTypes предоставляет методы справочника видов материалов.
+type MaterialTypes struct {
+	crud[MaterialType, NewMaterialType, MaterialTypePatch]
+}
+
+// Groups возвращает методы справочника взводов.
+func (c *Client) Groups() *Groups {
+	return &Groups{crud[Group, NewGroup, GroupPatch]{c, "/api/groups"}}
+}
+
+// Groups предоставляет методы справочника взводов.
+type Groups struct {
+	crud[Group, NewGroup, GroupPatch]
+}
+
+// List возвращает взводы, удовлетворяющие фильтру. Если фильтр равен nil, то возвращаются взводы не из архива.
+func (g *Groups) List(ctx context.Context, filter *GroupFilter) ([]Group, error) {
+	var groups []Group
+	if err := g.c.json(ctx, http.MethodGet, g.path, queryValues(filter), nil, &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// Users возвращает методы для работы с пользователями.
+func (c *Client) Users() *Users {
+	return &Users{crud[User, NewUser, UserPatch]{c, "/api/users"}}
+}
+
+// Users предоставляет методы для работы с пользователями.
+type Users struct {
+	crud[User, NewUser, UserPatch]
+}
+
+// List возвращает пользователей, удовлетворяющих фильтру. Если фильтр равен nil, то возвращаются все пользователи.
+func (u *Users) List(ctx context.Context, filter *UserFilter) ([]User, error) {
+	var users []User
+	if err := u.c.json(ctx, http.MethodGet, u.path, queryValues(filter), nil, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// Import импортирует студентов из списка fileName в формате CSV или XLSX и возвращает отчет об импорте.
+// Если dryRun истинно, то строки только проверяются. Если в списке есть ошибки, то ничего не создается,
+// а ошибки перечисляются в отчете.
+func (u *Users) Import(ctx context.Context, fileName string, roster io.Reader, dryRun bool) (*ImportReport, error) {
+	body, contentType, err := multipartBody(nil, "file", fileName, roster)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{"dryRun": {strconv.FormatBool(dryRun)}}
+	resp, err := u.c.do(ctx, request{
+		method:      http.MethodPost,
+		path:        u.path + "/import",
+		query:       query,
+		body:        body,
+		contentType: contentType,
+		accept:      []int{http.StatusUnprocessableEntity},
+	})
+	if err != nil {
+		return nil, err
+	}
+	report := new(ImportReport)
+	if err := decodeJSON(resp, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// Photo возвращает фотографию пользователя с номером ID и ее тип содержимого. Фотографию закрывает вызывающий.
+func (u *Users) Photo(ctx context.Context, ID int) (io.ReadCloser, string, error) {
+	return u.c.download(ctx, u.itemPath(ID)+"/photo")
+}
+
+// SetPhoto загружает фотографию fileName пользователя с номером ID.
+func (u *Users) SetPhoto(ctx context.Context, ID int, fileName string, photo io.Reader) error {
+	return u.c.upload(ctx, http.MethodPut, u.itemPath(ID)+"/photo", nil, "photo", fileName, photo, nil)
+}
+
+// DeletePhoto удаляет фотографию пользователя с номером ID.
+func (u *Users) DeletePhoto(ctx context.Context, ID int) error {
+	return u.c.exec(ctx, request{method: http.MethodDelete, path: u.itemPath(ID) + "/photo"})
+}
+
+// Materials возвращает методы для работы с дополнительными материалами.
+func (c *Client) Materials() *Materials {
+	return &Materials{crud[Material, NewMaterial, MaterialPatch]{c, "/api/materials"}}
+}
+
+// Materials предоставляет методы для работы с дополнительными материалами.
+type Materials struct {
+	crud[Material, NewMaterial, MaterialPatch]
+}
+
+// Create создает материал с файлом fileName и возвращает его номер.
+func (m *Materials) Create(ctx context.Context, input *NewMaterial, fileName string, file io.Reader) (int, error) {
+	fields := map[string]string{"name": input.Name, "typeID": strconv.Itoa(input.TypeID)}
+	var created createdID
+	if err := m.c.upload(ctx, http.MethodPost, m.path, fields, "file", fileName, file, &created); err != nil {
+		return 0, err
+	}
+	return created.ID, nil
+}
+
+// File возвращает файл материала с номером ID и его тип содержимого. Файл закрывает вызывающий.
+func (m *Materials) File(ctx context.Context, ID int) (io.ReadCloser, string, error) {
+	return m.c.download(ctx, m.itemPath(ID)+"/file")
+}
+
+// SetFile заменяет файл материала с номером ID файлом fileName.
+func (m *Materials) SetFile(ctx context.Context, ID int, fileName string, file io.Reader) error {
+	return m.c.upload(ctx, http.MethodPut, m.itemPath(ID)+"/file", nil, "file", fileName, file, nil)
+}
+
+// Me возвращает методы для работы с профилем текущего пользователя.
+func (c *Client) Me() *Me {
+	return &Me{c}
+}
+
+// Me предоставляет методы для работы с профилем текущего пользователя.
+type Me struct {
+	c *Client
+}
+
+// Get возвращает профиль текущего пользователя.
+func (m *Me) Get(ctx context.Context) (*Profile, error) {
+	profile := new(Profile)
+	if err := m.c.json(ctx, http.MethodGet, "/api/me", nil, nil, profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// Update изменяет заполненные поля профиля текущего пользователя. Пустая строка удаляет значение поля.
+func (m *Me) Update(ctx context.Context, update *ProfileUpdate) error {
+	return m.c.json(ctx, http.MethodPatch, "/api/me", nil, update, nil)
+}
+
+// ChangePassword меняет пароль текущего пользователя с currentPassword на newPassword.
+func (m *Me) ChangePassword(ctx context.Context, currentPassword, newPassword string) error {
+	change := model.PasswordChange{CurrentPassword: currentPassword, NewPassword: newPassword}
+	return m.c.json(ctx, http.MethodPut, "/api/me/password", nil, change, nil)
+}
+
+// Photo возвращает фотографию текущего пользователя и ее тип содержимого. Фотографию закрывает вызывающий.
+func (m *Me) Photo(ctx context.Context) (io.ReadCloser, string, error) {
+	return m.c.download(ctx, "/api/me/photo")
+}
+
+// SetPhoto загружает фотографию fileName текущего пользователя.
+func (m *Me) SetPhoto(ctx context.Context, fileName string, photo io.Reader) error {
+	return m.c.upload(ctx, http.MethodPut, "/api/me/photo", nil, "photo", fileName, photo, nil)
+}
+
+// DeletePhoto удаляет фотографию текущего пользователя.
+func (m *Me) DeletePhoto(ctx context.Context) error {
+	return m.c.exec(ctx, request{method: http.MethodDelete, path: "/api/me/photo"})
+}
+
+// Lessons возвращает методы для работы с занятиями.
+func (c *Client) Lessons() *Lessons {
+	return &Lessons{materialLinks{c, "/api/lessons"}}
+}
+
+// Lessons предоставляет методы для работы с занятиями.
+type Lessons struct {
+	materialLinks
+}
+
+// Get возвращает занятие с номером ID вместе с его дополнительными материалами.
+func (l *Lessons) Get(ctx context.Context, ID int) (*Lesson, error) {
+	lesson := new(Lesson)
+	if err := l.c.json(ctx, http.MethodGet, l.ownerPath(ID), nil, nil, lesson); err != nil {
+		return nil, err
+	}
+	return lesson, nil
+}
+
+// Books возвращает методы для работы с книгами.
+func (c *Client) Books() *Books {
+	return &Books{materialLinks{c, "/api/books"}}
+}
+
+// Books предоставляет методы для работы с книгами.
+type Books struct {
+	materialLinks
+}
+
+// materialLinks реализует методы для работы с материалами, прикрепленными к занятиям или книгам.
+type materialLinks struct {
+	c    *Client
+	path string // путь владельцев материалов, например /api/lessons
+}
+
+// ownerPath возвращает путь владельца материалов с номером ID.
+func (ml materialLinks) ownerPath(ID int) string {
+	return ml.path + "/" + strconv.Itoa(ID)
+}
+
+// Materials возвращает материалы, прикрепленные к владельцу с номером ID.
+func (ml materialLinks) Materials(ctx context.Context, ID int) ([]Material, error) {
+	var materials []Material
+	if err := ml.c.json(ctx, http.MethodGet, ml.ownerPath(ID)+"/materials", nil, nil, &materials); err != nil {
+		return nil, err
+	}
+	return materials, nil
+}
+
+// AttachMaterial прикрепляет материал с номером materialID к владельцу с номером ID.
+func (ml materialLinks) AttachMaterial(ctx context.Context, ID, materialID int) error {
+	return ml.c.exec(ctx, request{method: http.MethodPut, path: ml.ownerPath(ID) + "/materials/" + strconv.Itoa(materialID)})
+}
+
+// DetachMaterial открепляет материал с номером materialID от владельца с номером ID.
+func (ml materialLinks) DetachMaterial(ctx context.Context, ID, materialID int) error {
+	return ml.c.exec(ctx, request{method: http.MethodDelete, path: ml.ownerPath(ID) + "/materials/" + strconv.Itoa(materialID)})
+}
+
+// CatalogTree возвращает дерево каталога от кафедр до занятий с параметрами filter (nil — все уровни без количеств).
+func (c *Client) CatalogTree(ctx context.Context, filter *CatalogFilter) ([]CatalogDepartment, error) {
+	var tree []CatalogDepartment
+	if err := c.json(ctx, http.MethodGet, "/api/catalog/tree", queryValues(filter), nil, &tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// PreviewRollover возвращает план перехода на новый учебный год без изменения данных.
+func (c *Client) PreviewRollover(ctx context.Context, rollover *Rollover) (*RolloverPlan, error) {
+	plan := new(RolloverPlan)
+	if err := c.json(ctx, http.MethodPost, "/api/rollover/preview", nil, rollover, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// ApplyRollover применяет переход на новый учебный год и возвращает его план.
+// Если в плане есть ошибки, то ничего не изменяется.
+func (c *Client) ApplyRollover(ctx context.Context, rollover *Rollover) (*RolloverPlan, error) {
+	body, err := jsonBody(rollover)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(ctx, request{
+		method:      http.MethodPost,
+		path:        "/api/rollover",
+		body:        body,
+		contentType: "application/json",
+		accept:      []int{http.StatusUnprocessableEntity},
+	})
+	if err != nil {
+		return nil, err
+	}
+	plan := new(RolloverPlan)
+	if err := decodeJSON(resp, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// AuditEvents возвращает записи журнала аудита, удовлетворяющие фильтру, от новых к старым.
+func (c *Client) AuditEvents(ctx context.Context, filter *AuditFilter) ([]AuditEvent, error) {
+	var events []AuditEvent
+	if err := c.json(ctx, http.MethodGet, "/api/audit", queryValues(filter), nil, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Trash возвращает методы для работы с корзиной.
+func (c *Client) Trash() *Trash {
+	return &Trash{c}
+}
+
+// Trash предоставляет методы для работы с корзиной.
+type Trash struct {
+	c *Client
+}
+
+// List возвращает все записи в корзине от удаленных последними к удаленным первыми.
+func (t *Trash) List(ctx context.Context) ([]TrashItem, error) {
+	var items []TrashItem
+	if err := t.c.json(ctx, http.MethodGet, "/api/trash", nil, nil, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Restore возвращает из корзины запись сущности entity (например, [TrashDepartment]) с номером ID.
+func (t *Trash) Restore(ctx context.Context, entity string, ID int) error {
+	return t.c.exec(ctx, request{method: http.MethodPost, path: fmt.Sprintf("/api/trash/%v/%v/restore", entity, ID)})
+}
+
+// Jobs возвращает методы для работы с задачами обслуживания.
+func (c *Client) Jobs() *Jobs {
+	return &Jobs{c}
+}
+
+// Jobs предоставляет методы для работы с задачами обслуживания.
+type Jobs struct {
+	c *Client
+}
+
+// List возвращает все задачи обслуживания с их последними запусками.
+func (j *Jobs) List(ctx context.Context) ([]Job, error) {
+	var jobs []Job
+	if err := j.c.json(ctx, http.MethodGet, "/api/jobs", nil, nil, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// Runs возвращает записи истории запусков задач, удовлетворяющие фильтру, от новых к старым.
+func (j *Jobs) Runs(ctx context.Context, filter *JobRunFilter) ([]JobRun, error) {
+	var runs []JobRun
+	if err := j.c.json(ctx, http.MethodGet, "/api/jobs/runs", queryValues(filter), nil, &runs); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// Run запускает задачу с названием name, дожидается ее завершения и возвращает запись о запуске.
+// Ошибка самой задачи не возвращается, а записывается в запуск.
+func (j *Jobs) Run(ctx context.Context, name string) (*JobRun, error) {
+	run := new(JobRun)
+	if err := j.c.json(ctx, http.MethodPost, "/api/jobs/"+url.PathEscape(name)+"/runs", nil, nil, run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
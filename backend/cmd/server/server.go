@@ -1,148 +1,439 @@
 /*
 Server запускает сервер электронной библиотеки.
 
+Использование:
+
+	server [-config путь] [-print-config] [-migrate]
+
+Флаги:
+
+	-config string
+		файл конфигурации в формате YAML (по умолчанию configs/config.yml; пусто — без файла)
+	-print-config
+		вывести итоговую конфигурацию со скрытыми секретами и завершить работу
+	-migrate
+		применить недостающие миграции схемы базы данных перед запуском
+
 # Конфигурация
 
-Перед запуском сервер читает файл конфигурации configs/config.yml и
-файл .env с переменными окружения. Из файла конфигурации читаются
-настройки подключения к базе данных и порт сервера.
-Если порт в конфигурации не указан, сервер слушает порт 8080. 
-Из переменных окружения сервер читает ключ подписи jwt токенов
-и пароль к базе данных, если таковой имеется.
+Все настройки описаны в пакете config и имеют значения по умолчанию. Перед запуском сервер
+читает файл .env с переменными окружения, затем файл конфигурации, значения из которого
+переопределяются переменными окружения с префиксом ELIB_ (например, ELIB_SERVER_PORT
+или ELIB_DATABASE_MAX_OPEN_CONNS). Конфигурация проверяется при запуске; если какие-то
+настройки некорректны, сервер выводит все ошибки и завершает работу.
+
+Секреты задаются только переменными окружения: ключ подписи jwt токенов
+ELIB_TOKEN_SIGNING_KEY (обязателен), пароль к базе данных ELIB_DATABASE_PASSWORD
+и токен доступа к метрикам ELIB_METRICS_TOKEN. Для совместимости также читаются
+переменные TOKEN_SIGNING_KEY, DB_PASSWORD и METRICS_TOKEN.
+
+В разделе server задаются порт, время ожидания чтения и записи запросов, время на завершение
+работы и сертификат https (tls.cert_file и tls.key_file; без них сервер работает по http).
+Если сервер работает за обратным прокси, то его сети перечисляются в trusted_proxies: только
+для соединений из них ip-адрес клиента берется из заголовка X-Forwarded-For. Без этой настройки
+используется адрес соединения, а заголовки клиента не учитываются.
+В разделе database — подключение к базе данных и размеры пула соединений,
+в разделе token — сроки действия токенов доступа и обновления, общие и для отдельных ролей (roles),
+в разделе session — время простоя сессии, ее максимальная длительность и количество
+одновременных сессий пользователя (при входе сверх него завершаются самые старые),
+в разделе cors — источники, с которых браузерам разрешены запросы к api,
+в разделе jobs — расписание задач обслуживания (см. ниже),
+в разделе rate_limit — бюджеты запросов: к /auth по ip-адресу клиента (auth),
+на чтение (read) и изменение (write) данных через /api по пользователю. Бюджет
+requests за period позволяет сделать requests запросов подряд, а дальше — не чаще,
+чем requests за period; запросы сверх бюджета отклоняются с кодом 429.
+Журнал пишется в стандартный вывод в формате JSON с уровнем не ниже log.level.
+Трассировка OpenTelemetry настраивается в разделе tracing: exporter (none, otlp или stdout),
+endpoint (адрес приемника OTLP/HTTP; если не указан, то используются переменные
+окружения OTEL_EXPORTER_OTLP_*) и sample_ratio (доля записываемых трасс).
+Контекст трассировки клиента принимается в заголовке traceparent (W3C Trace Context).
+Если задан токен метрик, то по адресу /metrics доступны метрики
+в формате Prometheus для запросов с заголовком Authorization: Bearer <токен>.
+
+# Задачи обслуживания
+
+Если jobs.enabled истинно, то сервер раз в jobs.check_interval запускает задачи обслуживания,
+срок которых наступил: удаление истекших токенов обновления (expired-tokens), завершение сессий,
+которые уже нельзя продлить (stale-sessions), окончательное удаление записей, пролежавших
+в корзине дольше trash.retention (trash-purge), и удаление истории запусков старше
+jobs.history_retention (job-history). Периоды задач задаются в разделе jobs.intervals.
+Из нескольких экземпляров сервера задачи выполняет только тот, что захватил
+advisory-блокировку планировщика в базе данных; если он остановится, то блокировку
+захватит другой. История запусков доступна администратору по адресу /api/jobs/runs,
+а запустить задачу вручную можно запросом POST /api/jobs/<название>/runs.
+
+# Схема базы данных
+
+Схема базы данных задается только миграциями пакета postgres, которые встроены в исполняемый файл:
+новая база данных создается запуском сервера с флагом -migrate. Примененные миграции записываются в таблицу schema_migrations. С флагом -migrate сервер
+при запуске применяет недостающие миграции; без него схему можно обновить заранее,
+запустив сервер с этим флагом один раз. База данных, созданная до появления миграций,
+принимается за исходную схему, и к ней применяются только последующие миграции.
+
+# Проверки состояния
+
+По адресу /healthz сервер отвечает 200, пока процесс обрабатывает запросы.
+По адресу /readyz сервер проверяет подключение к базе данных, применены ли к ней все миграции,
+и доступность файлового хранилища и отвечает 200 или 503 с результатом каждой проверки.
+При получении SIGINT или SIGTERM /readyz сразу начинает отвечать 503, а сервер продолжает
+обрабатывать запросы еще server.shutdown_delay, чтобы оркестратор успел
+перестать направлять на него трафик, и только затем завершает работу.
 */
 package main
 
 import (
 	"context"
-	"log"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/foreverd34d/aumsu-elib/internal/app"
+	"github.com/foreverd34d/aumsu-elib/internal/config"
 	"github.com/foreverd34d/aumsu-elib/internal/handler"
+	"github.com/foreverd34d/aumsu-elib/internal/health"
+	"github.com/foreverd34d/aumsu-elib/internal/logging"
+	"github.com/foreverd34d/aumsu-elib/internal/metrics"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+	"github.com/foreverd34d/aumsu-elib/internal/ratelimit"
 	"github.com/foreverd34d/aumsu-elib/internal/repo/postgres"
 	"github.com/foreverd34d/aumsu-elib/internal/service"
+	"github.com/foreverd34d/aumsu-elib/internal/storage"
+	"github.com/foreverd34d/aumsu-elib/internal/tracing"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
-	echolog "github.com/labstack/gommon/log"
-	"github.com/spf13/viper"
 )
 
-// Порт по умолчанию.
-const defaultPort = "8080"
+const (
+	defaultConfigPath = "configs/config.yml" // файл конфигурации по умолчанию
+	serviceName       = "aumsu-elib"         // название сервиса в трассировке
+)
 
 func main() {
-	// Инициализация конфигурационных файлов
-	if err := godotenv.Load(); err != nil {
-		log.Printf("Couldn't read the .env file: %v. Using env variables.\n", err)
+	configPath := flag.String("config", defaultConfigPath, "YAML config file (empty to use defaults and env only)")
+	printConfig := flag.Bool("print-config", false, "print the effective config with secrets redacted and exit")
+	migrate := flag.Bool("migrate", false, "apply pending database schema migrations before starting")
+	flag.Parse()
+
+	slog.SetDefault(logging.New(os.Stdout, slog.LevelInfo))
+
+	// Инициализация конфигурации
+	envErr := godotenv.Load()
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fatal("Couldn't load config", err)
 	}
-	if err := initViperConfig(); err != nil {
-		log.Fatalf("Couldn't read config file: %v\n", err)
+	if *printConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			fatal("Couldn't print config", err)
+		}
+		if err := cfg.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid config:\n%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if envErr != nil {
+		slog.Info("Couldn't read the .env file. Using env variables.", logging.Err(envErr))
+	}
+	if err := cfg.Validate(); err != nil {
+		fatal("Invalid config", err)
 	}
 
-	// Получение ключа подписи jwt токенов
-	tokenSigningKey := os.Getenv("TOKEN_SIGNING_KEY")
-	if tokenSigningKey == "" {
-		log.Fatalln("Couldn't get signing key: TOKEN_SIGNING_KEY is not defined")
+	// Инициализация журнала
+	slog.SetDefault(logging.New(os.Stdout, cfg.Log.LogLevel()))
+
+	// Инициализация трассировки
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		ServiceName: serviceName,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		fatal("Couldn't init tracing", err)
 	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("Couldn't flush traces", logging.Err(err))
+		}
+	}()
 
 	// Подключение к базе данных
-	dbCtx, dbCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	dbCtx, dbCancel := context.WithTimeout(context.Background(), cfg.Database.ConnectTimeout)
 	defer dbCancel()
-	db, err := postgres.NewDB(dbCtx, getDBConfig())
+	db, err := postgres.NewDB(dbCtx, getDBConfig(cfg.Database))
 	if err != nil {
-		log.Fatalf("Couldn't connect to db: %v\n", err)
+		fatal("Couldn't connect to db", err)
 	}
 	defer db.Close()
+	if *migrate {
+		applied, err := postgres.Migrate(context.Background(), db)
+		if err != nil {
+			fatal("Couldn't migrate db", err)
+		}
+		slog.Info("Database schema migrated", slog.Int("applied", applied), slog.Int("version", postgres.LatestVersion()))
+	}
+
+	// Инициализация файлового хранилища
+	fileStorage, err := storage.NewDisk(cfg.Storage.Dir)
+	if err != nil {
+		fatal("Couldn't init file storage", err)
+	}
+
+	// Инициализация сессий и корзины
+	tokenSigningKey := string(cfg.Token.SigningKey)
+	sessionService := service.NewSessionService(postgres.NewUserRepo(db), postgres.NewSessionRepo(db), postgres.NewAuditRepo(db),
+		[]byte(tokenSigningKey), getSessionPolicy(cfg.Token, cfg.Session))
+	trashService := service.NewTrashService(postgres.NewTrashRepo(db), postgres.NewAuditRepo(db), cfg.Trash.Retention)
+
+	// Инициализация задач обслуживания
+	jobService := initJobs(db, cfg.Jobs, sessionService, trashService)
 
 	// Инициализация всех путей и middleware
-	handler := initHandler(db, tokenSigningKey)
-	app := app.NewApp(handler, tokenSigningKey)
+	handler := initHandler(db, fileStorage)
+	handler.Session = sessionService
+	handler.Trash = trashService
+	handler.Job = jobService
+	server := app.NewApp(handler, tokenSigningKey)
+	app.RegisterTrustedProxies(server, getTrustedProxies(cfg.Server))
+	app.RegisterCORS(server, cfg.CORS.AllowOrigins, cfg.CORS.MaxAge)
+	if cfg.RateLimit.Enabled {
+		app.RegisterRateLimit(server, getRateLimits(cfg.RateLimit))
+	}
 
-	// Получение порта, если есть
-	port := viper.GetString("server.port")
-	if port == "" {
-		port = defaultPort
+	// Инициализация метрик
+	registerMetrics(db)
+	if cfg.Metrics.Token == "" {
+		slog.Warn("Metrics token is not defined, /metrics is disabled")
 	}
+	app.RegisterMetrics(server, metrics.Default, string(cfg.Metrics.Token))
+
+	// Инициализация проверок состояния
+	checker := health.NewChecker(health.DefaultTimeout)
+	checker.Add("database", db.PingContext)
+	checker.Add("schema", func(ctx context.Context) error { return postgres.CheckSchema(ctx, db) })
+	checker.Add("storage", fileStorage.Check)
+	app.RegisterHealth(server, checker)
 
-	app.Logger.SetLevel(echolog.INFO)
-	runApp(app, port)
+	runApp(server, cfg.Server, cfg.Jobs, jobService, checker)
 }
 
-// getDBConfig загружает конфигурацию базы данных из файла configs/config.yml.
-func getDBConfig() postgres.Config {
-	dbPort, _ := strconv.Atoi(viper.GetString("database.port"))
-	cfg := postgres.Config{
-		Host:    viper.GetString("database.host"),
-		Port:    dbPort,
-		User:    viper.GetString("database.user"),
-		DBName:  viper.GetString("database.dbname"),
-		SSLMode: viper.GetString("database.sslmode"),
-	}
-	password := os.Getenv("DB_PASSWORD")
-	if password != "" {
-		cfg.Password = &password
-	}
-	return cfg
+// fatal записывает в журнал сообщение msg с ошибкой err и завершает работу сервера.
+func fatal(msg string, err error) {
+	slog.Error(msg, logging.Err(err))
+	os.Exit(1)
 }
 
-// initViperConfig считывает файлы конфигурации из директории configs.
-func initViperConfig() error {
-	viper.AddConfigPath("configs")
-	viper.SetConfigName("config")
-	return viper.ReadInConfig()
+// registerMetrics регистрирует метрики пула соединений с базой данных db и количества активных сессий.
+func registerMetrics(db *sqlx.DB) {
+	metrics.Default.RegisterDBStats(db.Stats)
+	sessions := postgres.NewSessionRepo(db)
+	metrics.Default.NewGaugeFunc("elib_sessions_active", "Number of sessions with a valid refresh token.",
+		func(ctx context.Context) (float64, error) {
+			count, err := sessions.CountActive(ctx, int(time.Now().Unix()))
+			return float64(count), err
+		})
 }
 
-// initHandler инициализирует все сервисы и репозитории для хэндлера.
-func initHandler(db *sqlx.DB, tokenSigningKey string) *handler.Handler {
-	userRepo := postgres.NewUserRepo(db)
-	userService := service.NewUserService(userRepo)
+// getSessionPolicy возвращает политику сессий из настроек токенов token и сессий session.
+func getSessionPolicy(token config.Token, session config.Session) service.SessionPolicy {
+	lifetimes := func(role config.RoleToken) service.TokenLifetimes {
+		return service.TokenLifetimes{AccessTTL: role.AccessTTL, RefreshTTL: role.RefreshTTL}
+	}
+	return service.SessionPolicy{
+		TokenLifetimes: service.TokenLifetimes{AccessTTL: token.AccessTTL, RefreshTTL: token.RefreshTTL},
+		Roles: map[model.UserRole]service.TokenLifetimes{
+			model.StudentRole: lifetimes(token.Roles.Student),
+			model.TeacherRole: lifetimes(token.Roles.Teacher),
+			model.ManagerRole: lifetimes(token.Roles.Manager),
+			model.AdminRole:   lifetimes(token.Roles.Admin),
+		},
+		IdleTimeout: session.IdleTimeout,
+		MaxAge:      session.MaxAge,
+		MaxSessions: session.MaxPerUser,
+	}
+}
+
+// getRateLimits возвращает бюджеты запросов из настроек cfg.
+func getRateLimits(cfg config.RateLimit) app.RateLimits {
+	limit := func(budget config.RateBudget) ratelimit.Limit {
+		return ratelimit.Limit{Requests: budget.Requests, Period: budget.Period}
+	}
+	return app.RateLimits{Auth: limit(cfg.Auth), Read: limit(cfg.Read), Write: limit(cfg.Write)}
+}
+
+// getTrustedProxies возвращает сети обратных прокси из настроек cfg. Настройки уже проверены при запуске.
+func getTrustedProxies(cfg config.Server) []*net.IPNet {
+	var proxies []*net.IPNet
+	for _, proxy := range cfg.TrustedProxies {
+		if _, network, err := net.ParseCIDR(proxy); err == nil {
+			proxies = append(proxies, network)
+		}
+	}
+	return proxies
+}
+
+// getDBConfig возвращает параметры подключения к базе данных из настроек cfg.
+func getDBConfig(cfg config.Database) postgres.Config {
+	return postgres.Config{
+		Host:            cfg.Host,
+		Port:            cfg.Port,
+		User:            cfg.User,
+		Password:        string(cfg.Password),
+		DBName:          cfg.DBName,
+		SSLMode:         cfg.SSLMode,
+		MaxOpenConns:    cfg.MaxOpenConns,
+		MaxIdleConns:    cfg.MaxIdleConns,
+		ConnMaxLifetime: cfg.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.ConnMaxIdleTime,
+	}
+}
+
+// initJobs инициализирует задачи обслуживания с периодами из настроек cfg.
+func initJobs(db *sqlx.DB, cfg config.Jobs, sessions *service.SessionService, trash *service.TrashService) *service.JobService {
+	jobs := service.NewJobService(postgres.NewJobRepo(db), postgres.NewLocker(db))
+	jobs.Add(service.Job{
+		Name:        "expired-tokens",
+		Description: "Удаление истекших токенов обновления",
+		Interval:    cfg.Intervals.ExpiredTokens,
+		Run:         sessions.DeleteExpiredTokens,
+	})
+	jobs.Add(service.Job{
+		Name:        "stale-sessions",
+		Description: "Завершение сессий без действительного токена, простаивающих и слишком долгих",
+		Interval:    cfg.Intervals.StaleSessions,
+		Run:         sessions.EndStaleSessions,
+	})
+	jobs.Add(service.Job{
+		Name:        "trash-purge",
+		Description: "Окончательное удаление записей, срок хранения которых в корзине истек",
+		Interval:    cfg.Intervals.TrashPurge,
+		Run:         trash.Purge,
+	})
+	jobs.Add(service.Job{
+		Name:        "job-history",
+		Description: "Удаление старой истории запусков задач",
+		Interval:    cfg.Intervals.JobHistory,
+		Run: func(ctx context.Context) (int, error) {
+			return jobs.PruneRuns(ctx, cfg.HistoryRetention)
+		},
+	})
+	return jobs
+}
+
+// initHandler инициализирует сервисы и репозитории для хэндлера, кроме сессий, корзины и задач обслуживания.
+func initHandler(db *sqlx.DB, fileStorage service.FileStorage) *handler.Handler {
+	auditRepo := postgres.NewAuditRepo(db)
+	auditService := service.NewAuditService(auditRepo)
 
-	tokenRepo := postgres.NewSessionRepo(db)
-	sessionService := service.NewSessionService(userRepo, tokenRepo, []byte(tokenSigningKey))
+	userRepo := postgres.NewUserRepo(db)
+	userService := service.NewUserService(userRepo, auditRepo, fileStorage)
 
 	groupRepo := postgres.NewGroupRepo(db)
-	groupService := service.NewGroupService(groupRepo)
+	groupService := service.NewGroupService(groupRepo, auditRepo)
+
+	importService := service.NewImportService(userRepo, groupRepo, auditRepo)
 
 	specialtyRepo := postgres.NewSpecialtyRepo(db)
-	specialtyService := service.NewSpecialtyService(specialtyRepo)
+	specialtyService := service.NewSpecialtyService(specialtyRepo, auditRepo)
 
 	departmentRepo := postgres.NewDepartmentRepo(db)
-	departmentService := service.NewDepartmentService(departmentRepo)
+	departmentService := service.NewDepartmentService(departmentRepo, auditRepo)
 
 	disciplineRepo := postgres.NewDisciplineRepo(db)
-	disciplineService := service.NewDisciplineService(disciplineRepo)
+	disciplineService := service.NewDisciplineService(disciplineRepo, auditRepo)
+
+	rolloverService := service.NewRolloverService(groupRepo, userRepo, specialtyRepo, auditRepo)
+
+	materialTypeRepo := postgres.NewMaterialTypeRepo(db)
+	materialTypeService := service.NewMaterialTypeService(materialTypeRepo, auditRepo)
+
+	materialRepo := postgres.NewMaterialRepo(db)
+	materialService := service.NewMaterialService(materialRepo, auditRepo, fileStorage)
+
+	lessonRepo := postgres.NewLessonRepo(db)
+	lessonService := service.NewLessonService(lessonRepo, materialRepo)
+
+	catalogRepo := postgres.NewCatalogRepo(db)
+	catalogService := service.NewCatalogService(catalogRepo)
 
 	return &handler.Handler{
-		User:       userService,
-		Session:    sessionService,
-		Group:      groupService,
-		Specialty:  specialtyService,
-		Department: departmentService,
-		Discipline: disciplineService,
+		User:         userService,
+		Group:        groupService,
+		Specialty:    specialtyService,
+		Department:   departmentService,
+		Discipline:   disciplineService,
+		Audit:        auditService,
+		Import:       importService,
+		Rollover:     rolloverService,
+		MaterialType: materialTypeService,
+		Material:     materialService,
+		Lesson:       lessonService,
+		Catalog:      catalogService,
 	}
 }
 
-// runApp запускает сервер и корректно завершает его работу при получении SIGINT.
-func runApp(app *echo.Echo, port string) {
-	appCtx, appStop := signal.NotifyContext(context.Background(), os.Interrupt)
+// runApp запускает сервер с настройками cfg и, если это включено в jobsCfg, выполнение задач jobs
+// по расписанию и корректно завершает их работу при получении SIGINT или SIGTERM.
+// Перед завершением сервер помечается в checker как неготовый и еще cfg.ShutdownDelay продолжает обрабатывать запросы.
+func runApp(app *echo.Echo, cfg config.Server, jobsCfg config.Jobs, jobs *service.JobService, checker *health.Checker) {
+	appCtx, appStop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer appStop()
+	scheduleDone := make(chan struct{})
+	if jobsCfg.Enabled {
+		go func() {
+			defer close(scheduleDone)
+			jobs.Schedule(appCtx, jobsCfg.CheckInterval)
+		}()
+	} else {
+		slog.Info("Job scheduler is disabled")
+		close(scheduleDone)
+	}
+
+	server := &http.Server{
+		Addr:              ":" + strconv.Itoa(cfg.Port),
+		Handler:           app,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		TLSConfig:         &tls.Config{MinVersion: tls.VersionTLS12},
+		ErrorLog:          app.StdLogger,
+	}
 	go func() {
-		if err := app.Start(":" + port); err != nil && err != http.ErrServerClosed {
-			app.Logger.Fatalf("Error while running server: %v", err)
+		slog.Info("Server started", slog.Int("port", cfg.Port), slog.Bool("tls", cfg.TLS.Enabled()))
+		var err error
+		if cfg.TLS.Enabled() {
+			err = server.ListenAndServeTLS(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			fatal("Error while running server", err)
 		}
 	}()
 	<-appCtx.Done()
 
-	app.Logger.Info("Interrupt signal received, shutting down...")
-	stopCtx, stopCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	slog.Info("Interrupt signal received, shutting down...", slog.Duration("delay", cfg.ShutdownDelay))
+	checker.SetShuttingDown()
+	time.Sleep(cfg.ShutdownDelay)
+	stopCtx, stopCancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer stopCancel()
-	if err := app.Shutdown(stopCtx); err != nil {
-		app.Logger.Fatalf("Couldn't shutdown gracefully: %v", err)
+	if err := server.Shutdown(stopCtx); err != nil {
+		fatal("Couldn't shutdown gracefully", err)
 	}
+	// Планировщик дожидается выполняемой задачи и освобождает блокировку до закрытия базы данных.
+	<-scheduleDone
 }
@@ -4,10 +4,15 @@ package app
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
 
 	"github.com/foreverd34d/aumsu-elib/internal/errs"
 	"github.com/foreverd34d/aumsu-elib/internal/handler"
+	"github.com/foreverd34d/aumsu-elib/internal/logging"
 	"github.com/foreverd34d/aumsu-elib/internal/model"
+	"github.com/foreverd34d/aumsu-elib/internal/service"
 	"github.com/go-playground/validator/v10"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -20,18 +25,37 @@ import (
 // tokenSigningKey используется для подписи и проверки jwt токенов.
 func NewApp(h *handler.Handler, tokenSigningKey string) *echo.Echo {
 	app := echo.New()
-	app.Use(middleware.Logger())
-	app.Use(middleware.Recover())
+	app.HTTPErrorHandler = problemHandler
+	// Без доверенных прокси (см. [RegisterTrustedProxies]) ip-адрес клиента берется из соединения,
+	// а не из заголовков X-Forwarded-For и X-Real-IP, которые клиент может подделать.
+	app.IPExtractor = echo.ExtractIPDirect()
+	app.Use(middleware.RequestIDWithConfig(middleware.RequestIDConfig{
+		RequestIDHandler: withRequestID,
+	}))
+	app.Use(traceRequests)
+	app.Use(middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
+		LogMethod:     true,
+		LogURI:        true,
+		LogStatus:     true,
+		LogLatency:    true,
+		LogRemoteIP:   true,
+		LogError:      true,
+		HandleError:   true,
+		LogValuesFunc: logRequest,
+	}))
+	app.Use(recordMetrics)
+	app.Use(middleware.RecoverWithConfig(middleware.RecoverConfig{
+		LogErrorFunc: logPanic,
+		// Ошибка передается внешним middleware, чтобы паника попала в журнал запросов, метрики и трассировку.
+		DisableErrorHandler: true,
+	}))
 	app.Use(mapErrors)
 
-	app.Validator = &BindValidator{validator: validator.New(validator.WithRequiredStructEnabled())}
+	app.Validator = NewBindValidator()
+	registerDocs(app)
 
-	auth := app.Group("/auth")
-	{
-		auth.POST("/session", h.CreateSession)
-		auth.PUT("/session", h.UpdateSession)
-		auth.DELETE("/session", h.DeleteSession)
-	}
+	auth := app.Group("/auth", withActor, limitRate)
+	registerRoutes(auth, h, authRoutes)
 
 	jwtConfig := echojwt.Config{
 		NewClaimsFunc: func(c echo.Context) jwt.Claims {
@@ -39,33 +63,8 @@ func NewApp(h *handler.Handler, tokenSigningKey string) *echo.Echo {
 		},
 		SigningKey: []byte(tokenSigningKey),
 	}
-	api := app.Group("/api", echojwt.WithConfig(jwtConfig))
-	{
-		users := api.Group("/users", checkRole(model.AdminRole))
-		{
-			users.POST("", h.CreateUser)
-			users.GET("", h.GetAllUsers)
-			users.GET("/:id", h.GetUser)
-			users.PUT("/:id", h.UpdateUser)
-			users.DELETE("/:id", h.DeleteUser)
-		}
-		groups := api.Group("/groups", checkRole(model.AdminRole))
-		{
-			groups.POST("", h.CreateGroup)
-			groups.GET("", h.GetAllGroups)
-			groups.GET("/:id", h.GetGroup)
-			groups.PUT("/:id", h.UpdateGroup)
-			groups.DELETE("/:id", h.DeleteGroup)
-		}
-		specialties := api.Group("/specialties", checkRole(model.AdminRole))
-		{
-			specialties.POST("", h.CreateSpecialty)
-			specialties.GET("", h.GetAllSpecialties)
-			specialties.GET("/:id", h.GetSpecialty)
-			specialties.PUT("/:id", h.UpdateSpecialty)
-			specialties.DELETE(":/id", h.DeleteSpecialty)
-		}
-	}
+	api := app.Group("/api", echojwt.WithConfig(jwtConfig), withActor, limitRate, requirePasswordChange)
+	registerRoutes(api, h, apiRoutes)
 
 	return app
 }
@@ -75,6 +74,29 @@ type BindValidator struct {
 	validator *validator.Validate
 }
 
+// NewBindValidator возвращает новый экземпляр [BindValidator].
+// В ошибках проверки поля называются так же, как в запросе: по тегам json, query или form.
+func NewBindValidator() *BindValidator {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "query", "form"} {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+	// Поля патчей проверяются по новому значению, если оно указано.
+	v.RegisterCustomTypeFunc(func(field reflect.Value) any {
+		return field.Interface().(model.PatchField).PatchValue()
+	}, model.Optional[string]{}, model.Optional[int]{}, model.Nullable[string]{}, model.Nullable[int]{})
+	return &BindValidator{validator: v}
+}
+
 // Validate реализует метод [echo.Validator] и валидирует входную структуру.
 func (bv *BindValidator) Validate(i any) error {
 	return bv.validator.Struct(i)
@@ -85,7 +107,7 @@ func (bv *BindValidator) Validate(i any) error {
 func checkRole(role model.UserRole) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			user, err := extractUser(c)
+			user, err := handler.CurrentUser(c)
 			if err != nil {
 				return err
 			}
@@ -97,18 +119,40 @@ func checkRole(role model.UserRole) echo.MiddlewareFunc {
 	}
 }
 
-// extractUser возвращает полезную нагрузку jwt токена пользователя.
-// В случае неудачи возвращается ошибка [echo.ErrUnauthorized]
-func extractUser(c echo.Context) (*model.JWTClaims, error) {
-	token, ok := c.Get("user").(*jwt.Token)
-	if !ok {
-		return nil, echo.ErrUnauthorized.WithInternal(fmt.Errorf("no user token"))
+// passwordChangePath — маршрут смены пароля, единственный доступный с одноразовым паролем.
+const passwordChangePath = "/api/me/password"
+
+// requirePasswordChange предоставляет middleware, которое отклоняет запросы пользователя с одноразовым паролем
+// ошибкой [errs.PasswordChangeRequired], кроме смены пароля. После смены пароля клиент обновляет токены
+// и получает jwt токен без ограничения.
+func requirePasswordChange(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := handler.CurrentUser(c)
+		if err != nil {
+			return err
+		}
+		if user.MustChangePassword && !(c.Request().Method == http.MethodPut && c.Path() == passwordChangePath) {
+			return fmt.Errorf("access %v %v with a one-time password: %w", c.Request().Method, c.Path(), errs.PasswordChangeRequired)
+		}
+		return next(c)
 	}
-	userClaims, ok := token.Claims.(*model.JWTClaims)
-	if !ok {
-		return nil, echo.ErrUnauthorized.WithInternal(fmt.Errorf("invalid user claims"))
+}
+
+// withActor предоставляет middleware, записывающее в контекст запроса его инициатора
+// (номер пользователя из jwt токена, если он есть, и ip-адрес клиента) для журнала аудита
+// и номер пользователя для записей журнала.
+func withActor(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context()
+		actor := model.Actor{IP: c.RealIP()}
+		if userID, err := handler.CurrentUserID(c); err == nil {
+			actor.UserID = &userID
+			ctx = logging.WithUserID(ctx, userID)
+		}
+		ctx = service.WithActor(ctx, actor)
+		c.SetRequest(c.Request().WithContext(ctx))
+		return next(c)
 	}
-	return userClaims, nil
 }
 
 // mapErrors предоставляет middleware для преобразования ошибок из пакета [errs] в http-ошибки [echo].
@@ -124,6 +168,21 @@ func mapErrors(next echo.HandlerFunc) echo.HandlerFunc {
 				errors.Is(err, errs.InvalidLogin) {
 				return echo.ErrUnauthorized.WithInternal(err)
 			}
+			if errors.Is(err, errs.Deactivated) || errors.Is(err, errs.PasswordChangeRequired) {
+				return echo.ErrForbidden.WithInternal(err)
+			}
+			if errors.Is(err, errs.UnsupportedMedia) {
+				return echo.ErrUnsupportedMediaType.WithInternal(err)
+			}
+			if errors.Is(err, errs.Conflict) || errors.Is(err, errs.ReferencedBy) {
+				return echo.NewHTTPError(http.StatusConflict).WithInternal(err)
+			}
+			if errors.Is(err, errs.VersionMismatch) {
+				return echo.ErrPreconditionFailed.WithInternal(err)
+			}
+			if errors.Is(err, errs.InvalidReference) {
+				return echo.NewHTTPError(http.StatusUnprocessableEntity).WithInternal(err)
+			}
 		}
 		return err
 	}
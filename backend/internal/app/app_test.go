@@ -0,0 +1,1100 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/foreverd34d/aumsu-elib/internal/handler"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+	"github.com/foreverd34d/aumsu-elib/internal/repo/memory"
+	"github.com/foreverd34d/aumsu-elib/internal/service"
+	"github.com/foreverd34d/aumsu-elib/internal/storage"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// pngHeader содержит начало png-файла, по которому определяется тип фотографии.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x02\x00\x00\x00")
+
+// testEnv содержит приложение, собранное из настоящих сервисов и репозиториев в памяти.
+type testEnv struct {
+	t     *testing.T
+	app   *echo.Echo
+	store *memory.Store
+	files *storage.Memory
+	admin string // jwt токен администратора
+}
+
+// newTestEnv собирает приложение так же, как сервер, но с репозиториями из пакета memory,
+// и создает администратора admin с паролем password.
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	return newTestEnvWithPolicy(t, service.DefaultSessionPolicy())
+}
+
+// newTestEnvWithPolicy собирает приложение, как [newTestEnv], но с политикой сессий policy.
+func newTestEnvWithPolicy(t *testing.T, policy service.SessionPolicy) *testEnv {
+	t.Helper()
+	store := memory.NewStore()
+	files := storage.NewMemory()
+
+	auditRepo := memory.NewAuditRepo(store)
+	userRepo := memory.NewUserRepo(store)
+	groupRepo := memory.NewGroupRepo(store)
+	specialtyRepo := memory.NewSpecialtyRepo(store)
+	materialRepo := memory.NewMaterialRepo(store)
+	sessions := service.NewSessionService(userRepo, memory.NewSessionRepo(store), auditRepo, []byte(testSigningKey), policy)
+	trash := service.NewTrashService(memory.NewTrashRepo(store), auditRepo, time.Hour)
+	jobs := service.NewJobService(memory.NewJobRepo(store), memory.NewLocker(store))
+	jobs.Add(service.Job{Name: "expired-tokens", Description: "Удаление истекших токенов", Interval: time.Hour, Run: sessions.DeleteExpiredTokens})
+	jobs.Add(service.Job{Name: "trash-purge", Description: "Очистка корзины", Interval: time.Hour, Run: trash.Purge})
+	h := &handler.Handler{
+		User:         service.NewUserService(userRepo, auditRepo, files),
+		Session:      sessions,
+		Group:        service.NewGroupService(groupRepo, auditRepo),
+		Specialty:    service.NewSpecialtyService(specialtyRepo, auditRepo),
+		Department:   service.NewDepartmentService(memory.NewDepartmentRepo(store), auditRepo),
+		Discipline:   service.NewDisciplineService(memory.NewDisciplineRepo(store), auditRepo),
+		Audit:        service.NewAuditService(auditRepo),
+		Import:       service.NewImportService(userRepo, groupRepo, auditRepo),
+		Rollover:     service.NewRolloverService(groupRepo, userRepo, specialtyRepo, auditRepo),
+		MaterialType: service.NewMaterialTypeService(memory.NewMaterialTypeRepo(store), auditRepo),
+		Material:     service.NewMaterialService(materialRepo, auditRepo, files),
+		Lesson:       service.NewLessonService(memory.NewLessonRepo(store), materialRepo),
+		Catalog:      service.NewCatalogService(memory.NewCatalogRepo(store)),
+		Trash:        trash,
+		Job:          jobs,
+	}
+	app := NewApp(h, testSigningKey)
+	app.Logger.SetOutput(io.Discard)
+
+	env := &testEnv{t: t, app: app, store: store, files: files}
+	env.createUser("admin", "password", 4, nil)
+	env.admin, _ = env.login("admin", "password")
+	return env
+}
+
+// createUser создает пользователя напрямую в хранилище и возвращает его номер.
+func (env *testEnv) createUser(login, password string, roleID int, groupID *int) int {
+	env.t.Helper()
+	hash := sha256.Sum256([]byte(password))
+	user, err := memory.NewUserRepo(env.store).Create(context.Background(), &model.NewUser{
+		Name:     "Иван",
+		Surname:  "Иванов",
+		Login:    login,
+		Password: hex.EncodeToString(hash[:]),
+		RoleID:   roleID,
+		GroupID:  groupID,
+	})
+	if err != nil {
+		env.t.Fatalf("create user %v: %v", login, err)
+	}
+	return user.ID
+}
+
+// do выполняет запрос с телом body в формате json и jwt токеном token, если они не пусты.
+func (env *testEnv) do(method, path, token string, body any) *httptest.ResponseRecorder {
+	env.t.Helper()
+	return env.serve(env.request(method, path, body), token)
+}
+
+// request возвращает запрос с телом body в формате json, если оно не пусто.
+func (env *testEnv) request(method, path string, body any) *http.Request {
+	env.t.Helper()
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			env.t.Fatalf("marshal body: %v", err)
+		}
+		reader = bytes.NewReader(raw)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	return req
+}
+
+// upload выполняет запрос с формой, содержащей поля fields и файл content в поле fileField.
+func (env *testEnv) upload(method, path, token string, fields map[string]string, fileField, fileName string, content []byte) *httptest.ResponseRecorder {
+	env.t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for key, value := range fields {
+		form.WriteField(key, value)
+	}
+	part, err := form.CreateFormFile(fileField, fileName)
+	if err != nil {
+		env.t.Fatalf("create form file: %v", err)
+	}
+	part.Write(content)
+	form.Close()
+	req := httptest.NewRequest(method, path, &body)
+	req.Header.Set(echo.HeaderContentType, form.FormDataContentType())
+	return env.serve(req, token)
+}
+
+// serve выполняет запрос к приложению с jwt токеном token, если он не пуст.
+func (env *testEnv) serve(req *http.Request, token string) *httptest.ResponseRecorder {
+	if token != "" {
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	env.app.ServeHTTP(rec, req)
+	return rec
+}
+
+// expect проверяет код ответа и возвращает тело ответа.
+func (env *testEnv) expect(rec *httptest.ResponseRecorder, status int) []byte {
+	env.t.Helper()
+	if rec.Code != status {
+		env.t.Fatalf("got status %v, want %v; body: %s", rec.Code, status, rec.Body.String())
+	}
+	return rec.Body.Bytes()
+}
+
+// decode разбирает тело ответа в формате json в v.
+func (env *testEnv) decode(body []byte, v any) {
+	env.t.Helper()
+	if err := json.Unmarshal(body, v); err != nil {
+		env.t.Fatalf("decode %s: %v", body, err)
+	}
+}
+
+// create выполняет запрос создания сущности и возвращает ее номер.
+func (env *testEnv) create(path string, body any) int {
+	env.t.Helper()
+	var created struct{ ID int }
+	env.decode(env.expect(env.do(http.MethodPost, path, env.admin, body), http.StatusCreated), &created)
+	return created.ID
+}
+
+// login входит в систему и возвращает токен доступа и токен обновления.
+func (env *testEnv) login(login, password string) (accessToken, refreshToken string) {
+	env.t.Helper()
+	rec := env.do(http.MethodPost, "/auth/session", "", model.Credentials{Username: login, Password: password})
+	var tokens struct {
+		AccessToken  string `json:"accessToken"`
+		RefreshToken string `json:"refreshToken"`
+	}
+	env.decode(env.expect(rec, http.StatusCreated), &tokens)
+	return tokens.AccessToken, tokens.RefreshToken
+}
+
+func TestSessionLifecycle(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.expect(env.do(http.MethodPost, "/auth/session", "", model.Credentials{Username: "admin", Password: "wrong"}), http.StatusUnauthorized)
+	env.expect(env.do(http.MethodPost, "/auth/session", "", model.Credentials{Username: "nobody", Password: "password"}), http.StatusUnauthorized)
+	env.expect(env.do(http.MethodPost, "/auth/session", "", map[string]string{}), http.StatusBadRequest)
+
+	access, refresh := env.login("admin", "password")
+	env.expect(env.do(http.MethodGet, "/api/me", access, nil), http.StatusOK)
+
+	var tokens struct {
+		AccessToken  string `json:"accessToken"`
+		RefreshToken string `json:"refreshToken"`
+	}
+	rec := env.do(http.MethodPut, "/auth/session", "", map[string]string{"refreshToken": refresh})
+	env.decode(env.expect(rec, http.StatusOK), &tokens)
+	if tokens.RefreshToken == refresh {
+		t.Error("refresh token was not rotated")
+	}
+	env.expect(env.do(http.MethodGet, "/api/me", tokens.AccessToken, nil), http.StatusOK)
+
+	// Старый токен обновления больше не действителен.
+	env.expect(env.do(http.MethodPut, "/auth/session", "", map[string]string{"refreshToken": refresh}), http.StatusNotFound)
+
+	env.expect(env.do(http.MethodDelete, "/auth/session", "", map[string]string{"refreshToken": tokens.RefreshToken}), http.StatusNoContent)
+	env.expect(env.do(http.MethodPut, "/auth/session", "", map[string]string{"refreshToken": tokens.RefreshToken}), http.StatusNotFound)
+}
+
+func TestExpiredRefreshToken(t *testing.T) {
+	env := newTestEnv(t)
+	_, refresh := env.login("admin", "password")
+	sessions := memory.NewSessionRepo(env.store)
+	token, err := sessions.PopByRefreshToken(context.Background(), refresh)
+	if err != nil {
+		t.Fatalf("pop token: %v", err)
+	}
+	expired := &model.NewToken{RefreshToken: refresh, ExpiresAt: int(time.Now().Add(-time.Hour).Unix())}
+	if _, err := sessions.UpdateRefreshToken(context.Background(), token.SessionID, expired); err != nil {
+		t.Fatalf("update token: %v", err)
+	}
+	env.expect(env.do(http.MethodPut, "/auth/session", "", map[string]string{"refreshToken": refresh}), http.StatusUnauthorized)
+}
+
+func TestSessionPolicy(t *testing.T) {
+	policy := service.DefaultSessionPolicy()
+	policy.Roles = map[model.UserRole]service.TokenLifetimes{model.AdminRole: {AccessTTL: time.Minute}}
+	policy.MaxSessions = 2
+	env := newTestEnvWithPolicy(t, policy)
+	env.createUser("student", "password", 1, nil)
+
+	expiresIn := func(accessToken string) time.Duration {
+		t.Helper()
+		claims := new(model.JWTClaims)
+		if _, err := jwt.ParseWithClaims(accessToken, claims, func(*jwt.Token) (any, error) { return []byte(testSigningKey), nil }); err != nil {
+			t.Fatalf("parse jwt: %v", err)
+		}
+		return time.Until(claims.ExpiresAt.Time)
+	}
+	if ttl := expiresIn(env.admin); ttl > time.Minute {
+		t.Errorf("admin access token lifetime: got %v, want at most 1m", ttl)
+	}
+	if student, _ := env.login("student", "password"); expiresIn(student) <= time.Minute {
+		t.Errorf("student access token lifetime: got %v, want the default 15m", expiresIn(student))
+	}
+
+	// У администратора уже есть сессия из newTestEnv, поэтому первая из новых сессий вытесняется.
+	_, oldest := env.login("admin", "password")
+	_, previous := env.login("admin", "password")
+	_, newest := env.login("admin", "password")
+	env.expect(env.do(http.MethodPut, "/auth/session", "", map[string]string{"refreshToken": oldest}), http.StatusNotFound)
+	env.expect(env.do(http.MethodPut, "/auth/session", "", map[string]string{"refreshToken": previous}), http.StatusOK)
+	env.expect(env.do(http.MethodPut, "/auth/session", "", map[string]string{"refreshToken": newest}), http.StatusOK)
+
+	// В журнал попадают вытесненные сессии с причиной завершения, а обновления токенов — нет.
+	var events []model.AuditEvent
+	env.decode(env.expect(env.do(http.MethodGet, "/api/audit?entity=session&action=update", env.admin, nil), http.StatusOK), &events)
+	if len(events) != 2 {
+		t.Fatalf("session update events: got %+v, want 2 evictions", events)
+	}
+	for _, event := range events {
+		if event.After == nil || !strings.Contains(string(*event.After), `"reason":"evicted"`) {
+			t.Errorf("eviction event: got %+v", event)
+		}
+	}
+
+	for name, policy := range map[string]service.SessionPolicy{
+		"idle timeout": {TokenLifetimes: policy.TokenLifetimes, IdleTimeout: time.Nanosecond},
+		"max age":      {TokenLifetimes: policy.TokenLifetimes, MaxAge: time.Nanosecond},
+	} {
+		t.Run(name, func(t *testing.T) {
+			env := newTestEnvWithPolicy(t, policy)
+			_, refresh := env.login("admin", "password")
+			env.expect(env.do(http.MethodPut, "/auth/session", "", map[string]string{"refreshToken": refresh}), http.StatusUnauthorized)
+		})
+	}
+}
+
+func TestErrorMapping(t *testing.T) {
+	env := newTestEnv(t)
+
+	expired, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &model.JWTClaims{
+		Role: model.AdminRole,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "1",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+		},
+	}).SignedString([]byte(testSigningKey))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	env.expect(env.do(http.MethodGet, "/api/departments", expired, nil), http.StatusUnauthorized)
+	env.expect(env.do(http.MethodGet, "/api/departments", testToken(t, model.StudentRole), nil), http.StatusOK)
+	env.expect(env.do(http.MethodPost, "/api/departments", testToken(t, model.TeacherRole), model.NewDepartment{Name: "Кафедра"}), http.StatusForbidden)
+	env.expect(env.do(http.MethodGet, "/api/departments/100", env.admin, nil), http.StatusNotFound)
+	env.expect(env.do(http.MethodGet, "/api/departments/abc", env.admin, nil), http.StatusBadRequest)
+	env.expect(env.do(http.MethodPut, "/api/departments/100", env.admin, model.NewDepartment{Name: "Кафедра"}), http.StatusNotFound)
+	env.expect(env.do(http.MethodDelete, "/api/departments/100", env.admin, nil), http.StatusNotFound)
+}
+
+func TestConstraintErrors(t *testing.T) {
+	env := newTestEnv(t)
+	departmentID := env.create("/api/departments", model.NewDepartment{Name: "Кафедра"})
+	env.create("/api/specialties", model.NewSpecialty{Name: "Специальность", DepartmentID: departmentID})
+
+	user := model.NewUser{Name: "Петр", Surname: "Петров", Login: "admin", Password: "secret", RoleID: 2}
+	env.expectProblem(env.do(http.MethodPost, "/api/users", env.admin, user), http.StatusConflict, "conflict", "login")
+	env.expectProblem(env.do(http.MethodDelete, fmt.Sprintf("/api/departments/%v", departmentID), env.admin, nil), http.StatusConflict, "referenced", "departmentID")
+	env.expectProblem(env.do(http.MethodPost, "/api/specialties", env.admin, model.NewSpecialty{Name: "Связь", DepartmentID: departmentID + 100}),
+		http.StatusUnprocessableEntity, "invalid_reference", "departmentID")
+}
+
+func TestProblemDetails(t *testing.T) {
+	env := newTestEnv(t)
+
+	rec := env.do(http.MethodPost, "/api/users", env.admin, map[string]any{"name": "Без логина", "roleID": 0})
+	problem := env.expectProblem(rec, http.StatusBadRequest, "validation_failed", "surname")
+	if problem.Title != "Некорректные данные запроса" || problem.Instance != "/api/users" {
+		t.Errorf("validation problem: got %+v", problem)
+	}
+	if problem.RequestID == "" || problem.RequestID != rec.Header().Get(echo.HeaderXRequestID) {
+		t.Errorf("validation problem: got request ID %q, header %q", problem.RequestID, rec.Header().Get(echo.HeaderXRequestID))
+	}
+	rules := make(map[string]string)
+	for _, fe := range problem.Errors {
+		rules[fe.Field] = fe.Rule
+	}
+	if rules["surname"] != "required" || rules["roleID"] != "required" {
+		t.Errorf("validation problem: got field errors %+v", problem.Errors)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/departments/100", nil)
+	req.Header.Set("Accept-Language", "de-DE, en-US;q=0.8, ru;q=0.5")
+	problem = env.expectProblem(env.serve(req, env.admin), http.StatusNotFound, "not_found", "")
+	if problem.Title != "Resource not found" {
+		t.Errorf("english problem: got title %q", problem.Title)
+	}
+
+	problem = env.expectProblem(env.do(http.MethodGet, "/api/departments", "", nil), http.StatusUnauthorized, "unauthorized", "")
+	if problem.Detail == "" {
+		t.Error("missing token problem: detail is empty")
+	}
+}
+
+func TestOptimisticConcurrency(t *testing.T) {
+	env := newTestEnv(t)
+	departmentID := env.create("/api/departments", model.NewDepartment{Name: "Кафедра"})
+	path := fmt.Sprintf("/api/specialties/%v", env.create("/api/specialties", model.NewSpecialty{Name: "Связь", DepartmentID: departmentID}))
+	update := model.NewSpecialty{Name: "Радиотехника", DepartmentID: departmentID}
+
+	// withIfMatch выполняет запрос с заголовком If-Match.
+	withIfMatch := func(method, etag string, body any) *httptest.ResponseRecorder {
+		req := env.request(method, path, body)
+		req.Header.Set("If-Match", etag)
+		return env.serve(req, env.admin)
+	}
+
+	rec := env.do(http.MethodGet, path, env.admin, nil)
+	env.expect(rec, http.StatusOK)
+	etag := rec.Header().Get("ETag")
+	if etag != `"1"` {
+		t.Fatalf("GET %v: got ETag %q, want \"1\"", path, etag)
+	}
+
+	rec = withIfMatch(http.MethodPut, etag, update)
+	env.expect(rec, http.StatusNoContent)
+	if got := rec.Header().Get("ETag"); got != `"2"` {
+		t.Errorf("PUT %v: got ETag %q, want \"2\"", path, got)
+	}
+	env.expectProblem(withIfMatch(http.MethodPut, etag, update), http.StatusPreconditionFailed, "version_mismatch", "")
+	env.expectProblem(withIfMatch(http.MethodDelete, etag, nil), http.StatusPreconditionFailed, "version_mismatch", "")
+	env.expectProblem(withIfMatch(http.MethodDelete, "abc", nil), http.StatusPreconditionFailed, "precondition_failed", "")
+
+	// Без заголовка If-Match ресурс изменяется без проверки версии.
+	env.expect(env.do(http.MethodPut, path, env.admin, update), http.StatusNoContent)
+	env.expect(withIfMatch(http.MethodDelete, `"3"`, nil), http.StatusNoContent)
+}
+
+func TestMergePatch(t *testing.T) {
+	env := newTestEnv(t)
+	departmentID := env.create("/api/departments", model.NewDepartment{Name: "Кафедра"})
+	specialtyID := env.create("/api/specialties", model.NewSpecialty{Name: "Связь", DepartmentID: departmentID})
+	groupID := env.create("/api/groups", model.NewGroup{Name: "1101", SpecialtyID: specialtyID})
+	otherGroupID := env.create("/api/groups", model.NewGroup{Name: "1102", SpecialtyID: specialtyID})
+	email := "petrov@example.com"
+	userPath := fmt.Sprintf("/api/users/%v", env.create("/api/users", model.NewUser{
+		Name: "Петр", Surname: "Петров", Login: "petrov", Password: "secret", RoleID: 1, GroupID: &groupID, Email: &email,
+	}))
+
+	// patch выполняет запрос PATCH с телом body в формате JSON Merge Patch и заголовком If-Match, если он не пуст.
+	patch := func(path, etag string, body any) *httptest.ResponseRecorder {
+		req := env.request(http.MethodPatch, path, body)
+		req.Header.Set(echo.HeaderContentType, model.MergePatchContentType)
+		if etag != "" {
+			req.Header.Set("If-Match", etag)
+		}
+		return env.serve(req, env.admin)
+	}
+
+	// Пароль не указан в патче и остается прежним, а email, равный null, удаляется.
+	rec := patch(userPath, `"1"`, map[string]any{"groupID": otherGroupID, "email": nil})
+	env.expect(rec, http.StatusNoContent)
+	if etag := rec.Header().Get("ETag"); etag != `"2"` {
+		t.Errorf("PATCH %v: got ETag %q, want \"2\"", userPath, etag)
+	}
+	var user model.User
+	env.decode(env.expect(env.do(http.MethodGet, userPath, env.admin, nil), http.StatusOK), &user)
+	if user.Name != "Петр" || user.GroupID == nil || *user.GroupID != otherGroupID || user.Email != nil {
+		t.Errorf("GET %v: got %+v, want name Петр, group %v and no email", userPath, user, otherGroupID)
+	}
+	env.login("petrov", "secret")
+
+	env.expectProblem(patch(userPath, "", json.RawMessage(`{"name": null}`)), http.StatusBadRequest, "bad_request", "")
+	env.expectProblem(patch(userPath, "", map[string]any{"name": ""}), http.StatusBadRequest, "validation_failed", "name")
+	env.expectProblem(patch(userPath, "", map[string]any{"groupID": 999}), http.StatusUnprocessableEntity, "invalid_reference", "groupID")
+	env.expectProblem(patch(userPath, `"1"`, map[string]any{"name": "Павел"}), http.StatusPreconditionFailed, "version_mismatch", "")
+
+	req := env.request(http.MethodPatch, userPath, map[string]any{"name": "Павел"})
+	req.Header.Set(echo.HeaderContentType, echo.MIMETextPlain)
+	env.expectProblem(env.serve(req, env.admin), http.StatusUnsupportedMediaType, "unsupported_media_type", "")
+
+	specialtyPath := fmt.Sprintf("/api/specialties/%v", specialtyID)
+	env.expect(patch(specialtyPath, "", map[string]any{"name": "Радиотехника"}), http.StatusNoContent)
+	var specialty model.Specialty
+	env.decode(env.expect(env.do(http.MethodGet, specialtyPath, env.admin, nil), http.StatusOK), &specialty)
+	if specialty.Name != "Радиотехника" || specialty.DepartmentID != departmentID {
+		t.Errorf("GET %v: got %+v, want renamed specialty of department %v", specialtyPath, specialty, departmentID)
+	}
+}
+
+// expectProblem проверяет, что ответ является описанием ошибки с кодом ответа status и кодом ошибки code.
+// Если field не пусто, то проверяется, что первая ошибка поля относится к нему.
+func (env *testEnv) expectProblem(rec *httptest.ResponseRecorder, status int, code, field string) *model.Problem {
+	env.t.Helper()
+	body := env.expect(rec, status)
+	if contentType := rec.Header().Get(echo.HeaderContentType); contentType != model.ProblemContentType {
+		env.t.Errorf("got content type %q, want %q", contentType, model.ProblemContentType)
+	}
+	problem := new(model.Problem)
+	env.decode(body, problem)
+	if problem.Status != status || problem.Code != code {
+		env.t.Errorf("got problem %+v, want status %v and code %v", problem, status, code)
+	}
+	if field != "" && (len(problem.Errors) == 0 || problem.Errors[0].Field != field) {
+		env.t.Errorf("got field errors %+v, want field %v", problem.Errors, field)
+	}
+	return problem
+}
+
+// crudCase описывает проверку маршрутов создания, чтения, изменения и удаления сущности.
+type crudCase struct {
+	path   string
+	create any
+	update any
+	field  string // поле ответа, которое меняет update
+	want   any    // значение поля после update
+}
+
+// testCRUD проверяет полный цикл маршрутов сущности.
+func (env *testEnv) testCRUD(c crudCase) int {
+	env.t.Helper()
+	ID := env.create(c.path, c.create)
+	itemPath := fmt.Sprintf("%v/%v", c.path, ID)
+
+	var all []map[string]any
+	env.decode(env.expect(env.do(http.MethodGet, c.path, env.admin, nil), http.StatusOK), &all)
+	if len(all) == 0 {
+		env.t.Fatalf("GET %v: created entity is missing", c.path)
+	}
+
+	env.expect(env.do(http.MethodPut, itemPath, env.admin, c.update), http.StatusNoContent)
+	var item map[string]any
+	env.decode(env.expect(env.do(http.MethodGet, itemPath, env.admin, nil), http.StatusOK), &item)
+	if fmt.Sprint(item[c.field]) != fmt.Sprint(c.want) {
+		env.t.Errorf("GET %v: %v = %v, want %v", itemPath, c.field, item[c.field], c.want)
+	}
+	return ID
+}
+
+// testDelete проверяет удаление сущности и повторное удаление.
+func (env *testEnv) testDelete(path string, ID int) {
+	env.t.Helper()
+	itemPath := fmt.Sprintf("%v/%v", path, ID)
+	env.expect(env.do(http.MethodDelete, itemPath, env.admin, nil), http.StatusNoContent)
+	env.expect(env.do(http.MethodGet, itemPath, env.admin, nil), http.StatusNotFound)
+	env.expect(env.do(http.MethodDelete, itemPath, env.admin, nil), http.StatusNotFound)
+}
+
+func TestReferenceDataCRUD(t *testing.T) {
+	env := newTestEnv(t)
+
+	departmentID := env.testCRUD(crudCase{
+		path:   "/api/departments",
+		create: model.NewDepartment{Name: "Кафедра 1"},
+		update: model.NewDepartment{Name: "Кафедра 2"},
+		field:  "name", want: "Кафедра 2",
+	})
+	specialtyID := env.testCRUD(crudCase{
+		path:   "/api/specialties",
+		create: model.NewSpecialty{Name: "Специальность 1", DepartmentID: departmentID},
+		update: model.NewSpecialty{Name: "Специальность 2", DepartmentID: departmentID},
+		field:  "name", want: "Специальность 2",
+	})
+	groupID := env.testCRUD(crudCase{
+		path:   "/api/groups",
+		create: model.NewGroup{Name: "1101", SpecialtyID: specialtyID},
+		update: model.NewGroup{Name: "1201", SpecialtyID: specialtyID},
+		field:  "name", want: "1201",
+	})
+	disciplineID := env.testCRUD(crudCase{
+		path:   "/api/disciplines",
+		create: model.NewDiscipline{Name: "Предмет 1", SpecialtyID: specialtyID},
+		update: model.NewDiscipline{Name: "Предмет 2", SpecialtyID: specialtyID},
+		field:  "name", want: "Предмет 2",
+	})
+	typeID := env.testCRUD(crudCase{
+		path:   "/api/material-types",
+		create: model.NewMaterialType{Name: "Статья"},
+		update: model.NewMaterialType{Name: "Методичка"},
+		field:  "name", want: "Методичка",
+	})
+
+	env.testDelete("/api/material-types", typeID)
+	env.testDelete("/api/disciplines", disciplineID)
+	env.testDelete("/api/groups", groupID)
+	env.testDelete("/api/specialties", specialtyID)
+	env.testDelete("/api/departments", departmentID)
+
+	env.expect(env.do(http.MethodPost, "/api/groups", env.admin, model.NewGroup{Name: "1101"}), http.StatusBadRequest)
+}
+
+func TestUsersCRUD(t *testing.T) {
+	env := newTestEnv(t)
+	departmentID := env.create("/api/departments", model.NewDepartment{Name: "Кафедра"})
+	specialtyID := env.create("/api/specialties", model.NewSpecialty{Name: "Специальность", DepartmentID: departmentID})
+	groupID := env.create("/api/groups", model.NewGroup{Name: "1101", SpecialtyID: specialtyID})
+
+	student := model.NewUser{Name: "Петр", Surname: "Петров", Login: "petrov", Password: "secret", RoleID: 1, GroupID: &groupID}
+	userID := env.testCRUD(crudCase{
+		path:   "/api/users",
+		create: student,
+		update: model.NewUser{Name: "Павел", Surname: "Петров", Login: "petrov", Password: "secret", RoleID: 1, GroupID: &groupID},
+		field:  "name", want: "Павел",
+	})
+
+	var found []model.User
+	env.decode(env.expect(env.do(http.MethodGet, "/api/users?q=павел", env.admin, nil), http.StatusOK), &found)
+	if len(found) != 1 || found[0].ID != userID {
+		t.Errorf("search users: got %+v, want user %v", found, userID)
+	}
+
+	env.expect(env.upload(http.MethodPut, fmt.Sprintf("/api/users/%v/photo", userID), env.admin, nil, "photo", "photo.png", pngHeader), http.StatusNoContent)
+	env.expect(env.do(http.MethodGet, fmt.Sprintf("/api/users/%v/photo", userID), env.admin, nil), http.StatusOK)
+	env.expect(env.do(http.MethodDelete, fmt.Sprintf("/api/users/%v/photo", userID), env.admin, nil), http.StatusNoContent)
+	env.expect(env.do(http.MethodGet, fmt.Sprintf("/api/users/%v/photo", userID), env.admin, nil), http.StatusNotFound)
+
+	env.testDelete("/api/users", userID)
+	teacher := model.NewUser{Name: "Сергей", Surname: "Сергеев", Login: "sergeev", Password: "secret", RoleID: 2}
+	env.testDelete("/api/users", env.create("/api/users", teacher))
+	env.expect(env.do(http.MethodPost, "/api/users", env.admin, map[string]any{"name": "Без логина"}), http.StatusBadRequest)
+}
+
+func TestCurrentUser(t *testing.T) {
+	env := newTestEnv(t)
+	env.createUser("teacher", "password", 2, nil)
+	token, _ := env.login("teacher", "password")
+
+	var profile model.Profile
+	env.decode(env.expect(env.do(http.MethodGet, "/api/me", token, nil), http.StatusOK), &profile)
+	if profile.RoleName != "teacher" {
+		t.Errorf("role name: got %q, want teacher", profile.RoleName)
+	}
+
+	env.expect(env.do(http.MethodPatch, "/api/me", token, map[string]any{"email": "teacher@example.com"}), http.StatusNoContent)
+	env.decode(env.expect(env.do(http.MethodGet, "/api/me", token, nil), http.StatusOK), &profile)
+	if profile.Email == nil || *profile.Email != "teacher@example.com" {
+		t.Errorf("email: got %v, want teacher@example.com", profile.Email)
+	}
+	env.expect(env.do(http.MethodPatch, "/api/me", token, map[string]any{"preferences": []int{1}}), http.StatusBadRequest)
+
+	env.expect(env.upload(http.MethodPut, "/api/me/photo", token, nil, "photo", "photo.txt", []byte("not an image")), http.StatusUnsupportedMediaType)
+	env.expect(env.upload(http.MethodPut, "/api/me/photo", token, nil, "photo", "photo.png", pngHeader), http.StatusNoContent)
+	rec := env.do(http.MethodGet, "/api/me/photo", token, nil)
+	env.expect(rec, http.StatusOK)
+	if contentType := rec.Header().Get(echo.HeaderContentType); contentType != "image/png" {
+		t.Errorf("photo content type: got %q, want image/png", contentType)
+	}
+	env.expect(env.do(http.MethodDelete, "/api/me/photo", token, nil), http.StatusNoContent)
+
+	env.expect(env.do(http.MethodPut, "/api/me/password", token, model.PasswordChange{CurrentPassword: "wrong", NewPassword: "new-password"}), http.StatusUnauthorized)
+	env.expect(env.do(http.MethodPut, "/api/me/password", token, model.PasswordChange{CurrentPassword: "password", NewPassword: "new-password"}), http.StatusNoContent)
+	env.login("teacher", "new-password")
+}
+
+// countingReader считает прочитанные из r байты.
+type countingReader struct {
+	r    io.Reader
+	read int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.read += int64(n)
+	return n, err
+}
+
+// zeros — бесконечный источник нулевых байтов.
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func TestUploadBodyLimit(t *testing.T) {
+	env := newTestEnv(t)
+	typeID := env.create("/api/material-types", model.NewMaterialType{Name: "Статья"})
+	fields := map[string]string{"name": "Статья", "typeID": fmt.Sprint(typeID)}
+	var material struct{ ID int }
+	env.decode(env.expect(env.upload(http.MethodPost, "/api/materials", env.admin, fields, "file", "article.pdf", []byte("%PDF-1.4")), http.StatusCreated), &material)
+
+	// Файл в 1 ГиБ генерируется по мере чтения: сервер должен отказать, не дочитывая тело.
+	const fileSize = 1 << 30
+	uploads := []struct {
+		method, path, field string
+		fields              map[string]string // поля формы перед файлом
+	}{
+		{http.MethodPut, "/api/me/photo", "photo", nil},
+		{http.MethodPost, "/api/users/import", "file", nil},
+		{http.MethodPost, "/api/materials", "file", fields},
+		{http.MethodPut, fmt.Sprintf("/api/materials/%v/file", material.ID), "file", nil},
+	}
+	for _, upload := range uploads {
+		var head bytes.Buffer
+		form := multipart.NewWriter(&head)
+		for key, value := range upload.fields {
+			form.WriteField(key, value)
+		}
+		if _, err := form.CreateFormFile(upload.field, "upload.csv"); err != nil {
+			t.Fatalf("create form file: %v", err)
+		}
+		body := &countingReader{r: io.MultiReader(&head, io.LimitReader(zeros{}, fileSize))}
+		req := httptest.NewRequest(upload.method, upload.path, body)
+		req.Header.Set(echo.HeaderContentType, form.FormDataContentType())
+		env.expectProblem(env.serve(req, env.admin), http.StatusRequestEntityTooLarge, "request_too_large", "")
+		if body.read > 128<<20 {
+			t.Errorf("%v %v: read %v bytes of a %v byte upload", upload.method, upload.path, body.read, fileSize)
+		}
+	}
+}
+
+func TestMaterials(t *testing.T) {
+	env := newTestEnv(t)
+	teacher := testToken(t, model.TeacherRole)
+	student := testToken(t, model.StudentRole)
+	typeID := env.create("/api/material-types", model.NewMaterialType{Name: "Статья"})
+	lesson := env.store.AddLesson(model.Lesson{ChapterID: 1, TypeID: 1})
+	bookID := env.store.AddBook()
+
+	fields := map[string]string{"name": "Статья о связи", "typeID": fmt.Sprint(typeID)}
+	env.expect(env.upload(http.MethodPost, "/api/materials", student, fields, "file", "article.pdf", []byte("%PDF-1.4")), http.StatusForbidden)
+	var created struct{ ID int }
+	env.decode(env.expect(env.upload(http.MethodPost, "/api/materials", teacher, fields, "file", "article.pdf", []byte("%PDF-1.4")), http.StatusCreated), &created)
+	materialPath := fmt.Sprintf("/api/materials/%v", created.ID)
+
+	rec := env.do(http.MethodGet, materialPath+"/file", student, nil)
+	if body := env.expect(rec, http.StatusOK); string(body) != "%PDF-1.4" {
+		t.Errorf("material file: got %q", body)
+	}
+	if contentType := rec.Header().Get(echo.HeaderContentType); contentType != "application/pdf" {
+		t.Errorf("material content type: got %q, want application/pdf", contentType)
+	}
+	env.expect(env.upload(http.MethodPut, materialPath+"/file", teacher, nil, "file", "article.txt", []byte("text")), http.StatusNoContent)
+	if env.files.Len() != 1 {
+		t.Errorf("storage has %v files after replacing the material file, want 1", env.files.Len())
+	}
+	env.expect(env.do(http.MethodPut, materialPath, teacher, model.NewMaterial{Name: "Статья", TypeID: typeID}), http.StatusNoContent)
+
+	lessonPath := fmt.Sprintf("/api/lessons/%v", lesson.ID)
+	linkPath := fmt.Sprintf("%v/materials/%v", lessonPath, created.ID)
+	env.expect(env.do(http.MethodPut, linkPath, student, nil), http.StatusForbidden)
+	env.expect(env.do(http.MethodPut, linkPath, teacher, nil), http.StatusNoContent)
+	env.expect(env.do(http.MethodPut, linkPath, teacher, nil), http.StatusNoContent)
+	env.expect(env.do(http.MethodPut, fmt.Sprintf("/api/lessons/100/materials/%v", created.ID), teacher, nil), http.StatusNotFound)
+
+	var got model.Lesson
+	env.decode(env.expect(env.do(http.MethodGet, lessonPath, student, nil), http.StatusOK), &got)
+	if len(got.Materials) != 1 || got.Materials[0].Name != "Статья" {
+		t.Errorf("lesson materials: got %+v", got.Materials)
+	}
+
+	bookLinkPath := fmt.Sprintf("/api/books/%v/materials/%v", bookID, created.ID)
+	env.expect(env.do(http.MethodPut, bookLinkPath, teacher, nil), http.StatusNoContent)
+	var bookMaterials []model.Material
+	env.decode(env.expect(env.do(http.MethodGet, fmt.Sprintf("/api/books/%v/materials", bookID), student, nil), http.StatusOK), &bookMaterials)
+	if len(bookMaterials) != 1 {
+		t.Errorf("book materials: got %+v", bookMaterials)
+	}
+	env.expect(env.do(http.MethodDelete, bookLinkPath, teacher, nil), http.StatusNoContent)
+	env.expect(env.do(http.MethodDelete, bookLinkPath, teacher, nil), http.StatusNotFound)
+
+	env.expect(env.do(http.MethodDelete, materialPath, teacher, nil), http.StatusNoContent)
+	env.decode(env.expect(env.do(http.MethodGet, lessonPath, student, nil), http.StatusOK), &got)
+	if len(got.Materials) != 0 {
+		t.Errorf("lesson materials after material deletion: got %+v", got.Materials)
+	}
+	if env.files.Len() != 0 {
+		t.Errorf("storage has %v files after material deletion, want 0", env.files.Len())
+	}
+}
+
+func TestCatalogTree(t *testing.T) {
+	env := newTestEnv(t)
+	departmentID := env.create("/api/departments", model.NewDepartment{Name: "Кафедра"})
+	specialtyID := env.create("/api/specialties", model.NewSpecialty{Name: "Специальность", DepartmentID: departmentID})
+	disciplineID := env.create("/api/disciplines", model.NewDiscipline{Name: "Предмет", SpecialtyID: specialtyID})
+	chapter := env.store.AddChapter(disciplineID, 1)
+	env.store.AddLesson(model.Lesson{ChapterID: chapter.ID, TypeID: 1})
+
+	var tree []model.CatalogDepartment
+	env.decode(env.expect(env.do(http.MethodGet, "/api/catalog/tree?counts=true", testToken(t, model.StudentRole), nil), http.StatusOK), &tree)
+	if len(tree) != 1 || len(tree[0].Specialties) != 1 || len(tree[0].Specialties[0].Disciplines) != 1 {
+		t.Fatalf("catalog tree: got %+v", tree)
+	}
+	chapters := tree[0].Specialties[0].Disciplines[0].Chapters
+	if len(chapters) != 1 || len(chapters[0].Lessons) != 1 || chapters[0].LessonCount == nil || *chapters[0].LessonCount != 1 {
+		t.Errorf("catalog chapters: got %+v", chapters)
+	}
+
+	var shallow []model.CatalogDepartment
+	env.decode(env.expect(env.do(http.MethodGet, "/api/catalog/tree?depth=1", env.admin, nil), http.StatusOK), &shallow)
+	if len(shallow) != 1 || shallow[0].Specialties != nil || shallow[0].SpecialtyCount != nil {
+		t.Errorf("catalog tree with depth 1: got %+v", shallow)
+	}
+	env.expect(env.do(http.MethodGet, "/api/catalog/tree?depth=6", env.admin, nil), http.StatusBadRequest)
+}
+
+func TestImportAndRollover(t *testing.T) {
+	env := newTestEnv(t)
+	departmentID := env.create("/api/departments", model.NewDepartment{Name: "Кафедра"})
+	specialtyID := env.create("/api/specialties", model.NewSpecialty{Name: "Специальность", DepartmentID: departmentID})
+	graduatesID := env.create("/api/groups", model.NewGroup{Name: "1501", SpecialtyID: specialtyID})
+	juniorsID := env.create("/api/groups", model.NewGroup{Name: "1101", SpecialtyID: specialtyID})
+	// Логин пользователя в корзине остается занятым, и импорт выдает следующий свободный.
+	trashedID := env.createUser("sidorov.ss", "password", 1, nil)
+	env.expect(env.do(http.MethodDelete, fmt.Sprintf("/api/users/%v", trashedID), env.admin, nil), http.StatusNoContent)
+
+	roster := "Фамилия;Имя;Отчество;Взвод\nСидоров;Сидор;Сидорович;1501\nКузнецов;Кузьма;;1101\n"
+	rec := env.upload(http.MethodPost, "/api/users/import", env.admin, nil, "file", "roster.csv", []byte(roster))
+	var report model.ImportReport
+	env.decode(env.expect(rec, http.StatusCreated), &report)
+	if report.Created != 2 {
+		t.Fatalf("import report: got %+v, want 2 created", report)
+	}
+	if report.Rows[0].Login != "sidorov.ss2" {
+		t.Errorf("import login next to a trashed user's login: got %q, want sidorov.ss2", report.Rows[0].Login)
+	}
+	junior := report.Rows[1]
+
+	// С выданным при импорте одноразовым паролем доступна только его смена,
+	// а после смены и обновления токенов — все остальное.
+	juniorAccess, juniorRefresh := env.login(junior.Login, junior.Password)
+	env.expectProblem(env.do(http.MethodGet, "/api/me", juniorAccess, nil), http.StatusForbidden, "password_change_required", "")
+	env.expectProblem(env.do(http.MethodGet, "/api/departments", juniorAccess, nil), http.StatusForbidden, "password_change_required", "")
+	env.expectProblem(env.do(http.MethodPatch, "/api/me", juniorAccess, map[string]any{}), http.StatusForbidden, "password_change_required", "")
+	var tokens struct {
+		AccessToken  string `json:"accessToken"`
+		RefreshToken string `json:"refreshToken"`
+	}
+	env.decode(env.expect(env.do(http.MethodPut, "/auth/session", "", map[string]string{"refreshToken": juniorRefresh}), http.StatusOK), &tokens)
+	env.expect(env.do(http.MethodGet, "/api/me", tokens.AccessToken, nil), http.StatusForbidden)
+	change := model.PasswordChange{CurrentPassword: junior.Password, NewPassword: "changed-password"}
+	env.expect(env.do(http.MethodPut, "/api/me/password", tokens.AccessToken, change), http.StatusNoContent)
+	env.decode(env.expect(env.do(http.MethodPut, "/auth/session", "", map[string]string{"refreshToken": tokens.RefreshToken}), http.StatusOK), &tokens)
+	env.expect(env.do(http.MethodGet, "/api/me", tokens.AccessToken, nil), http.StatusOK)
+	juniorAccess, _ = env.login(junior.Login, "changed-password")
+	env.expect(env.do(http.MethodGet, "/api/departments", juniorAccess, nil), http.StatusOK)
+
+	rec = env.upload(http.MethodPost, "/api/users/import", env.admin, nil, "file", "roster.csv", []byte(roster))
+	env.decode(env.expect(rec, http.StatusOK), &report)
+	if report.Existing != 2 {
+		t.Errorf("repeated import report: got %+v, want 2 existing", report)
+	}
+	graduate := report.Rows[0]
+	password := resetPassword(t, env, graduate.Login)
+	_, graduateRefresh := env.login(graduate.Login, password)
+
+	rollover := model.Rollover{
+		Promotions: []model.GroupPromotion{{GroupID: juniorsID, NewName: "1201"}},
+		Archives:   []int{graduatesID},
+	}
+	invalid := model.Rollover{Promotions: []model.GroupPromotion{{GroupID: graduatesID, NewName: "1601"}}, Archives: []int{graduatesID}}
+	env.expect(env.do(http.MethodPost, "/api/rollover", env.admin, invalid), http.StatusUnprocessableEntity)
+
+	var plan model.RolloverPlan
+	env.decode(env.expect(env.do(http.MethodPost, "/api/rollover/preview", env.admin, rollover), http.StatusOK), &plan)
+	if len(plan.Errors) != 0 || len(plan.Archives) != 1 || plan.Archives[0].Students != 1 {
+		t.Fatalf("rollover plan: got %+v", plan)
+	}
+	env.expect(env.do(http.MethodPost, "/api/rollover", env.admin, rollover), http.StatusOK)
+
+	var groups []model.Group
+	env.decode(env.expect(env.do(http.MethodGet, "/api/groups", env.admin, nil), http.StatusOK), &groups)
+	if len(groups) != 1 || groups[0].Name != "1201" {
+		t.Errorf("active groups after rollover: got %+v", groups)
+	}
+	env.decode(env.expect(env.do(http.MethodGet, "/api/groups?archived=true", env.admin, nil), http.StatusOK), &groups)
+	if len(groups) != 2 {
+		t.Errorf("all groups after rollover: got %+v", groups)
+	}
+
+	env.expect(env.do(http.MethodPost, "/auth/session", "", model.Credentials{Username: graduate.Login, Password: password}), http.StatusForbidden)
+	env.expect(env.do(http.MethodPut, "/auth/session", "", map[string]string{"refreshToken": graduateRefresh}), http.StatusNotFound)
+
+	// Отключение учетной записи выпускника записывается в журнал аудита.
+	var events []model.AuditEvent
+	env.decode(env.expect(env.do(http.MethodGet, fmt.Sprintf("/api/audit?entity=user&action=update&entityID=%v", *graduate.UserID), env.admin, nil), http.StatusOK), &events)
+	if len(events) != 1 || events[0].After == nil || string(*events[0].After) != `{"deactivated":true}` {
+		t.Errorf("audit of the graduate's deactivation: got %+v", events)
+	}
+	env.decode(env.expect(env.do(http.MethodGet, fmt.Sprintf("/api/audit?entity=group&action=update&entityID=%v", graduatesID), env.admin, nil), http.StatusOK), &events)
+	if len(events) != 1 || events[0].After == nil || !strings.Contains(string(*events[0].After), `"archivedAt"`) {
+		t.Errorf("audit of the graduated group: got %+v", events)
+	}
+}
+
+// resetPassword заменяет пароль пользователя с логином login на известный и возвращает его.
+// Пароли, выданные при импорте, в хранилище есть только в виде хеша.
+func resetPassword(t *testing.T, env *testEnv, login string) string {
+	t.Helper()
+	users := memory.NewUserRepo(env.store)
+	credentials, err := users.GetCredentialsByLogin(context.Background(), login)
+	if err != nil {
+		t.Fatalf("get credentials of %v: %v", login, err)
+	}
+	const password = "known-password"
+	hash := sha256.Sum256([]byte(password))
+	if err := users.UpdatePassword(context.Background(), credentials.UserID, hex.EncodeToString(hash[:]), false); err != nil {
+		t.Fatalf("update password of %v: %v", login, err)
+	}
+	return password
+}
+
+func TestAuditLog(t *testing.T) {
+	env := newTestEnv(t)
+	departmentID := env.create("/api/departments", model.NewDepartment{Name: "Кафедра"})
+	env.expect(env.do(http.MethodPut, fmt.Sprintf("/api/departments/%v", departmentID), env.admin, model.NewDepartment{Name: "Кафедра связи"}), http.StatusNoContent)
+
+	var events []model.AuditEvent
+	env.decode(env.expect(env.do(http.MethodGet, "/api/audit?entity=department", env.admin, nil), http.StatusOK), &events)
+	if len(events) != 2 || events[0].Action != model.AuditUpdate || events[1].Action != model.AuditCreate {
+		t.Fatalf("audit events: got %+v", events)
+	}
+	if events[0].ActorID == nil || *events[0].ActorID != 1 {
+		t.Errorf("audit actor: got %v, want 1", events[0].ActorID)
+	}
+	if after := string(*events[0].After); !strings.Contains(after, "Кафедра связи") || strings.Contains(after, "departmentID") {
+		t.Errorf("audit diff: got %s, want only the changed name", after)
+	}
+	env.expect(env.do(http.MethodGet, "/api/audit", testToken(t, model.ManagerRole), nil), http.StatusForbidden)
+}
+
+// failingAuditRepo — журнал аудита, в который не удается ничего записать.
+type failingAuditRepo struct {
+	*memory.AuditRepo
+}
+
+func (failingAuditRepo) Create(context.Context, *model.NewAuditEvent) (*model.AuditEvent, error) {
+	return nil, errors.New("audit is unavailable")
+}
+
+func TestAuditFailureKeepsChange(t *testing.T) {
+	store := memory.NewStore()
+	departments := service.NewDepartmentService(memory.NewDepartmentRepo(store), failingAuditRepo{memory.NewAuditRepo(store)})
+	department, err := departments.Create(context.Background(), &model.NewDepartment{Name: "Кафедра"})
+	if err != nil {
+		t.Fatalf("create department with failing audit: %v", err)
+	}
+	if _, err := departments.Get(context.Background(), department.ID); err != nil {
+		t.Errorf("get created department: %v", err)
+	}
+}
+
+func TestTrash(t *testing.T) {
+	env := newTestEnv(t)
+	departmentID := env.create("/api/departments", model.NewDepartment{Name: "Кафедра"})
+	specialtyID := env.create("/api/specialties", model.NewSpecialty{Name: "Специальность", DepartmentID: departmentID})
+	disciplineID := env.create("/api/disciplines", model.NewDiscipline{Name: "Предмет", SpecialtyID: specialtyID})
+	departmentPath := fmt.Sprintf("/api/departments/%v", departmentID)
+
+	// Кафедру нельзя удалить, пока на нее ссылается специальность не из корзины.
+	env.expectProblem(env.do(http.MethodDelete, departmentPath, env.admin, nil), http.StatusConflict, "referenced", "departmentID")
+	env.expect(env.do(http.MethodDelete, fmt.Sprintf("/api/disciplines/%v", disciplineID), env.admin, nil), http.StatusNoContent)
+	env.expect(env.do(http.MethodDelete, fmt.Sprintf("/api/specialties/%v", specialtyID), env.admin, nil), http.StatusNoContent)
+	env.expect(env.do(http.MethodDelete, departmentPath, env.admin, nil), http.StatusNoContent)
+
+	env.expect(env.do(http.MethodGet, departmentPath, env.admin, nil), http.StatusNotFound)
+	var tree []model.CatalogDepartment
+	env.decode(env.expect(env.do(http.MethodGet, "/api/catalog/tree", env.admin, nil), http.StatusOK), &tree)
+	if len(tree) != 0 {
+		t.Errorf("catalog tree after delete: got %+v, want empty", tree)
+	}
+
+	var items []model.TrashItem
+	env.decode(env.expect(env.do(http.MethodGet, "/api/trash", env.admin, nil), http.StatusOK), &items)
+	if len(items) != 3 {
+		t.Fatalf("trash: got %+v, want 3 items", items)
+	}
+	env.expect(env.do(http.MethodGet, "/api/trash", testToken(t, model.ManagerRole), nil), http.StatusForbidden)
+
+	// Запись нельзя восстановить раньше записи из корзины, на которую она ссылается.
+	specialtyRestorePath := fmt.Sprintf("/api/trash/specialty/%v/restore", specialtyID)
+	problem := env.expectProblem(env.do(http.MethodPost, specialtyRestorePath, env.admin, nil), http.StatusUnprocessableEntity, "invalid_reference", "departmentID")
+	if len(problem.Errors) == 0 || problem.Errors[0].Rule != "exists_trashed" {
+		t.Errorf("restore specialty of a department in the trash: got field errors %+v, want rule exists_trashed", problem.Errors)
+	}
+	restorePath := fmt.Sprintf("/api/trash/department/%v/restore", departmentID)
+	env.expect(env.do(http.MethodPost, restorePath, env.admin, nil), http.StatusNoContent)
+	env.expect(env.do(http.MethodGet, departmentPath, env.admin, nil), http.StatusOK)
+	env.expect(env.do(http.MethodPost, restorePath, env.admin, nil), http.StatusNotFound)
+	disciplineRestorePath := fmt.Sprintf("/api/trash/discipline/%v/restore", disciplineID)
+	env.expectProblem(env.do(http.MethodPost, disciplineRestorePath, env.admin, nil), http.StatusUnprocessableEntity, "invalid_reference", "specialtyID")
+	env.expect(env.do(http.MethodPost, specialtyRestorePath, env.admin, nil), http.StatusNoContent)
+	env.expect(env.do(http.MethodPost, fmt.Sprintf("/api/trash/group/%v/restore", departmentID), env.admin, nil), http.StatusNotFound)
+
+	// Пользователь из корзины не может войти, но его логин остается занятым.
+	userID := env.createUser("student", "password", 1, nil)
+	env.login("student", "password")
+	env.expect(env.do(http.MethodDelete, fmt.Sprintf("/api/users/%v", userID), env.admin, nil), http.StatusNoContent)
+	env.expect(env.do(http.MethodPost, "/auth/session", "", model.Credentials{Username: "student", Password: "password"}), http.StatusUnauthorized)
+	user := model.NewUser{Name: "Петр", Surname: "Петров", Login: "student", Password: "secret", RoleID: 1}
+	problem = env.expectProblem(env.do(http.MethodPost, "/api/users", env.admin, user), http.StatusConflict, "conflict", "login")
+	if len(problem.Errors) == 0 || problem.Errors[0].Rule != "unique_trashed" {
+		t.Errorf("login of a user in the trash: got field errors %+v, want rule unique_trashed", problem.Errors)
+	}
+
+	purged, err := memory.NewTrashRepo(env.store).Purge(context.Background(), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("purge trash: %v", err)
+	}
+	if purged != 2 {
+		t.Errorf("purge trash: got %v purged records, want 2", purged)
+	}
+	env.decode(env.expect(env.do(http.MethodGet, "/api/trash", env.admin, nil), http.StatusOK), &items)
+	if len(items) != 0 {
+		t.Errorf("trash after purge: got %+v, want empty", items)
+	}
+	env.create("/api/users", user)
+}
+
+func TestJobs(t *testing.T) {
+	env := newTestEnv(t)
+	var jobs []model.Job
+	env.decode(env.expect(env.do(http.MethodGet, "/api/jobs", env.admin, nil), http.StatusOK), &jobs)
+	if len(jobs) != 2 || jobs[0].Name != "expired-tokens" || jobs[0].LastRun != nil || jobs[0].NextRunAt != nil {
+		t.Fatalf("jobs: got %+v, want 2 jobs without runs", jobs)
+	}
+	env.expect(env.do(http.MethodGet, "/api/jobs", testToken(t, model.ManagerRole), nil), http.StatusForbidden)
+
+	var run model.JobRun
+	env.decode(env.expect(env.do(http.MethodPost, "/api/jobs/trash-purge/runs", env.admin, nil), http.StatusCreated), &run)
+	if run.Job != "trash-purge" || run.Status != model.JobSucceeded || run.TriggeredBy != model.JobTriggerManual || run.FinishedAt == nil {
+		t.Errorf("manual run: got %+v", run)
+	}
+	if run.ActorID == nil || *run.ActorID != 1 {
+		t.Errorf("manual run actor: got %v, want 1", run.ActorID)
+	}
+	env.expect(env.do(http.MethodPost, "/api/jobs/unknown/runs", env.admin, nil), http.StatusNotFound)
+
+	env.decode(env.expect(env.do(http.MethodGet, "/api/jobs", env.admin, nil), http.StatusOK), &jobs)
+	if jobs[1].LastRun == nil || jobs[1].LastRun.ID != run.ID || jobs[1].NextRunAt == nil || !jobs[1].NextRunAt.Equal(run.StartedAt.Add(time.Hour)) {
+		t.Errorf("job after run: got %+v", jobs[1])
+	}
+	var runs []model.JobRun
+	env.decode(env.expect(env.do(http.MethodGet, "/api/jobs/runs?job=trash-purge", env.admin, nil), http.StatusOK), &runs)
+	if len(runs) != 1 || runs[0].ID != run.ID {
+		t.Errorf("job runs: got %+v, want the manual run", runs)
+	}
+	env.expect(env.do(http.MethodGet, "/api/jobs/runs?status=unknown", env.admin, nil), http.StatusBadRequest)
+
+	// Пока задачу выполняет другой экземпляр сервера, ручной запуск отклоняется.
+	other := memory.NewLocker(env.store)
+	if held, err := other.TryLock(context.Background(), "elib:job:trash-purge"); err != nil || !held {
+		t.Fatalf("lock job on another instance: held %v, err %v", held, err)
+	}
+	env.expect(env.do(http.MethodPost, "/api/jobs/trash-purge/runs", env.admin, nil), http.StatusConflict)
+	if err := other.Unlock(context.Background(), "elib:job:trash-purge"); err != nil {
+		t.Fatalf("unlock job: %v", err)
+	}
+	env.expect(env.do(http.MethodPost, "/api/jobs/trash-purge/runs", env.admin, nil), http.StatusCreated)
+}
+
+func TestJobScheduleFailsInterrupted(t *testing.T) {
+	store := memory.NewStore()
+	repo := memory.NewJobRepo(store)
+	ctx := context.Background()
+	// Запуск, который остался незавершенным после остановки экземпляра сервера,
+	// и запуск, который еще выполняет другой экземпляр.
+	interrupted, err := repo.CreateRun(ctx, &model.NewJobRun{Job: "interrupted", TriggeredBy: model.JobTriggerSchedule, StartedAt: time.Now().UTC()})
+	if err != nil {
+		t.Fatalf("create run: %v", err)
+	}
+	running, err := repo.CreateRun(ctx, &model.NewJobRun{Job: "running", TriggeredBy: model.JobTriggerManual, StartedAt: time.Now().UTC()})
+	if err != nil {
+		t.Fatalf("create run: %v", err)
+	}
+	other := memory.NewLocker(store)
+	if held, err := other.TryLock(ctx, "elib:job:running"); err != nil || !held {
+		t.Fatalf("lock job on another instance: held %v, err %v", held, err)
+	}
+
+	jobs := service.NewJobService(repo, memory.NewLocker(store))
+	noop := func(context.Context) (int, error) { return 0, nil }
+	jobs.Add(service.Job{Name: "interrupted", Interval: time.Hour, Run: noop})
+	jobs.Add(service.Job{Name: "running", Interval: time.Hour, Run: noop})
+	scheduleCtx, stop := context.WithCancel(ctx)
+	stop()
+	jobs.Schedule(scheduleCtx, time.Hour)
+
+	runs, err := repo.GetRuns(ctx, &model.JobRunFilter{})
+	if err != nil {
+		t.Fatalf("get runs: %v", err)
+	}
+	for _, run := range runs {
+		switch run.ID {
+		case interrupted.ID:
+			if run.Status != model.JobFailed || run.Error == nil || run.FinishedAt == nil {
+				t.Errorf("interrupted run: got %+v, want failed", run)
+			}
+		case running.ID:
+			if run.Status != model.JobRunning {
+				t.Errorf("run on another instance: got %+v, want running", run)
+			}
+		}
+	}
+}
+
+func TestJobScheduleLeader(t *testing.T) {
+	store := memory.NewStore()
+	var mu sync.Mutex
+	counts := make(map[string]int)
+	newScheduler := func(name string) *service.JobService {
+		jobs := service.NewJobService(memory.NewJobRepo(store), memory.NewLocker(store))
+		jobs.Add(service.Job{Name: "count", Interval: time.Nanosecond, Run: func(context.Context) (int, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			counts[name]++
+			return 1, nil
+		}})
+		return jobs
+	}
+	count := func(name string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return counts[name]
+	}
+	waitFor := func(name string) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); count(name) < 2; {
+			if time.Now().After(deadline) {
+				t.Fatalf("scheduler %v: got %v runs, want at least 2", name, count(name))
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// Задачи выполняет только экземпляр, захвативший блокировку планировщика,
+	// а после его остановки блокировку захватывает другой.
+	ctxA, stopA := context.WithCancel(context.Background())
+	doneA := make(chan struct{})
+	go func() { newScheduler("a").Schedule(ctxA, time.Millisecond); close(doneA) }()
+	waitFor("a")
+	ctxB, stopB := context.WithCancel(context.Background())
+	defer stopB()
+	go newScheduler("b").Schedule(ctxB, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if runs := count("b"); runs != 0 {
+		t.Fatalf("scheduler b while a is leader: got %v runs, want 0", runs)
+	}
+	stopA()
+	<-doneA
+	waitFor("b")
+}
@@ -0,0 +1,26 @@
+package app
+
+import (
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// RegisterCORS разрешает браузерам запросы к app со страниц источников origins
+// (* — с любого источника) и кэширование ответов на предварительные запросы на время maxAge.
+// Ответы открывают клиенту заголовки ETag, X-Request-ID, Retry-After и RateLimit-*.
+// Если origins пуст, то CORS не включается.
+func RegisterCORS(app *echo.Echo, origins []string, maxAge time.Duration) {
+	if len(origins) == 0 {
+		return
+	}
+	app.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: origins,
+		ExposeHeaders: []string{
+			"ETag", echo.HeaderXRequestID, echo.HeaderRetryAfter,
+			headerRateLimitLimit, headerRateLimitRemaining, headerRateLimitReset, headerRateLimitPolicy,
+		},
+		MaxAge: int(maxAge.Seconds()),
+	}))
+}
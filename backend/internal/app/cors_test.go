@@ -0,0 +1,34 @@
+package app
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestCORS(t *testing.T) {
+	env := newTestEnv(t)
+	RegisterCORS(env.app, []string{"https://elib.example"}, 10*time.Minute)
+
+	req := env.request(http.MethodOptions, "/api/departments", nil)
+	req.Header.Set(echo.HeaderOrigin, "https://elib.example")
+	req.Header.Set(echo.HeaderAccessControlRequestMethod, http.MethodPost)
+	rec := env.serve(req, "")
+	env.expect(rec, http.StatusNoContent)
+	if got := rec.Header().Get(echo.HeaderAccessControlAllowOrigin); got != "https://elib.example" {
+		t.Errorf("preflight allow origin: got %q", got)
+	}
+	if got := rec.Header().Get(echo.HeaderAccessControlMaxAge); got != "600" {
+		t.Errorf("preflight max age: got %q", got)
+	}
+
+	req = env.request(http.MethodGet, "/api/departments", nil)
+	req.Header.Set(echo.HeaderOrigin, "https://other.example")
+	rec = env.serve(req, env.admin)
+	env.expect(rec, http.StatusOK)
+	if got := rec.Header().Get(echo.HeaderAccessControlAllowOrigin); got != "" {
+		t.Errorf("foreign origin: got allow origin %q", got)
+	}
+}
@@ -0,0 +1,29 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/foreverd34d/aumsu-elib/internal/health"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RegisterHealth добавляет в app маршруты проверки состояния для оркестратора:
+// GET /healthz отвечает 200, пока процесс обрабатывает запросы,
+// а GET /readyz выполняет проверки checker и отвечает 200, если сервер готов принимать запросы,
+// или 503, если какая-то проверка не пройдена или сервер завершает работу.
+// В обоих случаях в ответе возвращается [model.HealthReport].
+func RegisterHealth(app *echo.Echo, checker *health.Checker) {
+	app.GET("/healthz", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, model.HealthReport{Status: model.HealthOK})
+	})
+	app.GET("/readyz", func(c echo.Context) error {
+		report := checker.Ready(c.Request().Context())
+		status := http.StatusOK
+		if report.Status != model.HealthOK {
+			status = http.StatusServiceUnavailable
+		}
+		return c.JSON(status, report)
+	})
+}
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/foreverd34d/aumsu-elib/internal/health"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+)
+
+func TestHealth(t *testing.T) {
+	env := newTestEnv(t)
+	checker := health.NewChecker(0)
+	checker.Add("storage", env.files.Check)
+	var dbErr error
+	checker.Add("database", func(ctx context.Context) error { return dbErr })
+	RegisterHealth(env.app, checker)
+
+	ready := func(want int) model.HealthReport {
+		t.Helper()
+		var report model.HealthReport
+		if err := json.Unmarshal(env.expect(env.do(http.MethodGet, "/readyz", "", nil), want), &report); err != nil {
+			t.Fatalf("decode report: %v", err)
+		}
+		return report
+	}
+
+	env.expect(env.do(http.MethodGet, "/healthz", "", nil), http.StatusOK)
+	report := ready(http.StatusOK)
+	if report.Status != model.HealthOK || len(report.Checks) != 2 || report.Checks[0].Name != "storage" || report.Checks[1].Status != model.HealthOK {
+		t.Errorf("ready: got %+v", report)
+	}
+
+	dbErr = errors.New("connection refused")
+	report = ready(http.StatusServiceUnavailable)
+	if report.Status != model.HealthUnavailable || report.Checks[0].Status != model.HealthOK ||
+		report.Checks[1].Status != model.HealthUnavailable || report.Checks[1].Error != "connection refused" {
+		t.Errorf("database down: got %+v", report)
+	}
+
+	dbErr = nil
+	checker.SetShuttingDown()
+	report = ready(http.StatusServiceUnavailable)
+	if last := report.Checks[len(report.Checks)-1]; report.Status != model.HealthUnavailable || last.Name != "shutdown" {
+		t.Errorf("shutting down: got %+v", report)
+	}
+	env.expect(env.do(http.MethodGet, "/healthz", "", nil), http.StatusOK)
+}
@@ -0,0 +1,60 @@
+package app
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/foreverd34d/aumsu-elib/internal/logging"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// withRequestID записывает номер запроса requestID в контекст запроса для записей журнала.
+// Номер берется из заголовка X-Request-ID запроса или создается заново и возвращается в том же заголовке ответа.
+func withRequestID(c echo.Context, requestID string) {
+	ctx := logging.WithRequestID(c.Request().Context(), requestID)
+	c.SetRequest(c.Request().WithContext(ctx))
+}
+
+// logRequest записывает в журнал строку о выполненном запросе.
+// Если запрос завершился ошибкой, то в строку добавляется внутренняя ошибка с полной цепочкой.
+// Запросы, завершившиеся ошибкой сервера, записываются с уровнем ERROR, ошибкой клиента — WARN.
+func logRequest(c echo.Context, v middleware.RequestLoggerValues) error {
+	level := slog.LevelInfo
+	switch {
+	case v.Status >= http.StatusInternalServerError:
+		level = slog.LevelError
+	case v.Status >= http.StatusBadRequest:
+		level = slog.LevelWarn
+	}
+	attrs := []slog.Attr{
+		slog.String("method", v.Method),
+		slog.String("uri", v.URI),
+		slog.Int("status", v.Status),
+		slog.Duration("latency", v.Latency),
+		slog.String("remote_ip", v.RemoteIP),
+	}
+	if v.Error != nil {
+		attrs = append(attrs, logging.Err(internalError(v.Error)))
+	}
+	slog.LogAttrs(c.Request().Context(), level, "request", attrs...)
+	return nil
+}
+
+// logPanic записывает в журнал панику хэндлера err вместе со стеком вызовов stack
+// и возвращает ошибку для ответа клиенту.
+func logPanic(c echo.Context, err error, stack []byte) error {
+	slog.LogAttrs(c.Request().Context(), slog.LevelError, "panic recovered", logging.Err(err), slog.String("stack", string(stack)))
+	return err
+}
+
+// internalError возвращает внутреннюю ошибку err, если это [echo.HTTPError] с внутренней ошибкой, или саму err.
+func internalError(err error) error {
+	he := new(echo.HTTPError)
+	if errors.As(err, &he) && he.Internal != nil {
+		return he.Internal
+	}
+	return err
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/foreverd34d/aumsu-elib/internal/logging"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+// captureLog направляет журнал по умолчанию в буфер до конца теста и возвращает функцию,
+// которая разбирает записанные строки журнала.
+func captureLog(t *testing.T) func() []map[string]any {
+	t.Helper()
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(logging.New(&buf, slog.LevelDebug))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return func() []map[string]any {
+		var records []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			record := make(map[string]any)
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatalf("log line %q is not json: %v", line, err)
+			}
+			records = append(records, record)
+		}
+		return records
+	}
+}
+
+// findRecord возвращает первую запись журнала с сообщением msg.
+func findRecord(t *testing.T, records []map[string]any, msg string) map[string]any {
+	t.Helper()
+	for _, record := range records {
+		if record["msg"] == msg {
+			return record
+		}
+	}
+	t.Fatalf("no log record %q in %v", msg, records)
+	return nil
+}
+
+func TestRequestLogging(t *testing.T) {
+	env := newTestEnv(t)
+	records := captureLog(t)
+
+	req := env.request(http.MethodPost, "/auth/session", model.Credentials{Username: "admin", Password: "password"})
+	req.Header.Set(echo.HeaderXRequestID, "login-request")
+	rec := env.serve(req, "")
+	env.expect(rec, http.StatusCreated)
+	if got := rec.Header().Get(echo.HeaderXRequestID); got != "login-request" {
+		t.Errorf("X-Request-ID: got %q, want login-request", got)
+	}
+	session := findRecord(t, records(), "session created")
+	if session[logging.RequestIDKey] != "login-request" {
+		t.Errorf("service log record: got %v, want request ID login-request", session)
+	}
+
+	rec = env.do(http.MethodGet, "/api/departments/100", env.admin, nil)
+	env.expect(rec, http.StatusNotFound)
+	requestID := rec.Header().Get(echo.HeaderXRequestID)
+	if requestID == "" {
+		t.Fatal("X-Request-ID was not generated")
+	}
+	var access map[string]any
+	for _, record := range records() {
+		if record["msg"] == "request" && record[logging.RequestIDKey] == requestID {
+			access = record
+		}
+	}
+	if access == nil {
+		t.Fatalf("no access log record with request ID %v", requestID)
+	}
+	if access["level"] != "WARN" || access["status"] != float64(http.StatusNotFound) || access[logging.UserIDKey] == nil {
+		t.Errorf("access log record: got %v", access)
+	}
+	if msg, _ := access["error"].(string); !strings.Contains(msg, "not found") {
+		t.Errorf("access log error: got %q", msg)
+	}
+	if chain, _ := access["error_chain"].([]any); len(chain) < 2 {
+		t.Errorf("access log error chain: got %v", access["error_chain"])
+	}
+}
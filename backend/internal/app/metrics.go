@@ -0,0 +1,70 @@
+package app
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/foreverd34d/aumsu-elib/internal/metrics"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// Метрики запросов к api.
+var (
+	httpRequests = metrics.Default.NewCounter("elib_http_requests_total",
+		"Total number of HTTP requests by method, route and status code.", "method", "route", "status")
+	httpDuration = metrics.Default.NewHistogram("elib_http_request_duration_seconds",
+		"HTTP request latency by method and route.", nil, "method", "route")
+)
+
+// unmatchedRoute — метка маршрута для запросов, не подошедших ни к одному маршруту.
+const unmatchedRoute = "unmatched"
+
+// recordMetrics предоставляет middleware, которое считает запросы и их длительность по шаблонам маршрутов.
+// Ошибка хэндлера сразу передается обработчику ошибок, чтобы в метрику попал итоговый код ответа.
+func recordMetrics(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+		err := next(c)
+		if err != nil {
+			c.Error(err)
+		}
+		route := c.Path()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		method := c.Request().Method
+		httpRequests.Inc(method, route, strconv.Itoa(c.Response().Status))
+		httpDuration.Observe(time.Since(start).Seconds(), method, route)
+		return err
+	}
+}
+
+// RegisterMetrics добавляет в app маршрут GET /metrics с метриками реестра registry в формате Prometheus.
+// Маршрут закрыт от публичного доступа: запрос должен содержать заголовок Authorization
+// со схемой Bearer и токеном token. Если token пуст, то маршрут не добавляется.
+func RegisterMetrics(app *echo.Echo, registry *metrics.Registry, token string) {
+	if token == "" {
+		return
+	}
+	auth := middleware.KeyAuthWithConfig(middleware.KeyAuthConfig{
+		Validator: func(key string, c echo.Context) (bool, error) {
+			return subtle.ConstantTimeCompare([]byte(key), []byte(token)) == 1, nil
+		},
+		ErrorHandler: func(err error, c echo.Context) error {
+			return echo.ErrUnauthorized.WithInternal(fmt.Errorf("metrics: %w", err))
+		},
+	})
+	app.GET("/metrics", func(c echo.Context) error {
+		var body bytes.Buffer
+		if err := registry.Write(c.Request().Context(), &body); err != nil {
+			return fmt.Errorf("write metrics: %w", err)
+		}
+		return c.Blob(http.StatusOK, metrics.ContentType, body.Bytes())
+	}, auth)
+}
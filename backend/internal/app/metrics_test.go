@@ -0,0 +1,41 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/foreverd34d/aumsu-elib/internal/metrics"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+)
+
+func TestMetrics(t *testing.T) {
+	env := newTestEnv(t)
+	RegisterMetrics(env.app, metrics.Default, "scrape-token")
+
+	env.expect(env.do(http.MethodPost, "/auth/session", "", model.Credentials{Username: "admin", Password: "wrong"}), http.StatusUnauthorized)
+	env.expect(env.do(http.MethodGet, "/api/departments/100", env.admin, nil), http.StatusNotFound)
+	env.expect(env.do(http.MethodGet, "/unknown/path", "", nil), http.StatusNotFound)
+
+	env.expect(env.do(http.MethodGet, "/metrics", "", nil), http.StatusUnauthorized)
+	env.expect(env.do(http.MethodGet, "/metrics", "wrong-token", nil), http.StatusUnauthorized)
+	env.expect(env.do(http.MethodGet, "/metrics", env.admin, nil), http.StatusUnauthorized)
+	rec := env.do(http.MethodGet, "/metrics", "scrape-token", nil)
+	body := string(env.expect(rec, http.StatusOK))
+	if contentType := rec.Header().Get("Content-Type"); contentType != metrics.ContentType {
+		t.Errorf("content type: got %q, want %q", contentType, metrics.ContentType)
+	}
+	for _, want := range []string{
+		`elib_http_requests_total{method="GET",route="/api/departments/:id",status="404"}`,
+		`elib_http_request_duration_seconds_count{method="GET",route="/api/departments/:id"}`,
+		`elib_logins_total{result="invalid_password"}`,
+		`elib_logins_total{result="success"}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics do not contain %v", want)
+		}
+	}
+	if strings.Contains(body, "/unknown/path") {
+		t.Error("metrics contain a path of an unmatched request")
+	}
+}
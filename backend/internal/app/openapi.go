@@ -0,0 +1,442 @@
+package app
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/foreverd34d/aumsu-elib/internal/handler"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+// openAPIVersion — версия спецификации OpenAPI, по которой описывается api.
+const openAPIVersion = "3.1.0"
+
+// docsPage содержит страницу документации api, которая отрисовывает спецификацию с помощью Redoc.
+//
+//go:embed docs.html
+var docsPage []byte
+
+// registerDocs регистрирует маршруты спецификации OpenAPI /api/openapi.json и страницы документации /api/docs.
+// Оба маршрута доступны без jwt токена.
+func registerDocs(app *echo.Echo) {
+	spec := newOpenAPIDocument()
+	app.GET("/api/openapi.json", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, spec)
+	})
+	app.GET("/api/docs", func(c echo.Context) error {
+		return c.HTMLBlob(http.StatusOK, docsPage)
+	})
+}
+
+// openAPIDocument представляет документ спецификации OpenAPI.
+type openAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`    // версия спецификации
+	Info       openAPIInfo                             `json:"info"`       // сведения об api
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`      // операции по путям и http-методам
+	Components openAPIComponents                       `json:"components"` // общие схемы и способы аутентификации
+}
+
+// openAPIInfo содержит сведения об api.
+type openAPIInfo struct {
+	Title   string `json:"title"`   // название
+	Version string `json:"version"` // версия api
+}
+
+// openAPIComponents содержит схемы и способы аутентификации, на которые ссылаются операции.
+type openAPIComponents struct {
+	Schemas         map[string]*schema        `json:"schemas"`         // схемы моделей по названиям
+	SecuritySchemes map[string]map[string]any `json:"securitySchemes"` // способы аутентификации
+}
+
+// openAPIOperation описывает операцию api.
+type openAPIOperation struct {
+	OperationID  string                      `json:"operationId"`               // название хэндлера операции
+	Summary      string                      `json:"summary"`                   // краткое описание
+	Tags         []string                    `json:"tags"`                      // группы операции
+	Parameters   []openAPIParameter          `json:"parameters,omitempty"`      // параметры пути, запроса и заголовков
+	RequestBody  *openAPIRequestBody         `json:"requestBody,omitempty"`     // тело запроса
+	Responses    map[string]*openAPIResponse `json:"responses"`                 // ответы по http-кодам
+	Security     []map[string][]string       `json:"security,omitempty"`        // требуемая аутентификация
+	RequiredRole string                      `json:"x-required-role,omitempty"` // минимальная роль пользователя
+}
+
+// openAPIParameter описывает параметр операции.
+type openAPIParameter struct {
+	Name     string  `json:"name"`               // название
+	In       string  `json:"in"`                 // расположение: path, query или header
+	Required bool    `json:"required,omitempty"` // параметр обязателен
+	Schema   *schema `json:"schema"`             // схема значения
+}
+
+// openAPIRequestBody описывает тело запроса.
+type openAPIRequestBody struct {
+	Required bool                        `json:"required"` // тело обязательно
+	Content  map[string]openAPIMediaType `json:"content"`  // схемы по типам содержимого
+}
+
+// openAPIResponse описывает ответ операции.
+type openAPIResponse struct {
+	Description string                      `json:"description"`       // описание
+	Headers     map[string]openAPIHeader    `json:"headers,omitempty"` // заголовки ответа
+	Content     map[string]openAPIMediaType `json:"content,omitempty"` // схемы по типам содержимого
+}
+
+// openAPIHeader описывает заголовок ответа.
+type openAPIHeader struct {
+	Description string  `json:"description"` // описание
+	Schema      *schema `json:"schema"`      // схема значения
+}
+
+// openAPIMediaType описывает содержимое определенного типа.
+type openAPIMediaType struct {
+	Schema *schema `json:"schema"` // схема содержимого
+}
+
+// schema представляет схему JSON Schema, которой описываются модели в спецификации.
+type schema struct {
+	Ref              string             `json:"$ref,omitempty"`             // ссылка на схему из components
+	Type             any                `json:"type,omitempty"`             // тип или список типов
+	Format           string             `json:"format,omitempty"`           // формат строки
+	ContentMediaType string             `json:"contentMediaType,omitempty"` // тип содержимого двоичной строки
+	Properties       map[string]*schema `json:"properties,omitempty"`       // поля объекта
+	Required         []string           `json:"required,omitempty"`         // обязательные поля объекта
+	Items            *schema            `json:"items,omitempty"`            // схема элементов массива
+	AnyOf            []*schema          `json:"anyOf,omitempty"`            // допустимые схемы
+	Enum             []string           `json:"enum,omitempty"`             // допустимые значения
+	MinLength        *int               `json:"minLength,omitempty"`        // минимальная длина строки
+	MaxLength        *int               `json:"maxLength,omitempty"`        // максимальная длина строки
+	Minimum          *int               `json:"minimum,omitempty"`          // минимальное значение числа
+	Maximum          *int               `json:"maximum,omitempty"`          // максимальное значение числа
+	Pattern          string             `json:"pattern,omitempty"`          // регулярное выражение для строки
+}
+
+// openAPIRoles содержит названия ролей пользователей в спецификации.
+var openAPIRoles = map[model.UserRole]string{
+	model.StudentRole: "student",
+	model.TeacherRole: "teacher",
+	model.ManagerRole: "manager",
+	model.AdminRole:   "admin",
+}
+
+// newOpenAPIDocument собирает спецификацию OpenAPI из маршрутов групп /auth и /api и их описаний в [operations].
+// Маршруты без описания в спецификацию не попадают.
+func newOpenAPIDocument() *openAPIDocument {
+	doc := &openAPIDocument{
+		OpenAPI: openAPIVersion,
+		Info:    openAPIInfo{Title: "Электронная библиотека АУМСУ", Version: "1.0"},
+		Paths:   make(map[string]map[string]*openAPIOperation),
+		Components: openAPIComponents{
+			Schemas: make(map[string]*schema),
+			SecuritySchemes: map[string]map[string]any{
+				"bearerAuth": {"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+	for _, group := range []struct {
+		prefix string
+		routes []route
+	}{{"/auth", authRoutes}, {"/api", apiRoutes}} {
+		for _, r := range group.routes {
+			op, ok := operations[r.method+" "+group.prefix+r.path]
+			if !ok {
+				continue
+			}
+			path := openAPIPath(group.prefix + r.path)
+			if doc.Paths[path] == nil {
+				doc.Paths[path] = make(map[string]*openAPIOperation)
+			}
+			doc.Paths[path][strings.ToLower(r.method)] = doc.operation(group.prefix, r, op)
+		}
+	}
+	return doc
+}
+
+// openAPIPath заменяет параметры пути echo вида :id на параметры OpenAPI вида {id}.
+func openAPIPath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if name, ok := strings.CutPrefix(part, ":"); ok {
+			parts[i] = "{" + name + "}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// operation описывает маршрут r группы prefix по его описанию op.
+func (doc *openAPIDocument) operation(prefix string, r route, op operationDoc) *openAPIOperation {
+	tag, _, _ := strings.Cut(strings.TrimPrefix(r.path, "/"), "/")
+	operation := &openAPIOperation{
+		OperationID: handlerName(r.handle),
+		Summary:     op.summary,
+		Tags:        []string{tag},
+		Responses:   make(map[string]*openAPIResponse),
+	}
+	if prefix == "/api" {
+		operation.Security = []map[string][]string{{"bearerAuth": {}}}
+		operation.RequiredRole = openAPIRoles[r.role]
+	}
+
+	for _, part := range strings.Split(r.path, "/") {
+		if name, ok := strings.CutPrefix(part, ":"); ok {
+			param := openAPIParameter{Name: name, In: "path", Required: true, Schema: &schema{Type: "integer"}}
+			if name == "entity" {
+				param.Schema = &schema{Type: "string"}
+			}
+			operation.Parameters = append(operation.Parameters, param)
+		}
+	}
+	if op.query != nil {
+		operation.Parameters = append(operation.Parameters, doc.queryParameters(reflect.TypeOf(op.query))...)
+	}
+	if op.versioned && r.method != http.MethodGet {
+		operation.Parameters = append(operation.Parameters, openAPIParameter{
+			Name: "If-Match", In: "header", Schema: &schema{Type: "string"},
+		})
+	}
+
+	switch {
+	case op.body != nil:
+		contentType := echo.MIMEApplicationJSON
+		if r.method == http.MethodPatch {
+			contentType = model.MergePatchContentType
+		}
+		operation.RequestBody = &openAPIRequestBody{
+			Required: true,
+			Content:  map[string]openAPIMediaType{contentType: {doc.schemaOf(reflect.TypeOf(op.body))}},
+		}
+	case op.form != nil || len(op.files) > 0:
+		form := &schema{Type: "object", Properties: make(map[string]*schema)}
+		if op.form != nil {
+			form = doc.objectSchema(reflect.TypeOf(op.form), "form")
+		}
+		for _, name := range op.files {
+			form.Properties[name] = &schema{Type: "string", ContentMediaType: "application/octet-stream"}
+			form.Required = append(form.Required, name)
+		}
+		operation.RequestBody = &openAPIRequestBody{
+			Required: true,
+			Content:  map[string]openAPIMediaType{echo.MIMEMultipartForm: {form}},
+		}
+	}
+
+	status := op.status
+	if status == 0 {
+		status = http.StatusOK
+		if op.response == nil && !op.binary {
+			status = http.StatusNoContent
+		}
+	}
+	response := &openAPIResponse{Description: http.StatusText(status)}
+	switch {
+	case op.binary:
+		response.Content = map[string]openAPIMediaType{"*/*": {&schema{Type: "string", ContentMediaType: "application/octet-stream"}}}
+	case op.response != nil:
+		response.Content = map[string]openAPIMediaType{echo.MIMEApplicationJSON: {doc.schemaOf(reflect.TypeOf(op.response))}}
+	}
+	if op.versioned && r.method != http.MethodDelete {
+		response.Headers = map[string]openAPIHeader{
+			"ETag": {Description: "Версия ресурса для заголовка If-Match", Schema: &schema{Type: "string"}},
+		}
+	}
+	operation.Responses[strconv.Itoa(status)] = response
+	for status, body := range op.responses {
+		operation.Responses[strconv.Itoa(status)] = &openAPIResponse{
+			Description: http.StatusText(status),
+			Content:     map[string]openAPIMediaType{echo.MIMEApplicationJSON: {doc.schemaOf(reflect.TypeOf(body))}},
+		}
+	}
+	operation.Responses["default"] = &openAPIResponse{
+		Description: "Ошибка в формате RFC 7807",
+		Content:     map[string]openAPIMediaType{model.ProblemContentType: {doc.schemaOf(reflect.TypeOf(model.Problem{}))}},
+	}
+	return operation
+}
+
+// handlerName возвращает название метода хэндлера маршрута с маленькой буквы.
+func handlerName(handle func(h *handler.Handler, c echo.Context) error) string {
+	name := runtime.FuncForPC(reflect.ValueOf(handle).Pointer()).Name()
+	name = name[strings.LastIndex(name, ".")+1:]
+	return string(unicode.ToLower(rune(name[0]))) + name[1:]
+}
+
+// upperFirst возвращает название типа name с большой буквы.
+func upperFirst(name string) string {
+	return string(unicode.ToUpper(rune(name[0]))) + name[1:]
+}
+
+// queryParameters описывает поля структуры t с тегом query как параметры запроса.
+func (doc *openAPIDocument) queryParameters(t reflect.Type) []openAPIParameter {
+	var params []openAPIParameter
+	for _, field := range structFields(t) {
+		name, _, _ := strings.Cut(field.Tag.Get("query"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		params = append(params, openAPIParameter{
+			Name:     name,
+			In:       "query",
+			Required: isRequired(field),
+			Schema:   doc.fieldSchema(field, false),
+		})
+	}
+	return params
+}
+
+// schemaOf возвращает схему типа t. Именованные структуры добавляются в components,
+// и вместо их схемы возвращается ссылка на нее.
+func (doc *openAPIDocument) schemaOf(t reflect.Type) *schema {
+	switch t {
+	case reflect.TypeOf(time.Time{}):
+		return &schema{Type: "string", Format: "date-time"}
+	case reflect.TypeOf(json.RawMessage{}):
+		return &schema{}
+	}
+	if t.Implements(reflect.TypeOf((*model.PatchField)(nil)).Elem()) {
+		return doc.patchSchema(t)
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return doc.schemaOf(t.Elem())
+	case reflect.Slice, reflect.Array:
+		return &schema{Type: "array", Items: doc.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &schema{Type: "object"}
+	case reflect.String:
+		return &schema{Type: "string"}
+	case reflect.Bool:
+		return &schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &schema{Type: "number"}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return doc.objectSchema(t, "json")
+		}
+		name := upperFirst(t.Name())
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			// Схема добавляется до обхода полей, чтобы рекурсивные типы ссылались на нее.
+			doc.Components.Schemas[name] = &schema{}
+			*doc.Components.Schemas[name] = *doc.objectSchema(t, "json")
+		}
+		return &schema{Ref: "#/components/schemas/" + name}
+	}
+	return &schema{}
+}
+
+// patchSchema возвращает схему поля патча t: [model.Optional] описывается схемой значения,
+// а [model.Nullable] — схемой значения, допускающей null.
+func (doc *openAPIDocument) patchSchema(t reflect.Type) *schema {
+	value, _ := t.FieldByName("Value")
+	if value.Type.Kind() == reflect.Pointer {
+		return nullable(doc.schemaOf(value.Type.Elem()))
+	}
+	return doc.schemaOf(value.Type)
+}
+
+// objectSchema возвращает схему объекта с полями структуры t, названными по тегу tag.
+func (doc *openAPIDocument) objectSchema(t reflect.Type, tag string) *schema {
+	object := &schema{Type: "object", Properties: make(map[string]*schema)}
+	for _, field := range structFields(t) {
+		name, options, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" || (name == "" && tag != "json") {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		object.Properties[name] = doc.fieldSchema(field, !strings.Contains(options, "omitempty"))
+		if isRequired(field) {
+			object.Required = append(object.Required, name)
+		}
+	}
+	return object
+}
+
+// structFields возвращает поля структуры t, поднимая поля встроенных структур так же, как [encoding/json].
+func structFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			fields = append(fields, structFields(field.Type)...)
+			continue
+		}
+		if field.IsExported() {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// fieldSchema возвращает схему поля field с ограничениями из тега validate.
+// Если поле является указателем и present истинно (поле всегда есть в json), то схема допускает null.
+func (doc *openAPIDocument) fieldSchema(field reflect.StructField, present bool) *schema {
+	s := doc.schemaOf(field.Type)
+	rules := strings.Split(field.Tag.Get("validate"), ",")
+	if i := slices.Index(rules, "dive"); i >= 0 && s.Items != nil {
+		applyRules(s.Items, rules[i+1:])
+		rules = rules[:i]
+	}
+	if s.Ref == "" {
+		applyRules(s, rules)
+	}
+	if present && field.Type.Kind() == reflect.Pointer {
+		return nullable(s)
+	}
+	return s
+}
+
+// nullable возвращает схему s, допускающую значение null.
+func nullable(s *schema) *schema {
+	if typ, ok := s.Type.(string); ok {
+		s.Type = []string{typ, "null"}
+		return s
+	}
+	return &schema{AnyOf: []*schema{s, {Type: "null"}}}
+}
+
+// applyRules переносит в схему s ограничения из правил тега validate.
+func applyRules(s *schema, rules []string) {
+	for _, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+		n, err := strconv.Atoi(param)
+		switch {
+		case name == "email":
+			s.Format = "email"
+		case name == "e164":
+			s.Pattern = `^\+[1-9]\d{1,14}$`
+		case name == "oneof":
+			s.Enum = strings.Fields(param)
+		case err != nil:
+		case s.Type == "string" && (name == "min" || name == "len"):
+			s.MinLength = &n
+			if name == "len" {
+				s.MaxLength = &n
+			}
+		case s.Type == "string" && name == "max":
+			s.MaxLength = &n
+		case name == "min" || name == "gte":
+			s.Minimum = &n
+		case name == "max" || name == "lte":
+			s.Maximum = &n
+		}
+	}
+}
+
+// isRequired сообщает, является ли поле обязательным по тегу validate.
+func isRequired(field reflect.StructField) bool {
+	return slices.Contains(strings.Split(field.Tag.Get("validate"), ","), "required")
+}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/foreverd34d/aumsu-elib/internal/handler"
+)
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	spec := newOpenAPIDocument()
+	registered := make(map[string]bool)
+	for _, group := range []struct {
+		prefix string
+		routes []route
+	}{{"/auth", authRoutes}, {"/api", apiRoutes}} {
+		for _, r := range group.routes {
+			registered[r.method+" "+group.prefix+r.path] = true
+			if spec.Paths[openAPIPath(group.prefix+r.path)][strings.ToLower(r.method)] == nil {
+				t.Errorf("%v %v%v is missing from the OpenAPI spec", r.method, group.prefix, r.path)
+			}
+		}
+	}
+	for key := range operations {
+		if !registered[key] {
+			t.Errorf("OpenAPI operation %v has no route", key)
+		}
+	}
+}
+
+func TestOpenAPIEndpoint(t *testing.T) {
+	app := NewApp(&handler.Handler{}, testSigningKey)
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/openapi.json: got %v, want 200", rec.Code)
+	}
+
+	var spec struct {
+		OpenAPI    string                                `json:"openapi"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+				Required   []string                   `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("decode spec: %v", err)
+	}
+	if spec.OpenAPI != openAPIVersion {
+		t.Errorf("openapi: got %q, want %q", spec.OpenAPI, openAPIVersion)
+	}
+	if _, ok := spec.Paths["/api/users/{id}"]["patch"]; !ok {
+		t.Error("spec has no PATCH /api/users/{id}")
+	}
+	if _, ok := spec.Components.Schemas["User"].Properties["userID"]; !ok {
+		t.Errorf("User schema: got properties %v, want userID", spec.Components.Schemas["User"].Properties)
+	}
+	if required := spec.Components.Schemas["NewSpecialty"].Required; !slices.Contains(required, "departmentID") {
+		t.Errorf("NewSpecialty schema: got required %v, want departmentID", required)
+	}
+
+	rec = httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/docs", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "/api/openapi.json") {
+		t.Errorf("GET /api/docs: got %v, want the docs page", rec.Code)
+	}
+}
@@ -0,0 +1,135 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+)
+
+// operationDoc описывает маршрут приложения для спецификации OpenAPI.
+// Параметры пути берутся из самого маршрута, а типы запроса и ответа задаются значениями моделей.
+type operationDoc struct {
+	summary   string      // краткое описание
+	query     any         // структура параметров запроса (поля с тегом query)
+	body      any         // тело запроса в формате json (для PATCH — JSON Merge Patch)
+	form      any         // структура полей формы multipart/form-data (поля с тегом form)
+	files     []string    // файловые поля формы
+	status    int         // код успешного ответа (по умолчанию 200 или 204, если ответ пустой)
+	response  any         // тело успешного ответа в формате json
+	binary    bool        // в ответе возвращается файл
+	versioned bool        // ресурс имеет версию: GET возвращает ETag, изменения принимают If-Match
+	responses map[int]any // другие ответы в формате json по http-кодам
+}
+
+// createdID представляет ответ на создание сущности.
+type createdID struct {
+	ID int `json:"ID"` // номер созданной сущности
+}
+
+// tokenPair представляет ответ с парой токенов сессии.
+type tokenPair struct {
+	AccessToken  string `json:"accessToken"`  // jwt токен доступа
+	RefreshToken string `json:"refreshToken"` // токен обновления
+}
+
+// refreshToken представляет тело запроса с токеном обновления.
+type refreshToken struct {
+	RefreshToken string `json:"refreshToken" validate:"required"` // токен обновления
+}
+
+// importQuery представляет параметры запроса импорта студентов.
+type importQuery struct {
+	DryRun bool   `query:"dryRun"`                                          // пробный импорт без записи в базу данных
+	Format string `query:"format" validate:"omitempty,oneof=json csv xlsx"` // формат ответа
+}
+
+// operations содержит описания маршрутов по http-методу и полному пути маршрута.
+// Каждый маршрут из authRoutes и apiRoutes должен быть описан, это проверяется тестом.
+var operations = map[string]operationDoc{
+	"POST /auth/session":   {summary: "Вход в систему", body: model.Credentials{}, status: http.StatusCreated, response: tokenPair{}},
+	"PUT /auth/session":    {summary: "Обновление пары токенов", body: refreshToken{}, response: tokenPair{}},
+	"DELETE /auth/session": {summary: "Выход из системы", body: refreshToken{}},
+
+	"GET /api/me":                 {summary: "Профиль текущего пользователя", response: model.Profile{}},
+	"PATCH /api/me":               {summary: "Изменение контактов и настроек текущего пользователя", body: model.ProfileUpdate{}},
+	"PUT /api/me/password":        {summary: "Смена пароля текущего пользователя", body: model.PasswordChange{}},
+	"GET /api/me/photo":           {summary: "Фотография текущего пользователя", binary: true},
+	"PUT /api/me/photo":           {summary: "Загрузка фотографии текущего пользователя", files: []string{"photo"}},
+	"DELETE /api/me/photo":        {summary: "Удаление фотографии текущего пользователя"},
+	"POST /api/users":             {summary: "Создание пользователя", body: model.NewUser{}, status: http.StatusCreated, response: createdID{}},
+	"POST /api/users/import":      {summary: "Импорт студентов из CSV или XLSX", query: importQuery{}, files: []string{"file"}, response: model.ImportReport{}, responses: map[int]any{http.StatusCreated: model.ImportReport{}, http.StatusUnprocessableEntity: model.ImportReport{}}},
+	"GET /api/users":              {summary: "Список пользователей", query: model.UserFilter{}, response: []model.User{}},
+	"GET /api/users/:id":          {summary: "Пользователь", response: model.User{}, versioned: true},
+	"PUT /api/users/:id":          {summary: "Изменение пользователя", body: model.NewUser{}, versioned: true},
+	"PATCH /api/users/:id":        {summary: "Частичное изменение пользователя", body: model.UserPatch{}, versioned: true},
+	"DELETE /api/users/:id":       {summary: "Удаление пользователя в корзину", versioned: true},
+	"GET /api/users/:id/photo":    {summary: "Фотография пользователя", binary: true},
+	"PUT /api/users/:id/photo":    {summary: "Загрузка фотографии пользователя", files: []string{"photo"}},
+	"DELETE /api/users/:id/photo": {summary: "Удаление фотографии пользователя"},
+
+	"GET /api/departments":        {summary: "Список кафедр", response: []model.Department{}},
+	"GET /api/departments/:id":    {summary: "Кафедра", response: model.Department{}, versioned: true},
+	"POST /api/departments":       {summary: "Создание кафедры", body: model.NewDepartment{}, status: http.StatusCreated, response: createdID{}},
+	"PUT /api/departments/:id":    {summary: "Изменение кафедры", body: model.NewDepartment{}, versioned: true},
+	"PATCH /api/departments/:id":  {summary: "Частичное изменение кафедры", body: model.DepartmentPatch{}, versioned: true},
+	"DELETE /api/departments/:id": {summary: "Удаление кафедры в корзину", versioned: true},
+
+	"GET /api/specialties":        {summary: "Список специальностей", response: []model.Specialty{}},
+	"GET /api/specialties/:id":    {summary: "Специальность", response: model.Specialty{}, versioned: true},
+	"POST /api/specialties":       {summary: "Создание специальности", body: model.NewSpecialty{}, status: http.StatusCreated, response: createdID{}},
+	"PUT /api/specialties/:id":    {summary: "Изменение специальности", body: model.NewSpecialty{}, versioned: true},
+	"PATCH /api/specialties/:id":  {summary: "Частичное изменение специальности", body: model.SpecialtyPatch{}, versioned: true},
+	"DELETE /api/specialties/:id": {summary: "Удаление специальности в корзину", versioned: true},
+
+	"GET /api/groups":        {summary: "Список взводов", query: model.GroupFilter{}, response: []model.Group{}},
+	"GET /api/groups/:id":    {summary: "Взвод", response: model.Group{}, versioned: true},
+	"POST /api/groups":       {summary: "Создание взвода", body: model.NewGroup{}, status: http.StatusCreated, response: createdID{}},
+	"PUT /api/groups/:id":    {summary: "Изменение взвода", body: model.NewGroup{}, versioned: true},
+	"PATCH /api/groups/:id":  {summary: "Частичное изменение взвода", body: model.GroupPatch{}, versioned: true},
+	"DELETE /api/groups/:id": {summary: "Удаление взвода", versioned: true},
+
+	"GET /api/disciplines":        {summary: "Список предметов", response: []model.Discipline{}},
+	"GET /api/disciplines/:id":    {summary: "Предмет", response: model.Discipline{}, versioned: true},
+	"POST /api/disciplines":       {summary: "Создание предмета", body: model.NewDiscipline{}, status: http.StatusCreated, response: createdID{}},
+	"PUT /api/disciplines/:id":    {summary: "Изменение предмета", body: model.NewDiscipline{}, versioned: true},
+	"PATCH /api/disciplines/:id":  {summary: "Частичное изменение предмета", body: model.DisciplinePatch{}, versioned: true},
+	"DELETE /api/disciplines/:id": {summary: "Удаление предмета в корзину", versioned: true},
+
+	"GET /api/catalog/tree": {summary: "Дерево каталога от кафедр до занятий", query: model.CatalogFilter{}, response: []model.CatalogDepartment{}},
+
+	"GET /api/material-types":        {summary: "Список видов материалов", response: []model.MaterialType{}},
+	"GET /api/material-types/:id":    {summary: "Вид материала", response: model.MaterialType{}, versioned: true},
+	"POST /api/material-types":       {summary: "Создание вида материала", body: model.NewMaterialType{}, status: http.StatusCreated, response: createdID{}},
+	"PUT /api/material-types/:id":    {summary: "Изменение вида материала", body: model.NewMaterialType{}, versioned: true},
+	"PATCH /api/material-types/:id":  {summary: "Частичное изменение вида материала", body: model.MaterialTypePatch{}, versioned: true},
+	"DELETE /api/material-types/:id": {summary: "Удаление вида материала", versioned: true},
+
+	"GET /api/materials":          {summary: "Список материалов", response: []model.Material{}},
+	"GET /api/materials/:id":      {summary: "Материал", response: model.Material{}, versioned: true},
+	"GET /api/materials/:id/file": {summary: "Файл материала", binary: true},
+	"POST /api/materials":         {summary: "Создание материала", form: model.NewMaterial{}, files: []string{"file"}, status: http.StatusCreated, response: createdID{}},
+	"PUT /api/materials/:id":      {summary: "Изменение материала", body: model.NewMaterial{}, versioned: true},
+	"PATCH /api/materials/:id":    {summary: "Частичное изменение материала", body: model.MaterialPatch{}, versioned: true},
+	"PUT /api/materials/:id/file": {summary: "Замена файла материала", files: []string{"file"}},
+	"DELETE /api/materials/:id":   {summary: "Удаление материала", versioned: true},
+
+	"GET /api/lessons/:id":                          {summary: "Занятие с дополнительными материалами", response: model.Lesson{}},
+	"GET /api/lessons/:id/materials":                {summary: "Дополнительные материалы занятия", response: []model.Material{}},
+	"PUT /api/lessons/:id/materials/:materialID":    {summary: "Прикрепление материала к занятию"},
+	"DELETE /api/lessons/:id/materials/:materialID": {summary: "Открепление материала от занятия"},
+	"GET /api/books/:id/materials":                  {summary: "Дополнительные материалы книги", response: []model.Material{}},
+	"PUT /api/books/:id/materials/:materialID":      {summary: "Прикрепление материала к книге"},
+	"DELETE /api/books/:id/materials/:materialID":   {summary: "Открепление материала от книги"},
+
+	"POST /api/rollover":         {summary: "Переход на новый учебный год", body: model.Rollover{}, response: model.RolloverPlan{}, responses: map[int]any{http.StatusUnprocessableEntity: model.RolloverPlan{}}},
+	"POST /api/rollover/preview": {summary: "План перехода на новый учебный год", body: model.Rollover{}, response: model.RolloverPlan{}},
+
+	"GET /api/audit": {summary: "Журнал аудита", query: model.AuditFilter{}, response: []model.AuditEvent{}},
+
+	"GET /api/trash":                      {summary: "Записи в корзине", response: []model.TrashItem{}},
+	"POST /api/trash/:entity/:id/restore": {summary: "Восстановление записи из корзины"},
+
+	"GET /api/jobs":             {summary: "Задачи обслуживания", response: []model.Job{}},
+	"GET /api/jobs/runs":        {summary: "История запусков задач обслуживания", query: model.JobRunFilter{}, response: []model.JobRun{}},
+	"POST /api/jobs/:name/runs": {summary: "Запуск задачи обслуживания", status: http.StatusCreated, response: model.JobRun{}},
+}
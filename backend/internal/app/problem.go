@@ -0,0 +1,270 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/logging"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/labstack/echo/v4"
+)
+
+// problemTypePrefix — начало ссылки на вид ошибки в поле type ответа, после которого идет код ошибки.
+const problemTypePrefix = "urn:aumsu-elib:problem:"
+
+// language представляет язык, на котором описываются ошибки для клиента.
+type language int
+
+const (
+	russian language = iota // русский (по умолчанию)
+	english                 // английский
+)
+
+// text представляет текст на всех поддерживаемых языках.
+type text [2]string
+
+// in возвращает текст на языке lang.
+func (t text) in(lang language) string {
+	return t[lang]
+}
+
+// errorKind сопоставляет ошибку из пакета [errs] со стабильным кодом ошибки для клиента.
+type errorKind struct {
+	err  error  // ошибка из пакета errs
+	code string // код ошибки
+}
+
+// errorKinds содержит коды ошибок из пакета [errs] в порядке проверки.
+// Нарушения ограничений проверяются раньше остальных, так как их ошибки содержат исходную ошибку хранилища.
+var errorKinds = []errorKind{
+	{errs.Conflict, "conflict"},
+	{errs.ReferencedBy, "referenced"},
+	{errs.InvalidReference, "invalid_reference"},
+	{errs.VersionMismatch, "version_mismatch"},
+	{errs.RefreshExpired, "refresh_expired"},
+	{errs.InvalidPassword, "invalid_credentials"},
+	{errs.InvalidLogin, "invalid_credentials"},
+	{errs.Deactivated, "account_deactivated"},
+	{errs.PasswordChangeRequired, "password_change_required"},
+	{errs.UnsupportedMedia, "unsupported_media_type"},
+	{errs.NotFound, "not_found"},
+}
+
+// statusCodes содержит коды ошибок для http-кодов ответа, если ошибка не описана в пакете [errs].
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            "bad_request",
+	http.StatusUnauthorized:          "unauthorized",
+	http.StatusForbidden:             "forbidden",
+	http.StatusNotFound:              "not_found",
+	http.StatusMethodNotAllowed:      "method_not_allowed",
+	http.StatusConflict:              "conflict",
+	http.StatusPreconditionFailed:    "precondition_failed",
+	http.StatusRequestEntityTooLarge: "request_too_large",
+	http.StatusUnsupportedMediaType:  "unsupported_media_type",
+	http.StatusUnprocessableEntity:   "unprocessable",
+	http.StatusTooManyRequests:       "too_many_requests",
+	http.StatusInternalServerError:   "internal",
+	http.StatusServiceUnavailable:    "unavailable",
+}
+
+// titles содержит описания ошибок по их кодам.
+var titles = map[string]text{
+	"validation_failed":        {"Некорректные данные запроса", "Request validation failed"},
+	"bad_request":              {"Некорректный запрос", "Bad request"},
+	"unauthorized":             {"Требуется авторизация", "Authentication required"},
+	"invalid_credentials":      {"Неверный логин или пароль", "Invalid login or password"},
+	"refresh_expired":          {"Сессия истекла", "Session expired"},
+	"forbidden":                {"Недостаточно прав", "Access denied"},
+	"account_deactivated":      {"Учетная запись отключена", "Account deactivated"},
+	"password_change_required": {"Требуется сменить одноразовый пароль", "One-time password must be changed"},
+	"not_found":                {"Ресурс не найден", "Resource not found"},
+	"method_not_allowed":       {"Метод не поддерживается", "Method not allowed"},
+	"conflict":                 {"Значение уже занято", "Value already taken"},
+	"referenced":               {"На ресурс ссылаются другие ресурсы", "Resource is still referenced"},
+	"invalid_reference":        {"Ссылка на несуществующий ресурс", "Reference to a missing resource"},
+	"version_mismatch":         {"Ресурс был изменен другим пользователем", "Resource was modified by another client"},
+	"precondition_failed":      {"Условие запроса не выполнено", "Precondition failed"},
+	"request_too_large":        {"Слишком большой запрос", "Request too large"},
+	"unsupported_media_type":   {"Формат файла не поддерживается", "Unsupported media type"},
+	"unprocessable":            {"Запрос не может быть обработан", "Unprocessable request"},
+	"too_many_requests":        {"Слишком много запросов", "Too many requests"},
+	"internal":                 {"Внутренняя ошибка сервера", "Internal server error"},
+	"unavailable":              {"Сервис недоступен", "Service unavailable"},
+	"error":                    {"Ошибка запроса", "Request failed"},
+}
+
+// ruleMessages содержит описания нарушенных правил проверки полей.
+// Параметр правила подставляется вместо %v.
+var ruleMessages = map[string]text{
+	"required":       {"обязательное поле", "is required"},
+	"gte":            {"должно быть не меньше %v", "must be at least %v"},
+	"gt":             {"должно быть больше %v", "must be greater than %v"},
+	"lte":            {"должно быть не больше %v", "must be at most %v"},
+	"lt":             {"должно быть меньше %v", "must be less than %v"},
+	"min":            {"должно быть не меньше %v", "must be at least %v"},
+	"max":            {"должно быть не больше %v", "must be at most %v"},
+	"len":            {"должно иметь длину %v", "must have length %v"},
+	"oneof":          {"должно быть одним из значений: %v", "must be one of: %v"},
+	"email":          {"должно быть адресом электронной почты", "must be an email address"},
+	"unique":         {"значение уже занято", "is already taken"},
+	"exists":         {"ссылается на несуществующий ресурс", "refers to a missing resource"},
+	"referenced":     {"на ресурс ссылаются другие ресурсы через это поле", "other resources still refer to it through this field"},
+	"exists_trashed": {"ссылается на ресурс в корзине, который нужно восстановить первым", "refers to a resource in the trash that must be restored first"},
+	"unique_trashed": {"значение занято записью в корзине до ее окончательного удаления", "is taken by a record in the trash until it is purged"},
+}
+
+// invalidValue — описание нарушения правила, для которого нет отдельного описания.
+var invalidValue = text{"некорректное значение", "is invalid"}
+
+// problemHandler обрабатывает ошибки хэндлеров и middleware вместо [echo.DefaultHTTPErrorHandler]
+// и отвечает описанием ошибки по RFC 7807 на языке из заголовка Accept-Language.
+func problemHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+	lang := acceptedLanguage(c.Request().Header.Get("Accept-Language"))
+	problem := newProblem(err, lang)
+	problem.Instance = c.Request().URL.Path
+	problem.RequestID = c.Response().Header().Get(echo.HeaderXRequestID)
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(problem.Status)
+	} else {
+		var body []byte
+		body, err = json.Marshal(problem)
+		if err == nil {
+			err = c.Blob(problem.Status, model.ProblemContentType, body)
+		}
+	}
+	if err != nil {
+		slog.LogAttrs(c.Request().Context(), slog.LevelError, "couldn't send problem", logging.Err(err))
+	}
+}
+
+// newProblem возвращает описание ошибки err на языке lang.
+// Подробности внутренних ошибок сервера клиенту не раскрываются.
+func newProblem(err error, lang language) *model.Problem {
+	he := new(echo.HTTPError)
+	if !errors.As(err, &he) {
+		he = echo.ErrInternalServerError.WithInternal(err)
+	}
+	problem := &model.Problem{Status: he.Code, Code: problemCode(he)}
+
+	var validationErrs validator.ValidationErrors
+	var constraintErr *errs.ConstraintError
+	switch {
+	case errors.As(err, &validationErrs):
+		problem.Code = "validation_failed"
+		for _, fe := range validationErrs {
+			problem.Errors = append(problem.Errors, fieldError(fe.Field(), fe.Tag(), fe.Param(), lang))
+		}
+	case errors.As(err, &constraintErr) && constraintErr.Field != "":
+		problem.Errors = append(problem.Errors, fieldError(jsonFieldName(constraintErr.Field), constraintRule(constraintErr, errors.Is(err, errs.InTrash)), "", lang))
+	}
+
+	if problem.Status < http.StatusInternalServerError {
+		problem.Detail = problemDetail(he)
+	}
+	problem.Type = problemTypePrefix + problem.Code
+	title, ok := titles[problem.Code]
+	if !ok {
+		title = titles["error"]
+	}
+	problem.Title = title.in(lang)
+	return problem
+}
+
+// problemCode возвращает стабильный код ошибки he: по ошибке из пакета [errs], если она есть, иначе по http-коду.
+func problemCode(he *echo.HTTPError) string {
+	if he.Code < http.StatusInternalServerError {
+		for _, kind := range errorKinds {
+			if errors.Is(he.Internal, kind.err) {
+				return kind.code
+			}
+		}
+	}
+	if code, ok := statusCodes[he.Code]; ok {
+		return code
+	}
+	return "error"
+}
+
+// problemDetail возвращает подробности ошибки he, если они отличаются от стандартного описания http-кода.
+// Ошибки привязки данных запроса [echo] оборачиваются хэндлерами, поэтому их описание ищется и во внутренней ошибке.
+func problemDetail(he *echo.HTTPError) string {
+	if msg, ok := he.Message.(string); ok && msg != http.StatusText(he.Code) {
+		return msg
+	}
+	inner := new(echo.HTTPError)
+	if errors.As(he.Internal, &inner) {
+		if msg, ok := inner.Message.(string); ok && msg != http.StatusText(inner.Code) {
+			return msg
+		}
+	}
+	return ""
+}
+
+// constraintRule возвращает правило проверки поля, которое нарушает ошибка ce.
+// Если ограничение нарушает запись в корзине (inTrash), то правило сообщает об этом отдельно.
+func constraintRule(ce *errs.ConstraintError, inTrash bool) string {
+	rule := "exists"
+	switch {
+	case errors.Is(ce.Kind, errs.Conflict):
+		rule = "unique"
+	case errors.Is(ce.Kind, errs.ReferencedBy):
+		return "referenced"
+	}
+	if inTrash {
+		rule += "_trashed"
+	}
+	return rule
+}
+
+// fieldError возвращает ошибку поля field, нарушающего правило rule с параметром param, на языке lang.
+func fieldError(field, rule, param string, lang language) model.FieldError {
+	message, ok := ruleMessages[rule]
+	if !ok {
+		message = invalidValue
+	}
+	text := message.in(lang)
+	if strings.Contains(text, "%v") {
+		text = fmt.Sprintf(text, param)
+	}
+	return model.FieldError{Field: field, Rule: rule, Param: param, Message: text}
+}
+
+// acceptedLanguage возвращает первый поддерживаемый язык из заголовка Accept-Language.
+// Если ни один из языков не поддерживается, то возвращается русский.
+func acceptedLanguage(header string) language {
+	for _, tag := range strings.Split(header, ",") {
+		tag, _, _ = strings.Cut(tag, ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		switch {
+		case strings.HasPrefix(tag, "ru"):
+			return russian
+		case strings.HasPrefix(tag, "en"):
+			return english
+		}
+	}
+	return russian
+}
+
+// jsonFieldName переводит название столбца базы данных в название поля в JSON: department_id -> departmentID.
+func jsonFieldName(column string) string {
+	parts := strings.Split(column, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] == "id" {
+			parts[i] = "ID"
+		} else if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
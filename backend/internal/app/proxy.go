@@ -0,0 +1,23 @@
+package app
+
+import (
+	"net"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RegisterTrustedProxies настраивает app так, чтобы ip-адрес клиента для ограничения частоты запросов,
+// журнала запросов и журнала аудита брался из заголовка X-Forwarded-For, если соединение пришло
+// от обратного прокси из сетей proxies. Адреса в заголовке принимаются справа налево, пока они
+// принадлежат этим сетям. Если proxies пуст, то используется адрес соединения, как по умолчанию в [NewApp].
+func RegisterTrustedProxies(app *echo.Echo, proxies []*net.IPNet) {
+	if len(proxies) == 0 {
+		app.IPExtractor = echo.ExtractIPDirect()
+		return
+	}
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, proxy := range proxies {
+		options = append(options, echo.TrustIPRange(proxy))
+	}
+	app.IPExtractor = echo.ExtractIPFromXFFHeader(options...)
+}
@@ -0,0 +1,111 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/foreverd34d/aumsu-elib/internal/handler"
+	"github.com/foreverd34d/aumsu-elib/internal/metrics"
+	"github.com/foreverd34d/aumsu-elib/internal/ratelimit"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Бюджеты запросов, которые расходуются независимо друг от друга.
+const (
+	budgetAuth  = "auth"  // вход, обновление токенов и выход
+	budgetRead  = "read"  // чтение api: GET и HEAD
+	budgetWrite = "write" // изменение данных через api
+)
+
+// Заголовки ограничения частоты запросов (draft-ietf-httpapi-ratelimit-headers).
+const (
+	headerRateLimitLimit     = "RateLimit-Limit"
+	headerRateLimitRemaining = "RateLimit-Remaining"
+	headerRateLimitReset     = "RateLimit-Reset"
+	headerRateLimitPolicy    = "RateLimit-Policy"
+)
+
+// rateLimitersKey — ключ контекста запроса, под которым хранятся ограничители частоты запросов.
+const rateLimitersKey = "rateLimiters"
+
+// rateLimited считает запросы, отклоненные из-за превышения бюджета.
+var rateLimited = metrics.Default.NewCounter("elib_http_rate_limited_total", "Total number of requests rejected by the rate limiter by budget.", "budget")
+
+// RateLimits представляет бюджеты запросов. Бюджет с нулевым количеством запросов не ограничен.
+type RateLimits struct {
+	Auth  ratelimit.Limit // маршруты /auth по ip-адресу клиента
+	Read  ratelimit.Limit // чтение /api по пользователю
+	Write ratelimit.Limit // изменение данных через /api по пользователю
+}
+
+// RegisterRateLimit ограничивает частоту запросов к маршрутам /auth и /api бюджетами limits.
+// Запросы с jwt токеном учитываются по номеру пользователя, остальные — по ip-адресу клиента.
+// Ответы содержат заголовки RateLimit-*, а запросы сверх бюджета отклоняются с кодом 429
+// и заголовком Retry-After.
+func RegisterRateLimit(app *echo.Echo, limits RateLimits) {
+	limiters := make(map[string]*ratelimit.Limiter)
+	for budget, limit := range map[string]ratelimit.Limit{budgetAuth: limits.Auth, budgetRead: limits.Read, budgetWrite: limits.Write} {
+		if limit.Requests > 0 {
+			limiters[budget] = ratelimit.New(limit)
+		}
+	}
+	app.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(rateLimitersKey, limiters)
+			return next(c)
+		}
+	})
+}
+
+// limitRate предоставляет middleware групп маршрутов, которое расходует бюджет запроса,
+// если ограничение частоты включено [RegisterRateLimit]. Middleware выполняется после проверки
+// jwt токена, чтобы запросы учитывались по пользователю.
+func limitRate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		limiters, _ := c.Get(rateLimitersKey).(map[string]*ratelimit.Limiter)
+		budget := budgetOf(c)
+		limiter, ok := limiters[budget]
+		if !ok {
+			return next(c)
+		}
+		key := "ip:" + c.RealIP()
+		if user, err := handler.CurrentUser(c); err == nil {
+			key = "user:" + user.Subject
+		}
+
+		result := limiter.Allow(key)
+		header := c.Response().Header()
+		header.Set(headerRateLimitLimit, strconv.Itoa(result.Limit))
+		header.Set(headerRateLimitRemaining, strconv.Itoa(result.Remaining))
+		header.Set(headerRateLimitReset, seconds(result.Reset))
+		header.Set(headerRateLimitPolicy, fmt.Sprintf("%v;w=%v", result.Limit, seconds(limiter.Limit().Period)))
+		if !result.Allowed {
+			rateLimited.Inc(budget)
+			header.Set(echo.HeaderRetryAfter, seconds(result.RetryAfter))
+			return echo.NewHTTPError(http.StatusTooManyRequests).WithInternal(fmt.Errorf("%v budget exceeded by %v", budget, key))
+		}
+		return next(c)
+	}
+}
+
+// budgetOf возвращает бюджет, который расходует запрос.
+func budgetOf(c echo.Context) string {
+	switch {
+	case strings.HasPrefix(c.Path(), "/auth/"):
+		return budgetAuth
+	case c.Request().Method == http.MethodGet || c.Request().Method == http.MethodHead:
+		return budgetRead
+	default:
+		return budgetWrite
+	}
+}
+
+// seconds возвращает длительность d в целых секундах с округлением вверх.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
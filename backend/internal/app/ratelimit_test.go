@@ -0,0 +1,103 @@
+package app
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+	"github.com/foreverd34d/aumsu-elib/internal/ratelimit"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestRateLimit(t *testing.T) {
+	env := newTestEnv(t)
+	env.createUser("student", "password", 1, nil)
+	student, _ := env.login("student", "password")
+	rec := env.do(http.MethodGet, "/api/departments", env.admin, nil)
+	if got := rec.Header().Get(headerRateLimitLimit); got != "" {
+		t.Errorf("without rate limit: got %v %q", headerRateLimitLimit, got)
+	}
+
+	RegisterRateLimit(env.app, RateLimits{
+		Auth:  ratelimit.Limit{Requests: 2, Period: time.Minute},
+		Read:  ratelimit.Limit{Requests: 2, Period: time.Minute},
+		Write: ratelimit.Limit{Requests: 1, Period: time.Minute},
+	})
+	expectHeaders := func(resp *http.Response, want map[string]string) {
+		t.Helper()
+		for name, value := range want {
+			if got := resp.Header.Get(name); got != value {
+				t.Errorf("%v: got %q, want %q", name, got, value)
+			}
+		}
+	}
+
+	for _, remaining := range []string{"1", "0"} {
+		rec := env.do(http.MethodGet, "/api/departments", env.admin, nil)
+		env.expect(rec, http.StatusOK)
+		expectHeaders(rec.Result(), map[string]string{
+			headerRateLimitLimit:     "2",
+			headerRateLimitRemaining: remaining,
+			headerRateLimitPolicy:    "2;w=60",
+		})
+	}
+	rec = env.do(http.MethodGet, "/api/departments", env.admin, nil)
+	env.expectProblem(rec, http.StatusTooManyRequests, "too_many_requests", "")
+	expectHeaders(rec.Result(), map[string]string{
+		headerRateLimitRemaining: "0",
+		headerRateLimitReset:     "60",
+		echo.HeaderRetryAfter:    "30",
+	})
+
+	// Бюджеты пользователей и видов запросов расходуются независимо.
+	env.expect(env.do(http.MethodGet, "/api/departments", student, nil), http.StatusOK)
+	env.create("/api/departments", model.NewDepartment{Name: "Кафедра"})
+	env.expect(env.do(http.MethodPost, "/api/departments", env.admin, model.NewDepartment{Name: "Кафедра связи"}), http.StatusTooManyRequests)
+
+	// Маршруты без jwt токена учитываются по ip-адресу клиента.
+	credentials := model.Credentials{Username: "student", Password: "wrong"}
+	env.expect(env.do(http.MethodPost, "/auth/session", "", credentials), http.StatusUnauthorized)
+	env.expect(env.do(http.MethodPost, "/auth/session", "", credentials), http.StatusUnauthorized)
+	env.expect(env.do(http.MethodPost, "/auth/session", "", credentials), http.StatusTooManyRequests)
+	req := env.request(http.MethodPost, "/auth/session", credentials)
+	req.RemoteAddr = "203.0.113.7:4000"
+	env.expect(env.serve(req, ""), http.StatusUnauthorized)
+
+	// Подделанные заголовки с адресом клиента не дают нового бюджета.
+	for _, header := range []string{echo.HeaderXForwardedFor, echo.HeaderXRealIP} {
+		req := env.request(http.MethodPost, "/auth/session", credentials)
+		req.Header.Set(header, "198.51.100.1")
+		env.expectProblem(env.serve(req, ""), http.StatusTooManyRequests, "too_many_requests", "")
+	}
+}
+
+func TestRateLimitTrustedProxies(t *testing.T) {
+	env := newTestEnv(t)
+	RegisterRateLimit(env.app, RateLimits{Auth: ratelimit.Limit{Requests: 1, Period: time.Minute}})
+	_, proxies, err := net.ParseCIDR("10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterTrustedProxies(env.app, []*net.IPNet{proxies})
+	credentials := model.Credentials{Username: "admin", Password: "wrong"}
+	login := func(remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+		req := env.request(http.MethodPost, "/auth/session", credentials)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+		return env.serve(req, "")
+	}
+
+	// За доверенным прокси клиенты различаются по X-Forwarded-For.
+	env.expect(login("10.0.0.2:4000", "203.0.113.7"), http.StatusUnauthorized)
+	env.expect(login("10.0.0.2:4000", "203.0.113.7"), http.StatusTooManyRequests)
+	env.expect(login("10.0.0.2:4000", "203.0.113.8"), http.StatusUnauthorized)
+	// Адрес, добавленный клиентом левее адреса, который записал прокси, не учитывается.
+	env.expect(login("10.0.0.2:4000", "198.51.100.1, 203.0.113.7"), http.StatusTooManyRequests)
+	// Заголовку от соединения не из сетей прокси не доверяют.
+	env.expect(login("192.0.2.1:4000", "203.0.113.9"), http.StatusUnauthorized)
+	env.expect(login("192.0.2.1:4000", "203.0.113.10"), http.StatusTooManyRequests)
+}
@@ -0,0 +1,128 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/foreverd34d/aumsu-elib/internal/handler"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+// route описывает маршрут приложения и минимальную роль, необходимую для обращения к нему.
+type route struct {
+	method string                                         // http-метод
+	path   string                                         // путь относительно группы маршрутов
+	handle func(h *handler.Handler, c echo.Context) error // хэндлер маршрута
+	role   model.UserRole                                 // минимальная роль пользователя
+}
+
+// authRoutes содержит маршруты группы /auth, доступные без jwt токена.
+var authRoutes = []route{
+	{http.MethodPost, "/session", (*handler.Handler).CreateSession, model.StudentRole},
+	{http.MethodPut, "/session", (*handler.Handler).UpdateSession, model.StudentRole},
+	{http.MethodDelete, "/session", (*handler.Handler).DeleteSession, model.StudentRole},
+}
+
+// apiRoutes содержит маршруты группы /api, доступные только с действительным jwt токеном.
+// Справочные данные доступны на чтение любой роли, а изменять их может только указанная роль и выше.
+var apiRoutes = []route{
+	{http.MethodGet, "/me", (*handler.Handler).GetMe, model.StudentRole},
+	{http.MethodPatch, "/me", (*handler.Handler).UpdateMe, model.StudentRole},
+	{http.MethodPut, "/me/password", (*handler.Handler).ChangeMyPassword, model.StudentRole},
+	{http.MethodGet, "/me/photo", (*handler.Handler).GetMyPhoto, model.StudentRole},
+	{http.MethodPut, "/me/photo", (*handler.Handler).SetMyPhoto, model.StudentRole},
+	{http.MethodDelete, "/me/photo", (*handler.Handler).DeleteMyPhoto, model.StudentRole},
+
+	{http.MethodPost, "/users", (*handler.Handler).CreateUser, model.AdminRole},
+	{http.MethodPost, "/users/import", (*handler.Handler).ImportUsers, model.AdminRole},
+	{http.MethodGet, "/users", (*handler.Handler).GetAllUsers, model.AdminRole},
+	{http.MethodGet, "/users/:id", (*handler.Handler).GetUser, model.AdminRole},
+	{http.MethodPut, "/users/:id", (*handler.Handler).UpdateUser, model.AdminRole},
+	{http.MethodPatch, "/users/:id", (*handler.Handler).PatchUser, model.AdminRole},
+	{http.MethodDelete, "/users/:id", (*handler.Handler).DeleteUser, model.AdminRole},
+	{http.MethodGet, "/users/:id/photo", (*handler.Handler).GetUserPhoto, model.AdminRole},
+	{http.MethodPut, "/users/:id/photo", (*handler.Handler).SetUserPhoto, model.AdminRole},
+	{http.MethodDelete, "/users/:id/photo", (*handler.Handler).DeleteUserPhoto, model.AdminRole},
+
+	{http.MethodGet, "/departments", (*handler.Handler).GetAllDepartments, model.StudentRole},
+	{http.MethodGet, "/departments/:id", (*handler.Handler).GetDepartment, model.StudentRole},
+	{http.MethodPost, "/departments", (*handler.Handler).CreateDepartment, model.AdminRole},
+	{http.MethodPut, "/departments/:id", (*handler.Handler).UpdateDepartment, model.AdminRole},
+	{http.MethodPatch, "/departments/:id", (*handler.Handler).PatchDepartment, model.AdminRole},
+	{http.MethodDelete, "/departments/:id", (*handler.Handler).DeleteDepartment, model.AdminRole},
+
+	{http.MethodGet, "/specialties", (*handler.Handler).GetAllSpecialties, model.StudentRole},
+	{http.MethodGet, "/specialties/:id", (*handler.Handler).GetSpecialty, model.StudentRole},
+	{http.MethodPost, "/specialties", (*handler.Handler).CreateSpecialty, model.AdminRole},
+	{http.MethodPut, "/specialties/:id", (*handler.Handler).UpdateSpecialty, model.AdminRole},
+	{http.MethodPatch, "/specialties/:id", (*handler.Handler).PatchSpecialty, model.AdminRole},
+	{http.MethodDelete, "/specialties/:id", (*handler.Handler).DeleteSpecialty, model.AdminRole},
+
+	{http.MethodGet, "/groups", (*handler.Handler).GetAllGroups, model.StudentRole},
+	{http.MethodGet, "/groups/:id", (*handler.Handler).GetGroup, model.StudentRole},
+	{http.MethodPost, "/groups", (*handler.Handler).CreateGroup, model.AdminRole},
+	{http.MethodPut, "/groups/:id", (*handler.Handler).UpdateGroup, model.AdminRole},
+	{http.MethodPatch, "/groups/:id", (*handler.Handler).PatchGroup, model.AdminRole},
+	{http.MethodDelete, "/groups/:id", (*handler.Handler).DeleteGroup, model.AdminRole},
+
+	{http.MethodGet, "/disciplines", (*handler.Handler).GetAllDisciplines, model.StudentRole},
+	{http.MethodGet, "/disciplines/:id", (*handler.Handler).GetDiscipline, model.StudentRole},
+	{http.MethodPost, "/disciplines", (*handler.Handler).CreateDiscipline, model.ManagerRole},
+	{http.MethodPut, "/disciplines/:id", (*handler.Handler).UpdateDiscipline, model.ManagerRole},
+	{http.MethodPatch, "/disciplines/:id", (*handler.Handler).PatchDiscipline, model.ManagerRole},
+	{http.MethodDelete, "/disciplines/:id", (*handler.Handler).DeleteDiscipline, model.ManagerRole},
+
+	{http.MethodGet, "/catalog/tree", (*handler.Handler).GetCatalogTree, model.StudentRole},
+
+	{http.MethodGet, "/material-types", (*handler.Handler).GetAllMaterialTypes, model.StudentRole},
+	{http.MethodGet, "/material-types/:id", (*handler.Handler).GetMaterialType, model.StudentRole},
+	{http.MethodPost, "/material-types", (*handler.Handler).CreateMaterialType, model.AdminRole},
+	{http.MethodPut, "/material-types/:id", (*handler.Handler).UpdateMaterialType, model.AdminRole},
+	{http.MethodPatch, "/material-types/:id", (*handler.Handler).PatchMaterialType, model.AdminRole},
+	{http.MethodDelete, "/material-types/:id", (*handler.Handler).DeleteMaterialType, model.AdminRole},
+
+	{http.MethodGet, "/materials", (*handler.Handler).GetAllMaterials, model.StudentRole},
+	{http.MethodGet, "/materials/:id", (*handler.Handler).GetMaterial, model.StudentRole},
+	{http.MethodGet, "/materials/:id/file", (*handler.Handler).GetMaterialFile, model.StudentRole},
+	{http.MethodPost, "/materials", (*handler.Handler).CreateMaterial, model.TeacherRole},
+	{http.MethodPut, "/materials/:id", (*handler.Handler).UpdateMaterial, model.TeacherRole},
+	{http.MethodPatch, "/materials/:id", (*handler.Handler).PatchMaterial, model.TeacherRole},
+	{http.MethodPut, "/materials/:id/file", (*handler.Handler).SetMaterialFile, model.TeacherRole},
+	{http.MethodDelete, "/materials/:id", (*handler.Handler).DeleteMaterial, model.TeacherRole},
+
+	{http.MethodGet, "/lessons/:id", (*handler.Handler).GetLesson, model.StudentRole},
+	{http.MethodGet, "/lessons/:id/materials", (*handler.Handler).GetLessonMaterials, model.StudentRole},
+	{http.MethodPut, "/lessons/:id/materials/:materialID", (*handler.Handler).AttachLessonMaterial, model.TeacherRole},
+	{http.MethodDelete, "/lessons/:id/materials/:materialID", (*handler.Handler).DetachLessonMaterial, model.TeacherRole},
+
+	{http.MethodGet, "/books/:id/materials", (*handler.Handler).GetBookMaterials, model.StudentRole},
+	{http.MethodPut, "/books/:id/materials/:materialID", (*handler.Handler).AttachBookMaterial, model.TeacherRole},
+	{http.MethodDelete, "/books/:id/materials/:materialID", (*handler.Handler).DetachBookMaterial, model.TeacherRole},
+
+	{http.MethodPost, "/rollover", (*handler.Handler).ApplyRollover, model.AdminRole},
+	{http.MethodPost, "/rollover/preview", (*handler.Handler).PreviewRollover, model.AdminRole},
+
+	{http.MethodGet, "/audit", (*handler.Handler).GetAuditEvents, model.AdminRole},
+
+	{http.MethodGet, "/trash", (*handler.Handler).GetTrash, model.AdminRole},
+	{http.MethodPost, "/trash/:entity/:id/restore", (*handler.Handler).RestoreFromTrash, model.AdminRole},
+
+	{http.MethodGet, "/jobs", (*handler.Handler).GetJobs, model.AdminRole},
+	{http.MethodGet, "/jobs/runs", (*handler.Handler).GetJobRuns, model.AdminRole},
+	{http.MethodPost, "/jobs/:name/runs", (*handler.Handler).RunJob, model.AdminRole},
+}
+
+// registerRoutes регистрирует маршруты routes в группе g.
+// Маршруты, требующие роль выше студента, дополнительно проверяются middleware [checkRole].
+func registerRoutes(g *echo.Group, h *handler.Handler, routes []route) {
+	for _, r := range routes {
+		var middlewares []echo.MiddlewareFunc
+		if r.role > model.StudentRole {
+			middlewares = append(middlewares, checkRole(r.role))
+		}
+		g.Add(r.method, r.path, func(c echo.Context) error {
+			return r.handle(h, c)
+		}, middlewares...)
+	}
+}
@@ -0,0 +1,223 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/foreverd34d/aumsu-elib/internal/handler"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSigningKey = "test-signing-key"
+
+// roleNames содержит названия ролей для сообщений тестов.
+var roleNames = map[model.UserRole]string{
+	model.StudentRole: "student",
+	model.TeacherRole: "teacher",
+	model.ManagerRole: "manager",
+	model.AdminRole:   "admin",
+}
+
+// testToken возвращает подписанный jwt токен пользователя с ролью role.
+func testToken(t *testing.T, role model.UserRole) string {
+	t.Helper()
+	claims := &model.JWTClaims{
+		Role: role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "1",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSigningKey))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return token
+}
+
+// routePath подставляет номер 1 вместо параметров пути маршрута.
+func routePath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") {
+			parts[i] = "1"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// serve выполняет запрос к приложению и возвращает код ответа.
+func serve(t *testing.T, method, path, token string) int {
+	t.Helper()
+	// Сервисы не заданы: запрос, прошедший проверку доступа, завершается паникой в хэндлере,
+	// которую перехватывает middleware Recover, поэтому тесты проверяют только доступ.
+	app := NewApp(&handler.Handler{}, testSigningKey)
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestAPIRoutesRequireToken(t *testing.T) {
+	for _, r := range apiRoutes {
+		path := "/api" + routePath(r.path)
+		if code := serve(t, r.method, path, ""); code != http.StatusUnauthorized && code != http.StatusBadRequest {
+			t.Errorf("%v %v without token: got %v, want 401 or 400", r.method, path, code)
+		}
+		if code := serve(t, r.method, path, "invalid"); code != http.StatusUnauthorized {
+			t.Errorf("%v %v with invalid token: got %v, want 401", r.method, path, code)
+		}
+	}
+}
+
+// accessMatrix содержит минимальные роли для маршрутов /api. Таблица задана отдельно от [apiRoutes],
+// чтобы изменение роли маршрута не проходило незамеченным.
+var accessMatrix = []struct {
+	method string
+	path   string
+	role   model.UserRole
+}{
+	{http.MethodGet, "/api/me", model.StudentRole},
+	{http.MethodPatch, "/api/me", model.StudentRole},
+	{http.MethodPut, "/api/me/password", model.StudentRole},
+	{http.MethodGet, "/api/me/photo", model.StudentRole},
+	{http.MethodPut, "/api/me/photo", model.StudentRole},
+	{http.MethodDelete, "/api/me/photo", model.StudentRole},
+
+	{http.MethodPost, "/api/users", model.AdminRole},
+	{http.MethodPost, "/api/users/import", model.AdminRole},
+	{http.MethodGet, "/api/users", model.AdminRole},
+	{http.MethodGet, "/api/users/1", model.AdminRole},
+	{http.MethodPut, "/api/users/1", model.AdminRole},
+	{http.MethodPatch, "/api/users/1", model.AdminRole},
+	{http.MethodDelete, "/api/users/1", model.AdminRole},
+	{http.MethodGet, "/api/users/1/photo", model.AdminRole},
+	{http.MethodPut, "/api/users/1/photo", model.AdminRole},
+	{http.MethodDelete, "/api/users/1/photo", model.AdminRole},
+
+	{http.MethodGet, "/api/departments", model.StudentRole},
+	{http.MethodGet, "/api/departments/1", model.StudentRole},
+	{http.MethodPost, "/api/departments", model.AdminRole},
+	{http.MethodPut, "/api/departments/1", model.AdminRole},
+	{http.MethodPatch, "/api/departments/1", model.AdminRole},
+	{http.MethodDelete, "/api/departments/1", model.AdminRole},
+
+	{http.MethodGet, "/api/specialties", model.StudentRole},
+	{http.MethodGet, "/api/specialties/1", model.StudentRole},
+	{http.MethodPost, "/api/specialties", model.AdminRole},
+	{http.MethodPut, "/api/specialties/1", model.AdminRole},
+	{http.MethodPatch, "/api/specialties/1", model.AdminRole},
+	{http.MethodDelete, "/api/specialties/1", model.AdminRole},
+
+	{http.MethodGet, "/api/groups", model.StudentRole},
+	{http.MethodGet, "/api/groups/1", model.StudentRole},
+	{http.MethodPost, "/api/groups", model.AdminRole},
+	{http.MethodPut, "/api/groups/1", model.AdminRole},
+	{http.MethodPatch, "/api/groups/1", model.AdminRole},
+	{http.MethodDelete, "/api/groups/1", model.AdminRole},
+
+	{http.MethodGet, "/api/disciplines", model.StudentRole},
+	{http.MethodGet, "/api/disciplines/1", model.StudentRole},
+	{http.MethodPost, "/api/disciplines", model.ManagerRole},
+	{http.MethodPut, "/api/disciplines/1", model.ManagerRole},
+	{http.MethodPatch, "/api/disciplines/1", model.ManagerRole},
+	{http.MethodDelete, "/api/disciplines/1", model.ManagerRole},
+
+	{http.MethodGet, "/api/catalog/tree", model.StudentRole},
+
+	{http.MethodGet, "/api/material-types", model.StudentRole},
+	{http.MethodGet, "/api/material-types/1", model.StudentRole},
+	{http.MethodPost, "/api/material-types", model.AdminRole},
+	{http.MethodPut, "/api/material-types/1", model.AdminRole},
+	{http.MethodPatch, "/api/material-types/1", model.AdminRole},
+	{http.MethodDelete, "/api/material-types/1", model.AdminRole},
+
+	{http.MethodGet, "/api/materials", model.StudentRole},
+	{http.MethodGet, "/api/materials/1", model.StudentRole},
+	{http.MethodGet, "/api/materials/1/file", model.StudentRole},
+	{http.MethodPost, "/api/materials", model.TeacherRole},
+	{http.MethodPut, "/api/materials/1", model.TeacherRole},
+	{http.MethodPatch, "/api/materials/1", model.TeacherRole},
+	{http.MethodPut, "/api/materials/1/file", model.TeacherRole},
+	{http.MethodDelete, "/api/materials/1", model.TeacherRole},
+
+	{http.MethodGet, "/api/lessons/1", model.StudentRole},
+	{http.MethodGet, "/api/lessons/1/materials", model.StudentRole},
+	{http.MethodPut, "/api/lessons/1/materials/1", model.TeacherRole},
+	{http.MethodDelete, "/api/lessons/1/materials/1", model.TeacherRole},
+
+	{http.MethodGet, "/api/books/1/materials", model.StudentRole},
+	{http.MethodPut, "/api/books/1/materials/1", model.TeacherRole},
+	{http.MethodDelete, "/api/books/1/materials/1", model.TeacherRole},
+
+	{http.MethodPost, "/api/rollover", model.AdminRole},
+	{http.MethodPost, "/api/rollover/preview", model.AdminRole},
+
+	{http.MethodGet, "/api/audit", model.AdminRole},
+
+	{http.MethodGet, "/api/trash", model.AdminRole},
+	{http.MethodPost, "/api/trash/department/1/restore", model.AdminRole},
+
+	{http.MethodGet, "/api/jobs", model.AdminRole},
+	{http.MethodGet, "/api/jobs/runs", model.AdminRole},
+	{http.MethodPost, "/api/jobs/trash-purge/runs", model.AdminRole},
+}
+
+func TestAPIRoutesAccessMatrix(t *testing.T) {
+	roles := []model.UserRole{model.StudentRole, model.TeacherRole, model.ManagerRole, model.AdminRole}
+	tokens := make(map[model.UserRole]string, len(roles))
+	for _, role := range roles {
+		tokens[role] = testToken(t, role)
+	}
+
+	for _, r := range accessMatrix {
+		// Каждый маршрут проверяется в отдельном окружении, чтобы изменения одних запросов не влияли на другие.
+		env := newTestEnv(t)
+		for _, role := range roles {
+			code := env.serve(httptest.NewRequest(r.method, r.path, nil), tokens[role]).Code
+			if role < r.role {
+				if code != http.StatusForbidden {
+					t.Errorf("%v %v as %v: got %v, want 403", r.method, r.path, roleNames[role], code)
+				}
+				continue
+			}
+			switch code {
+			case http.StatusUnauthorized, http.StatusForbidden, http.StatusMethodNotAllowed, http.StatusInternalServerError:
+				t.Errorf("%v %v as %v: got %v, want access to the handler", r.method, r.path, roleNames[role], code)
+			}
+		}
+	}
+}
+
+func TestReferenceDataReadableByAnyRole(t *testing.T) {
+	token := testToken(t, model.StudentRole)
+	for _, path := range []string{
+		"/api/departments", "/api/departments/1",
+		"/api/specialties", "/api/specialties/1",
+		"/api/groups", "/api/groups/1",
+		"/api/disciplines", "/api/disciplines/1",
+	} {
+		switch code := serve(t, http.MethodGet, path, token); code {
+		case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusMethodNotAllowed:
+			t.Errorf("GET %v as student: got %v, want access to the handler", path, code)
+		}
+	}
+}
+
+func TestAuthRoutesArePublic(t *testing.T) {
+	for _, r := range authRoutes {
+		path := "/auth" + r.path
+		switch code := serve(t, r.method, path, ""); code {
+		case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusMethodNotAllowed:
+			t.Errorf("%v %v without token: got %v, want access to the handler", r.method, path, code)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/foreverd34d/aumsu-elib/internal/logging"
+	"github.com/foreverd34d/aumsu-elib/internal/tracing"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceRequests предоставляет middleware, которое начинает серверный span для каждого запроса.
+// Контекст трассировки клиента берется из заголовков traceparent и tracestate по W3C Trace Context.
+// Ошибка хэндлера сразу передается обработчику ошибок, чтобы в span попал итоговый код ответа.
+func traceRequests(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		route := c.Path()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+		ctx, span := tracing.Start(ctx, req.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(req.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(req.URL.Path),
+				semconv.ClientAddress(c.RealIP()),
+				semconv.UserAgentOriginal(req.UserAgent()),
+				attribute.String(logging.RequestIDKey, c.Response().Header().Get(echo.HeaderXRequestID)),
+			))
+		defer span.End()
+		c.SetRequest(req.WithContext(ctx))
+
+		err := next(c)
+		if err != nil {
+			c.Error(err)
+		}
+		status := c.Response().Status
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			tracing.RecordError(span, internalError(err))
+		}
+		return err
+	}
+}
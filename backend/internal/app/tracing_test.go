@@ -0,0 +1,77 @@
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans направляет span в память до конца теста и возвращает их получателя.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+	return recorder
+}
+
+func TestTracing(t *testing.T) {
+	env := newTestEnv(t)
+	recorder := recordSpans(t)
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := env.request(http.MethodGet, "/api/departments/100", nil)
+	req.Header.Set("traceparent", traceparent)
+	env.expect(env.serve(req, env.admin), http.StatusNotFound)
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	server, ok := spans["GET /api/departments/:id"]
+	if !ok {
+		t.Fatalf("no server span in %v", spans)
+	}
+	if server.SpanKind() != trace.SpanKindServer {
+		t.Errorf("server span kind: got %v", server.SpanKind())
+	}
+	if got := server.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("server span trace ID: got %v, want the one from traceparent", got)
+	}
+	if got := server.Parent().SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Errorf("server span parent: got %v, want the one from traceparent", got)
+	}
+	service, ok := spans["DepartmentService.Get"]
+	if !ok {
+		t.Fatalf("no service span in %v", spans)
+	}
+	if service.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Error("service span is not a child of the server span")
+	}
+	for _, attr := range server.Attributes() {
+		if attr.Key == "http.response.status_code" && attr.Value.AsInt64() != http.StatusNotFound {
+			t.Errorf("server span status code: got %v", attr.Value.AsInt64())
+		}
+	}
+
+	// Ошибки сервера помечают span как завершившийся ошибкой.
+	env.app.GET("/panic", func(c echo.Context) error { panic("boom") })
+	env.expect(env.do(http.MethodGet, "/panic", "", nil), http.StatusInternalServerError)
+	for _, span := range recorder.Ended() {
+		if span.Name() == "GET /panic" && span.Status().Code != codes.Error {
+			t.Errorf("span of failed request: got status %v", span.Status())
+		}
+	}
+}
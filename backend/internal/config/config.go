@@ -0,0 +1,322 @@
+// Пакет config описывает все настройки сервера и загружает их из файла YAML и переменных окружения.
+//
+// Каждая настройка имеет значение по умолчанию (см. [Default]), которое переопределяется файлом конфигурации,
+// а тот — переменной окружения с префиксом ELIB_, в названии которой точки ключа заменены подчеркиваниями:
+// например, server.tls.cert_file задается переменной ELIB_SERVER_TLS_CERT_FILE.
+// Списки в переменных окружения перечисляются через запятую.
+// Для совместимости секреты также читаются из переменных TOKEN_SIGNING_KEY, DB_PASSWORD и METRICS_TOKEN.
+//
+// Загруженная конфигурация проверяется методом [Config.Validate], а метод [Config.Print]
+// выводит ее в формате YAML со скрытыми секретами.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix — префикс переменных окружения с настройками.
+const EnvPrefix = "ELIB"
+
+// Config представляет все настройки сервера.
+type Config struct {
+	Server    Server    `yaml:"server"`     // http-сервер
+	Database  Database  `yaml:"database"`   // подключение к базе данных
+	Token     Token     `yaml:"token"`      // токены доступа и обновления
+	Session   Session   `yaml:"session"`    // ограничения сессий
+	Storage   Storage   `yaml:"storage"`    // файловое хранилище
+	Trash     Trash     `yaml:"trash"`      // корзина
+	Jobs      Jobs      `yaml:"jobs"`       // задачи обслуживания
+	Log       Log       `yaml:"log"`        // журнал
+	Tracing   Tracing   `yaml:"tracing"`    // трассировка OpenTelemetry
+	Metrics   Metrics   `yaml:"metrics"`    // метрики Prometheus
+	CORS      CORS      `yaml:"cors"`       // запросы из браузера с других доменов
+	RateLimit RateLimit `yaml:"rate_limit"` // ограничение частоты запросов
+}
+
+// Server представляет настройки http-сервера.
+type Server struct {
+	Port              int           `yaml:"port"`                // порт
+	ReadTimeout       time.Duration `yaml:"read_timeout"`        // время чтения запроса вместе с телом (0 — без ограничения)
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"` // время чтения заголовков запроса (0 — как read_timeout)
+	WriteTimeout      time.Duration `yaml:"write_timeout"`       // время записи ответа (0 — без ограничения)
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`        // время ожидания следующего запроса в соединении
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout"`    // время на завершение обрабатываемых запросов при остановке
+	ShutdownDelay     time.Duration `yaml:"shutdown_delay"`      // время между снятием готовности и остановкой сервера
+	TLS               TLS           `yaml:"tls"`                 // сертификат для https
+	TrustedProxies    []string      `yaml:"trusted_proxies"`     // сети обратных прокси (CIDR), которым доверяется X-Forwarded-For (пусто — адрес соединения)
+}
+
+// TLS представляет файлы сертификата и ключа для https. Если они не заданы, то сервер работает по http.
+type TLS struct {
+	CertFile string `yaml:"cert_file"` // файл сертификата в формате PEM
+	KeyFile  string `yaml:"key_file"`  // файл закрытого ключа в формате PEM
+}
+
+// Enabled сообщает, задан ли сертификат для https.
+func (t TLS) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != ""
+}
+
+// Database представляет настройки подключения к базе данных PostgreSQL.
+type Database struct {
+	Host            string        `yaml:"host"`               // адрес сервера
+	Port            int           `yaml:"port"`               // порт сервера
+	User            string        `yaml:"user"`               // пользователь
+	Password        Secret        `yaml:"password"`           // пароль
+	DBName          string        `yaml:"dbname"`             // название базы данных
+	SSLMode         string        `yaml:"sslmode"`            // режим SSL
+	ConnectTimeout  time.Duration `yaml:"connect_timeout"`    // время ожидания подключения при запуске
+	MaxOpenConns    int           `yaml:"max_open_conns"`     // максимальное количество соединений (0 — без ограничения)
+	MaxIdleConns    int           `yaml:"max_idle_conns"`     // максимальное количество простаивающих соединений
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`  // время жизни соединения (0 — без ограничения)
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"` // время простоя соединения до закрытия (0 — без ограничения)
+}
+
+// Token представляет настройки токенов доступа и обновления.
+type Token struct {
+	SigningKey Secret        `yaml:"signing_key"` // ключ подписи jwt токенов
+	AccessTTL  time.Duration `yaml:"access_ttl"`  // срок действия jwt токена доступа
+	RefreshTTL time.Duration `yaml:"refresh_ttl"` // срок действия токена обновления
+	Roles      Roles         `yaml:"roles"`       // сроки действия токенов для отдельных ролей
+}
+
+// Roles представляет сроки действия токенов для каждой роли пользователя.
+type Roles struct {
+	Student RoleToken `yaml:"student"` // студент
+	Teacher RoleToken `yaml:"teacher"` // преподаватель
+	Manager RoleToken `yaml:"manager"` // руководитель
+	Admin   RoleToken `yaml:"admin"`   // администратор
+}
+
+// RoleToken представляет сроки действия токенов для роли. Нулевой срок берется из общих настроек токенов.
+type RoleToken struct {
+	AccessTTL  time.Duration `yaml:"access_ttl"`  // срок действия jwt токена доступа
+	RefreshTTL time.Duration `yaml:"refresh_ttl"` // срок действия токена обновления
+}
+
+// Session представляет ограничения сессий пользователей. Нулевые ограничения не действуют.
+type Session struct {
+	IdleTimeout time.Duration `yaml:"idle_timeout"` // время без обновления токенов, после которого сессия завершается
+	MaxAge      time.Duration `yaml:"max_age"`      // максимальная длительность сессии от входа
+	MaxPerUser  int           `yaml:"max_per_user"` // максимальное количество одновременных сессий пользователя
+}
+
+// Storage представляет настройки файлового хранилища.
+type Storage struct {
+	Dir string `yaml:"dir"` // директория хранилища на диске
+}
+
+// Trash представляет настройки корзины.
+type Trash struct {
+	Retention time.Duration `yaml:"retention"` // срок хранения записей в корзине
+}
+
+// Jobs представляет настройки задач обслуживания, которые выполняются по расписанию.
+// Из нескольких экземпляров сервера задачи выполняет только один.
+type Jobs struct {
+	Enabled          bool          `yaml:"enabled"`           // выполнять задачи по расписанию
+	CheckInterval    time.Duration `yaml:"check_interval"`    // период проверки сроков задач и блокировки планировщика
+	HistoryRetention time.Duration `yaml:"history_retention"` // срок хранения истории запусков
+	Intervals        JobIntervals  `yaml:"intervals"`         // периоды запуска задач
+}
+
+// JobIntervals представляет периоды запуска каждой задачи обслуживания.
+type JobIntervals struct {
+	ExpiredTokens time.Duration `yaml:"expired_tokens"` // удаление истекших токенов обновления
+	StaleSessions time.Duration `yaml:"stale_sessions"` // завершение устаревших сессий
+	TrashPurge    time.Duration `yaml:"trash_purge"`    // окончательное удаление записей из корзины
+	JobHistory    time.Duration `yaml:"job_history"`    // удаление старой истории запусков
+}
+
+// Log представляет настройки журнала.
+type Log struct {
+	Level string `yaml:"level"` // минимальный уровень записей: debug, info, warn или error
+}
+
+// LogLevel возвращает уровень журнала из настройки log.level или [slog.LevelInfo], если она некорректна.
+func (l Log) LogLevel() slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(l.Level)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// Tracing представляет настройки трассировки OpenTelemetry.
+type Tracing struct {
+	Exporter    string  `yaml:"exporter"`     // вид экспорта: none, otlp или stdout
+	Endpoint    string  `yaml:"endpoint"`     // адрес приемника OTLP/HTTP
+	SampleRatio float64 `yaml:"sample_ratio"` // доля записываемых трасс
+}
+
+// Metrics представляет настройки метрик Prometheus.
+type Metrics struct {
+	Token Secret `yaml:"token"` // токен доступа к /metrics (пусто — метрики недоступны)
+}
+
+// CORS представляет настройки запросов из браузера с других доменов.
+type CORS struct {
+	AllowOrigins []string      `yaml:"allow_origins"` // разрешенные источники (пусто — CORS выключен, * — любой источник)
+	MaxAge       time.Duration `yaml:"max_age"`       // время кэширования ответа на предварительный запрос
+}
+
+// RateLimit представляет настройки ограничения частоты запросов. Запросы с jwt токеном
+// учитываются по пользователю, остальные — по ip-адресу клиента.
+type RateLimit struct {
+	Enabled bool       `yaml:"enabled"` // ограничивать частоту запросов
+	Auth    RateBudget `yaml:"auth"`    // вход, обновление токенов и выход
+	Read    RateBudget `yaml:"read"`    // чтение api
+	Write   RateBudget `yaml:"write"`   // изменение данных через api
+}
+
+// RateBudget представляет бюджет запросов: не больше Requests запросов подряд
+// и дальше не чаще, чем Requests за Period. Нулевой бюджет не ограничен.
+type RateBudget struct {
+	Requests int           `yaml:"requests"` // количество запросов за период
+	Period   time.Duration `yaml:"period"`   // период
+}
+
+// Secret представляет секретную настройку, которая скрывается при выводе конфигурации.
+type Secret string
+
+// redacted заменяет значение заданного секрета при выводе.
+const redacted = "[REDACTED]"
+
+// String возвращает [redacted] для заданного секрета и пустую строку для пустого.
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// MarshalYAML реализует интерфейс [yaml.Marshaler] и скрывает значение секрета.
+func (s Secret) MarshalYAML() (any, error) {
+	return s.String(), nil
+}
+
+// Default возвращает конфигурацию со значениями по умолчанию.
+func Default() Config {
+	return Config{
+		Server: Server{
+			Port:              8080,
+			ReadTimeout:       time.Minute,
+			ReadHeaderTimeout: 10 * time.Second,
+			WriteTimeout:      5 * time.Minute,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   10 * time.Second,
+			TrustedProxies:    []string{},
+		},
+		Database: Database{
+			Host:            "localhost",
+			Port:            5432,
+			User:            "postgres",
+			DBName:          "aumsu",
+			SSLMode:         "disable",
+			ConnectTimeout:  5 * time.Second,
+			MaxOpenConns:    20,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+		},
+		Token: Token{
+			AccessTTL:  15 * time.Minute,
+			RefreshTTL: 30 * 24 * time.Hour,
+		},
+		Storage: Storage{Dir: "storage"},
+		Trash:   Trash{Retention: 30 * 24 * time.Hour},
+		Jobs: Jobs{
+			Enabled:          true,
+			CheckInterval:    time.Minute,
+			HistoryRetention: 30 * 24 * time.Hour,
+			Intervals: JobIntervals{
+				ExpiredTokens: time.Hour,
+				StaleSessions: time.Hour,
+				TrashPurge:    time.Hour,
+				JobHistory:    24 * time.Hour,
+			},
+		},
+		Log:     Log{Level: "info"},
+		Tracing: Tracing{Exporter: "none", SampleRatio: 1},
+		CORS:    CORS{AllowOrigins: []string{}, MaxAge: 10 * time.Minute},
+		RateLimit: RateLimit{
+			Enabled: true,
+			Auth:    RateBudget{Requests: 10, Period: time.Minute},
+			Read:    RateBudget{Requests: 300, Period: time.Minute},
+			Write:   RateBudget{Requests: 60, Period: time.Minute},
+		},
+	}
+}
+
+// legacyEnv содержит переменные окружения, из которых секреты читались до появления префикса ELIB_.
+var legacyEnv = map[string]string{
+	"token.signing_key": "TOKEN_SIGNING_KEY",
+	"database.password": "DB_PASSWORD",
+	"metrics.token":     "METRICS_TOKEN",
+}
+
+// Load загружает конфигурацию: значения по умолчанию, затем файл YAML path (если path не пуст)
+// и переменные окружения. Конфигурация не проверяется, для этого нужно вызвать [Config.Validate].
+func Load(path string) (Config, error) {
+	v := viper.New()
+	setDefaults(v, "", reflect.ValueOf(Default()))
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	for key, env := range legacyEnv {
+		if err := v.BindEnv(key, EnvPrefix+"_"+strings.ToUpper(strings.ReplaceAll(key, ".", "_")), env); err != nil {
+			return Config{}, fmt.Errorf("bind env %v: %w", env, err)
+		}
+	}
+
+	if path != "" {
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return Config{}, fmt.Errorf("config file %v does not exist", path)
+			}
+			return Config{}, fmt.Errorf("read config file %v: %w", path, err)
+		}
+	}
+
+	var cfg Config
+	if err := v.Unmarshal(&cfg, func(dc *mapstructure.DecoderConfig) { dc.TagName = "yaml" }); err != nil {
+		return Config{}, fmt.Errorf("decode config: %w", err)
+	}
+	return cfg, nil
+}
+
+// setDefaults задает в v значения по умолчанию для всех полей структуры value с ключами от prefix.
+// Без значения по умолчанию viper не ищет ключ в переменных окружения.
+func setDefaults(v *viper.Viper, prefix string, value reflect.Value) {
+	for i := range value.NumField() {
+		field := value.Type().Field(i)
+		key := prefix + field.Tag.Get("yaml")
+		if field.Type.Kind() == reflect.Struct {
+			setDefaults(v, key+".", value.Field(i))
+			continue
+		}
+		v.SetDefault(key, value.Field(i).Interface())
+	}
+}
+
+// Print выводит конфигурацию в w в формате YAML. Значения секретов скрываются.
+func (cfg Config) Print(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(cfg); err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	return encoder.Close()
+}
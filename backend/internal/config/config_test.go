@@ -0,0 +1,112 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	file := "server:\n  port: 9000\n  read_timeout: 30s\ndatabase:\n  host: db\n  max_open_conns: 10\ncors:\n  allow_origins: [https://elib.example]\n"
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ELIB_SERVER_PORT", "9443")
+	t.Setenv("ELIB_DATABASE_MAX_IDLE_CONNS", "7")
+	t.Setenv("ELIB_TOKEN_ACCESS_TTL", "5m")
+	t.Setenv("ELIB_CORS_ALLOW_ORIGINS", "https://a.example,https://b.example")
+	t.Setenv("ELIB_TOKEN_SIGNING_KEY", "")
+	t.Setenv("TOKEN_SIGNING_KEY", "legacy-key")
+	t.Setenv("ELIB_DATABASE_PASSWORD", "secret")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	checks := []struct {
+		name      string
+		got, want any
+	}{
+		{"env over file", cfg.Server.Port, 9443},
+		{"file over default", cfg.Server.ReadTimeout, 30 * time.Second},
+		{"default", cfg.Server.IdleTimeout, Default().Server.IdleTimeout},
+		{"file string", cfg.Database.Host, "db"},
+		{"file int", cfg.Database.MaxOpenConns, 10},
+		{"env int", cfg.Database.MaxIdleConns, 7},
+		{"env duration", cfg.Token.AccessTTL, 5 * time.Minute},
+		{"legacy env", cfg.Token.SigningKey, Secret("legacy-key")},
+		{"secret env", cfg.Database.Password, Secret("secret")},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%v: got %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if want := []string{"https://a.example", "https://b.example"}; !slices.Equal(cfg.CORS.AllowOrigins, want) {
+		t.Errorf("env list: got %v, want %v", cfg.CORS.AllowOrigins, want)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yml")); err == nil {
+		t.Error("Load of missing file: got no error")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg := Default()
+	cfg.Token.SigningKey = "key"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("default config with signing key: %v", err)
+	}
+
+	cfg.Server.Port = 0
+	cfg.Server.TLS.CertFile = "missing.pem"
+	cfg.Server.TrustedProxies = []string{"10.0.0.1"}
+	cfg.Database.SSLMode = "maybe"
+	cfg.Database.MaxIdleConns = 50
+	cfg.Token.SigningKey = ""
+	cfg.Token.RefreshTTL = time.Minute
+	cfg.Jobs.Intervals.TrashPurge = 0
+	cfg.RateLimit.Read.Period = 0
+	cfg.Log.Level = "loud"
+	cfg.CORS.AllowOrigins = []string{"*", "elib.example"}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("invalid config: got no error")
+	}
+	for _, key := range []string{
+		"server.port", "server.tls.key_file", "server.tls.cert_file", "server.trusted_proxies", "database.sslmode", "database.max_idle_conns",
+		"token.signing_key", "token.refresh_ttl", "jobs.intervals.trash_purge", "log.level", "cors.allow_origins",
+		"rate_limit.read.period",
+	} {
+		if !strings.Contains(err.Error(), key+":") {
+			t.Errorf("errors do not mention %v:\n%v", key, err)
+		}
+	}
+}
+
+func TestPrint(t *testing.T) {
+	cfg := Default()
+	cfg.Token.SigningKey = "signing-key"
+	cfg.Database.Password = "db-password"
+	var buf bytes.Buffer
+	if err := cfg.Print(&buf); err != nil {
+		t.Fatalf("Print: %v", err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "signing-key") || strings.Contains(out, "db-password") {
+		t.Errorf("printed config contains secrets:\n%v", out)
+	}
+	for _, want := range []string{"signing_key: '[REDACTED]'", "access_ttl: 15m0s", "port: 8080", "token: \"\""} {
+		if !strings.Contains(out, want) {
+			t.Errorf("printed config does not contain %q:\n%v", want, out)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"time"
+)
+
+// Допустимые значения перечислимых настроек.
+var (
+	sslModes  = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	exporters = []string{"none", "otlp", "stdout"}
+)
+
+// Validate проверяет конфигурацию и возвращает все найденные ошибки, объединенные [errors.Join].
+// Каждая ошибка начинается с ключа настройки, например «server.port: must be between 1 and 65535».
+func (cfg Config) Validate() error {
+	var v validator
+	v.check(cfg.Server.Port >= 1 && cfg.Server.Port <= 65535, "server.port", "must be between 1 and 65535, got %v", cfg.Server.Port)
+	v.nonNegative("server.read_timeout", cfg.Server.ReadTimeout)
+	v.nonNegative("server.read_header_timeout", cfg.Server.ReadHeaderTimeout)
+	v.nonNegative("server.write_timeout", cfg.Server.WriteTimeout)
+	v.nonNegative("server.idle_timeout", cfg.Server.IdleTimeout)
+	v.positive("server.shutdown_timeout", cfg.Server.ShutdownTimeout)
+	v.nonNegative("server.shutdown_delay", cfg.Server.ShutdownDelay)
+	if cfg.Server.TLS.Enabled() {
+		v.check(cfg.Server.TLS.CertFile != "", "server.tls.cert_file", "is required when server.tls.key_file is set")
+		v.check(cfg.Server.TLS.KeyFile != "", "server.tls.key_file", "is required when server.tls.cert_file is set")
+		v.file("server.tls.cert_file", cfg.Server.TLS.CertFile)
+		v.file("server.tls.key_file", cfg.Server.TLS.KeyFile)
+	}
+	for _, proxy := range cfg.Server.TrustedProxies {
+		_, _, err := net.ParseCIDR(proxy)
+		v.check(err == nil, "server.trusted_proxies", "must be a CIDR network such as 10.0.0.0/8, got %q", proxy)
+	}
+
+	v.required("database.host", cfg.Database.Host)
+	v.check(cfg.Database.Port >= 1 && cfg.Database.Port <= 65535, "database.port", "must be between 1 and 65535, got %v", cfg.Database.Port)
+	v.required("database.user", cfg.Database.User)
+	v.required("database.dbname", cfg.Database.DBName)
+	v.oneOf("database.sslmode", cfg.Database.SSLMode, sslModes)
+	v.positive("database.connect_timeout", cfg.Database.ConnectTimeout)
+	v.check(cfg.Database.MaxOpenConns >= 0, "database.max_open_conns", "must not be negative, got %v", cfg.Database.MaxOpenConns)
+	v.check(cfg.Database.MaxIdleConns >= 0, "database.max_idle_conns", "must not be negative, got %v", cfg.Database.MaxIdleConns)
+	v.check(cfg.Database.MaxOpenConns == 0 || cfg.Database.MaxIdleConns <= cfg.Database.MaxOpenConns,
+		"database.max_idle_conns", "must not exceed database.max_open_conns (%v), got %v", cfg.Database.MaxOpenConns, cfg.Database.MaxIdleConns)
+	v.nonNegative("database.conn_max_lifetime", cfg.Database.ConnMaxLifetime)
+	v.nonNegative("database.conn_max_idle_time", cfg.Database.ConnMaxIdleTime)
+
+	v.check(cfg.Token.SigningKey != "", "token.signing_key", "is required (set %v_TOKEN_SIGNING_KEY)", EnvPrefix)
+	v.positive("token.access_ttl", cfg.Token.AccessTTL)
+	v.positive("token.refresh_ttl", cfg.Token.RefreshTTL)
+	v.check(cfg.Token.RefreshTTL >= cfg.Token.AccessTTL, "token.refresh_ttl",
+		"must not be shorter than token.access_ttl (%v), got %v", cfg.Token.AccessTTL, cfg.Token.RefreshTTL)
+
+	for role, token := range map[string]RoleToken{
+		"student": cfg.Token.Roles.Student,
+		"teacher": cfg.Token.Roles.Teacher,
+		"manager": cfg.Token.Roles.Manager,
+		"admin":   cfg.Token.Roles.Admin,
+	} {
+		key := "token.roles." + role
+		v.nonNegative(key+".access_ttl", token.AccessTTL)
+		v.nonNegative(key+".refresh_ttl", token.RefreshTTL)
+		access, refresh := cmp.Or(token.AccessTTL, cfg.Token.AccessTTL), cmp.Or(token.RefreshTTL, cfg.Token.RefreshTTL)
+		v.check(refresh >= access, key+".refresh_ttl", "must not be shorter than the access token lifetime (%v), got %v", access, refresh)
+	}
+
+	v.nonNegative("session.idle_timeout", cfg.Session.IdleTimeout)
+	v.nonNegative("session.max_age", cfg.Session.MaxAge)
+	v.check(cfg.Session.MaxPerUser >= 0, "session.max_per_user", "must not be negative, got %v", cfg.Session.MaxPerUser)
+
+	v.required("storage.dir", cfg.Storage.Dir)
+	v.positive("trash.retention", cfg.Trash.Retention)
+
+	v.positive("jobs.check_interval", cfg.Jobs.CheckInterval)
+	v.positive("jobs.history_retention", cfg.Jobs.HistoryRetention)
+	v.positive("jobs.intervals.expired_tokens", cfg.Jobs.Intervals.ExpiredTokens)
+	v.positive("jobs.intervals.stale_sessions", cfg.Jobs.Intervals.StaleSessions)
+	v.positive("jobs.intervals.trash_purge", cfg.Jobs.Intervals.TrashPurge)
+	v.positive("jobs.intervals.job_history", cfg.Jobs.Intervals.JobHistory)
+
+	var level slog.Level
+	v.check(level.UnmarshalText([]byte(cfg.Log.Level)) == nil, "log.level", "must be one of debug, info, warn, error, got %q", cfg.Log.Level)
+
+	v.oneOf("tracing.exporter", cfg.Tracing.Exporter, exporters)
+	v.check(cfg.Tracing.SampleRatio >= 0 && cfg.Tracing.SampleRatio <= 1, "tracing.sample_ratio", "must be between 0 and 1, got %v", cfg.Tracing.SampleRatio)
+	if cfg.Tracing.Endpoint != "" {
+		v.url("tracing.endpoint", cfg.Tracing.Endpoint)
+	}
+
+	for _, origin := range cfg.CORS.AllowOrigins {
+		if origin != "*" {
+			v.url("cors.allow_origins", origin)
+		}
+	}
+	v.nonNegative("cors.max_age", cfg.CORS.MaxAge)
+
+	for name, budget := range map[string]RateBudget{
+		"auth":  cfg.RateLimit.Auth,
+		"read":  cfg.RateLimit.Read,
+		"write": cfg.RateLimit.Write,
+	} {
+		key := "rate_limit." + name
+		v.check(budget.Requests >= 0, key+".requests", "must not be negative, got %v", budget.Requests)
+		if budget.Requests > 0 {
+			v.positive(key+".period", budget.Period)
+		}
+	}
+	return errors.Join(v.errs...)
+}
+
+// validator накапливает ошибки проверки конфигурации.
+type validator struct {
+	errs []error
+}
+
+// check добавляет ошибку с ключом key и сообщением по формату format, если условие ok не выполнено.
+func (v *validator) check(ok bool, key, format string, args ...any) {
+	if !ok {
+		v.errs = append(v.errs, fmt.Errorf("%v: %v", key, fmt.Sprintf(format, args...)))
+	}
+}
+
+// required проверяет, что строковая настройка задана.
+func (v *validator) required(key, value string) {
+	v.check(value != "", key, "is required")
+}
+
+// positive проверяет, что длительность больше нуля.
+func (v *validator) positive(key string, d time.Duration) {
+	v.check(d > 0, key, "must be positive, got %v", d)
+}
+
+// nonNegative проверяет, что длительность не меньше нуля.
+func (v *validator) nonNegative(key string, d time.Duration) {
+	v.check(d >= 0, key, "must not be negative, got %v", d)
+}
+
+// oneOf проверяет, что значение настройки входит в список допустимых.
+func (v *validator) oneOf(key, value string, allowed []string) {
+	v.check(slices.Contains(allowed, value), key, "must be one of %v, got %q", allowed, value)
+}
+
+// file проверяет, что заданный файл существует и не является директорией.
+func (v *validator) file(key, path string) {
+	if path == "" {
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		v.check(false, key, "%v", err)
+		return
+	}
+	v.check(!info.IsDir(), key, "%v is a directory", path)
+}
+
+// url проверяет, что значение является абсолютным адресом http или https.
+func (v *validator) url(key, value string) {
+	u, err := url.Parse(value)
+	v.check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", key,
+		"must be an absolute http or https URL, got %q", value)
+}
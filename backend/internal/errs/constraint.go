@@ -0,0 +1,44 @@
+package errs
+
+import "fmt"
+
+// ConstraintError описывает нарушение ограничения целостности хранилища.
+// С помощью [errors.Is] ошибка сравнивается со своим видом: [Conflict], [ReferencedBy] или [InvalidReference].
+type ConstraintError struct {
+	Kind       error  // вид нарушения
+	Constraint string // название нарушенного ограничения
+	Table      string // таблица, в которой находится поле
+	Field      string // поле, значение которого нарушает ограничение
+	Err        error  // исходная ошибка хранилища (если имеется)
+}
+
+// NewConstraintError возвращает ошибку нарушения ограничения вида kind для поля field таблицы table.
+// Название ограничения строится так же, как его по умолчанию строит PostgreSQL:
+// с окончанием _key для уникальности и _fkey для внешнего ключа.
+func NewConstraintError(kind error, table, field string) *ConstraintError {
+	suffix := "fkey"
+	if kind == Conflict {
+		suffix = "key"
+	}
+	return &ConstraintError{
+		Kind:       kind,
+		Constraint: fmt.Sprintf("%v_%v_%v", table, field, suffix),
+		Table:      table,
+		Field:      field,
+	}
+}
+
+func (ce *ConstraintError) Error() string {
+	msg := fmt.Sprintf("%v: %v.%v violates %v", ce.Kind, ce.Table, ce.Field, ce.Constraint)
+	if ce.Err != nil {
+		msg += ": " + ce.Err.Error()
+	}
+	return msg
+}
+
+func (ce *ConstraintError) Unwrap() []error {
+	if ce.Err == nil {
+		return []error{ce.Kind}
+	}
+	return []error{ce.Kind, ce.Err}
+}
@@ -5,10 +5,18 @@ package errs
 import "errors"
 
 var (
-	NotFound        = errors.New("not found")             // запрошенный ресурс не был найден
-	Empty           = errors.New("the repo is empty")     // запрос в пустой репозиторий
-	Internal        = errors.New("internal error")        // внутренняя ошибка репозитория или сервиса
-	InvalidPassword = errors.New("invalid password")      // предоставленный пароль не совпадает с действительным
-	InvalidLogin    = errors.New("invalid login")         // пользователь с таким именем не был найден
-	RefreshExpired  = errors.New("refresh token expired") // токен для обновления истек
+	NotFound               = errors.New("not found")                // запрошенный ресурс не был найден
+	Empty                  = errors.New("the repo is empty")        // запрос в пустой репозиторий
+	Internal               = errors.New("internal error")           // внутренняя ошибка репозитория или сервиса
+	InvalidPassword        = errors.New("invalid password")         // предоставленный пароль не совпадает с действительным
+	InvalidLogin           = errors.New("invalid login")            // пользователь с таким именем не был найден
+	RefreshExpired         = errors.New("refresh token expired")    // токен для обновления истек
+	UnsupportedMedia       = errors.New("unsupported media type")   // формат загруженного файла не поддерживается
+	Deactivated            = errors.New("account deactivated")      // учетная запись пользователя отключена
+	Conflict               = errors.New("conflict")                 // значение должно быть уникальным, но уже занято
+	ReferencedBy           = errors.New("referenced by")            // на ресурс ссылаются другие ресурсы
+	InvalidReference       = errors.New("invalid reference")        // ресурс ссылается на несуществующий ресурс
+	VersionMismatch        = errors.New("version mismatch")         // ресурс изменился с тех пор, как его получил клиент
+	PasswordChangeRequired = errors.New("password change required") // пользователь должен сменить одноразовый пароль
+	InTrash                = errors.New("in trash")                 // ресурс, из-за которого нарушено ограничение, находится в корзине
 )
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AuditService определяет методы для чтения журнала аудита.
+type AuditService interface {
+	// GetAll возвращает слайс записей журнала аудита, удовлетворяющих фильтру, или ошибку.
+	GetAll(ctx context.Context, filter *model.AuditFilter) ([]model.AuditEvent, error)
+}
+
+// GetAuditEvents получает условия отбора из параметров запроса
+// и возвращает в ответе записи журнала аудита от новых к старым.
+func (h *Handler) GetAuditEvents(c echo.Context) error {
+	filter := new(model.AuditFilter)
+	if err := bindAndValidate(c, filter); err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("bind audit filter: %w", err))
+	}
+	events, err := h.Audit.GetAll(c.Request().Context(), filter)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, events)
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CatalogService определяет методы для чтения каталога.
+type CatalogService interface {
+	// GetTree возвращает дерево каталога от кафедр до занятий с учетом параметров фильтра или ошибку.
+	GetTree(ctx context.Context, filter *model.CatalogFilter) ([]model.CatalogDepartment, error)
+}
+
+// GetCatalogTree возвращает в ответе дерево каталога: кафедры, специальности, предметы, разделы и занятия.
+// Параметр depth ограничивает количество уровней (от 1 до 5), а параметр counts
+// добавляет к узлам количество их дочерних элементов.
+func (h *Handler) GetCatalogTree(c echo.Context) error {
+	filter := new(model.CatalogFilter)
+	if err := bindAndValidate(c, filter); err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("bind catalog filter: %w", err))
+	}
+	tree, err := h.Catalog.GetTree(c.Request().Context(), filter)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, tree)
+}
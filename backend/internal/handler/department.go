@@ -24,11 +24,18 @@ type DepartmentService interface {
 
 	// Update обновляет кафедру по номеру и возвращает обновленную кафедру с номером или ошибку.
 	// Если кафедра с таким номером не нашлась, то возращается ошибка [errs.NotFound]
-	Update(ctx context.Context, ID int, update *model.NewDepartment) (*model.Department, error)
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Update(ctx context.Context, ID, version int, update *model.NewDepartment) (*model.Department, error)
 
-	// Delete удаляет кафедру по номеру и возвращает ошибку, если удаления не произошло.
+	// Patch изменяет указанные в патче поля кафедры по номеру и возвращает обновленную кафедру с номером или ошибку.
 	// Если кафедра с таким номером не нашлась, то возращается ошибка [errs.NotFound]
-	Delete(ctx context.Context, ID int) error
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Patch(ctx context.Context, ID, version int, patch *model.DepartmentPatch) (*model.Department, error)
+
+	// Delete удаляет кафедру по номеру в корзину и возвращает ошибку, если удаления не произошло.
+	// Если кафедра с таким номером не нашлась, то возращается ошибка [errs.NotFound]
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Delete(ctx context.Context, ID, version int) error
 }
 
 // CreateDepartment получает данные о кафедре из тела запроса и создает ее.
@@ -69,6 +76,7 @@ func (h *Handler) GetDepartment(c echo.Context) error {
 	if err != nil {
 		return err
 	}
+	setETag(c, department.Version)
 	return c.JSON(http.StatusOK, department)
 }
 
@@ -83,10 +91,38 @@ func (h *Handler) UpdateDepartment(c echo.Context) error {
 	if err := bindAndValidate(c, departmentUpdate); err != nil {
 		return echo.ErrBadRequest.WithInternal(fmt.Errorf("bind departmentUpdate: %w", err))
 	}
-	_, err = h.Department.Update(c.Request().Context(), departmentID, departmentUpdate)
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+	updated, err := h.Department.Update(c.Request().Context(), departmentID, version, departmentUpdate)
 	if err != nil {
 		return err
 	}
+	setETag(c, updated.Version)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// PatchDepartment получает номер кафедры из параметра id, изменения в формате JSON Merge Patch из тела запроса
+// и изменяет только указанные поля кафедре с данным номером. В ответе ничего не возвращает.
+func (h *Handler) PatchDepartment(c echo.Context) error {
+	departmentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("parse departmentID: %w", err))
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+	departmentPatch := new(model.DepartmentPatch)
+	if err := bindPatch(c, departmentPatch); err != nil {
+		return err
+	}
+	patched, err := h.Department.Patch(c.Request().Context(), departmentID, version, departmentPatch)
+	if err != nil {
+		return err
+	}
+	setETag(c, patched.Version)
 	return c.NoContent(http.StatusNoContent)
 }
 
@@ -97,7 +133,11 @@ func (h *Handler) DeleteDepartment(c echo.Context) error {
 	if err != nil {
 		return echo.ErrBadRequest.WithInternal(fmt.Errorf("parse departmentID: %w", err))
 	}
-	if err := h.Department.Delete(c.Request().Context(), departmentID); err != nil {
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+	if err := h.Department.Delete(c.Request().Context(), departmentID, version); err != nil {
 		return err
 	}
 	return c.NoContent(http.StatusNoContent)
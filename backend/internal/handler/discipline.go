@@ -24,11 +24,18 @@ type DisciplineService interface {
 
 	// Update обновляет предмет по номеру и возвращает обновленный предмет с номером.
 	// Если предмет с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
-	Update(ctx context.Context, ID int, update *model.NewDiscipline) (*model.Discipline, error)
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Update(ctx context.Context, ID, version int, update *model.NewDiscipline) (*model.Discipline, error)
 
-	// Delete удаляет предмет по номеру и возвращает ошибку, если удаления не произошло.
+	// Patch изменяет указанные в патче поля предмета по номеру и возвращает обновленный предмет с номером.
+	// Если предмет с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Patch(ctx context.Context, ID, version int, patch *model.DisciplinePatch) (*model.Discipline, error)
+
+	// Delete удаляет предмет по номеру в корзину и возвращает ошибку, если удаления не произошло.
 	// Если предмета с таким номером не нашлось, то возращается ошибка [errs.NotFound].
-	Delete(ctx context.Context, ID int) error
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Delete(ctx context.Context, ID, version int) error
 }
 
 // CreateDiscipline получает данные о предмете из тела запроса и создает его.
@@ -67,6 +74,7 @@ func (h *Handler) GetDiscipline(c echo.Context) error {
 	if err != nil {
 		return err
 	}
+	setETag(c, discipline.Version)
 	return c.JSON(http.StatusOK, discipline)
 }
 
@@ -81,9 +89,38 @@ func (h *Handler) UpdateDiscipline(c echo.Context) error {
 	if err := bindAndValidate(c, disciplineUpdate); err != nil {
 		return echo.ErrBadRequest.WithInternal(fmt.Errorf("bind newDiscipline: %w", err))
 	}
-	if _, err = h.Discipline.Update(c.Request().Context(), disciplineID, disciplineUpdate); err != nil {
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+	updated, err := h.Discipline.Update(c.Request().Context(), disciplineID, version, disciplineUpdate)
+	if err != nil {
+		return err
+	}
+	setETag(c, updated.Version)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// PatchDiscipline получает номер предмета из параметра id, изменения в формате JSON Merge Patch из тела запроса
+// и изменяет только указанные поля предмета с данным номером. В ответе ничего не возвращает.
+func (h *Handler) PatchDiscipline(c echo.Context) error {
+	disciplineID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("parse disciplineID: %w", err))
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+	disciplinePatch := new(model.DisciplinePatch)
+	if err := bindPatch(c, disciplinePatch); err != nil {
 		return err
 	}
+	patched, err := h.Discipline.Patch(c.Request().Context(), disciplineID, version, disciplinePatch)
+	if err != nil {
+		return err
+	}
+	setETag(c, patched.Version)
 	return c.NoContent(http.StatusNoContent)
 }
 
@@ -94,7 +131,11 @@ func (h *Handler) DeleteDiscipline(c echo.Context) error {
 	if err != nil {
 		return echo.ErrBadRequest.WithInternal(fmt.Errorf("parse disciplineID: %w", err))
 	}
-	if err := h.Discipline.Delete(c.Request().Context(), disciplineID); err != nil {
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+	if err := h.Discipline.Delete(c.Request().Context(), disciplineID, version); err != nil {
 		return err
 	}
 	return c.NoContent(http.StatusNoContent)
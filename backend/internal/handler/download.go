@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/foreverd34d/aumsu-elib/internal/metrics"
+
+	"github.com/labstack/echo/v4"
+)
+
+// downloadBytes считает байты файлов, отданных клиентам, по виду файла.
+var downloadBytes = metrics.Default.NewCounter("elib_download_bytes_total",
+	"Total number of file bytes sent to clients by file kind.", "kind")
+
+// streamFile возвращает в ответе файл вида kind из file с типом содержимого contentType
+// и учитывает отправленные байты в метрике [downloadBytes].
+func streamFile(c echo.Context, kind, contentType string, file io.Reader) error {
+	counter := &countingReader{r: file}
+	defer func() { downloadBytes.Add(float64(counter.n), kind) }()
+	return c.Stream(http.StatusOK, contentType, counter)
+}
+
+// countingReader считает байты, прочитанные из r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
@@ -16,19 +16,26 @@ type GroupService interface {
 	// Create создает новую группу и возвращает ее с номером или ошибку.
 	Create(ctx context.Context, input *model.NewGroup) (*model.Group, error)
 
-	// GetAll возвращает слайс всех групп или ошибку. Если групп нет, то возвращается ошибка [errs.Empty].
-	GetAll(ctx context.Context) ([]model.Group, error)
+	// GetAll возвращает слайс групп, удовлетворяющих фильтру, или ошибку. Если групп нет, то возвращается ошибка [errs.Empty].
+	GetAll(ctx context.Context, filter *model.GroupFilter) ([]model.Group, error)
 
 	// Get возвращает группу по номеру или ошибку. Если группа с таким номером не нашлась, то возвращается ошибка [errs.NotFound].
 	Get(ctx context.Context, ID int) (*model.Group, error)
 
 	// Update обновляет группу по номеру и возвращает обновленную группу с номером или ошибку.
 	// Если группа с таким номером не нашлась, то возращается ошибка [errs.NotFound].
-	Update(ctx context.Context, ID int, update *model.NewGroup) (*model.Group, error)
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Update(ctx context.Context, ID, version int, update *model.NewGroup) (*model.Group, error)
+
+	// Patch изменяет указанные в патче поля группы по номеру и возвращает обновленную группу с номером или ошибку.
+	// Если группа с таким номером не нашлась, то возращается ошибка [errs.NotFound].
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Patch(ctx context.Context, ID, version int, patch *model.GroupPatch) (*model.Group, error)
 
 	// Delete удаляет группу по номеру и возвращает ошибку, если удаления не произошло.
 	// Если группа с таким номером не нашлась, то возращается ошибка [errs.NotFound].
-	Delete(ctx context.Context, ID int) error
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Delete(ctx context.Context, ID, version int) error
 }
 
 // CreateGroup получает данные о группе из тела запроса и создает ее.
@@ -47,9 +54,14 @@ func (h *Handler) CreateGroup(c echo.Context) error {
 	})
 }
 
-// GetAllGroups возвращает в ответе все кафедры.
+// GetAllGroups возвращает в ответе все группы.
+// Группы из архива возвращаются только при параметре archived, равном true.
 func (h *Handler) GetAllGroups(c echo.Context) error {
-	groups, err := h.Group.GetAll(c.Request().Context())
+	filter := new(model.GroupFilter)
+	if err := bindAndValidate(c, filter); err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("bind group filter: %w", err))
+	}
+	groups, err := h.Group.GetAll(c.Request().Context(), filter)
 	if err != nil {
 		return err
 	}
@@ -67,6 +79,7 @@ func (h *Handler) GetGroup(c echo.Context) error {
 	if err != nil {
 		return err
 	}
+	setETag(c, group.Version)
 	return c.JSON(http.StatusOK, group)
 }
 
@@ -81,9 +94,38 @@ func (h *Handler) UpdateGroup(c echo.Context) error {
 	if err := bindAndValidate(c, groupUpdate); err != nil {
 		return echo.ErrBadRequest.WithInternal(fmt.Errorf("bind groupUpdate: %w", err))
 	}
-	if _, err := h.Group.Update(c.Request().Context(), groupID, groupUpdate); err != nil {
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+	updated, err := h.Group.Update(c.Request().Context(), groupID, version, groupUpdate)
+	if err != nil {
 		return err
 	}
+	setETag(c, updated.Version)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// PatchGroup получает номер группы из параметра id, изменения в формате JSON Merge Patch из тела запроса
+// и изменяет только указанные поля группы с данным номером. В ответе ничего не возвращает.
+func (h *Handler) PatchGroup(c echo.Context) error {
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("parse groupID: %w", err))
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+	groupPatch := new(model.GroupPatch)
+	if err := bindPatch(c, groupPatch); err != nil {
+		return err
+	}
+	patched, err := h.Group.Patch(c.Request().Context(), groupID, version, groupPatch)
+	if err != nil {
+		return err
+	}
+	setETag(c, patched.Version)
 	return c.NoContent(http.StatusNoContent)
 }
 
@@ -94,7 +136,11 @@ func (h *Handler) DeleteGroup(c echo.Context) error {
 	if err != nil {
 		return echo.ErrBadRequest.WithInternal(fmt.Errorf("parse groupID: %w", err))
 	}
-	if err := h.Group.Delete(c.Request().Context(), groupID); err != nil {
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+	if err := h.Group.Delete(c.Request().Context(), groupID, version); err != nil {
 		return err
 	}
 	return c.NoContent(http.StatusNoContent)
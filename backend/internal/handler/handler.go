@@ -1,17 +1,46 @@
 package handler
 
-import "github.com/labstack/echo/v4"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
 
 // Handler определяет методы обработчиков маршрутов.
 type Handler struct {
-	User       UserService
-	Session    SessionService
-	Group      GroupService
-	Specialty  SpecialtyService
-	Department DepartmentService
-	Discipline DisciplineService
+	User         UserService
+	Session      SessionService
+	Group        GroupService
+	Specialty    SpecialtyService
+	Department   DepartmentService
+	Discipline   DisciplineService
+	Audit        AuditService
+	Import       ImportService
+	Rollover     RolloverService
+	MaterialType MaterialTypeService
+	Material     MaterialService
+	Lesson       LessonService
+	Catalog      CatalogService
+	Trash        TrashService
+	Job          JobService
 }
 
+// Заголовки условных запросов для оптимистичной блокировки.
+const (
+	headerETag    = "ETag"
+	headerIfMatch = "If-Match"
+)
+
 // bindAndValidate биндит структуру из тела запроса и проверяет ее.
 func bindAndValidate(c echo.Context, i any) error {
 	if err := c.Bind(i); err != nil {
@@ -19,3 +48,97 @@ func bindAndValidate(c echo.Context, i any) error {
 	}
 	return c.Validate(i)
 }
+
+// bindPatch декодирует патч в формате JSON Merge Patch (RFC 7396) из тела запроса и проверяет его.
+// Кроме application/merge-patch+json принимается и application/json.
+// Если тип содержимого другой, то возвращается ошибка [echo.ErrUnsupportedMediaType],
+// если патч некорректен — ошибка [echo.ErrBadRequest].
+func bindPatch(c echo.Context, patch any) error {
+	contentType := c.Request().Header.Get(echo.HeaderContentType)
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || (mediaType != model.MergePatchContentType && mediaType != echo.MIMEApplicationJSON) {
+		return echo.ErrUnsupportedMediaType.WithInternal(fmt.Errorf("patch content type %q", contentType))
+	}
+	if err := json.NewDecoder(c.Request().Body).Decode(patch); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).WithInternal(fmt.Errorf("decode %T: %w", patch, err))
+	}
+	if err := c.Validate(patch); err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("validate %T: %w", patch, err))
+	}
+	return nil
+}
+
+// CurrentUser возвращает полезную нагрузку jwt токена пользователя из запроса.
+// В случае неудачи возвращается ошибка [echo.ErrUnauthorized].
+func CurrentUser(c echo.Context) (*model.JWTClaims, error) {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return nil, echo.ErrUnauthorized.WithInternal(fmt.Errorf("no user token"))
+	}
+	claims, ok := token.Claims.(*model.JWTClaims)
+	if !ok {
+		return nil, echo.ErrUnauthorized.WithInternal(fmt.Errorf("invalid user claims"))
+	}
+	return claims, nil
+}
+
+// CurrentUserID возвращает номер пользователя из jwt токена запроса.
+// В случае неудачи возвращается ошибка [echo.ErrUnauthorized].
+func CurrentUserID(c echo.Context) (int, error) {
+	claims, err := CurrentUser(c)
+	if err != nil {
+		return 0, err
+	}
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return 0, echo.ErrUnauthorized.WithInternal(fmt.Errorf("parse token subject: %w", err))
+	}
+	return userID, nil
+}
+
+// Запас размера тела запроса с файлом на заголовки и остальные поля формы.
+const maxFormOverhead = 1 << 20
+
+// formFile возвращает заголовок файла из поля field формы запроса размером не больше maxSize.
+// Тело запроса ограничивается до разбора формы, поэтому слишком большой запрос не читается целиком.
+// Если файл или тело запроса больше допустимого, то возвращается ошибка [echo.ErrStatusRequestEntityTooLarge].
+func formFile(c echo.Context, field string, maxSize int64) (*multipart.FileHeader, error) {
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, maxSize+maxFormOverhead)
+	fileHeader, err := c.FormFile(field)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, echo.ErrStatusRequestEntityTooLarge.WithInternal(fmt.Errorf("form with %v: %w", field, err))
+		}
+		return nil, echo.ErrBadRequest.WithInternal(fmt.Errorf("get %v from form: %w", field, err))
+	}
+	if fileHeader.Size > maxSize {
+		return nil, echo.ErrStatusRequestEntityTooLarge.WithInternal(fmt.Errorf("%v size %v exceeds %v", field, fileHeader.Size, maxSize))
+	}
+	return fileHeader, nil
+}
+
+// setETag записывает версию ресурса в заголовок ETag ответа.
+func setETag(c echo.Context, version int) {
+	c.Response().Header().Set(headerETag, strconv.Quote(strconv.Itoa(version)))
+}
+
+// ifMatch возвращает версию ресурса из заголовка If-Match запроса.
+// Если заголовок не указан или равен *, то возвращается 0 и ресурс изменяется без проверки версии.
+// Если заголовок не содержит версии, выданной в ETag, то возвращается ошибка [echo.ErrPreconditionFailed].
+func ifMatch(c echo.Context) (int, error) {
+	header := strings.TrimSpace(c.Request().Header.Get(headerIfMatch))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	tag, err := strconv.Unquote(strings.TrimPrefix(header, "W/"))
+	if err != nil {
+		return 0, echo.ErrPreconditionFailed.WithInternal(fmt.Errorf("parse If-Match %q: %w", header, err))
+	}
+	version, err := strconv.Atoi(tag)
+	if err != nil || version < 1 {
+		return 0, echo.ErrPreconditionFailed.WithInternal(fmt.Errorf("If-Match %q is not a version", header))
+	}
+	return version, nil
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+	"github.com/foreverd34d/aumsu-elib/internal/sheet"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Максимальный размер файла со списком студентов в байтах.
+const maxRosterSize = 10 << 20
+
+// ImportService определяет методы для массового импорта пользователей.
+type ImportService interface {
+	// ImportStudents проверяет строки списка студентов и создает учетные записи для новых студентов.
+	// Если хотя бы одна строка содержит ошибки или dryRun истинно, то ничего не создается.
+	ImportStudents(ctx context.Context, rows []model.RosterRow, dryRun bool) (*model.ImportReport, error)
+}
+
+// importParams содержит параметры запроса импорта студентов.
+type importParams struct {
+	DryRun bool   `query:"dryRun"`                                          // пробный импорт без записи в базу данных
+	Format string `query:"format" validate:"omitempty,oneof=json csv xlsx"` // формат ответа
+}
+
+// ImportUsers получает список студентов (фамилия, имя, отчество, взвод) в формате CSV или XLSX
+// из поля file формы запроса и создает учетные записи новых студентов.
+// Параметр dryRun включает пробный импорт, который только проверяет строки.
+// По умолчанию в ответе возвращается отчет об импорте в формате json, а при параметре format,
+// равном csv или xlsx, — ведомость с логинами и одноразовыми паролями для раздачи студентам.
+// Если в списке есть ошибки, то ничего не создается и возвращается отчет со статусом 422.
+func (h *Handler) ImportUsers(c echo.Context) error {
+	params := new(importParams)
+	if err := (&echo.DefaultBinder{}).BindQueryParams(c, params); err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("bind import params: %w", err))
+	}
+	if err := c.Validate(params); err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("validate import params: %w", err))
+	}
+	fileHeader, err := formFile(c, "file", maxRosterSize)
+	if err != nil {
+		return err
+	}
+	format, err := sheet.FormatFromName(fileHeader.Filename)
+	if err != nil {
+		return echo.ErrUnsupportedMediaType.WithInternal(err)
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("open roster: %w", err))
+	}
+	defer file.Close()
+	records, err := sheet.Read(file, format)
+	if err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("read roster: %w", err))
+	}
+
+	ctx := c.Request().Context()
+	report, err := h.Import.ImportStudents(ctx, parseRoster(records), params.DryRun)
+	if err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "roster imported", slog.String("file", fileHeader.Filename), slog.Bool("dry_run", report.DryRun),
+		slog.Int("created", report.Created), slog.Int("existing", report.Existing), slog.Int("invalid", report.Invalid))
+	if !report.DryRun && report.Invalid > 0 {
+		return c.JSON(http.StatusUnprocessableEntity, report)
+	}
+	status := http.StatusOK
+	if !report.DryRun && report.Created > 0 {
+		status = http.StatusCreated
+	}
+	if params.Format == "" || params.Format == "json" {
+		return c.JSON(status, report)
+	}
+
+	outFormat := sheet.Format(params.Format)
+	fileName := fmt.Sprintf("credentials-%s.%s", time.Now().Format("2006-01-02-150405"), outFormat)
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", fileName))
+	c.Response().Header().Set(echo.HeaderContentType, outFormat.MIMEType())
+	c.Response().WriteHeader(status)
+	return sheet.Write(c.Response(), outFormat, credentialsSheet(report))
+}
+
+// rosterHeaders содержит допустимые названия первого столбца строки заголовков списка.
+var rosterHeaders = map[string]bool{"фамилия": true, "surname": true}
+
+// parseRoster преобразует строки табличного файла в строки списка студентов.
+// Строка заголовков и пустые строки пропускаются, пустое отчество считается отсутствующим.
+func parseRoster(records [][]string) []model.RosterRow {
+	rows := make([]model.RosterRow, 0, len(records))
+	for i, record := range records {
+		if i == 0 && len(record) > 0 && rosterHeaders[strings.ToLower(record[0])] {
+			continue
+		}
+		if strings.Join(record, "") == "" {
+			continue
+		}
+		cell := func(column int) string {
+			if column < len(record) {
+				return record[column]
+			}
+			return ""
+		}
+		row := model.RosterRow{
+			Line:      i + 1,
+			Surname:   cell(0),
+			Name:      cell(1),
+			GroupName: cell(3),
+		}
+		if patronymic := cell(2); patronymic != "" {
+			row.Patronymic = &patronymic
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// credentialsSheet возвращает строки ведомости с логинами и паролями новых студентов.
+func credentialsSheet(report *model.ImportReport) [][]string {
+	rows := [][]string{{"Взвод", "Фамилия", "Имя", "Отчество", "Логин", "Пароль"}}
+	for _, row := range report.Rows {
+		if row.Login == "" {
+			continue
+		}
+		var patronymic string
+		if row.Patronymic != nil {
+			patronymic = *row.Patronymic
+		}
+		rows = append(rows, []string{row.GroupName, row.Surname, row.Name, patronymic, row.Login, row.Password})
+	}
+	return rows
+}
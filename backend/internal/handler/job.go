@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+// JobService определяет методы для работы с задачами обслуживания.
+type JobService interface {
+	// GetAll возвращает слайс всех задач с их последними запусками или ошибку.
+	GetAll(ctx context.Context) ([]model.Job, error)
+
+	// GetRuns возвращает слайс записей истории запусков, удовлетворяющих фильтру, или ошибку.
+	GetRuns(ctx context.Context, filter *model.JobRunFilter) ([]model.JobRun, error)
+
+	// Run вручную запускает задачу с названием name, дожидается ее завершения и возвращает запись о запуске.
+	// Если задачи нет, то возвращается ошибка [errs.NotFound], если она уже выполняется — [errs.Conflict].
+	Run(ctx context.Context, name string) (*model.JobRun, error)
+}
+
+// GetJobs возвращает в ответе все задачи обслуживания с их последними запусками.
+func (h *Handler) GetJobs(c echo.Context) error {
+	jobs, err := h.Job.GetAll(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, jobs)
+}
+
+// GetJobRuns получает условия отбора из параметров запроса
+// и возвращает в ответе записи истории запусков задач от новых к старым.
+func (h *Handler) GetJobRuns(c echo.Context) error {
+	filter := new(model.JobRunFilter)
+	if err := bindAndValidate(c, filter); err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("bind job run filter: %w", err))
+	}
+	runs, err := h.Job.GetRuns(c.Request().Context(), filter)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, runs)
+}
+
+// RunJob получает название задачи из параметра name, запускает ее
+// и возвращает в ответе запись о завершенном запуске.
+func (h *Handler) RunJob(c echo.Context) error {
+	run, err := h.Job.Run(c.Request().Context(), c.Param("name"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, run)
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+// LessonService определяет методы для работы с занятиями.
+type LessonService interface {
+	// Get возвращает занятие по номеру вместе с прикрепленными к нему материалами или ошибку.
+	// Если занятие с таким номером не нашлось, то возвращается ошибка [errs.NotFound].
+	Get(ctx context.Context, ID int) (*model.Lesson, error)
+
+	// GetMaterials возвращает слайс материалов, прикрепленных к занятию, или ошибку.
+	// Если занятие с таким номером не нашлось, то возвращается ошибка [errs.NotFound].
+	GetMaterials(ctx context.Context, ID int) ([]model.Material, error)
+}
+
+// GetLesson получает номер занятия из параметра id
+// и возвращает в ответе занятие с данным номером вместе с его материалами.
+func (h *Handler) GetLesson(c echo.Context) error {
+	lessonID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("parse lessonID: %w", err))
+	}
+	lesson, err := h.Lesson.Get(c.Request().Context(), lessonID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, lesson)
+}
+
+// GetLessonMaterials получает номер занятия из параметра id
+// и возвращает в ответе прикрепленные к нему материалы.
+func (h *Handler) GetLessonMaterials(c echo.Context) error {
+	lessonID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("parse lessonID: %w", err))
+	}
+	materials, err := h.Lesson.GetMaterials(c.Request().Context(), lessonID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, materials)
+}
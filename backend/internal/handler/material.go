@@ -0,0 +1,308 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Максимальный размер файла материала в байтах.
+const maxMaterialSize = 100 << 20
+
+// MaterialService определяет методы для работы с материалами и их связями с занятиями и книгами.
+type MaterialService interface {
+	// Create сохраняет файл материала с именем fileName и создает материал.
+	// Возвращается материал с номером или ошибка.
+	Create(ctx context.Context, input *model.NewMaterial, file io.Reader, fileName string) (*model.Material, error)
+
+	// GetAll возвращает слайс всех материалов или ошибку.
+	// Если материалов нет, то возвращается ошибка [errs.Empty].
+	GetAll(ctx context.Context) ([]model.Material, error)
+
+	// Get возвращает материал по номеру или ошибку.
+	// Если материал с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
+	Get(ctx context.Context, ID int) (*model.Material, error)
+
+	// Update обновляет название и вид материала по номеру и возвращает материал с номером или ошибку.
+	// Если материал с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Update(ctx context.Context, ID, version int, update *model.NewMaterial) (*model.Material, error)
+
+	// Patch изменяет указанные в патче название и вид материала по номеру и возвращает материал с номером или ошибку.
+	// Если материал с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Patch(ctx context.Context, ID, version int, patch *model.MaterialPatch) (*model.Material, error)
+
+	// SetFile заменяет файл материала по номеру файлом с именем fileName.
+	// Если материал с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+	SetFile(ctx context.Context, ID int, file io.Reader, fileName string) error
+
+	// GetFile открывает файл материала по номеру и возвращает его вместе с типом содержимого.
+	// Если материал с таким номером или его файл не нашелся, то возвращается ошибка [errs.NotFound].
+	GetFile(ctx context.Context, ID int) (file io.ReadCloser, contentType string, err error)
+
+	// Delete удаляет материал по номеру вместе с его файлом и связями с занятиями и книгами.
+	// Если материал с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Delete(ctx context.Context, ID, version int) error
+
+	// GetByBook возвращает слайс материалов, прикрепленных к книге, или ошибку.
+	GetByBook(ctx context.Context, bookID int) ([]model.Material, error)
+
+	// AttachToLesson прикрепляет материал к занятию. Повторное прикрепление ничего не меняет.
+	// Если материал или занятие не нашлись, то возвращается ошибка [errs.NotFound].
+	AttachToLesson(ctx context.Context, materialID, lessonID int) error
+
+	// DetachFromLesson открепляет материал от занятия.
+	// Если материал не был прикреплен к занятию, то возвращается ошибка [errs.NotFound].
+	DetachFromLesson(ctx context.Context, materialID, lessonID int) error
+
+	// AttachToBook прикрепляет материал к книге. Повторное прикрепление ничего не меняет.
+	// Если материал или книга не нашлись, то возвращается ошибка [errs.NotFound].
+	AttachToBook(ctx context.Context, materialID, bookID int) error
+
+	// DetachFromBook открепляет материал от книги.
+	// Если материал не был прикреплен к книге, то возвращается ошибка [errs.NotFound].
+	DetachFromBook(ctx context.Context, materialID, bookID int) error
+}
+
+// CreateMaterial получает название и номер вида материала из полей name и typeID формы запроса,
+// файл материала из поля file и создает материал. В ответе возвращается номер нового материала.
+func (h *Handler) CreateMaterial(c echo.Context) error {
+	// Форма разбирается вместе с файлом, поэтому размер тела запроса ограничивается до биндинга полей.
+	fileHeader, err := materialFile(c)
+	if err != nil {
+		return err
+	}
+	newMaterial := new(model.NewMaterial)
+	if err := bindAndValidate(c, newMaterial); err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("bind newMaterial: %w", err))
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("open material file: %w", err))
+	}
+	defer file.Close()
+	material, err := h.Material.Create(c.Request().Context(), newMaterial, file, fileHeader.Filename)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, echo.Map{
+		"ID": material.ID,
+	})
+}
+
+// GetAllMaterials возвращает в ответе все материалы.
+func (h *Handler) GetAllMaterials(c echo.Context) error {
+	materials, err := h.Material.GetAll(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, materials)
+}
+
+// GetMaterial получает номер материала из параметра id
+// и возвращает в ответе материал с данным номером.
+func (h *Handler) GetMaterial(c echo.Context) error {
+	materialID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("parse materialID: %w", err))
+	}
+	material, err := h.Material.Get(c.Request().Context(), materialID)
+	if err != nil {
+		return err
+	}
+	setETag(c, material.Version)
+	return c.JSON(http.StatusOK, material)
+}
+
+// UpdateMaterial получает номер материала из параметра id, название и вид материала из тела запроса
+// и обновляет материал с данным номером. В ответе ничего не возвращает.
+func (h *Handler) UpdateMaterial(c echo.Context) error {
+	materialID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("parse materialID: %w", err))
+	}
+	materialUpdate := new(model.NewMaterial)
+	if err := bindAndValidate(c, materialUpdate); err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("bind newMaterial: %w", err))
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+	updated, err := h.Material.Update(c.Request().Context(), materialID, version, materialUpdate)
+	if err != nil {
+		return err
+	}
+	setETag(c, updated.Version)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// PatchMaterial получает номер материала из параметра id, изменения в формате JSON Merge Patch из тела запроса
+// и изменяет только указанные поля материала с данным номером. В ответе ничего не возвращает.
+func (h *Handler) PatchMaterial(c echo.Context) error {
+	materialID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("parse materialID: %w", err))
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+	materialPatch := new(model.MaterialPatch)
+	if err := bindPatch(c, materialPatch); err != nil {
+		return err
+	}
+	patched, err := h.Material.Patch(c.Request().Context(), materialID, version, materialPatch)
+	if err != nil {
+		return err
+	}
+	setETag(c, patched.Version)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// DeleteMaterial получает номер материала из параметра id и удаляет материал с данным номером.
+// В ответе ничего не возвращает.
+func (h *Handler) DeleteMaterial(c echo.Context) error {
+	materialID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("parse materialID: %w", err))
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+	if err := h.Material.Delete(c.Request().Context(), materialID, version); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// SetMaterialFile получает номер материала из параметра id, файл из поля file формы запроса
+// и заменяет им файл материала с данным номером. В ответе ничего не возвращает.
+func (h *Handler) SetMaterialFile(c echo.Context) error {
+	materialID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("parse materialID: %w", err))
+	}
+	fileHeader, err := materialFile(c)
+	if err != nil {
+		return err
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("open material file: %w", err))
+	}
+	defer file.Close()
+	if err := h.Material.SetFile(c.Request().Context(), materialID, file, fileHeader.Filename); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetMaterialFile получает номер материала из параметра id и возвращает в ответе файл материала.
+func (h *Handler) GetMaterialFile(c echo.Context) error {
+	materialID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("parse materialID: %w", err))
+	}
+	file, contentType, err := h.Material.GetFile(c.Request().Context(), materialID)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return streamFile(c, "material", contentType, file)
+}
+
+// GetBookMaterials получает номер книги из параметра id
+// и возвращает в ответе прикрепленные к ней материалы.
+func (h *Handler) GetBookMaterials(c echo.Context) error {
+	bookID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("parse bookID: %w", err))
+	}
+	materials, err := h.Material.GetByBook(c.Request().Context(), bookID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, materials)
+}
+
+// AttachLessonMaterial получает номер занятия из параметра id, номер материала из параметра materialID
+// и прикрепляет материал к занятию. В ответе ничего не возвращает.
+func (h *Handler) AttachLessonMaterial(c echo.Context) error {
+	lessonID, materialID, err := materialLinkParams(c)
+	if err != nil {
+		return err
+	}
+	if err := h.Material.AttachToLesson(c.Request().Context(), materialID, lessonID); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// DetachLessonMaterial получает номер занятия из параметра id, номер материала из параметра materialID
+// и открепляет материал от занятия. В ответе ничего не возвращает.
+func (h *Handler) DetachLessonMaterial(c echo.Context) error {
+	lessonID, materialID, err := materialLinkParams(c)
+	if err != nil {
+		return err
+	}
+	if err := h.Material.DetachFromLesson(c.Request().Context(), materialID, lessonID); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// AttachBookMaterial получает номер книги из параметра id, номер материала из параметра materialID
+// и прикрепляет материал к книге. В ответе ничего не возвращает.
+func (h *Handler) AttachBookMaterial(c echo.Context) error {
+	bookID, materialID, err := materialLinkParams(c)
+	if err != nil {
+		return err
+	}
+	if err := h.Material.AttachToBook(c.Request().Context(), materialID, bookID); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// DetachBookMaterial получает номер книги из параметра id, номер материала из параметра materialID
+// и открепляет материал от книги. В ответе ничего не возвращает.
+func (h *Handler) DetachBookMaterial(c echo.Context) error {
+	bookID, materialID, err := materialLinkParams(c)
+	if err != nil {
+		return err
+	}
+	if err := h.Material.DetachFromBook(c.Request().Context(), materialID, bookID); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// materialFile возвращает заголовок файла материала из поля file формы запроса.
+func materialFile(c echo.Context) (*multipart.FileHeader, error) {
+	return formFile(c, "file", maxMaterialSize)
+}
+
+// materialLinkParams возвращает номер владельца материала из параметра id
+// и номер материала из параметра materialID.
+func materialLinkParams(c echo.Context) (ownerID, materialID int, err error) {
+	ownerID, err = strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return 0, 0, echo.ErrBadRequest.WithInternal(fmt.Errorf("parse ownerID: %w", err))
+	}
+	materialID, err = strconv.Atoi(c.Param("materialID"))
+	if err != nil {
+		return 0, 0, echo.ErrBadRequest.WithInternal(fmt.Errorf("parse materialID: %w", err))
+	}
+	return ownerID, materialID, nil
+}
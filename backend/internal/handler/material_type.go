@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MaterialTypeService определяет методы для работы с видами материалов.
+type MaterialTypeService interface {
+	// Create создает новый вид материалов и возвращает его с номером или ошибку.
+	Create(ctx context.Context, input *model.NewMaterialType) (*model.MaterialType, error)
+
+	// GetAll возвращает слайс всех видов материалов или ошибку.
+	// Если видов материалов нет, то возвращается ошибка [errs.Empty].
+	GetAll(ctx context.Context) ([]model.MaterialType, error)
+
+	// Get возвращает вид материалов по номеру или ошибку.
+	// Если вид материалов с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
+	Get(ctx context.Context, ID int) (*model.MaterialType, error)
+
+	// Update обновляет вид материалов по номеру и возвращает его с номером или ошибку.
+	// Если вид материалов с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Update(ctx context.Context, ID, version int, update *model.NewMaterialType) (*model.MaterialType, error)
+
+	// Patch изменяет указанные в патче поля вида материалов по номеру и возвращает его с номером или ошибку.
+	// Если вид материалов с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Patch(ctx context.Context, ID, version int, patch *model.MaterialTypePatch) (*model.MaterialType, error)
+
+	// Delete удаляет вид материалов по номеру и возвращает ошибку, если удаления не произошло.
+	// Если вид материалов с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Delete(ctx context.Context, ID, version int) error
+}
+
+// CreateMaterialType получает данные о виде материалов из тела запроса и создает его.
+// В ответе возвращается номер нового вида материалов.
+func (h *Handler) CreateMaterialType(c echo.Context) error {
+	newMaterialType := new(model.NewMaterialType)
+	if err := bindAndValidate(c, newMaterialType); err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("bind newMaterialType: %w", err))
+	}
+	materialType, err := h.MaterialType.Create(c.Request().Context(), newMaterialType)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, echo.Map{
+		"ID": materialType.ID,
+	})
+}
+
+// GetAllMaterialTypes возвращает в ответе все виды материалов.
+func (h *Handler) GetAllMaterialTypes(c echo.Context) error {
+	materialTypes, err := h.MaterialType.GetAll(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, materialTypes)
+}
+
+// GetMaterialType получает номер вида материалов из параметра id
+// и возвращает в ответе вид материалов с данным номером.
+func (h *Handler) GetMaterialType(c echo.Context) error {
+	typeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("parse typeID: %w", err))
+	}
+	materialType, err := h.MaterialType.Get(c.Request().Context(), typeID)
+	if err != nil {
+		return err
+	}
+	setETag(c, materialType.Version)
+	return c.JSON(http.StatusOK, materialType)
+}
+
+// UpdateMaterialType получает номер вида материалов из параметра id, данные о нем из тела запроса
+// и обновляет вид материалов с данным номером. В ответе ничего не возвращает.
+func (h *Handler) UpdateMaterialType(c echo.Context) error {
+	typeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("parse typeID: %w", err))
+	}
+	materialTypeUpdate := new(model.NewMaterialType)
+	if err := bindAndValidate(c, materialTypeUpdate); err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("bind newMaterialType: %w", err))
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+	updated, err := h.MaterialType.Update(c.Request().Context(), typeID, version, materialTypeUpdate)
+	if err != nil {
+		return err
+	}
+	setETag(c, updated.Version)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// PatchMaterialType получает номер вида материалов из параметра id, изменения в формате JSON Merge Patch из тела запроса
+// и изменяет только указанные поля вида материалов с данным номером. В ответе ничего не возвращает.
+func (h *Handler) PatchMaterialType(c echo.Context) error {
+	typeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("parse typeID: %w", err))
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+	materialTypePatch := new(model.MaterialTypePatch)
+	if err := bindPatch(c, materialTypePatch); err != nil {
+		return err
+	}
+	patched, err := h.MaterialType.Patch(c.Request().Context(), typeID, version, materialTypePatch)
+	if err != nil {
+		return err
+	}
+	setETag(c, patched.Version)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// DeleteMaterialType получает номер вида материалов из параметра id и удаляет вид материалов с данным номером.
+// В ответе ничего не возвращает.
+func (h *Handler) DeleteMaterialType(c echo.Context) error {
+	typeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("parse typeID: %w", err))
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+	if err := h.MaterialType.Delete(c.Request().Context(), typeID, version); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Максимальный размер фотографии профиля в байтах.
+const maxPhotoSize = 5 << 20
+
+// GetMe возвращает в ответе профиль текущего пользователя
+// с названием роли, взводом, специальностью и кафедрой.
+func (h *Handler) GetMe(c echo.Context) error {
+	userID, err := CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	profile, err := h.User.GetProfile(c.Request().Context(), userID)
+	if err != nil {
+		return err
+	}
+	setETag(c, profile.Version)
+	return c.JSON(http.StatusOK, profile)
+}
+
+// UpdateMe получает из тела запроса изменения профиля текущего пользователя
+// и применяет их. Изменяются только переданные поля. В ответе ничего не возвращает.
+func (h *Handler) UpdateMe(c echo.Context) error {
+	userID, err := CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	profileUpdate := new(model.ProfileUpdate)
+	if err := bindAndValidate(c, profileUpdate); err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("bind profileUpdate: %w", err))
+	}
+	if profileUpdate.Preferences != nil {
+		var preferences map[string]any
+		if err := json.Unmarshal(*profileUpdate.Preferences, &preferences); err != nil {
+			return echo.ErrBadRequest.WithInternal(fmt.Errorf("preferences is not a json object: %w", err))
+		}
+	}
+	if _, err := h.User.UpdateProfile(c.Request().Context(), userID, profileUpdate); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ChangeMyPassword получает из тела запроса действующий и новый пароли
+// и меняет пароль текущего пользователя. В ответе ничего не возвращает.
+func (h *Handler) ChangeMyPassword(c echo.Context) error {
+	userID, err := CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	passwordChange := new(model.PasswordChange)
+	if err := bindAndValidate(c, passwordChange); err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("bind passwordChange: %w", err))
+	}
+	if err := h.User.ChangePassword(c.Request().Context(), userID, passwordChange); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// SetMyPhoto получает фотографию из поля photo формы запроса
+// и устанавливает ее фотографией профиля текущего пользователя. В ответе ничего не возвращает.
+func (h *Handler) SetMyPhoto(c echo.Context) error {
+	userID, err := CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	return h.setPhoto(c, userID)
+}
+
+// GetMyPhoto возвращает в ответе фотографию профиля текущего пользователя.
+func (h *Handler) GetMyPhoto(c echo.Context) error {
+	userID, err := CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	return h.getPhoto(c, userID)
+}
+
+// DeleteMyPhoto удаляет фотографию профиля текущего пользователя. В ответе ничего не возвращает.
+func (h *Handler) DeleteMyPhoto(c echo.Context) error {
+	userID, err := CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	if err := h.User.DeletePhoto(c.Request().Context(), userID); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// setPhoto получает фотографию из поля photo формы запроса
+// и устанавливает ее фотографией профиля пользователя с номером userID.
+func (h *Handler) setPhoto(c echo.Context, userID int) error {
+	fileHeader, err := formFile(c, "photo", maxPhotoSize)
+	if err != nil {
+		return err
+	}
+	photo, err := fileHeader.Open()
+	if err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("open photo: %w", err))
+	}
+	defer photo.Close()
+	if err := h.User.SetPhoto(c.Request().Context(), userID, photo); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// getPhoto возвращает в ответе фотографию профиля пользователя с номером userID.
+func (h *Handler) getPhoto(c echo.Context, userID int) error {
+	photo, contentType, err := h.User.GetPhoto(c.Request().Context(), userID)
+	if err != nil {
+		return err
+	}
+	defer photo.Close()
+	return streamFile(c, "photo", contentType, photo)
+}
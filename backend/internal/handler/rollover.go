@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RolloverService определяет методы для перехода на новый учебный год.
+type RolloverService interface {
+	// Preview проверяет изменения взводов и возвращает план перехода без записи в базу данных.
+	Preview(ctx context.Context, rollover *model.Rollover) (*model.RolloverPlan, error)
+
+	// Apply проверяет изменения взводов и, если ошибок нет, применяет их в одной транзакции.
+	// Если в возвращенном плане есть ошибки, то ничего не изменяется.
+	Apply(ctx context.Context, rollover *model.Rollover) (*model.RolloverPlan, error)
+}
+
+// PreviewRollover возвращает в ответе план перехода на новый учебный год без его применения.
+func (h *Handler) PreviewRollover(c echo.Context) error {
+	rollover := new(model.Rollover)
+	if err := bindAndValidate(c, rollover); err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("bind rollover: %w", err))
+	}
+	plan, err := h.Rollover.Preview(c.Request().Context(), rollover)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, plan)
+}
+
+// ApplyRollover выполняет переход на новый учебный год и возвращает в ответе примененный план.
+// Если в плане есть ошибки, то ничего не изменяется и возвращается план со статусом 422.
+func (h *Handler) ApplyRollover(c echo.Context) error {
+	rollover := new(model.Rollover)
+	if err := bindAndValidate(c, rollover); err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("bind rollover: %w", err))
+	}
+	plan, err := h.Rollover.Apply(c.Request().Context(), rollover)
+	if err != nil {
+		return err
+	}
+	if len(plan.Errors) > 0 {
+		return c.JSON(http.StatusUnprocessableEntity, plan)
+	}
+	return c.JSON(http.StatusOK, plan)
+}
@@ -26,11 +26,18 @@ type SpecialtyService interface {
 
 	// Update обновляет специальность по номеру и возвращает ее с номером или ошибку.
 	// Если специальность с таким номером не нашлась, то возращается ошибка [errs.NotFound].
-	Update(ctx context.Context, ID int, update *model.NewSpecialty) (*model.Specialty, error)
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Update(ctx context.Context, ID, version int, update *model.NewSpecialty) (*model.Specialty, error)
 
-	// Delete удаляет специальность по номеру и возвращает ошибку, если удаления не произошло.
+	// Patch изменяет указанные в патче поля специальности по номеру и возвращает ее с номером или ошибку.
 	// Если специальность с таким номером не нашлась, то возращается ошибка [errs.NotFound].
-	Delete(ctx context.Context, ID int) error
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Patch(ctx context.Context, ID, version int, patch *model.SpecialtyPatch) (*model.Specialty, error)
+
+	// Delete удаляет специальность по номеру в корзину и возвращает ошибку, если удаления не произошло.
+	// Если специальность с таким номером не нашлась, то возращается ошибка [errs.NotFound].
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Delete(ctx context.Context, ID, version int) error
 }
 
 // CreateSpecialty получает данные о специальности из тела запроса и создает ее.
@@ -71,6 +78,7 @@ func (h *Handler) GetSpecialty(c echo.Context) error {
 	if err != nil {
 		return echo.ErrNotFound
 	}
+	setETag(c, specialty.Version)
 	return c.JSON(http.StatusOK, specialty)
 }
 
@@ -85,10 +93,38 @@ func (h *Handler) UpdateSpecialty(c echo.Context) error {
 	if err := bindAndValidate(c, specialtyUpdate); err != nil {
 		return echo.ErrBadRequest.WithInternal(fmt.Errorf("bind specialtyUpdate: %w", err))
 	}
-	_, err = h.Specialty.Update(c.Request().Context(), specialtyID, specialtyUpdate)
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+	updated, err := h.Specialty.Update(c.Request().Context(), specialtyID, version, specialtyUpdate)
 	if err != nil {
 		return err
 	}
+	setETag(c, updated.Version)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// PatchSpecialty получает номер специальности из параметра id, изменения в формате JSON Merge Patch из тела запроса
+// и изменяет только указанные поля специальности с данным номером. В ответе ничего не возвращает.
+func (h *Handler) PatchSpecialty(c echo.Context) error {
+	specialtyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("parse specialtyID: %w", err))
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+	specialtyPatch := new(model.SpecialtyPatch)
+	if err := bindPatch(c, specialtyPatch); err != nil {
+		return err
+	}
+	patched, err := h.Specialty.Patch(c.Request().Context(), specialtyID, version, specialtyPatch)
+	if err != nil {
+		return err
+	}
+	setETag(c, patched.Version)
 	return c.NoContent(http.StatusNoContent)
 }
 
@@ -99,7 +135,11 @@ func (h *Handler) DeleteSpecialty(c echo.Context) error {
 	if err != nil {
 		return echo.ErrBadRequest.WithInternal(fmt.Errorf("parse specialtyID: %w", err))
 	}
-	if err := h.Specialty.Delete(c.Request().Context(), specialtyID); err != nil {
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+	if err := h.Specialty.Delete(c.Request().Context(), specialtyID, version); err != nil {
 		return err
 	}
 	return c.NoContent(http.StatusNoContent)
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+// TrashService определяет методы для работы с корзиной удаленных записей.
+type TrashService interface {
+	// GetAll возвращает слайс всех записей в корзине или ошибку.
+	GetAll(ctx context.Context) ([]model.TrashItem, error)
+
+	// Restore возвращает запись сущности entity с номером ID из корзины.
+	// Если записи нет в корзине, то возвращается ошибка [errs.NotFound].
+	Restore(ctx context.Context, entity string, ID int) error
+}
+
+// GetTrash возвращает в ответе все записи в корзине от удаленных последними к удаленным первыми.
+func (h *Handler) GetTrash(c echo.Context) error {
+	items, err := h.Trash.GetAll(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, items)
+}
+
+// RestoreFromTrash получает название сущности из параметра entity и номер записи из параметра id
+// и возвращает запись из корзины. В ответе ничего не возвращает.
+func (h *Handler) RestoreFromTrash(c echo.Context) error {
+	ID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("parse trash item ID: %w", err))
+	}
+	if err := h.Trash.Restore(c.Request().Context(), c.Param("entity"), ID); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 
@@ -18,9 +19,9 @@ type UserService interface {
 	// Create создает нового пользователя и его данные для входа и возвращает пользователя с номером или ошибку.
 	Create(ctx context.Context, input *model.NewUser) (*model.User, error)
 
-	// GetAll возвращает слайс всех пользователей или ошибку.
+	// GetAll возвращает слайс пользователей, удовлетворяющих фильтру, или ошибку.
 	// Если пользователей нет, то возвращается ошибка [errs.Empty].
-	GetAll(ctx context.Context) ([]model.User, error)
+	GetAll(ctx context.Context, filter *model.UserFilter) ([]model.User, error)
 
 	// Get возвращает пользователя по номеру или ошибку.
 	// Если пользователь с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
@@ -28,11 +29,42 @@ type UserService interface {
 
 	// Update обновляет пользователя и его данные для входа по номеру и возвращает его или ошибку.
 	// Если пользователь с таким номером не нашелся, то возращается ошибка [errs.NotFound].
-	Update(ctx context.Context, ID int, update *model.NewUser) (*model.User, error)
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Update(ctx context.Context, ID, version int, update *model.NewUser) (*model.User, error)
 
-	// Delete удаляет пользователя по номеру и возвращает ошибку, если удаления не произошло.
+	// Patch изменяет указанные в патче поля пользователя и его данных для входа по номеру и возвращает его или ошибку.
 	// Если пользователь с таким номером не нашелся, то возращается ошибка [errs.NotFound].
-	Delete(ctx context.Context, ID int) error
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Patch(ctx context.Context, ID, version int, patch *model.UserPatch) (*model.User, error)
+
+	// Delete удаляет пользователя по номеру в корзину и возвращает ошибку, если удаления не произошло.
+	// Если пользователь с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Delete(ctx context.Context, ID, version int) error
+
+	// GetProfile возвращает профиль пользователя по номеру или ошибку.
+	// Если пользователь с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
+	GetProfile(ctx context.Context, ID int) (*model.Profile, error)
+
+	// UpdateProfile обновляет заполненные поля профиля пользователя по номеру и возвращает его или ошибку.
+	// Если пользователь с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+	UpdateProfile(ctx context.Context, ID int, update *model.ProfileUpdate) (*model.User, error)
+
+	// ChangePassword меняет пароль пользователя по номеру после проверки действующего пароля.
+	// Если действующий пароль не совпадает с сохраненным, то возвращается ошибка [errs.InvalidPassword].
+	ChangePassword(ctx context.Context, ID int, change *model.PasswordChange) error
+
+	// SetPhoto сохраняет фотографию профиля пользователя по номеру, заменяя предыдущую.
+	// Если формат изображения не поддерживается, то возвращается ошибка [errs.UnsupportedMedia].
+	SetPhoto(ctx context.Context, ID int, photo io.Reader) error
+
+	// GetPhoto открывает фотографию профиля пользователя по номеру и возвращает ее вместе с типом содержимого.
+	// Если у пользователя нет фотографии, то возвращается ошибка [errs.NotFound].
+	GetPhoto(ctx context.Context, ID int) (photo io.ReadCloser, contentType string, err error)
+
+	// DeletePhoto удаляет фотографию профиля пользователя по номеру.
+	// Если у пользователя нет фотографии, то возвращается ошибка [errs.NotFound].
+	DeletePhoto(ctx context.Context, ID int) error
 }
 
 // CreateUser получает данные о пользователе и его данных для входа
@@ -51,9 +83,14 @@ func (h *Handler) CreateUser(c echo.Context) error {
 	})
 }
 
-// GetAllUsers возвращает в ответе всех пользователей.
+// GetAllUsers получает условия поиска из параметров запроса
+// и возвращает в ответе подходящих пользователей.
 func (h *Handler) GetAllUsers(c echo.Context) error {
-	users, err := h.User.GetAll(c.Request().Context())
+	filter := new(model.UserFilter)
+	if err := bindAndValidate(c, filter); err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("bind user filter: %w", err))
+	}
+	users, err := h.User.GetAll(c.Request().Context(), filter)
 	if err != nil && !errors.Is(err, errs.Empty) {
 		return err
 	}
@@ -73,6 +110,7 @@ func (h *Handler) GetUser(c echo.Context) error {
 	if err != nil {
 		return err
 	}
+	setETag(c, user.Version)
 	return c.JSON(http.StatusOK, user)
 }
 
@@ -88,11 +126,38 @@ func (h *Handler) UpdateUser(c echo.Context) error {
 		return echo.ErrBadRequest.WithInternal(err)
 	}
 
-	_, err = h.User.Update(c.Request().Context(), userID, userUpdate)
+	version, err := ifMatch(c)
 	if err != nil {
 		return err
 	}
+	updated, err := h.User.Update(c.Request().Context(), userID, version, userUpdate)
+	if err != nil {
+		return err
+	}
+	setETag(c, updated.Version)
+	return c.NoContent(http.StatusNoContent)
+}
 
+// PatchUser получает номер пользователя из параметра id, изменения в формате JSON Merge Patch из тела запроса
+// и изменяет только указанные поля пользователя с данным номером. В ответе ничего не возвращает.
+func (h *Handler) PatchUser(c echo.Context) error {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("parse userID: %w", err))
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+	userPatch := new(model.UserPatch)
+	if err := bindPatch(c, userPatch); err != nil {
+		return err
+	}
+	patched, err := h.User.Patch(c.Request().Context(), userID, version, userPatch)
+	if err != nil {
+		return err
+	}
+	setETag(c, patched.Version)
 	return c.NoContent(http.StatusNoContent)
 }
 
@@ -103,9 +168,45 @@ func (h *Handler) DeleteUser(c echo.Context) error {
 	if err != nil {
 		return echo.ErrBadRequest.WithInternal(fmt.Errorf("bind ID: %w", err))
 	}
-	err = h.User.Delete(c.Request().Context(), userID)
+	version, err := ifMatch(c)
 	if err != nil {
 		return err
 	}
+	if err := h.User.Delete(c.Request().Context(), userID, version); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// SetUserPhoto получает номер пользователя из параметра id и фотографию из поля photo формы запроса
+// и устанавливает ее фотографией профиля пользователя. В ответе ничего не возвращает.
+func (h *Handler) SetUserPhoto(c echo.Context) error {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("bind ID: %w", err))
+	}
+	return h.setPhoto(c, userID)
+}
+
+// GetUserPhoto получает номер пользователя из параметра id
+// и возвращает в ответе фотографию его профиля.
+func (h *Handler) GetUserPhoto(c echo.Context) error {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("bind ID: %w", err))
+	}
+	return h.getPhoto(c, userID)
+}
+
+// DeleteUserPhoto получает номер пользователя из параметра id
+// и удаляет фотографию его профиля. В ответе ничего не возвращает.
+func (h *Handler) DeleteUserPhoto(c echo.Context) error {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.ErrBadRequest.WithInternal(fmt.Errorf("bind ID: %w", err))
+	}
+	if err := h.User.DeletePhoto(c.Request().Context(), userID); err != nil {
+		return err
+	}
 	return c.NoContent(http.StatusNoContent)
 }
@@ -0,0 +1,100 @@
+// Пакет health проверяет готовность сервера принимать запросы.
+//
+// Проверки (подключение к базе данных, актуальность схемы, доступность хранилища)
+// добавляются в [Checker] при запуске сервера и выполняются одновременно при каждом
+// запросе готовности. При корректном завершении работы сервер помечается методом
+// [Checker.SetShuttingDown], после чего готовность больше не подтверждается.
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+)
+
+// DefaultTimeout — время ожидания одной проверки по умолчанию.
+const DefaultTimeout = 2 * time.Second
+
+// ErrShuttingDown возвращается проверкой shutdown, когда сервер завершает работу.
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// CheckFunc проверяет одну зависимость сервера и возвращает ошибку, если она недоступна.
+type CheckFunc func(ctx context.Context) error
+
+// check представляет именованную проверку.
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+// Checker выполняет проверки готовности сервера. Checker безопасен для одновременного использования,
+// но все проверки должны быть добавлены до первого вызова [Checker.Ready].
+type Checker struct {
+	timeout      time.Duration // время ожидания одной проверки
+	checks       []check
+	shuttingDown atomic.Bool // сервер завершает работу
+}
+
+// NewChecker возвращает новый экземпляр [Checker] без проверок
+// с временем ожидания одной проверки timeout (если не больше 0, то [DefaultTimeout]).
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Checker{timeout: timeout}
+}
+
+// Add добавляет проверку fn с названием name.
+func (hc *Checker) Add(name string, fn CheckFunc) {
+	hc.checks = append(hc.checks, check{name, fn})
+}
+
+// SetShuttingDown помечает сервер как завершающий работу. После этого [Checker.Ready]
+// всегда сообщает о неготовности, чтобы балансировщик перестал направлять на сервер запросы.
+func (hc *Checker) SetShuttingDown() {
+	hc.shuttingDown.Store(true)
+}
+
+// Ready одновременно выполняет все проверки и возвращает отчет в порядке их добавления.
+// Сервер готов, если он не завершает работу и все проверки пройдены.
+func (hc *Checker) Ready(ctx context.Context) model.HealthReport {
+	results := make([]model.HealthCheck, len(hc.checks))
+	var wg sync.WaitGroup
+	for i, c := range hc.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = hc.run(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	if hc.shuttingDown.Load() {
+		results = append(results, model.HealthCheck{Name: "shutdown", Status: model.HealthUnavailable, Duration: "0s", Error: ErrShuttingDown.Error()})
+	}
+	report := model.HealthReport{Status: model.HealthOK, Checks: results}
+	for _, result := range results {
+		if result.Status != model.HealthOK {
+			report.Status = model.HealthUnavailable
+		}
+	}
+	return report
+}
+
+// run выполняет проверку c с ограничением по времени и возвращает ее результат.
+func (hc *Checker) run(ctx context.Context, c check) model.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, hc.timeout)
+	defer cancel()
+	start := time.Now()
+	err := c.fn(ctx)
+	result := model.HealthCheck{Name: c.name, Status: model.HealthOK, Duration: time.Since(start).Round(time.Microsecond).String()}
+	if err != nil {
+		result.Status = model.HealthUnavailable
+		result.Error = err.Error()
+	}
+	return result
+}
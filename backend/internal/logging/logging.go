@@ -0,0 +1,121 @@
+// Пакет logging предоставляет структурированный журнал в формате JSON на основе [log/slog].
+//
+// Атрибуты запроса (номер запроса, номер пользователя) записываются в контекст функциями
+// [WithRequestID] и [WithUserID] и добавляются к каждой записи журнала, сделанной
+// с этим контекстом через функции slog с суффиксом Context (например, [slog.InfoContext]).
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Названия атрибутов запроса в записях журнала.
+const (
+	RequestIDKey = "request_id" // номер запроса из заголовка X-Request-ID
+	UserIDKey    = "user_id"    // номер пользователя из jwt токена
+	TraceIDKey   = "trace_id"   // номер трассы OpenTelemetry
+	SpanIDKey    = "span_id"    // номер span OpenTelemetry
+)
+
+// attrsKey — ключ атрибутов журнала в контексте.
+type attrsKey struct{}
+
+// With возвращает копию контекста, записи журнала с которым дополняются атрибутами attrs.
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	return context.WithValue(ctx, attrsKey{}, append(slices.Clip(attrsFrom(ctx)), attrs...))
+}
+
+// WithRequestID возвращает копию контекста с номером запроса requestID для записей журнала.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return With(ctx, slog.String(RequestIDKey, requestID))
+}
+
+// WithUserID возвращает копию контекста с номером пользователя userID для записей журнала.
+func WithUserID(ctx context.Context, userID int) context.Context {
+	return With(ctx, slog.Int(UserIDKey, userID))
+}
+
+// attrsFrom возвращает атрибуты журнала из контекста.
+func attrsFrom(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// ContextHandler дополняет записи журнала атрибутами из контекста и передает их обработчику [slog.Handler].
+type ContextHandler struct {
+	slog.Handler
+}
+
+// NewContextHandler возвращает новый экземпляр [ContextHandler], передающий записи обработчику handler.
+func NewContextHandler(handler slog.Handler) *ContextHandler {
+	return &ContextHandler{Handler: handler}
+}
+
+// Handle реализует метод [slog.Handler] и добавляет к записи атрибуты из контекста ctx,
+// а также номера трассы и span OpenTelemetry, если в контексте есть span.
+func (ch *ContextHandler) Handle(ctx context.Context, record slog.Record) error {
+	attrs := attrsFrom(ctx)
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		attrs = append(slices.Clip(attrs), slog.String(TraceIDKey, sc.TraceID().String()), slog.String(SpanIDKey, sc.SpanID().String()))
+	}
+	if len(attrs) > 0 {
+		record = record.Clone()
+		record.AddAttrs(attrs...)
+	}
+	return ch.Handler.Handle(ctx, record)
+}
+
+// WithAttrs реализует метод [slog.Handler].
+func (ch *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return NewContextHandler(ch.Handler.WithAttrs(attrs))
+}
+
+// WithGroup реализует метод [slog.Handler].
+func (ch *ContextHandler) WithGroup(name string) slog.Handler {
+	return NewContextHandler(ch.Handler.WithGroup(name))
+}
+
+// New возвращает журнал, который пишет записи уровня не ниже level в w в формате JSON
+// и дополняет их атрибутами из контекста.
+func New(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(NewContextHandler(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})))
+}
+
+// Err возвращает атрибут error с текстом ошибки err и атрибут error_chain
+// с типами всех ошибок в ее цепочке, начиная с внешней.
+func Err(err error) slog.Attr {
+	if err == nil {
+		return slog.String("error", "")
+	}
+	return slog.Group("",
+		slog.String("error", err.Error()),
+		slog.Any("error_chain", errorChain(err)),
+	)
+}
+
+// errorChain возвращает типы ошибок в цепочке err в порядке обхода в глубину.
+// Ошибки, объединяющие несколько ошибок, раскрываются по порядку.
+func errorChain(err error) []string {
+	var chain []string
+	for err != nil {
+		chain = append(chain, fmt.Sprintf("%T", err))
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, inner := range joined.Unwrap() {
+				chain = append(chain, errorChain(inner)...)
+			}
+			break
+		}
+		err = errors.Unwrap(err)
+	}
+	return chain
+}
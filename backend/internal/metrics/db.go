@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+)
+
+// RegisterDBStats регистрирует в реестре r метрики пула соединений с базой данных,
+// статистику которого возвращает функция stats (например, [sql.DB.Stats]).
+func (r *Registry) RegisterDBStats(stats func() sql.DBStats) {
+	gauge := func(value func(s sql.DBStats) float64) func(context.Context) (float64, error) {
+		return func(context.Context) (float64, error) {
+			return value(stats()), nil
+		}
+	}
+	r.NewGaugeFunc("elib_db_connections_max", "Maximum number of open connections to the database.",
+		gauge(func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }))
+	r.NewGaugeFunc("elib_db_connections_open", "Number of established connections to the database.",
+		gauge(func(s sql.DBStats) float64 { return float64(s.OpenConnections) }))
+	r.NewGaugeFunc("elib_db_connections_in_use", "Number of connections currently in use.",
+		gauge(func(s sql.DBStats) float64 { return float64(s.InUse) }))
+	r.NewGaugeFunc("elib_db_connections_idle", "Number of idle connections.",
+		gauge(func(s sql.DBStats) float64 { return float64(s.Idle) }))
+	r.NewCounterFunc("elib_db_wait_total", "Total number of connections waited for.",
+		gauge(func(s sql.DBStats) float64 { return float64(s.WaitCount) }))
+	r.NewCounterFunc("elib_db_wait_seconds_total", "Total time blocked waiting for a new connection.",
+		gauge(func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }))
+	r.NewCounterFunc("elib_db_connections_closed_max_idle_total", "Total number of connections closed due to SetMaxIdleConns.",
+		gauge(func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }))
+	r.NewCounterFunc("elib_db_connections_closed_max_lifetime_total", "Total number of connections closed due to SetConnMaxLifetime.",
+		gauge(func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }))
+}
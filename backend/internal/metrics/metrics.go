@@ -0,0 +1,290 @@
+// Пакет metrics предоставляет метрики приложения в текстовом формате Prometheus.
+//
+// Счетчики и гистограммы создаются в реестре [Default] при инициализации пакетов, которые их обновляют,
+// а метрики, значения которых вычисляются при чтении (статистика пула соединений, число активных сессий),
+// регистрируются при запуске сервера. Реестр отдает все метрики методом [Registry.Write].
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType — тип содержимого ответа с метриками в текстовом формате Prometheus.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets содержит границы интервалов гистограмм длительности в секундах по умолчанию.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Default — реестр метрик приложения.
+var Default = NewRegistry()
+
+// metric определяет метрику, которую реестр выводит в текстовом формате.
+type metric interface {
+	// describe возвращает название, описание и тип метрики.
+	describe() (name, help, kind string)
+
+	// write выводит значения метрики в w.
+	write(ctx context.Context, w io.Writer) error
+}
+
+// Registry представляет реестр метрик. Реестр безопасен для одновременного использования.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+// NewRegistry возвращает новый пустой экземпляр [Registry].
+func NewRegistry() *Registry {
+	return new(Registry)
+}
+
+// register добавляет метрику m в реестр.
+// Если метрика с таким же названием уже есть, то вызывается паника, как при повторной регистрации обработчика.
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name, _, _ := m.describe()
+	for _, registered := range r.metrics {
+		if other, _, _ := registered.describe(); other == name {
+			panic(fmt.Sprintf("metrics: metric %v is already registered", name))
+		}
+	}
+	r.metrics = append(r.metrics, m)
+}
+
+// Write выводит все метрики реестра в w в текстовом формате Prometheus в порядке регистрации.
+func (r *Registry) Write(ctx context.Context, w io.Writer) error {
+	r.mu.Lock()
+	metrics := slices.Clone(r.metrics)
+	r.mu.Unlock()
+	for _, m := range metrics {
+		name, help, kind := m.describe()
+		if _, err := fmt.Fprintf(w, "# HELP %v %v\n# TYPE %v %v\n", name, escapeHelp(help), name, kind); err != nil {
+			return err
+		}
+		if err := m.write(ctx, w); err != nil {
+			return fmt.Errorf("write metric %v: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// desc содержит описание метрики и названия ее меток.
+type desc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (d *desc) describe() (name, help, kind string) {
+	return d.name, d.help, d.kind
+}
+
+// formatLabels возвращает метки с названиями из d и значениями values в формате {name="value",...}.
+// Если количество значений не совпадает с количеством меток, то вызывается паника.
+func (d *desc) formatLabels(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: metric %v expects %v label values, got %v", d.name, len(d.labels), len(values)))
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(values))
+	for i, value := range values {
+		pairs[i] = d.labels[i] + `="` + escapeLabel(value) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// Counter представляет счетчик, значение которого только увеличивается.
+type Counter struct {
+	desc
+	mu     sync.Mutex
+	values map[string]float64 // значения по меткам
+}
+
+// NewCounter создает в реестре счетчик с названием name, описанием help и названиями меток labels.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{desc: desc{name, help, "counter", labels}, values: make(map[string]float64)}
+	r.register(c)
+	return c
+}
+
+// Inc увеличивает на единицу значение счетчика с метками labelValues.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add увеличивает значение счетчика с метками labelValues на value. Отрицательные значения игнорируются.
+func (c *Counter) Add(value float64, labelValues ...string) {
+	if value < 0 {
+		return
+	}
+	labels := c.formatLabels(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[labels] += value
+}
+
+// Value возвращает значение счетчика с метками labelValues.
+func (c *Counter) Value(labelValues ...string) float64 {
+	labels := c.formatLabels(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[labels]
+}
+
+func (c *Counter) write(ctx context.Context, w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, labels := range sortedKeys(c.values) {
+		if _, err := fmt.Fprintf(w, "%v%v %v\n", c.name, labels, formatValue(c.values[labels])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Histogram представляет гистограмму распределения наблюдаемых значений по интервалам.
+type Histogram struct {
+	desc
+	buckets []float64 // верхние границы интервалов по возрастанию
+	mu      sync.Mutex
+	values  map[string]*histogramValue // значения по меткам
+}
+
+// histogramValue содержит значения гистограммы для одного набора меток.
+type histogramValue struct {
+	counts []uint64 // количество наблюдений в каждом интервале (не накопительно)
+	count  uint64   // общее количество наблюдений
+	sum    float64  // сумма наблюдаемых значений
+}
+
+// NewHistogram создает в реестре гистограмму с названием name, описанием help,
+// верхними границами интервалов buckets (если nil, то [DefaultBuckets]) и названиями меток labels.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+	h := &Histogram{desc: desc{name, help, "histogram", labels}, buckets: buckets, values: make(map[string]*histogramValue)}
+	r.register(h)
+	return h
+}
+
+// Observe добавляет в гистограмму с метками labelValues наблюдаемое значение value.
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	labels := h.formatLabels(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	v, ok := h.values[labels]
+	if !ok {
+		v = &histogramValue{counts: make([]uint64, len(h.buckets))}
+		h.values[labels] = v
+	}
+	if i, _ := slices.BinarySearch(h.buckets, value); i < len(h.buckets) {
+		v.counts[i]++
+	}
+	v.count++
+	v.sum += value
+}
+
+func (h *Histogram) write(ctx context.Context, w io.Writer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, labels := range sortedKeys(h.values) {
+		v := h.values[labels]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += v.counts[i]
+			if _, err := fmt.Fprintf(w, "%v_bucket%v %v\n", h.name, withLabel(labels, "le", formatValue(bound)), cumulative); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%v_bucket%v %v\n%v_sum%v %v\n%v_count%v %v\n",
+			h.name, withLabel(labels, "le", "+Inf"), v.count,
+			h.name, labels, formatValue(v.sum),
+			h.name, labels, v.count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// funcMetric представляет метрику без меток, значение которой вычисляется функцией при каждом чтении.
+type funcMetric struct {
+	desc
+	value func(ctx context.Context) (float64, error)
+}
+
+// NewGaugeFunc создает в реестре метрику-значение с названием name и описанием help,
+// которое вычисляется функцией value при каждом чтении реестра.
+func (r *Registry) NewGaugeFunc(name, help string, value func(ctx context.Context) (float64, error)) {
+	r.register(&funcMetric{desc{name: name, help: help, kind: "gauge"}, value})
+}
+
+// NewCounterFunc создает в реестре счетчик с названием name и описанием help,
+// значение которого вычисляется функцией value при каждом чтении реестра.
+func (r *Registry) NewCounterFunc(name, help string, value func(ctx context.Context) (float64, error)) {
+	r.register(&funcMetric{desc{name: name, help: help, kind: "counter"}, value})
+}
+
+func (fm *funcMetric) write(ctx context.Context, w io.Writer) error {
+	value, err := fm.value(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%v %v\n", fm.name, formatValue(value))
+	return err
+}
+
+// sortedKeys возвращает ключи m по возрастанию, чтобы ряды метрики выводились в одном и том же порядке.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// withLabel добавляет к меткам labels в формате {name="value",...} метку name со значением value.
+func withLabel(labels, name, value string) string {
+	pair := name + `="` + value + `"`
+	if labels == "" {
+		return "{" + pair + "}"
+	}
+	return strings.TrimSuffix(labels, "}") + "," + pair + "}"
+}
+
+// formatValue форматирует значение метрики так, как его ожидает Prometheus.
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// escapeLabel экранирует обратную косую черту, кавычки и переводы строк в значении метки.
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// escapeHelp экранирует обратную косую черту и переводы строк в описании метрики.
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
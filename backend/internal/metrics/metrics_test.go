@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRegistryWrite(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounter("requests_total", "Requests.", "method", "path")
+	latency := r.NewHistogram("latency_seconds", "Latency.", []float64{1, 0.1}, "method")
+	r.NewGaugeFunc("sessions", "Sessions.\nActive.", func(context.Context) (float64, error) { return 3, nil })
+
+	requests.Inc("GET", `/a"b`)
+	requests.Add(2, "GET", `/a"b`)
+	requests.Inc("POST", "/a")
+	latency.Observe(0.05, "GET")
+	latency.Observe(0.1, "GET")
+	latency.Observe(5, "GET")
+
+	var out strings.Builder
+	if err := r.Write(context.Background(), &out); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	want := `# HELP requests_total Requests.
+# TYPE requests_total counter
+requests_total{method="GET",path="/a\"b"} 3
+requests_total{method="POST",path="/a"} 1
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{method="GET",le="0.1"} 2
+latency_seconds_bucket{method="GET",le="1"} 2
+latency_seconds_bucket{method="GET",le="+Inf"} 3
+latency_seconds_sum{method="GET"} 5.15
+latency_seconds_count{method="GET"} 3
+# HELP sessions Sessions.\nActive.
+# TYPE sessions gauge
+sessions 3
+`
+	if out.String() != want {
+		t.Errorf("write: got\n%v\nwant\n%v", out.String(), want)
+	}
+	if got := requests.Value("GET", `/a"b`); got != 3 {
+		t.Errorf("value: got %v, want 3", got)
+	}
+}
+
+func TestRegistryErrors(t *testing.T) {
+	r := NewRegistry()
+	errFailed := errors.New("failed")
+	r.NewGaugeFunc("broken", "Broken.", func(context.Context) (float64, error) { return 0, errFailed })
+	if err := r.Write(context.Background(), new(strings.Builder)); !errors.Is(err, errFailed) {
+		t.Errorf("write broken gauge: got %v, want %v", err, errFailed)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("duplicate registration did not panic")
+		}
+	}()
+	r.NewCounter("broken", "Duplicate.")
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditAction представляет вид изменяющей операции, записываемой в журнал аудита.
+type AuditAction string
+
+const (
+	AuditCreate AuditAction = "create" // создание
+	AuditUpdate AuditAction = "update" // изменение
+	AuditDelete AuditAction = "delete" // удаление
+)
+
+// AuditEvent представляет запись журнала аудита об изменяющей операции.
+type AuditEvent struct {
+	ID        int              `json:"auditEventID" db:"audit_event_id"` // номер
+	ActorID   *int             `json:"actorID,omitempty" db:"actor_id"`  // номер пользователя, выполнившего операцию
+	Action    AuditAction      `json:"action" db:"action"`               // вид операции
+	Entity    string           `json:"entity" db:"entity"`               // название сущности
+	EntityID  int              `json:"entityID" db:"entity_id"`          // номер сущности
+	Before    *json.RawMessage `json:"before,omitempty" db:"before"`     // измененные поля до операции
+	After     *json.RawMessage `json:"after,omitempty" db:"after"`       // измененные поля после операции
+	IP        *string          `json:"ip,omitempty" db:"ip"`             // ip-адрес клиента
+	CreatedAt time.Time        `json:"createdAt" db:"created_at"`        // время операции
+}
+
+// Actor описывает инициатора запроса: пользователя и его ip-адрес.
+type Actor struct {
+	UserID *int   // номер пользователя (отсутствует у неаутентифицированных запросов)
+	IP     string // ip-адрес клиента
+}
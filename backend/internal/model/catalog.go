@@ -0,0 +1,60 @@
+package model
+
+// Уровни дерева каталога.
+const (
+	CatalogDepartments = iota + 1 // кафедры
+	CatalogSpecialties            // специальности
+	CatalogDisciplines            // предметы
+	CatalogChapters               // разделы
+	CatalogLessons                // занятия
+)
+
+// CatalogFilter содержит параметры построения дерева каталога.
+type CatalogFilter struct {
+	Depth  int  `query:"depth" validate:"gte=0,lte=5"` // количество уровней дерева (по умолчанию все)
+	Counts bool `query:"counts"`                       // добавлять к узлам количество дочерних элементов
+}
+
+// Chapter представляет раздел (модуль) предмета.
+type Chapter struct {
+	ID           int `json:"chapterID" db:"chapter_id"`       // номер
+	DisciplineID int `json:"disciplineID" db:"discipline_id"` // номер предмета
+	Module       int `json:"module" db:"module"`              // номер модуля
+}
+
+// CatalogDepartment представляет кафедру в дереве каталога.
+type CatalogDepartment struct {
+	Department
+	SpecialtyCount *int               `json:"specialtyCount,omitempty"` // количество специальностей
+	Specialties    []CatalogSpecialty `json:"specialties,omitempty"`    // специальности кафедры
+}
+
+// CatalogSpecialty представляет специальность в дереве каталога.
+type CatalogSpecialty struct {
+	Specialty
+	DisciplineCount *int                `json:"disciplineCount,omitempty"` // количество предметов
+	Disciplines     []CatalogDiscipline `json:"disciplines,omitempty"`     // предметы специальности
+}
+
+// CatalogDiscipline представляет предмет в дереве каталога.
+type CatalogDiscipline struct {
+	Discipline
+	ChapterCount *int             `json:"chapterCount,omitempty"` // количество разделов
+	Chapters     []CatalogChapter `json:"chapters,omitempty"`     // разделы предмета
+}
+
+// CatalogChapter представляет раздел предмета в дереве каталога.
+type CatalogChapter struct {
+	Chapter
+	LessonCount *int            `json:"lessonCount,omitempty"` // количество занятий
+	Lessons     []CatalogLesson `json:"lessons,omitempty"`     // занятия раздела
+}
+
+// CatalogLesson представляет занятие в дереве каталога.
+type CatalogLesson struct {
+	ID            int     `json:"lessonID" db:"lesson_id"`   // номер
+	Name          *string `json:"name,omitempty" db:"name"`  // название
+	ChapterID     int     `json:"chapterID" db:"chapter_id"` // номер раздела
+	TypeID        int     `json:"typeID" db:"type_id"`       // номер вида занятия
+	MaterialCount *int    `json:"materialCount,omitempty"`   // количество дополнительных материалов
+}
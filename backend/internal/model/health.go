@@ -0,0 +1,21 @@
+package model
+
+// Состояния сервера и его проверок.
+const (
+	HealthOK          = "ok"          // проверка пройдена
+	HealthUnavailable = "unavailable" // проверка не пройдена или сервер завершает работу
+)
+
+// HealthReport представляет результат проверки готовности сервера.
+type HealthReport struct {
+	Status string        `json:"status"`           // общее состояние: ok или unavailable
+	Checks []HealthCheck `json:"checks,omitempty"` // результаты отдельных проверок
+}
+
+// HealthCheck представляет результат одной проверки готовности.
+type HealthCheck struct {
+	Name     string `json:"name"`            // название проверки
+	Status   string `json:"status"`          // состояние: ok или unavailable
+	Duration string `json:"duration"`        // длительность проверки
+	Error    string `json:"error,omitempty"` // текст ошибки, если проверка не пройдена
+}
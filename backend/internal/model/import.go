@@ -0,0 +1,41 @@
+package model
+
+// RosterRow представляет строку списка студентов для массового импорта.
+type RosterRow struct {
+	Line       int     `json:"line"`                 // номер строки в исходном файле
+	Surname    string  `json:"surname"`              // фамилия
+	Name       string  `json:"name"`                 // имя
+	Patronymic *string `json:"patronymic,omitempty"` // отчество (если имеется)
+	GroupName  string  `json:"groupName"`            // название взвода
+}
+
+// ImportStatus представляет результат импорта строки списка студентов.
+type ImportStatus string
+
+const (
+	ImportNew      ImportStatus = "new"      // студент будет создан (пробный импорт)
+	ImportCreated  ImportStatus = "created"  // студент создан
+	ImportExisting ImportStatus = "existing" // студент уже есть во взводе и пропущен
+	ImportInvalid  ImportStatus = "invalid"  // строка содержит ошибки
+)
+
+// ImportRowResult представляет результат импорта строки списка студентов.
+// Логин и пароль заполнены только у новых студентов, пароль не генерируется при пробном импорте.
+// Пароль показывается один раз и должен быть сменен студентом при первом входе.
+type ImportRowResult struct {
+	RosterRow
+	Status   ImportStatus `json:"status"`             // результат импорта
+	Errors   []string     `json:"errors,omitempty"`   // ошибки проверки строки
+	UserID   *int         `json:"userID,omitempty"`   // номер созданного или найденного пользователя
+	Login    string       `json:"login,omitempty"`    // сгенерированный логин
+	Password string       `json:"password,omitempty"` // сгенерированный одноразовый пароль
+}
+
+// ImportReport представляет отчет о массовом импорте студентов.
+type ImportReport struct {
+	DryRun   bool              `json:"dryRun"`   // пробный импорт без записи в базу данных
+	Created  int               `json:"created"`  // количество созданных (или подлежащих созданию) студентов
+	Existing int               `json:"existing"` // количество уже существующих студентов
+	Invalid  int               `json:"invalid"`  // количество строк с ошибками
+	Rows     []ImportRowResult `json:"rows"`     // результаты по строкам
+}
@@ -1,14 +1,52 @@
 package model
 
+import (
+	"encoding/json"
+	"time"
+)
+
 // NewUser содержит данные для добавления нового пользователя.
 type NewUser struct {
-	Name       string  `json:"name" validate:"required"`           // имя
-	Surname    string  `json:"surname" validate:"required"`        // фамилия
-	Patronymic *string `json:"patronymic,omitempty"`               // отчество (если имеется)
-	Login      string  `json:"login" validate:"required"`          // имя пользователя
-	Password   string  `json:"password" validate:"required"`       // пароль
-	RoleID     int     `json:"roleID" validate:"required,gte=1"`   // номер роли
-	GroupID    *int    `json:"groupID,omitempty" validate:"gte=1"` // номер группы (должен быть у студента)
+	Name         string  `json:"name" validate:"required"`                           // имя
+	Surname      string  `json:"surname" validate:"required"`                        // фамилия
+	Patronymic   *string `json:"patronymic,omitempty"`                               // отчество (если имеется)
+	Login        string  `json:"login" validate:"required"`                          // имя пользователя
+	Password     string  `json:"password" validate:"required"`                       // пароль
+	RoleID       int     `json:"roleID" validate:"required,gte=1"`                   // номер роли
+	GroupID      *int    `json:"groupID,omitempty" validate:"omitempty,gte=1"`       // номер группы (должен быть у студента)
+	Rank         *string `json:"rank,omitempty" validate:"omitempty,max=30"`         // воинское звание
+	Position     *string `json:"position,omitempty" validate:"omitempty,max=100"`    // должность
+	DepartmentID *int    `json:"departmentID,omitempty" validate:"omitempty,gte=1"`  // номер кафедры (есть у сотрудников)
+	Email        *string `json:"email,omitempty" validate:"omitempty,email,max=100"` // адрес электронной почты
+	Phone        *string `json:"phone,omitempty" validate:"omitempty,e164"`          // номер телефона в формате E.164
+
+	MustChangePassword bool `json:"-"` // пароль одноразовый и должен быть сменен при первом входе
+}
+
+// UserFilter содержит условия поиска пользователей.
+// Незаполненные поля не участвуют в отборе.
+type UserFilter struct {
+	Query        *string `query:"q"`                                       // строка поиска по ФИО, званию, должности и контактам
+	Rank         *string `query:"rank"`                                    // воинское звание
+	RoleID       *int    `query:"roleID" validate:"omitempty,gte=1"`       // номер роли
+	GroupID      *int    `query:"groupID" validate:"omitempty,gte=1"`      // номер взвода
+	DepartmentID *int    `query:"departmentID" validate:"omitempty,gte=1"` // номер кафедры
+	Limit        int     `query:"limit" validate:"gte=0,lte=1000"`         // максимальное количество записей
+	Offset       int     `query:"offset" validate:"gte=0"`                 // количество пропускаемых записей
+}
+
+// ProfileUpdate содержит данные, которые пользователь может изменить в своем профиле.
+// Незаполненные поля не изменяются, пустая строка удаляет значение.
+type ProfileUpdate struct {
+	Email       *string          `json:"email" validate:"omitempty,email,max=100"` // адрес электронной почты
+	Phone       *string          `json:"phone" validate:"omitempty,e164"`          // номер телефона в формате E.164
+	Preferences *json.RawMessage `json:"preferences"`                              // пользовательские настройки клиента в виде json-объекта
+}
+
+// PasswordChange содержит данные для смены пароля пользователем.
+type PasswordChange struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`   // действующий пароль
+	NewPassword     string `json:"newPassword" validate:"required,min=8"` // новый пароль
 }
 
 // Credentials содержит данные для входа в систему.
@@ -29,6 +67,23 @@ type NewGroup struct {
 	SpecialtyID int    `json:"specialtyID" validate:"required,gte=1"` // номер специальности
 }
 
+// GroupFilter содержит условия отбора взводов.
+type GroupFilter struct {
+	Archived bool `query:"archived"` // включать взводы из архива
+}
+
+// NewMaterialType содержит данные для добавления нового вида материалов.
+type NewMaterialType struct {
+	Name string `json:"name" validate:"required,max=50"` // название
+}
+
+// NewMaterial содержит данные для добавления нового материала.
+// При создании материала данные передаются полями формы вместе с файлом.
+type NewMaterial struct {
+	Name   string `json:"name" form:"name" validate:"required,max=50"`    // название
+	TypeID int    `json:"typeID" form:"typeID" validate:"required,gte=1"` // номер вида материала
+}
+
 // NewSpecialty содержит данные для добавления новой специальности.
 type NewSpecialty struct {
 	Name         string `json:"name" validate:"required"`               // название
@@ -44,3 +99,36 @@ type NewDiscipline struct {
 	Name        string `json:"name" validate:"required"`
 	SpecialtyID int    `json:"specialtyID" validate:"required,gte=1"`
 }
+
+// NewAuditEvent содержит данные для добавления новой записи в журнал аудита.
+type NewAuditEvent struct {
+	ActorID  *int            // номер пользователя, выполнившего операцию
+	Action   AuditAction     // вид операции
+	Entity   string          // название сущности
+	EntityID int             // номер сущности
+	Before   json.RawMessage // измененные поля до операции
+	After    json.RawMessage // измененные поля после операции
+	IP       *string         // ip-адрес клиента
+}
+
+// AuditFilter содержит условия отбора записей журнала аудита.
+// Незаполненные поля не участвуют в отборе.
+type AuditFilter struct {
+	ActorID  *int         `query:"actorID" validate:"omitempty,gte=1"`                     // номер пользователя
+	Action   *AuditAction `query:"action" validate:"omitempty,oneof=create update delete"` // вид операции
+	Entity   *string      `query:"entity"`                                                 // название сущности
+	EntityID *int         `query:"entityID" validate:"omitempty,gte=1"`                    // номер сущности
+	From     *time.Time   `query:"from"`                                                   // начало периода
+	To       *time.Time   `query:"to"`                                                     // конец периода
+	Limit    int          `query:"limit" validate:"gte=0,lte=1000"`                        // максимальное количество записей
+	Offset   int          `query:"offset" validate:"gte=0"`                                // количество пропускаемых записей
+}
+
+// JobRunFilter содержит условия отбора записей истории запусков задач обслуживания.
+// Незаполненные поля не участвуют в отборе.
+type JobRunFilter struct {
+	Job    *string `query:"job"`                                                        // название задачи
+	Status *string `query:"status" validate:"omitempty,oneof=running succeeded failed"` // состояние запуска
+	Limit  int     `query:"limit" validate:"gte=0,lte=1000"`                            // максимальное количество записей
+	Offset int     `query:"offset" validate:"gte=0"`                                    // количество пропускаемых записей
+}
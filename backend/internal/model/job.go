@@ -0,0 +1,54 @@
+package model
+
+import "time"
+
+// Способы запуска задачи обслуживания.
+const (
+	JobTriggerSchedule = "schedule" // по расписанию
+	JobTriggerManual   = "manual"   // вручную администратором
+)
+
+// Состояния запуска задачи обслуживания.
+const (
+	JobRunning   = "running"   // выполняется
+	JobSucceeded = "succeeded" // завершился успешно
+	JobFailed    = "failed"    // завершился ошибкой
+)
+
+// Job представляет периодическую задачу обслуживания.
+type Job struct {
+	Name        string     `json:"name"`                // название
+	Description string     `json:"description"`         // описание
+	Interval    string     `json:"interval"`            // период запуска по расписанию
+	LastRun     *JobRun    `json:"lastRun,omitempty"`   // последний запуск
+	NextRunAt   *time.Time `json:"nextRunAt,omitempty"` // время следующего запуска по расписанию, если задача уже запускалась
+}
+
+// JobRun представляет запись истории запусков задачи обслуживания.
+type JobRun struct {
+	ID          int        `json:"jobRunID" db:"job_run_id"`              // номер
+	Job         string     `json:"job" db:"job"`                          // название задачи
+	TriggeredBy string     `json:"triggeredBy" db:"triggered_by"`         // способ запуска: schedule или manual
+	ActorID     *int       `json:"actorID,omitempty" db:"actor_id"`       // номер пользователя, запустившего задачу вручную
+	Status      string     `json:"status" db:"status"`                    // состояние: running, succeeded или failed
+	Affected    int        `json:"affected" db:"affected"`                // количество обработанных записей
+	Error       *string    `json:"error,omitempty" db:"error"`            // текст ошибки, если запуск завершился ошибкой
+	StartedAt   time.Time  `json:"startedAt" db:"started_at"`             // время начала
+	FinishedAt  *time.Time `json:"finishedAt,omitempty" db:"finished_at"` // время окончания
+}
+
+// NewJobRun представляет начало запуска задачи обслуживания.
+type NewJobRun struct {
+	Job         string    // название задачи
+	TriggeredBy string    // способ запуска: schedule или manual
+	ActorID     *int      // номер пользователя, запустившего задачу вручную
+	StartedAt   time.Time // время начала
+}
+
+// JobRunResult представляет итог запуска задачи обслуживания.
+type JobRunResult struct {
+	Status     string    // состояние: succeeded или failed
+	Affected   int       // количество обработанных записей
+	Error      *string   // текст ошибки
+	FinishedAt time.Time // время окончания
+}
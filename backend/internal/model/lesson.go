@@ -1,8 +1,40 @@
 package model
 
+import "time"
+
 // Discipline представляет учебный предмет (дисциплину).
 type Discipline struct {
-	ID          int    `json:"disciplineID" db:"discipline_id"` // номер
-	Name        string `json:"name" db:"name"`                  // название предмета
-	SpecialtyID int    `json:"specialtyID" db:"specialty_id"`   // номер специальности
+	ID          int        `json:"disciplineID" db:"discipline_id"` // номер
+	Name        string     `json:"name" db:"name"`                  // название предмета
+	SpecialtyID int        `json:"specialtyID" db:"specialty_id"`   // номер специальности
+	DeletedAt   *time.Time `json:"-" db:"deleted_at"`               // время удаления в корзину
+	Version     int        `json:"version" db:"version"`            // версия (увеличивается при каждом изменении)
+}
+
+// Lesson представляет занятие раздела предмета.
+type Lesson struct {
+	ID               int        `json:"lessonID" db:"lesson_id"`      // номер
+	Name             *string    `json:"name,omitempty" db:"name"`     // название
+	PlanPath         string     `json:"-" db:"plan_filepath"`         // путь к плану занятия в хранилище
+	CompendiumPath   string     `json:"-" db:"compendium_filepath"`   // путь к конспекту в хранилище
+	PresentationPath string     `json:"-" db:"presentation_filepath"` // путь к презентации в хранилище
+	ChapterID        int        `json:"chapterID" db:"chapter_id"`    // номер раздела
+	TypeID           int        `json:"typeID" db:"type_id"`          // номер вида занятия
+	Materials        []Material `json:"materials" db:"-"`             // дополнительные материалы занятия
+}
+
+// MaterialType представляет вид дополнительного материала (статья, методичка, видео и т.п.).
+type MaterialType struct {
+	ID      int    `json:"typeID" db:"type_id"`  // номер
+	Name    string `json:"name" db:"name"`       // название
+	Version int    `json:"version" db:"version"` // версия (увеличивается при каждом изменении)
+}
+
+// Material представляет дополнительный материал, который прикрепляется к занятиям и книгам.
+type Material struct {
+	ID       int    `json:"materialID" db:"material_id"` // номер
+	Name     string `json:"name" db:"name"`              // название
+	FilePath string `json:"-" db:"filepath"`             // путь к файлу материала в хранилище
+	TypeID   int    `json:"typeID" db:"type_id"`         // номер вида материала
+	Version  int    `json:"version" db:"version"`        // версия (увеличивается при каждом изменении)
 }
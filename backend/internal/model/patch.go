@@ -0,0 +1,129 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// MergePatchContentType — тип содержимого запроса с изменениями в формате JSON Merge Patch (RFC 7396).
+const MergePatchContentType = "application/merge-patch+json"
+
+// PatchField описывает поле патча, которое может отсутствовать в запросе.
+// Незаполненные поля патча не изменяются.
+type PatchField interface {
+	// IsSet сообщает, указано ли поле в патче.
+	IsSet() bool
+
+	// PatchValue возвращает указатель на новое значение поля
+	// или nil, если поле не указано в патче или равно null.
+	PatchValue() any
+}
+
+// Optional представляет поле патча, которое не может быть равно null.
+type Optional[T any] struct {
+	Set   bool // поле указано в патче
+	Value T    // новое значение
+}
+
+// UnmarshalJSON отмечает поле как указанное в патче и декодирует его значение. Значение null запрещено.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return errors.New("null is not allowed")
+	}
+	o.Set = true
+	return json.Unmarshal(data, &o.Value)
+}
+
+// IsSet сообщает, указано ли поле в патче.
+func (o Optional[T]) IsSet() bool {
+	return o.Set
+}
+
+// PatchValue возвращает указатель на новое значение или nil, если поле не указано в патче.
+func (o Optional[T]) PatchValue() any {
+	if !o.Set {
+		return (*T)(nil)
+	}
+	return &o.Value
+}
+
+// Nullable представляет поле патча, значение которого удаляется, если поле равно null.
+type Nullable[T any] struct {
+	Set   bool // поле указано в патче
+	Value *T   // новое значение или nil, если значение удаляется
+}
+
+// UnmarshalJSON отмечает поле как указанное в патче и декодирует его значение; null удаляет значение.
+func (n *Nullable[T]) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	if string(data) == "null" {
+		n.Value = nil
+		return nil
+	}
+	n.Value = new(T)
+	return json.Unmarshal(data, n.Value)
+}
+
+// IsSet сообщает, указано ли поле в патче.
+func (n Nullable[T]) IsSet() bool {
+	return n.Set
+}
+
+// PatchValue возвращает указатель на новое значение или nil, если поле не указано в патче или равно null.
+func (n Nullable[T]) PatchValue() any {
+	return n.Value
+}
+
+// Поля патчей проверяются по тем же правилам, что и данные для создания,
+// но только если поле указано в патче (omitnil). Указатель на новое значение
+// всегда удовлетворяет правилу required, поэтому пустые строки запрещаются правилом min=1.
+
+// DepartmentPatch содержит изменения кафедры.
+type DepartmentPatch struct {
+	Name Optional[string] `json:"name" validate:"omitnil,min=1"` // название
+}
+
+// SpecialtyPatch содержит изменения специальности.
+type SpecialtyPatch struct {
+	Name         Optional[string] `json:"name" validate:"omitnil,min=1"`         // название
+	DepartmentID Optional[int]    `json:"departmentID" validate:"omitnil,gte=1"` // номер кафедры
+}
+
+// GroupPatch содержит изменения взвода.
+type GroupPatch struct {
+	Name        Optional[string] `json:"name" validate:"omitnil,min=1"`        // название
+	SpecialtyID Optional[int]    `json:"specialtyID" validate:"omitnil,gte=1"` // номер специальности
+}
+
+// DisciplinePatch содержит изменения предмета.
+type DisciplinePatch struct {
+	Name        Optional[string] `json:"name" validate:"omitnil,min=1"`        // название
+	SpecialtyID Optional[int]    `json:"specialtyID" validate:"omitnil,gte=1"` // номер специальности
+}
+
+// MaterialTypePatch содержит изменения вида материалов.
+type MaterialTypePatch struct {
+	Name Optional[string] `json:"name" validate:"omitnil,min=1,max=50"` // название
+}
+
+// MaterialPatch содержит изменения названия и вида материала.
+type MaterialPatch struct {
+	Name   Optional[string] `json:"name" validate:"omitnil,min=1,max=50"` // название
+	TypeID Optional[int]    `json:"typeID" validate:"omitnil,gte=1"`      // номер вида материала
+}
+
+// UserPatch содержит изменения пользователя и его данных для входа.
+type UserPatch struct {
+	Name         Optional[string] `json:"name" validate:"omitnil,min=1"`          // имя
+	Surname      Optional[string] `json:"surname" validate:"omitnil,min=1"`       // фамилия
+	Patronymic   Nullable[string] `json:"patronymic"`                             // отчество
+	Login        Optional[string] `json:"login" validate:"omitnil,min=1"`         // имя пользователя
+	Password     Optional[string] `json:"password" validate:"omitnil,min=1"`      // пароль
+	RoleID       Optional[int]    `json:"roleID" validate:"omitnil,gte=1"`        // номер роли
+	GroupID      Nullable[int]    `json:"groupID" validate:"omitnil,gte=1"`       // номер группы
+	Rank         Nullable[string] `json:"rank" validate:"omitnil,max=30"`         // воинское звание
+	Position     Nullable[string] `json:"position" validate:"omitnil,max=100"`    // должность
+	DepartmentID Nullable[int]    `json:"departmentID" validate:"omitnil,gte=1"`  // номер кафедры
+	Email        Nullable[string] `json:"email" validate:"omitnil,email,max=100"` // адрес электронной почты
+	Phone        Nullable[string] `json:"phone" validate:"omitnil,e164"`          // номер телефона в формате E.164
+}
@@ -0,0 +1,24 @@
+package model
+
+// ProblemContentType — тип содержимого ответа с описанием ошибки по RFC 7807.
+const ProblemContentType = "application/problem+json"
+
+// Problem представляет описание ошибки запроса по RFC 7807.
+type Problem struct {
+	Type      string       `json:"type"`                // ссылка на описание вида ошибки
+	Title     string       `json:"title"`               // краткое описание вида ошибки на языке клиента
+	Status    int          `json:"status"`              // http-код ответа
+	Detail    string       `json:"detail,omitempty"`    // подробности ошибки (если имеются)
+	Instance  string       `json:"instance,omitempty"`  // путь запроса, вызвавшего ошибку
+	Code      string       `json:"code"`                // стабильный код ошибки для обработки клиентом
+	RequestID string       `json:"requestID,omitempty"` // номер запроса для поиска в журнале сервера
+	Errors    []FieldError `json:"errors,omitempty"`    // ошибки отдельных полей запроса
+}
+
+// FieldError представляет ошибку значения отдельного поля запроса.
+type FieldError struct {
+	Field   string `json:"field"`           // название поля в запросе
+	Rule    string `json:"rule"`            // нарушенное правило проверки
+	Param   string `json:"param,omitempty"` // параметр правила (если имеется)
+	Message string `json:"message"`         // описание ошибки на языке клиента
+}
@@ -0,0 +1,60 @@
+package model
+
+// Rollover содержит изменения взводов при переходе на новый учебный год.
+// Взвод может участвовать только в одном изменении, кроме взвода, в который
+// переводятся студенты другого взвода: его также можно переименовать.
+type Rollover struct {
+	Promotions []GroupPromotion `json:"promotions" validate:"dive"`     // переименования взводов при переводе на следующий курс
+	Merges     []GroupMerge     `json:"merges" validate:"dive"`         // слияния взводов
+	Archives   []int            `json:"archives" validate:"dive,gte=1"` // номера выпускаемых взводов
+}
+
+// GroupPromotion описывает перевод взвода на следующий курс.
+type GroupPromotion struct {
+	GroupID     int    `json:"groupID" validate:"required,gte=1"`                // номер взвода
+	NewName     string `json:"newName" validate:"required,len=4"`                // новое название взвода
+	SpecialtyID *int   `json:"specialtyID,omitempty" validate:"omitempty,gte=1"` // новый номер специальности (если меняется)
+}
+
+// GroupMerge описывает слияние взводов: студенты исходного взвода переводятся
+// в целевой, а исходный взвод отправляется в архив.
+type GroupMerge struct {
+	SourceID int `json:"sourceID" validate:"required,gte=1"` // номер исходного взвода
+	TargetID int `json:"targetID" validate:"required,gte=1"` // номер целевого взвода
+}
+
+// RolloverPlan представляет предварительный просмотр перехода на новый учебный год.
+// Если в плане есть ошибки, то переход не может быть выполнен.
+type RolloverPlan struct {
+	Promotions []PlannedPromotion `json:"promotions"`       // переименования взводов
+	Merges     []PlannedMerge     `json:"merges"`           // слияния взводов
+	Archives   []PlannedArchive   `json:"archives"`         // выпуски взводов
+	Errors     []string           `json:"errors,omitempty"` // ошибки плана
+}
+
+// PlannedPromotion описывает запланированное переименование взвода.
+type PlannedPromotion struct {
+	Group       Group  `json:"group"`                 // взвод до перехода
+	NewName     string `json:"newName"`               // новое название
+	SpecialtyID *int   `json:"specialtyID,omitempty"` // новый номер специальности
+	Students    int    `json:"students"`              // количество студентов взвода
+}
+
+// PlannedMerge описывает запланированное слияние взводов.
+type PlannedMerge struct {
+	Source   Group `json:"source"`   // исходный взвод
+	Target   Group `json:"target"`   // целевой взвод
+	Students int   `json:"students"` // количество переводимых студентов
+}
+
+// PlannedArchive описывает запланированный выпуск взвода.
+type PlannedArchive struct {
+	Group    Group `json:"group"`    // выпускаемый взвод
+	Students int   `json:"students"` // количество студентов, чьи учетные записи будут отключены
+}
+
+// AppliedRollover описывает изменения, внесенные переходом на новый учебный год.
+type AppliedRollover struct {
+	Groups      []Group // измененные взводы после перехода
+	Deactivated []int   // номера студентов, чьи учетные записи отключены
+}
@@ -20,13 +20,15 @@ type Session struct {
 	ID          int        `json:"sessionID" db:"session_id"`      // номер
 	LoggedInAt  time.Time  `json:"loggedInAt" db:"logged_in_at"`   // время входа в систему
 	LoggedOutAt *time.Time `json:"loggedOutAt" db:"logged_out_at"` // время выхода из системы
+	LastUsedAt  time.Time  `json:"lastUsedAt" db:"last_used_at"`   // время последнего обновления токенов
 	UserID      int        `json:"userID" db:"user_id"`            // номер пользователя
 }
 
 // JWTClaims представляет пользовательскую полезную нагрузку jwt токена.
 type JWTClaims struct {
 	jwt.RegisteredClaims
-	Role UserRole
+	Role               UserRole
+	MustChangePassword bool `json:",omitempty"` // пароль одноразовый: токен действует только для его смены
 }
 
 // UserRole представляет роль пользователя для использования в jwt токенах.
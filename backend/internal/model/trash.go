@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// Названия сущностей, которые удаляются в корзину.
+const (
+	TrashDepartment = "department" // кафедра
+	TrashSpecialty  = "specialty"  // специальность
+	TrashDiscipline = "discipline" // предмет
+	TrashUser       = "user"       // пользователь
+)
+
+// TrashItem представляет запись в корзине.
+type TrashItem struct {
+	Entity    string    `json:"entity" db:"entity"`        // название сущности
+	ID        int       `json:"id" db:"id"`                // номер
+	Name      string    `json:"name" db:"name"`            // название (для пользователя — ФИО)
+	DeletedAt time.Time `json:"deletedAt" db:"deleted_at"` // время удаления в корзину
+}
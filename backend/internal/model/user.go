@@ -1,21 +1,49 @@
 package model
 
+import (
+	"encoding/json"
+	"time"
+)
+
 // User представляет пользователя библиотеки.
 type User struct {
-	ID         int     `json:"userID" db:"user_id"`                  // номер
-	Name       string  `json:"name" db:"name"`                       // имя
-	Surname    string  `json:"surname" db:"surname"`                 // фамилия
-	Patronymic *string `json:"patronymic,omitempty" db:"patronymic"` // отчество (если имеется)
-	RoleID     int     `json:"roleID" db:"role_id"`                  // номер роли
-	GroupID    *int    `json:"groupID,omitempty" db:"group_id"`      // номер взвода (есть у студентов, отсутствует у остальных)
+	ID            int             `json:"userID" db:"user_id"`                         // номер
+	Name          string          `json:"name" db:"name"`                              // имя
+	Surname       string          `json:"surname" db:"surname"`                        // фамилия
+	Patronymic    *string         `json:"patronymic,omitempty" db:"patronymic"`        // отчество (если имеется)
+	RoleID        int             `json:"roleID" db:"role_id"`                         // номер роли
+	GroupID       *int            `json:"groupID,omitempty" db:"group_id"`             // номер взвода (есть у студентов, отсутствует у остальных)
+	Rank          *string         `json:"rank,omitempty" db:"rank"`                    // воинское звание
+	Position      *string         `json:"position,omitempty" db:"position"`            // должность
+	DepartmentID  *int            `json:"departmentID,omitempty" db:"department_id"`   // номер кафедры (есть у сотрудников)
+	Email         *string         `json:"email,omitempty" db:"email"`                  // адрес электронной почты
+	Phone         *string         `json:"phone,omitempty" db:"phone"`                  // номер телефона
+	PhotoPath     *string         `json:"-" db:"photo_filepath"`                       // путь к фотографии профиля в файловом хранилище
+	Preferences   json.RawMessage `json:"preferences" db:"preferences"`                // пользовательские настройки клиента в виде json-объекта
+	DeactivatedAt *time.Time      `json:"deactivatedAt,omitempty" db:"deactivated_at"` // время отключения учетной записи (например, при выпуске)
+	DeletedAt     *time.Time      `json:"-" db:"deleted_at"`                           // время удаления в корзину
+	Version       int             `json:"version" db:"version"`                        // версия (увеличивается при каждом изменении)
+}
+
+// Profile представляет пользователя вместе с названием его роли,
+// а также взводом, специальностью и кафедрой, к которым он относится.
+// Кафедра сотрудника берется из его профиля, кафедра студента — из специальности его взвода.
+type Profile struct {
+	User
+	RoleName           string      `json:"roleName"`             // название роли
+	MustChangePassword bool        `json:"mustChangePassword"`   // пароль одноразовый и должен быть сменен
+	Group              *Group      `json:"group,omitempty"`      // взвод
+	Specialty          *Specialty  `json:"specialty,omitempty"`  // специальность
+	Department         *Department `json:"department,omitempty"` // кафедра
 }
 
 // UserCredentials представляет входные данные пользователя.
 type UserCredentials struct {
-	ID           int    `json:"userCredentialsID" db:"user_credential_id"` // номер
-	Login        string `json:"login" db:"login"`                          // имя пользователя
-	PasswordHash string `json:"passwordHash" db:"password_hash"`           // захэшированный алгоритмом sha-256 пароль
-	UserID       int    `json:"userID" db:"user_id"`                       // номер пользователя
+	ID                 int    `json:"userCredentialsID" db:"user_credential_id"`    // номер
+	Login              string `json:"login" db:"login"`                             // имя пользователя
+	PasswordHash       string `json:"passwordHash" db:"password_hash"`              // захэшированный алгоритмом sha-256 пароль
+	UserID             int    `json:"userID" db:"user_id"`                          // номер пользователя
+	MustChangePassword bool   `json:"mustChangePassword" db:"must_change_password"` // пароль одноразовый и должен быть сменен при входе
 }
 
 // Role представляет роль пользователя.
@@ -26,20 +54,26 @@ type Role struct {
 
 // Group представляет взвод студентов.
 type Group struct {
-	ID          int    `json:"groupID" db:"group_id"`         // номер
-	Name        string `json:"name" db:"name"`                // название группы
-	SpecialtyID int    `json:"specialtyID" db:"specialty_id"` // номер специальности
+	ID          int        `json:"groupID" db:"group_id"`                 // номер
+	Name        string     `json:"name" db:"name"`                        // название группы
+	SpecialtyID int        `json:"specialtyID" db:"specialty_id"`         // номер специальности
+	ArchivedAt  *time.Time `json:"archivedAt,omitempty" db:"archived_at"` // время отправки в архив (при выпуске или слиянии)
+	Version     int        `json:"version" db:"version"`                  // версия (увеличивается при каждом изменении)
 }
 
 // Specialty представляет специальность.
 type Specialty struct {
-	ID           int    `json:"specialtyID" db:"specialty_id"`   // номер
-	Name         string `json:"name" db:"name"`                  // название специальности
-	DepartmentID int    `json:"departmentID" db:"department_id"` // номер кафедры
+	ID           int        `json:"specialtyID" db:"specialty_id"`   // номер
+	Name         string     `json:"name" db:"name"`                  // название специальности
+	DepartmentID int        `json:"departmentID" db:"department_id"` // номер кафедры
+	DeletedAt    *time.Time `json:"-" db:"deleted_at"`               // время удаления в корзину
+	Version      int        `json:"version" db:"version"`            // версия (увеличивается при каждом изменении)
 }
 
 // Department представляет кафедру.
 type Department struct {
-	ID   int    `json:"departmentID" db:"department_id"` // номер
-	Name string `json:"name" db:"name"`                  // название
+	ID        int        `json:"departmentID" db:"department_id"` // номер
+	Name      string     `json:"name" db:"name"`                  // название
+	DeletedAt *time.Time `json:"-" db:"deleted_at"`               // время удаления в корзину
+	Version   int        `json:"version" db:"version"`            // версия (увеличивается при каждом изменении)
 }
@@ -0,0 +1,121 @@
+// Пакет ratelimit ограничивает частоту запросов алгоритмом token bucket.
+//
+// Для каждого ключа (пользователя или ip-адреса) [Limiter] хранит корзину, вмещающую
+// [Limit.Requests] токенов и равномерно пополняющуюся до полной за [Limit.Period].
+// Каждый запрос забирает один токен; если токенов нет, то запрос отклоняется.
+// Таким образом, клиент может сделать до Requests запросов подряд, а дальше —
+// не чаще, чем Requests за Period.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit представляет бюджет запросов.
+type Limit struct {
+	Requests int           // размер корзины: количество запросов за период
+	Period   time.Duration // время, за которое пустая корзина пополняется до полной
+}
+
+// Result представляет решение [Limiter.Allow] о запросе.
+type Result struct {
+	Allowed    bool          // запрос разрешен
+	Limit      int           // размер корзины
+	Remaining  int           // количество запросов, которые еще можно сделать сразу
+	Reset      time.Duration // время, через которое корзина пополнится до полной
+	RetryAfter time.Duration // время, через которое можно повторить отклоненный запрос
+}
+
+// bucket представляет корзину токенов одного ключа.
+type bucket struct {
+	tokens  float64   // количество токенов на момент updated
+	updated time.Time // время последнего запроса
+}
+
+// Limiter ограничивает частоту запросов по ключам. Limiter безопасен для одновременного использования.
+// Корзины, которые успели пополниться до полной, периодически удаляются, чтобы не занимать память.
+type Limiter struct {
+	limit Limit
+	rate  float64 // скорость пополнения корзины в токенах в секунду
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time // время последнего удаления полных корзин
+
+	now func() time.Time
+}
+
+// New возвращает новый экземпляр [Limiter] с бюджетом limit.
+// Если limit.Requests или limit.Period не больше 0, то вызывается паника.
+func New(limit Limit) *Limiter {
+	if limit.Requests <= 0 || limit.Period <= 0 {
+		panic("ratelimit: limit must have positive requests and period")
+	}
+	return &Limiter{
+		limit:   limit,
+		rate:    float64(limit.Requests) / limit.Period.Seconds(),
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Limit возвращает бюджет запросов.
+func (l *Limiter) Limit() Limit {
+	return l.limit
+}
+
+// Allow забирает токен из корзины ключа key, если он есть, и возвращает решение о запросе.
+func (l *Limiter) Allow(key string) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.sweep(now)
+
+	capacity := float64(l.limit.Requests)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity}
+		l.buckets[key] = b
+	} else {
+		b.tokens = l.refill(b, now)
+	}
+	b.updated = now
+
+	result := Result{Limit: l.limit.Requests}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = l.duration(1 - b.tokens)
+	}
+	result.Remaining = int(math.Floor(b.tokens))
+	result.Reset = l.duration(capacity - b.tokens)
+	return result
+}
+
+// refill возвращает количество токенов в корзине b в момент now.
+func (l *Limiter) refill(b *bucket, now time.Time) float64 {
+	elapsed := max(now.Sub(b.updated).Seconds(), 0)
+	return min(float64(l.limit.Requests), b.tokens+elapsed*l.rate)
+}
+
+// duration возвращает время, за которое в корзину добавится tokens токенов.
+func (l *Limiter) duration(tokens float64) time.Duration {
+	return time.Duration(math.Ceil(tokens / l.rate * float64(time.Second)))
+}
+
+// sweep не чаще раза в период удаляет корзины, которые к моменту now пополнились до полной:
+// новая корзина для того же ключа ничем от них не отличается. Вызывается под блокировкой.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.limit.Period {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if l.refill(b, now) >= float64(l.limit.Requests) {
+			delete(l.buckets, key)
+		}
+	}
+}
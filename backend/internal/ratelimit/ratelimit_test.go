@@ -0,0 +1,40 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	now := time.Date(2024, 9, 1, 9, 0, 0, 0, time.UTC)
+	l := New(Limit{Requests: 3, Period: 3 * time.Second})
+	l.now = func() time.Time { return now }
+
+	for i, want := range []int{2, 1, 0} {
+		result := l.Allow("user:1")
+		if !result.Allowed || result.Remaining != want || result.Limit != 3 {
+			t.Fatalf("request %v: got %+v, want allowed with %v remaining", i+1, result, want)
+		}
+	}
+	result := l.Allow("user:1")
+	if result.Allowed || result.Remaining != 0 || result.RetryAfter != time.Second || result.Reset != 3*time.Second {
+		t.Errorf("request over the limit: got %+v", result)
+	}
+	if result := l.Allow("user:2"); !result.Allowed || result.Remaining != 2 {
+		t.Errorf("other key: got %+v, want its own bucket", result)
+	}
+
+	now = now.Add(1500 * time.Millisecond)
+	result = l.Allow("user:1")
+	if !result.Allowed || result.Remaining != 0 || result.Reset != 2500*time.Millisecond {
+		t.Errorf("after partial refill: got %+v", result)
+	}
+
+	now = now.Add(time.Hour)
+	if result := l.Allow("user:1"); !result.Allowed || result.Remaining != 2 {
+		t.Errorf("after full refill: got %+v, want a full bucket", result)
+	}
+	if len(l.buckets) != 1 {
+		t.Errorf("sweep: got %v buckets, want only the last used one", len(l.buckets))
+	}
+}
@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+)
+
+// Количество записей журнала аудита, возвращаемых по умолчанию.
+const defaultAuditLimit = 100
+
+// AuditRepo предоставляет доступ к журналу аудита в хранилище [Store].
+type AuditRepo struct {
+	s *Store
+}
+
+// NewAuditRepo возвращает новый экземпляр [AuditRepo].
+func NewAuditRepo(s *Store) *AuditRepo {
+	return &AuditRepo{s}
+}
+
+// Create сохраняет новую запись журнала аудита и возвращает ее с номером или ошибку.
+func (ar *AuditRepo) Create(ctx context.Context, input *model.NewAuditEvent) (*model.AuditEvent, error) {
+	ar.s.mu.Lock()
+	defer ar.s.mu.Unlock()
+	event := model.AuditEvent{
+		ID:        ar.s.nextID("audit_events"),
+		ActorID:   input.ActorID,
+		Action:    input.Action,
+		Entity:    input.Entity,
+		EntityID:  input.EntityID,
+		Before:    rawJSON(input.Before),
+		After:     rawJSON(input.After),
+		IP:        input.IP,
+		CreatedAt: ar.s.now(),
+	}
+	ar.s.auditEvents = append(ar.s.auditEvents, event)
+	return &event, nil
+}
+
+// GetAll возвращает слайс записей журнала аудита, удовлетворяющих фильтру, или ошибку.
+// Записи упорядочены от новых к старым.
+func (ar *AuditRepo) GetAll(ctx context.Context, filter *model.AuditFilter) ([]model.AuditEvent, error) {
+	ar.s.mu.Lock()
+	defer ar.s.mu.Unlock()
+	events := []model.AuditEvent{}
+	for i := len(ar.s.auditEvents) - 1; i >= 0; i-- {
+		event := ar.s.auditEvents[i]
+		if (filter.ActorID == nil || event.ActorID != nil && *event.ActorID == *filter.ActorID) &&
+			(filter.Action == nil || event.Action == *filter.Action) &&
+			(filter.Entity == nil || event.Entity == *filter.Entity) &&
+			(filter.EntityID == nil || event.EntityID == *filter.EntityID) &&
+			(filter.From == nil || !event.CreatedAt.Before(*filter.From)) &&
+			(filter.To == nil || !event.CreatedAt.After(*filter.To)) {
+			events = append(events, event)
+		}
+	}
+	limit := filter.Limit
+	if limit == 0 {
+		limit = defaultAuditLimit
+	}
+	events = events[min(filter.Offset, len(events)):]
+	return events[:min(limit, len(events))], nil
+}
+
+// rawJSON возвращает копию json или nil для пустого json, как NULL в базе данных.
+func rawJSON(raw json.RawMessage) *json.RawMessage {
+	if len(raw) == 0 {
+		return nil
+	}
+	copied := append(json.RawMessage(nil), raw...)
+	return &copied
+}
@@ -0,0 +1,142 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+)
+
+// CatalogRepo предоставляет доступ к иерархии каталога в хранилище [Store].
+type CatalogRepo struct {
+	s *Store
+}
+
+// NewCatalogRepo возвращает новый экземпляр [CatalogRepo].
+func NewCatalogRepo(s *Store) *CatalogRepo {
+	return &CatalogRepo{s}
+}
+
+// GetTree возвращает дерево каталога глубиной depth уровней, начиная с кафедр, или ошибку.
+// Если counts истинно, то к узлам добавляется количество их дочерних элементов.
+func (cr *CatalogRepo) GetTree(ctx context.Context, depth int, counts bool) ([]model.CatalogDepartment, error) {
+	cr.s.mu.Lock()
+	defer cr.s.mu.Unlock()
+	count := func(n int) *int {
+		if !counts {
+			return nil
+		}
+		return &n
+	}
+
+	tree := make([]model.CatalogDepartment, 0, len(cr.s.departments))
+	for _, department := range sortedByName(cr.s.departments, func(d model.Department) (string, int) { return d.Name, d.ID }) {
+		if department.DeletedAt != nil {
+			continue
+		}
+		node := model.CatalogDepartment{Department: department}
+		var specialties []model.Specialty
+		for _, specialty := range cr.s.specialties {
+			if specialty.DepartmentID == department.ID && specialty.DeletedAt == nil {
+				specialties = append(specialties, specialty)
+			}
+		}
+		node.SpecialtyCount = count(len(specialties))
+		if depth >= model.CatalogSpecialties {
+			for _, specialty := range sortedByName(toMap(specialties, func(s model.Specialty) int { return s.ID }),
+				func(s model.Specialty) (string, int) { return s.Name, s.ID }) {
+				node.Specialties = append(node.Specialties, cr.specialtyNode(specialty, depth, count))
+			}
+		}
+		tree = append(tree, node)
+	}
+	return tree, nil
+}
+
+// specialtyNode возвращает узел специальности со всеми уровнями ниже нее. Вызывается под блокировкой.
+func (cr *CatalogRepo) specialtyNode(specialty model.Specialty, depth int, count func(int) *int) model.CatalogSpecialty {
+	node := model.CatalogSpecialty{Specialty: specialty}
+	var disciplines []model.Discipline
+	for _, discipline := range cr.s.disciplines {
+		if discipline.SpecialtyID == specialty.ID && discipline.DeletedAt == nil {
+			disciplines = append(disciplines, discipline)
+		}
+	}
+	node.DisciplineCount = count(len(disciplines))
+	if depth < model.CatalogDisciplines {
+		return node
+	}
+	for _, discipline := range sortedByName(toMap(disciplines, func(d model.Discipline) int { return d.ID }),
+		func(d model.Discipline) (string, int) { return d.Name, d.ID }) {
+		disciplineNode := model.CatalogDiscipline{Discipline: discipline}
+		var chapters []model.Chapter
+		for _, ID := range sortedKeys(cr.s.chapters) {
+			if chapter := cr.s.chapters[ID]; chapter.DisciplineID == discipline.ID {
+				chapters = append(chapters, chapter)
+			}
+		}
+		slices.SortStableFunc(chapters, func(a, b model.Chapter) int { return cmp.Compare(a.Module, b.Module) })
+		disciplineNode.ChapterCount = count(len(chapters))
+		if depth >= model.CatalogChapters {
+			for _, chapter := range chapters {
+				disciplineNode.Chapters = append(disciplineNode.Chapters, cr.chapterNode(chapter, depth, count))
+			}
+		}
+		node.Disciplines = append(node.Disciplines, disciplineNode)
+	}
+	return node
+}
+
+// chapterNode возвращает узел раздела вместе с его занятиями. Вызывается под блокировкой.
+func (cr *CatalogRepo) chapterNode(chapter model.Chapter, depth int, count func(int) *int) model.CatalogChapter {
+	node := model.CatalogChapter{Chapter: chapter}
+	var lessons []model.CatalogLesson
+	for _, ID := range sortedKeys(cr.s.lessons) {
+		lesson := cr.s.lessons[ID]
+		if lesson.ChapterID != chapter.ID {
+			continue
+		}
+		materials := 0
+		for l := range cr.s.lessonLinks {
+			if l.ownerID == lesson.ID {
+				materials++
+			}
+		}
+		lessons = append(lessons, model.CatalogLesson{
+			ID:            lesson.ID,
+			Name:          lesson.Name,
+			ChapterID:     lesson.ChapterID,
+			TypeID:        lesson.TypeID,
+			MaterialCount: count(materials),
+		})
+	}
+	node.LessonCount = count(len(lessons))
+	if depth >= model.CatalogLessons {
+		node.Lessons = lessons
+	}
+	return node
+}
+
+// toMap возвращает словарь элементов слайса по их номерам.
+func toMap[T any](items []T, id func(T) int) map[int]T {
+	table := make(map[int]T, len(items))
+	for _, item := range items {
+		table[id(item)] = item
+	}
+	return table
+}
+
+// sortedByName возвращает элементы таблицы, упорядоченные по названию и номеру.
+func sortedByName[T any](table map[int]T, key func(T) (string, int)) []T {
+	items := make([]T, 0, len(table))
+	for _, item := range table {
+		items = append(items, item)
+	}
+	slices.SortFunc(items, func(a, b T) int {
+		aName, aID := key(a)
+		bName, bID := key(b)
+		return cmp.Or(cmp.Compare(aName, bName), cmp.Compare(aID, bID))
+	})
+	return items
+}
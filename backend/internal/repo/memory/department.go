@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+)
+
+// DepartmentRepo предоставляет доступ к кафедрам в хранилище [Store].
+type DepartmentRepo struct {
+	s *Store
+}
+
+// NewDepartmentRepo возвращает новый экземпляр [DepartmentRepo].
+func NewDepartmentRepo(s *Store) *DepartmentRepo {
+	return &DepartmentRepo{s}
+}
+
+// Create сохраняет новую кафедру и возвращает ее с номером или ошибку.
+func (dr *DepartmentRepo) Create(ctx context.Context, input *model.NewDepartment) (*model.Department, error) {
+	dr.s.mu.Lock()
+	defer dr.s.mu.Unlock()
+	department := model.Department{ID: dr.s.nextID("departments"), Name: input.Name, Version: 1}
+	dr.s.departments[department.ID] = department
+	return &department, nil
+}
+
+// GetAll возвращает слайс всех кафедр или ошибку.
+func (dr *DepartmentRepo) GetAll(ctx context.Context) ([]model.Department, error) {
+	dr.s.mu.Lock()
+	defer dr.s.mu.Unlock()
+	var departments []model.Department
+	for _, ID := range sortedKeys(dr.s.departments) {
+		if department := dr.s.departments[ID]; department.DeletedAt == nil {
+			departments = append(departments, department)
+		}
+	}
+	return departments, nil
+}
+
+// Get возвращает кафедру по номеру или ошибку.
+// Если кафедра с таким номером не нашлась, то возвращается ошибка [errs.NotFound].
+func (dr *DepartmentRepo) Get(ctx context.Context, ID int) (*model.Department, error) {
+	dr.s.mu.Lock()
+	defer dr.s.mu.Unlock()
+	department, ok := dr.s.departments[ID]
+	if !ok || department.DeletedAt != nil {
+		return nil, fmt.Errorf("get department %v: %w", ID, errs.NotFound)
+	}
+	return &department, nil
+}
+
+// Update обновляет кафедру по номеру и возвращает ее с номером или ошибку.
+// Если кафедра с таким номером не нашлась, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (dr *DepartmentRepo) Update(ctx context.Context, ID, version int, update *model.NewDepartment) (*model.Department, error) {
+	dr.s.mu.Lock()
+	defer dr.s.mu.Unlock()
+	department, ok := dr.s.departments[ID]
+	if !ok || department.DeletedAt != nil {
+		return nil, fmt.Errorf("update department %v: %w", ID, errs.NotFound)
+	}
+	if err := checkVersion(department.Version, version); err != nil {
+		return nil, fmt.Errorf("update department %v: %w", ID, err)
+	}
+	department.Name = update.Name
+	department.Version++
+	dr.s.departments[ID] = department
+	return &department, nil
+}
+
+// Patch изменяет указанные в патче поля кафедры по номеру и возвращает ее с номером или ошибку.
+// Если кафедра с таким номером не нашлась, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (dr *DepartmentRepo) Patch(ctx context.Context, ID, version int, patch *model.DepartmentPatch) (*model.Department, error) {
+	dr.s.mu.Lock()
+	defer dr.s.mu.Unlock()
+	department, ok := dr.s.departments[ID]
+	if !ok || department.DeletedAt != nil {
+		return nil, fmt.Errorf("patch department %v: %w", ID, errs.NotFound)
+	}
+	if err := checkVersion(department.Version, version); err != nil {
+		return nil, fmt.Errorf("patch department %v: %w", ID, err)
+	}
+	patchValue(&department.Name, patch.Name)
+	department.Version++
+	dr.s.departments[ID] = department
+	return &department, nil
+}
+
+// Delete удаляет кафедру по номеру в корзину и возвращает ошибку, если удаления не произошло.
+// Если кафедра с таким номером не нашлась, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (dr *DepartmentRepo) Delete(ctx context.Context, ID, version int) error {
+	dr.s.mu.Lock()
+	defer dr.s.mu.Unlock()
+	department, ok := dr.s.departments[ID]
+	if !ok || department.DeletedAt != nil {
+		return fmt.Errorf("delete department %v: %w", ID, errs.NotFound)
+	}
+	if err := checkVersion(department.Version, version); err != nil {
+		return fmt.Errorf("delete department %v: %w", ID, err)
+	}
+	if err := dr.s.referencedBy("departments", ID, false); err != nil {
+		return fmt.Errorf("delete department %v: %w", ID, err)
+	}
+	deletedAt := dr.s.now()
+	department.DeletedAt = &deletedAt
+	department.Version++
+	dr.s.departments[ID] = department
+	return nil
+}
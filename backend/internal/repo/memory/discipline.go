@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+)
+
+// DisciplineRepo предоставляет доступ к предметам в хранилище [Store].
+type DisciplineRepo struct {
+	s *Store
+}
+
+// NewDisciplineRepo возвращает новый экземпляр [DisciplineRepo].
+func NewDisciplineRepo(s *Store) *DisciplineRepo {
+	return &DisciplineRepo{s}
+}
+
+// Create сохраняет новый предмет и возвращает его с номером или ошибку.
+func (dr *DisciplineRepo) Create(ctx context.Context, input *model.NewDiscipline) (*model.Discipline, error) {
+	dr.s.mu.Lock()
+	defer dr.s.mu.Unlock()
+	_, ok := dr.s.specialties[input.SpecialtyID]
+	if err := checkRef(ok, "disciplines", "specialty_id"); err != nil {
+		return nil, fmt.Errorf("create discipline: %w", err)
+	}
+	discipline := model.Discipline{ID: dr.s.nextID("disciplines"), Name: input.Name, SpecialtyID: input.SpecialtyID, Version: 1}
+	dr.s.disciplines[discipline.ID] = discipline
+	return &discipline, nil
+}
+
+// GetAll возвращает слайс всех предметов или ошибку.
+func (dr *DisciplineRepo) GetAll(ctx context.Context) ([]model.Discipline, error) {
+	dr.s.mu.Lock()
+	defer dr.s.mu.Unlock()
+	var disciplines []model.Discipline
+	for _, ID := range sortedKeys(dr.s.disciplines) {
+		if discipline := dr.s.disciplines[ID]; discipline.DeletedAt == nil {
+			disciplines = append(disciplines, discipline)
+		}
+	}
+	return disciplines, nil
+}
+
+// Get возвращает предмет по номеру или ошибку.
+// Если предмет с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
+func (dr *DisciplineRepo) Get(ctx context.Context, ID int) (*model.Discipline, error) {
+	dr.s.mu.Lock()
+	defer dr.s.mu.Unlock()
+	discipline, ok := dr.s.disciplines[ID]
+	if !ok || discipline.DeletedAt != nil {
+		return nil, fmt.Errorf("get discipline %v: %w", ID, errs.NotFound)
+	}
+	return &discipline, nil
+}
+
+// Update обновляет предмет по номеру и возвращает его с номером или ошибку.
+// Если предмет с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (dr *DisciplineRepo) Update(ctx context.Context, ID, version int, update *model.NewDiscipline) (*model.Discipline, error) {
+	dr.s.mu.Lock()
+	defer dr.s.mu.Unlock()
+	discipline, ok := dr.s.disciplines[ID]
+	if !ok || discipline.DeletedAt != nil {
+		return nil, fmt.Errorf("update discipline %v: %w", ID, errs.NotFound)
+	}
+	if err := checkVersion(discipline.Version, version); err != nil {
+		return nil, fmt.Errorf("update discipline %v: %w", ID, err)
+	}
+	_, ok = dr.s.specialties[update.SpecialtyID]
+	if err := checkRef(ok, "disciplines", "specialty_id"); err != nil {
+		return nil, fmt.Errorf("update discipline %v: %w", ID, err)
+	}
+	discipline.Name = update.Name
+	discipline.SpecialtyID = update.SpecialtyID
+	discipline.Version++
+	dr.s.disciplines[ID] = discipline
+	return &discipline, nil
+}
+
+// Patch изменяет указанные в патче поля предмета по номеру и возвращает его с номером или ошибку.
+// Если предмет с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (dr *DisciplineRepo) Patch(ctx context.Context, ID, version int, patch *model.DisciplinePatch) (*model.Discipline, error) {
+	dr.s.mu.Lock()
+	defer dr.s.mu.Unlock()
+	discipline, ok := dr.s.disciplines[ID]
+	if !ok || discipline.DeletedAt != nil {
+		return nil, fmt.Errorf("patch discipline %v: %w", ID, errs.NotFound)
+	}
+	if err := checkVersion(discipline.Version, version); err != nil {
+		return nil, fmt.Errorf("patch discipline %v: %w", ID, err)
+	}
+	patchValue(&discipline.Name, patch.Name)
+	patchValue(&discipline.SpecialtyID, patch.SpecialtyID)
+	_, ok = dr.s.specialties[discipline.SpecialtyID]
+	if err := checkRef(ok, "disciplines", "specialty_id"); err != nil {
+		return nil, fmt.Errorf("patch discipline %v: %w", ID, err)
+	}
+	discipline.Version++
+	dr.s.disciplines[ID] = discipline
+	return &discipline, nil
+}
+
+// Delete удаляет предмет по номеру в корзину и возвращает ошибку, если удаления не произошло.
+// Если предмет с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (dr *DisciplineRepo) Delete(ctx context.Context, ID, version int) error {
+	dr.s.mu.Lock()
+	defer dr.s.mu.Unlock()
+	discipline, ok := dr.s.disciplines[ID]
+	if !ok || discipline.DeletedAt != nil {
+		return fmt.Errorf("delete discipline %v: %w", ID, errs.NotFound)
+	}
+	if err := checkVersion(discipline.Version, version); err != nil {
+		return fmt.Errorf("delete discipline %v: %w", ID, err)
+	}
+	if err := dr.s.referencedBy("disciplines", ID, false); err != nil {
+		return fmt.Errorf("delete discipline %v: %w", ID, err)
+	}
+	deletedAt := dr.s.now()
+	discipline.DeletedAt = &deletedAt
+	discipline.Version++
+	dr.s.disciplines[ID] = discipline
+	return nil
+}
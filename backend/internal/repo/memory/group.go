@@ -0,0 +1,234 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+)
+
+// GroupRepo предоставляет доступ к взводам в хранилище [Store].
+type GroupRepo struct {
+	s *Store
+}
+
+// NewGroupRepo возвращает новый экземпляр [GroupRepo].
+func NewGroupRepo(s *Store) *GroupRepo {
+	return &GroupRepo{s}
+}
+
+// Create сохраняет новую группу и возвращает группу с номером или ошибку.
+func (gr *GroupRepo) Create(ctx context.Context, input *model.NewGroup) (*model.Group, error) {
+	gr.s.mu.Lock()
+	defer gr.s.mu.Unlock()
+	_, ok := gr.s.specialties[input.SpecialtyID]
+	if err := checkRef(ok, "groups", "specialty_id"); err != nil {
+		return nil, fmt.Errorf("create group: %w", err)
+	}
+	group := model.Group{ID: gr.s.nextID("groups"), Name: input.Name, SpecialtyID: input.SpecialtyID, Version: 1}
+	gr.s.groups[group.ID] = group
+	return &group, nil
+}
+
+// GetAll возвращает слайс групп, удовлетворяющих фильтру, или ошибку.
+// Группы из архива возвращаются, только если это указано в фильтре.
+func (gr *GroupRepo) GetAll(ctx context.Context, filter *model.GroupFilter) ([]model.Group, error) {
+	gr.s.mu.Lock()
+	defer gr.s.mu.Unlock()
+	var groups []model.Group
+	for _, group := range gr.s.groups {
+		if group.ArchivedAt == nil || filter.Archived {
+			groups = append(groups, group)
+		}
+	}
+	slices.SortFunc(groups, func(a, b model.Group) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+	return groups, nil
+}
+
+// Get возвращает группу по номеру или ошибку.
+// Если группа с таким номером не нашлась, то возвращается ошибка [errs.NotFound].
+func (gr *GroupRepo) Get(ctx context.Context, ID int) (*model.Group, error) {
+	gr.s.mu.Lock()
+	defer gr.s.mu.Unlock()
+	group, ok := gr.s.groups[ID]
+	if !ok {
+		return nil, fmt.Errorf("get group %v: %w", ID, errs.NotFound)
+	}
+	return &group, nil
+}
+
+// GetByName возвращает группу не из архива по названию или ошибку.
+// Если группа с таким названием не нашлась, то возвращается ошибка [errs.NotFound].
+func (gr *GroupRepo) GetByName(ctx context.Context, name string) (*model.Group, error) {
+	gr.s.mu.Lock()
+	defer gr.s.mu.Unlock()
+	for _, ID := range sortedKeys(gr.s.groups) {
+		if group := gr.s.groups[ID]; group.Name == name && group.ArchivedAt == nil {
+			return &group, nil
+		}
+	}
+	return nil, fmt.Errorf("get group by name %v: %w", name, errs.NotFound)
+}
+
+// Update обновляет группу по номеру и возвращает обновленную группу с номером или ошибку.
+// Если группа с таким номером не нашлась, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (gr *GroupRepo) Update(ctx context.Context, ID, version int, update *model.NewGroup) (*model.Group, error) {
+	gr.s.mu.Lock()
+	defer gr.s.mu.Unlock()
+	group, ok := gr.s.groups[ID]
+	if !ok {
+		return nil, fmt.Errorf("update group %v: %w", ID, errs.NotFound)
+	}
+	if err := checkVersion(group.Version, version); err != nil {
+		return nil, fmt.Errorf("update group %v: %w", ID, err)
+	}
+	_, ok = gr.s.specialties[update.SpecialtyID]
+	if err := checkRef(ok, "groups", "specialty_id"); err != nil {
+		return nil, fmt.Errorf("update group %v: %w", ID, err)
+	}
+	group.Name = update.Name
+	group.SpecialtyID = update.SpecialtyID
+	group.Version++
+	gr.s.groups[ID] = group
+	return &group, nil
+}
+
+// Patch изменяет указанные в патче поля группы по номеру и возвращает обновленную группу с номером или ошибку.
+// Если группа с таким номером не нашлась, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (gr *GroupRepo) Patch(ctx context.Context, ID, version int, patch *model.GroupPatch) (*model.Group, error) {
+	gr.s.mu.Lock()
+	defer gr.s.mu.Unlock()
+	group, ok := gr.s.groups[ID]
+	if !ok {
+		return nil, fmt.Errorf("patch group %v: %w", ID, errs.NotFound)
+	}
+	if err := checkVersion(group.Version, version); err != nil {
+		return nil, fmt.Errorf("patch group %v: %w", ID, err)
+	}
+	patchValue(&group.Name, patch.Name)
+	patchValue(&group.SpecialtyID, patch.SpecialtyID)
+	_, ok = gr.s.specialties[group.SpecialtyID]
+	if err := checkRef(ok, "groups", "specialty_id"); err != nil {
+		return nil, fmt.Errorf("patch group %v: %w", ID, err)
+	}
+	group.Version++
+	gr.s.groups[ID] = group
+	return &group, nil
+}
+
+// Delete удаляет группу по номеру и возвращает ошибку, если удаления не произошло.
+// Если группа с таким номером не нашлась, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (gr *GroupRepo) Delete(ctx context.Context, ID, version int) error {
+	gr.s.mu.Lock()
+	defer gr.s.mu.Unlock()
+	group, ok := gr.s.groups[ID]
+	if !ok {
+		return fmt.Errorf("delete group %v: %w", ID, errs.NotFound)
+	}
+	if err := checkVersion(group.Version, version); err != nil {
+		return fmt.Errorf("delete group %v: %w", ID, err)
+	}
+	if err := gr.s.referencedBy("groups", ID, true); err != nil {
+		return fmt.Errorf("delete group %v: %w", ID, err)
+	}
+	delete(gr.s.groups, ID)
+	return nil
+}
+
+// ApplyRollover выполняет переход на новый учебный год: переводит студентов сливаемых взводов,
+// переименовывает взводы, отправляет выпускаемые взводы в архив и отключает учетные записи их студентов.
+// Возвращает измененные взводы после перехода и номера отключенных студентов или ошибку.
+// Если версия взвода из versions отличается от указанной или взвод уже находится в архиве,
+// то возвращается ошибка [errs.VersionMismatch] и ничего не изменяется.
+func (gr *GroupRepo) ApplyRollover(ctx context.Context, rollover *model.Rollover, versions map[int]int) (*model.AppliedRollover, error) {
+	gr.s.mu.Lock()
+	defer gr.s.mu.Unlock()
+	for groupID, version := range versions {
+		if group, ok := gr.s.groups[groupID]; !ok || group.Version != version || group.ArchivedAt != nil {
+			return nil, fmt.Errorf("group %v changed: %w", groupID, errs.VersionMismatch)
+		}
+	}
+	now := gr.s.now()
+	archive := func(groupID int) {
+		if group, ok := gr.s.groups[groupID]; ok && group.ArchivedAt == nil {
+			group.ArchivedAt = &now
+			group.Version++
+			gr.s.groups[groupID] = group
+		}
+	}
+
+	for _, merge := range rollover.Merges {
+		for userID, user := range gr.s.users {
+			if user.GroupID != nil && *user.GroupID == merge.SourceID {
+				user.GroupID = &merge.TargetID
+				user.Version++
+				gr.s.users[userID] = user
+			}
+		}
+		archive(merge.SourceID)
+	}
+	for _, promotion := range rollover.Promotions {
+		group, ok := gr.s.groups[promotion.GroupID]
+		if !ok {
+			continue
+		}
+		group.Name = promotion.NewName
+		if promotion.SpecialtyID != nil {
+			group.SpecialtyID = *promotion.SpecialtyID
+		}
+		group.Version++
+		gr.s.groups[group.ID] = group
+	}
+	var deactivated []int
+	for _, groupID := range rollover.Archives {
+		archive(groupID)
+		for userID, user := range gr.s.users {
+			if user.GroupID == nil || *user.GroupID != groupID {
+				continue
+			}
+			if user.DeactivatedAt == nil {
+				user.DeactivatedAt = &now
+				user.Version++
+				gr.s.users[userID] = user
+				deactivated = append(deactivated, userID)
+			}
+			for sessionID, session := range gr.s.sessions {
+				if session.UserID != userID {
+					continue
+				}
+				for tokenID, token := range gr.s.tokens {
+					if token.SessionID == sessionID {
+						delete(gr.s.tokens, tokenID)
+					}
+				}
+				if session.LoggedOutAt == nil {
+					session.LoggedOutAt = &now
+					gr.s.sessions[sessionID] = session
+				}
+			}
+		}
+	}
+	slices.Sort(deactivated)
+	applied := &model.AppliedRollover{Deactivated: deactivated}
+	changed := slices.Clone(rollover.Archives)
+	for _, merge := range rollover.Merges {
+		changed = append(changed, merge.SourceID)
+	}
+	for _, promotion := range rollover.Promotions {
+		changed = append(changed, promotion.GroupID)
+	}
+	for _, groupID := range changed {
+		if group, ok := gr.s.groups[groupID]; ok {
+			applied.Groups = append(applied.Groups, group)
+		}
+	}
+	return applied, nil
+}
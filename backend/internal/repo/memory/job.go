@@ -0,0 +1,164 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+)
+
+// Количество записей истории запусков задач, возвращаемых по умолчанию.
+const defaultJobRunLimit = 100
+
+// JobRepo предоставляет доступ к истории запусков задач обслуживания в хранилище [Store].
+type JobRepo struct {
+	s *Store
+}
+
+// NewJobRepo возвращает новый экземпляр [JobRepo].
+func NewJobRepo(s *Store) *JobRepo {
+	return &JobRepo{s}
+}
+
+// CreateRun сохраняет начало запуска задачи и возвращает запись с номером или ошибку.
+func (jr *JobRepo) CreateRun(ctx context.Context, input *model.NewJobRun) (*model.JobRun, error) {
+	jr.s.mu.Lock()
+	defer jr.s.mu.Unlock()
+	run := model.JobRun{
+		ID:          jr.s.nextID("job_runs"),
+		Job:         input.Job,
+		TriggeredBy: input.TriggeredBy,
+		ActorID:     input.ActorID,
+		Status:      model.JobRunning,
+		StartedAt:   input.StartedAt,
+	}
+	jr.s.jobRuns = append(jr.s.jobRuns, run)
+	return &run, nil
+}
+
+// FinishRun записывает итог запуска с номером ID и возвращает обновленную запись или ошибку.
+// Если запуск не найден, то возвращается ошибка [errs.NotFound].
+func (jr *JobRepo) FinishRun(ctx context.Context, ID int, result *model.JobRunResult) (*model.JobRun, error) {
+	jr.s.mu.Lock()
+	defer jr.s.mu.Unlock()
+	i := slices.IndexFunc(jr.s.jobRuns, func(run model.JobRun) bool { return run.ID == ID })
+	if i < 0 {
+		return nil, errs.NotFound
+	}
+	run := &jr.s.jobRuns[i]
+	finishedAt := result.FinishedAt
+	run.Status, run.Affected, run.Error, run.FinishedAt = result.Status, result.Affected, result.Error, &finishedAt
+	finished := *run
+	return &finished, nil
+}
+
+// GetRuns возвращает слайс записей истории, удовлетворяющих фильтру, от новых к старым или ошибку.
+func (jr *JobRepo) GetRuns(ctx context.Context, filter *model.JobRunFilter) ([]model.JobRun, error) {
+	jr.s.mu.Lock()
+	defer jr.s.mu.Unlock()
+	runs := []model.JobRun{}
+	for _, run := range jr.s.newestRuns() {
+		if (filter.Job == nil || run.Job == *filter.Job) &&
+			(filter.Status == nil || run.Status == *filter.Status) {
+			runs = append(runs, run)
+		}
+	}
+	limit := filter.Limit
+	if limit == 0 {
+		limit = defaultJobRunLimit
+	}
+	runs = runs[min(filter.Offset, len(runs)):]
+	return runs[:min(limit, len(runs))], nil
+}
+
+// GetLastRuns возвращает последний запуск каждой задачи, которая хоть раз запускалась, или ошибку.
+func (jr *JobRepo) GetLastRuns(ctx context.Context) ([]model.JobRun, error) {
+	jr.s.mu.Lock()
+	defer jr.s.mu.Unlock()
+	runs := []model.JobRun{}
+	seen := make(map[string]bool)
+	for _, run := range jr.s.newestRuns() {
+		if !seen[run.Job] {
+			seen[run.Job] = true
+			runs = append(runs, run)
+		}
+	}
+	return runs, nil
+}
+
+// DeleteRunsBefore удаляет завершенные запуски, начавшиеся раньше момента before,
+// и возвращает их количество или ошибку.
+func (jr *JobRepo) DeleteRunsBefore(ctx context.Context, before time.Time) (int, error) {
+	jr.s.mu.Lock()
+	defer jr.s.mu.Unlock()
+	count := len(jr.s.jobRuns)
+	jr.s.jobRuns = slices.DeleteFunc(jr.s.jobRuns, func(run model.JobRun) bool {
+		return run.StartedAt.Before(before) && run.Status != model.JobRunning
+	})
+	return count - len(jr.s.jobRuns), nil
+}
+
+// FailUnfinishedRuns записывает итог result во все незавершенные запуски задачи job
+// и возвращает их количество или ошибку.
+func (jr *JobRepo) FailUnfinishedRuns(ctx context.Context, job string, result *model.JobRunResult) (int, error) {
+	jr.s.mu.Lock()
+	defer jr.s.mu.Unlock()
+	var failed int
+	for i := range jr.s.jobRuns {
+		run := &jr.s.jobRuns[i]
+		if run.Job == job && run.Status == model.JobRunning {
+			finishedAt := result.FinishedAt
+			run.Status, run.Affected, run.Error, run.FinishedAt = result.Status, result.Affected, result.Error, &finishedAt
+			failed++
+		}
+	}
+	return failed, nil
+}
+
+// newestRuns возвращает копию истории запусков от новых к старым. Вызывается под блокировкой.
+func (s *Store) newestRuns() []model.JobRun {
+	runs := slices.Clone(s.jobRuns)
+	slices.SortStableFunc(runs, func(a, b model.JobRun) int {
+		if c := b.StartedAt.Compare(a.StartedAt); c != 0 {
+			return c
+		}
+		return b.ID - a.ID
+	})
+	return runs
+}
+
+// Locker предоставляет блокировки, общие для всех экземпляров [Locker] одного хранилища [Store].
+// Каждый экземпляр представляет отдельного владельца, как экземпляр сервера.
+type Locker struct {
+	s *Store
+}
+
+// NewLocker возвращает новый экземпляр [Locker] — нового владельца блокировок хранилища s.
+func NewLocker(s *Store) *Locker {
+	return &Locker{s}
+}
+
+// TryLock пытается захватить блокировку с названием name, не дожидаясь ее освобождения,
+// и сообщает, удерживает ли ее этот владелец.
+func (l *Locker) TryLock(ctx context.Context, name string) (bool, error) {
+	l.s.mu.Lock()
+	defer l.s.mu.Unlock()
+	owner, ok := l.s.locks[name]
+	if !ok {
+		l.s.locks[name] = l
+		return true, nil
+	}
+	return owner == l, nil
+}
+
+// Unlock освобождает блокировку с названием name, если она удерживается этим владельцем.
+func (l *Locker) Unlock(ctx context.Context, name string) error {
+	l.s.mu.Lock()
+	defer l.s.mu.Unlock()
+	if l.s.locks[name] == l {
+		delete(l.s.locks, name)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+)
+
+// LessonRepo предоставляет доступ к занятиям в хранилище [Store].
+type LessonRepo struct {
+	s *Store
+}
+
+// NewLessonRepo возвращает новый экземпляр [LessonRepo].
+func NewLessonRepo(s *Store) *LessonRepo {
+	return &LessonRepo{s}
+}
+
+// Get возвращает занятие по номеру без материалов или ошибку.
+// Если занятие с таким номером не нашлось, то возвращается ошибка [errs.NotFound].
+func (lr *LessonRepo) Get(ctx context.Context, ID int) (*model.Lesson, error) {
+	lr.s.mu.Lock()
+	defer lr.s.mu.Unlock()
+	lesson, ok := lr.s.lessons[ID]
+	if !ok {
+		return nil, fmt.Errorf("get lesson %v: %w", ID, errs.NotFound)
+	}
+	return &lesson, nil
+}
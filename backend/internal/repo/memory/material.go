@@ -0,0 +1,229 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+)
+
+// MaterialRepo предоставляет доступ к материалам и их связям с занятиями и книгами в хранилище [Store].
+type MaterialRepo struct {
+	s *Store
+}
+
+// NewMaterialRepo возвращает новый экземпляр [MaterialRepo].
+func NewMaterialRepo(s *Store) *MaterialRepo {
+	return &MaterialRepo{s}
+}
+
+// Create сохраняет материал с путем к его файлу и возвращает материал с номером или ошибку.
+func (mr *MaterialRepo) Create(ctx context.Context, input *model.NewMaterial, filePath string) (*model.Material, error) {
+	mr.s.mu.Lock()
+	defer mr.s.mu.Unlock()
+	_, ok := mr.s.materialTypes[input.TypeID]
+	if err := checkRef(ok, "materials", "type_id"); err != nil {
+		return nil, fmt.Errorf("create material: %w", err)
+	}
+	material := model.Material{ID: mr.s.nextID("materials"), Name: input.Name, FilePath: filePath, TypeID: input.TypeID, Version: 1}
+	mr.s.materials[material.ID] = material
+	return &material, nil
+}
+
+// GetAll возвращает слайс всех материалов, упорядоченных по названию, или ошибку.
+func (mr *MaterialRepo) GetAll(ctx context.Context) ([]model.Material, error) {
+	mr.s.mu.Lock()
+	defer mr.s.mu.Unlock()
+	return mr.sorted(func(model.Material) bool { return true }), nil
+}
+
+// Get возвращает материал по номеру или ошибку.
+// Если материал с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
+func (mr *MaterialRepo) Get(ctx context.Context, ID int) (*model.Material, error) {
+	mr.s.mu.Lock()
+	defer mr.s.mu.Unlock()
+	material, ok := mr.s.materials[ID]
+	if !ok {
+		return nil, fmt.Errorf("get material %v: %w", ID, errs.NotFound)
+	}
+	return &material, nil
+}
+
+// Update обновляет название и вид материала по номеру и возвращает материал с номером или ошибку.
+// Если материал с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (mr *MaterialRepo) Update(ctx context.Context, ID, version int, update *model.NewMaterial) (*model.Material, error) {
+	mr.s.mu.Lock()
+	defer mr.s.mu.Unlock()
+	material, ok := mr.s.materials[ID]
+	if !ok {
+		return nil, fmt.Errorf("update material %v: %w", ID, errs.NotFound)
+	}
+	if err := checkVersion(material.Version, version); err != nil {
+		return nil, fmt.Errorf("update material %v: %w", ID, err)
+	}
+	_, ok = mr.s.materialTypes[update.TypeID]
+	if err := checkRef(ok, "materials", "type_id"); err != nil {
+		return nil, fmt.Errorf("update material %v: %w", ID, err)
+	}
+	material.Name = update.Name
+	material.TypeID = update.TypeID
+	material.Version++
+	mr.s.materials[ID] = material
+	return &material, nil
+}
+
+// Patch изменяет указанные в патче название и вид материала по номеру и возвращает материал с номером или ошибку.
+// Если материал с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (mr *MaterialRepo) Patch(ctx context.Context, ID, version int, patch *model.MaterialPatch) (*model.Material, error) {
+	mr.s.mu.Lock()
+	defer mr.s.mu.Unlock()
+	material, ok := mr.s.materials[ID]
+	if !ok {
+		return nil, fmt.Errorf("patch material %v: %w", ID, errs.NotFound)
+	}
+	if err := checkVersion(material.Version, version); err != nil {
+		return nil, fmt.Errorf("patch material %v: %w", ID, err)
+	}
+	patchValue(&material.Name, patch.Name)
+	patchValue(&material.TypeID, patch.TypeID)
+	_, ok = mr.s.materialTypes[material.TypeID]
+	if err := checkRef(ok, "materials", "type_id"); err != nil {
+		return nil, fmt.Errorf("patch material %v: %w", ID, err)
+	}
+	material.Version++
+	mr.s.materials[ID] = material
+	return &material, nil
+}
+
+// UpdateFile заменяет путь к файлу материала по номеру и возвращает материал с номером или ошибку.
+// Если материал с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+func (mr *MaterialRepo) UpdateFile(ctx context.Context, ID int, filePath string) (*model.Material, error) {
+	mr.s.mu.Lock()
+	defer mr.s.mu.Unlock()
+	material, ok := mr.s.materials[ID]
+	if !ok {
+		return nil, fmt.Errorf("update file of material %v: %w", ID, errs.NotFound)
+	}
+	material.FilePath = filePath
+	material.Version++
+	mr.s.materials[ID] = material
+	return &material, nil
+}
+
+// Delete удаляет материал по номеру вместе с его связями с занятиями и книгами.
+// Если материал с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (mr *MaterialRepo) Delete(ctx context.Context, ID, version int) error {
+	mr.s.mu.Lock()
+	defer mr.s.mu.Unlock()
+	material, ok := mr.s.materials[ID]
+	if !ok {
+		return fmt.Errorf("delete material %v: %w", ID, errs.NotFound)
+	}
+	if err := checkVersion(material.Version, version); err != nil {
+		return fmt.Errorf("delete material %v: %w", ID, err)
+	}
+	for _, links := range []map[link]bool{mr.s.lessonLinks, mr.s.bookLinks} {
+		for l := range links {
+			if l.materialID == ID {
+				delete(links, l)
+			}
+		}
+	}
+	delete(mr.s.materials, ID)
+	return nil
+}
+
+// GetByLesson возвращает слайс материалов, прикрепленных к занятию, или ошибку.
+func (mr *MaterialRepo) GetByLesson(ctx context.Context, lessonID int) ([]model.Material, error) {
+	mr.s.mu.Lock()
+	defer mr.s.mu.Unlock()
+	materials := mr.sorted(func(m model.Material) bool { return mr.s.lessonLinks[link{m.ID, lessonID}] })
+	if materials == nil {
+		materials = []model.Material{}
+	}
+	return materials, nil
+}
+
+// GetByBook возвращает слайс материалов, прикрепленных к книге, или ошибку.
+func (mr *MaterialRepo) GetByBook(ctx context.Context, bookID int) ([]model.Material, error) {
+	mr.s.mu.Lock()
+	defer mr.s.mu.Unlock()
+	materials := mr.sorted(func(m model.Material) bool { return mr.s.bookLinks[link{m.ID, bookID}] })
+	if materials == nil {
+		materials = []model.Material{}
+	}
+	return materials, nil
+}
+
+// AttachToLesson прикрепляет материал к занятию. Повторное прикрепление ничего не меняет.
+// Если материал или занятие не нашлись, то возвращается ошибка [errs.NotFound].
+func (mr *MaterialRepo) AttachToLesson(ctx context.Context, materialID, lessonID int) error {
+	mr.s.mu.Lock()
+	defer mr.s.mu.Unlock()
+	_, lessonExists := mr.s.lessons[lessonID]
+	return mr.attach(mr.s.lessonLinks, "lesson", materialID, lessonID, lessonExists)
+}
+
+// DetachFromLesson открепляет материал от занятия.
+// Если материал не был прикреплен к занятию, то возвращается ошибка [errs.NotFound].
+func (mr *MaterialRepo) DetachFromLesson(ctx context.Context, materialID, lessonID int) error {
+	mr.s.mu.Lock()
+	defer mr.s.mu.Unlock()
+	return detach(mr.s.lessonLinks, "lesson", materialID, lessonID)
+}
+
+// AttachToBook прикрепляет материал к книге. Повторное прикрепление ничего не меняет.
+// Если материал или книга не нашлись, то возвращается ошибка [errs.NotFound].
+func (mr *MaterialRepo) AttachToBook(ctx context.Context, materialID, bookID int) error {
+	mr.s.mu.Lock()
+	defer mr.s.mu.Unlock()
+	return mr.attach(mr.s.bookLinks, "book", materialID, bookID, mr.s.books[bookID])
+}
+
+// DetachFromBook открепляет материал от книги.
+// Если материал не был прикреплен к книге, то возвращается ошибка [errs.NotFound].
+func (mr *MaterialRepo) DetachFromBook(ctx context.Context, materialID, bookID int) error {
+	mr.s.mu.Lock()
+	defer mr.s.mu.Unlock()
+	return detach(mr.s.bookLinks, "book", materialID, bookID)
+}
+
+// sorted возвращает материалы, удовлетворяющие условию match, упорядоченные по названию.
+// Вызывается под блокировкой.
+func (mr *MaterialRepo) sorted(match func(model.Material) bool) []model.Material {
+	var materials []model.Material
+	for _, material := range mr.s.materials {
+		if match(material) {
+			materials = append(materials, material)
+		}
+	}
+	slices.SortFunc(materials, func(a, b model.Material) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+	return materials
+}
+
+// attach добавляет связь материала с владельцем в links. Вызывается под блокировкой.
+func (mr *MaterialRepo) attach(links map[link]bool, owner string, materialID, ownerID int, ownerExists bool) error {
+	if _, ok := mr.s.materials[materialID]; !ok || !ownerExists {
+		return fmt.Errorf("attach material %v to %v %v: %w", materialID, owner, ownerID, errs.NotFound)
+	}
+	links[link{materialID, ownerID}] = true
+	return nil
+}
+
+// detach удаляет связь материала с владельцем из links. Вызывается под блокировкой.
+func detach(links map[link]bool, owner string, materialID, ownerID int) error {
+	l := link{materialID, ownerID}
+	if !links[l] {
+		return fmt.Errorf("detach material %v from %v %v: %w", materialID, owner, ownerID, errs.NotFound)
+	}
+	delete(links, l)
+	return nil
+}
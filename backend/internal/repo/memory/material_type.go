@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+)
+
+// MaterialTypeRepo предоставляет доступ к видам материалов в хранилище [Store].
+type MaterialTypeRepo struct {
+	s *Store
+}
+
+// NewMaterialTypeRepo возвращает новый экземпляр [MaterialTypeRepo].
+func NewMaterialTypeRepo(s *Store) *MaterialTypeRepo {
+	return &MaterialTypeRepo{s}
+}
+
+// Create сохраняет вид материалов и возвращает его с номером или ошибку.
+func (mr *MaterialTypeRepo) Create(ctx context.Context, input *model.NewMaterialType) (*model.MaterialType, error) {
+	mr.s.mu.Lock()
+	defer mr.s.mu.Unlock()
+	materialType := model.MaterialType{ID: mr.s.nextID("material_types"), Name: input.Name, Version: 1}
+	mr.s.materialTypes[materialType.ID] = materialType
+	return &materialType, nil
+}
+
+// GetAll возвращает слайс всех видов материалов, упорядоченных по названию, или ошибку.
+func (mr *MaterialTypeRepo) GetAll(ctx context.Context) ([]model.MaterialType, error) {
+	mr.s.mu.Lock()
+	defer mr.s.mu.Unlock()
+	var materialTypes []model.MaterialType
+	for _, materialType := range mr.s.materialTypes {
+		materialTypes = append(materialTypes, materialType)
+	}
+	slices.SortFunc(materialTypes, func(a, b model.MaterialType) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+	return materialTypes, nil
+}
+
+// Get возвращает вид материалов по номеру или ошибку.
+// Если вид материалов с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
+func (mr *MaterialTypeRepo) Get(ctx context.Context, ID int) (*model.MaterialType, error) {
+	mr.s.mu.Lock()
+	defer mr.s.mu.Unlock()
+	materialType, ok := mr.s.materialTypes[ID]
+	if !ok {
+		return nil, fmt.Errorf("get material type %v: %w", ID, errs.NotFound)
+	}
+	return &materialType, nil
+}
+
+// Update обновляет вид материалов по номеру и возвращает его с номером или ошибку.
+// Если вид материалов с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (mr *MaterialTypeRepo) Update(ctx context.Context, ID, version int, update *model.NewMaterialType) (*model.MaterialType, error) {
+	mr.s.mu.Lock()
+	defer mr.s.mu.Unlock()
+	materialType, ok := mr.s.materialTypes[ID]
+	if !ok {
+		return nil, fmt.Errorf("update material type %v: %w", ID, errs.NotFound)
+	}
+	if err := checkVersion(materialType.Version, version); err != nil {
+		return nil, fmt.Errorf("update material type %v: %w", ID, err)
+	}
+	materialType.Name = update.Name
+	materialType.Version++
+	mr.s.materialTypes[ID] = materialType
+	return &materialType, nil
+}
+
+// Patch изменяет указанные в патче поля вида материалов по номеру и возвращает его с номером или ошибку.
+// Если вид материалов с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (mr *MaterialTypeRepo) Patch(ctx context.Context, ID, version int, patch *model.MaterialTypePatch) (*model.MaterialType, error) {
+	mr.s.mu.Lock()
+	defer mr.s.mu.Unlock()
+	materialType, ok := mr.s.materialTypes[ID]
+	if !ok {
+		return nil, fmt.Errorf("patch material type %v: %w", ID, errs.NotFound)
+	}
+	if err := checkVersion(materialType.Version, version); err != nil {
+		return nil, fmt.Errorf("patch material type %v: %w", ID, err)
+	}
+	patchValue(&materialType.Name, patch.Name)
+	materialType.Version++
+	mr.s.materialTypes[ID] = materialType
+	return &materialType, nil
+}
+
+// Delete удаляет вид материалов по номеру и возвращает ошибку, если удаления не произошло.
+// Если вид материалов с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (mr *MaterialTypeRepo) Delete(ctx context.Context, ID, version int) error {
+	mr.s.mu.Lock()
+	defer mr.s.mu.Unlock()
+	materialType, ok := mr.s.materialTypes[ID]
+	if !ok {
+		return fmt.Errorf("delete material type %v: %w", ID, errs.NotFound)
+	}
+	if err := checkVersion(materialType.Version, version); err != nil {
+		return fmt.Errorf("delete material type %v: %w", ID, err)
+	}
+	if err := mr.s.referencedBy("material_types", ID, true); err != nil {
+		return fmt.Errorf("delete material type %v: %w", ID, err)
+	}
+	delete(mr.s.materialTypes, ID)
+	return nil
+}
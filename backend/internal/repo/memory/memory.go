@@ -0,0 +1,230 @@
+// Пакет memory предоставляет репозитории, хранящие данные в памяти процесса.
+// Репозитории реализуют те же интерфейсы пакета service, что и репозитории пакета postgres,
+// и предназначены для тестов и локального запуска без базы данных.
+package memory
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+)
+
+// Названия ролей, которые создаются в новом хранилище.
+var defaultRoles = []string{"student", "teacher", "manager", "admin"}
+
+// Store представляет хранилище всех таблиц в памяти.
+// Репозитории одного хранилища видят общие данные, как таблицы одной базы данных.
+type Store struct {
+	mu sync.Mutex
+
+	ids map[string]int // последние выданные номера по названию таблицы
+
+	roles         map[int]model.Role
+	users         map[int]model.User
+	credentials   map[int]model.UserCredentials // по номеру пользователя
+	sessions      map[int]model.Session
+	tokens        map[int]model.Token
+	departments   map[int]model.Department
+	specialties   map[int]model.Specialty
+	groups        map[int]model.Group
+	disciplines   map[int]model.Discipline
+	chapters      map[int]model.Chapter
+	lessons       map[int]model.Lesson
+	books         map[int]bool
+	materialTypes map[int]model.MaterialType
+	materials     map[int]model.Material
+	lessonLinks   map[link]bool // связи материалов с занятиями
+	bookLinks     map[link]bool // связи материалов с книгами
+	auditEvents   []model.AuditEvent
+	jobRuns       []model.JobRun
+	locks         map[string]*Locker // владельцы захваченных блокировок по их названию
+
+	now func() time.Time
+}
+
+// link представляет связь материала с занятием или книгой.
+type link struct {
+	materialID int
+	ownerID    int
+}
+
+// NewStore возвращает новое пустое хранилище с ролями студента, преподавателя, руководителя и администратора.
+func NewStore() *Store {
+	s := &Store{
+		ids:           make(map[string]int),
+		roles:         make(map[int]model.Role),
+		users:         make(map[int]model.User),
+		credentials:   make(map[int]model.UserCredentials),
+		sessions:      make(map[int]model.Session),
+		tokens:        make(map[int]model.Token),
+		departments:   make(map[int]model.Department),
+		specialties:   make(map[int]model.Specialty),
+		groups:        make(map[int]model.Group),
+		disciplines:   make(map[int]model.Discipline),
+		chapters:      make(map[int]model.Chapter),
+		lessons:       make(map[int]model.Lesson),
+		books:         make(map[int]bool),
+		materialTypes: make(map[int]model.MaterialType),
+		materials:     make(map[int]model.Material),
+		lessonLinks:   make(map[link]bool),
+		bookLinks:     make(map[link]bool),
+		locks:         make(map[string]*Locker),
+		now:           time.Now,
+	}
+	for _, name := range defaultRoles {
+		id := s.nextID("roles")
+		s.roles[id] = model.Role{ID: id, Name: name}
+	}
+	return s
+}
+
+// nextID возвращает следующий номер для таблицы table. Вызывается под блокировкой.
+func (s *Store) nextID(table string) int {
+	s.ids[table]++
+	return s.ids[table]
+}
+
+// AddChapter добавляет раздел предмета и возвращает его с номером.
+// Разделы не имеют собственного репозитория и создаются напрямую для заполнения хранилища.
+func (s *Store) AddChapter(disciplineID, module int) model.Chapter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	chapter := model.Chapter{ID: s.nextID("chapters"), DisciplineID: disciplineID, Module: module}
+	s.chapters[chapter.ID] = chapter
+	return chapter
+}
+
+// AddLesson добавляет занятие и возвращает его с номером.
+// Занятия не имеют собственного репозитория с созданием и добавляются напрямую для заполнения хранилища.
+func (s *Store) AddLesson(lesson model.Lesson) model.Lesson {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lesson.ID = s.nextID("lessons")
+	lesson.Materials = nil
+	s.lessons[lesson.ID] = lesson
+	return lesson
+}
+
+// AddBook добавляет книгу и возвращает ее номер.
+// Хранилище запоминает только существование книги, чтобы к ней можно было прикреплять материалы.
+func (s *Store) AddBook() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.nextID("books")
+	s.books[id] = true
+	return id
+}
+
+// sortedKeys возвращает номера записей таблицы в порядке возрастания.
+func sortedKeys[T any](table map[int]T) []int {
+	keys := make([]int, 0, len(table))
+	for key := range table {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// anyOf сообщает, есть ли в таблице table запись, удовлетворяющая условию match.
+func anyOf[T any](table map[int]T, match func(T) bool) bool {
+	for _, row := range table {
+		if match(row) {
+			return true
+		}
+	}
+	return false
+}
+
+// refersTo возвращает условие для anyOf, истинное для записей, поле field которых равно ID.
+func refersTo[T any](ID int, field func(T) *int) func(T) bool {
+	return func(row T) bool {
+		value := field(row)
+		return value != nil && *value == ID
+	}
+}
+
+// liveRef возвращает ссылку field записи, находящейся в корзине с момента deletedAt,
+// или nil, если запись в корзине, а ссылки из корзины не учитываются.
+func liveRef(field *int, deletedAt *time.Time, withTrash bool) *int {
+	if deletedAt != nil && !withTrash {
+		return nil
+	}
+	return field
+}
+
+// checkVersion возвращает ошибку [errs.VersionMismatch], если версия version отлична от 0
+// и не совпадает с текущей версией записи current.
+func checkVersion(current, version int) error {
+	if version != 0 && version != current {
+		return errs.VersionMismatch
+	}
+	return nil
+}
+
+// patchValue записывает в target новое значение поля патча field, если оно указано.
+func patchValue[T any](target *T, field model.Optional[T]) {
+	if field.Set {
+		*target = field.Value
+	}
+}
+
+// patchNullable записывает в target новое значение поля патча field, если оно указано.
+// Поле, равное null, удаляет значение.
+func patchNullable[T any](target **T, field model.Nullable[T]) {
+	if field.Set {
+		*target = field.Value
+	}
+}
+
+// checkRef возвращает ошибку [errs.InvalidReference] для поля field таблицы table,
+// если запись, на которую оно ссылается, не нашлась.
+func checkRef(found bool, table, field string) error {
+	if found {
+		return nil
+	}
+	return errs.NewConstraintError(errs.InvalidReference, table, field)
+}
+
+// referencedBy возвращает ошибку [errs.ReferencedBy], если на запись таблицы table с номером ID
+// ссылаются записи других таблиц, как это проверяют внешние ключи PostgreSQL.
+// Ссылки из записей в корзине учитываются, только если withTrash истинно. Вызывается под блокировкой.
+func (s *Store) referencedBy(table string, ID int, withTrash bool) error {
+	type reference struct {
+		table, field string
+		found        bool
+	}
+	var references []reference
+	switch table {
+	case "departments":
+		references = []reference{
+			{"specialties", "department_id", anyOf(s.specialties, refersTo(ID, func(sp model.Specialty) *int { return liveRef(&sp.DepartmentID, sp.DeletedAt, withTrash) }))},
+			{"users", "department_id", anyOf(s.users, refersTo(ID, func(u model.User) *int { return liveRef(u.DepartmentID, u.DeletedAt, withTrash) }))},
+		}
+	case "specialties":
+		references = []reference{
+			{"groups", "specialty_id", anyOf(s.groups, refersTo(ID, func(g model.Group) *int { return &g.SpecialtyID }))},
+			{"disciplines", "specialty_id", anyOf(s.disciplines, refersTo(ID, func(d model.Discipline) *int { return liveRef(&d.SpecialtyID, d.DeletedAt, withTrash) }))},
+		}
+	case "groups":
+		references = []reference{
+			{"users", "group_id", anyOf(s.users, refersTo(ID, func(u model.User) *int { return liveRef(u.GroupID, u.DeletedAt, withTrash) }))},
+		}
+	case "disciplines":
+		references = []reference{
+			{"chapters", "discipline_id", anyOf(s.chapters, refersTo(ID, func(c model.Chapter) *int { return &c.DisciplineID }))},
+		}
+	case "material_types":
+		references = []reference{
+			{"materials", "type_id", anyOf(s.materials, refersTo(ID, func(m model.Material) *int { return &m.TypeID }))},
+		}
+	}
+	for _, reference := range references {
+		if reference.found {
+			return errs.NewConstraintError(errs.ReferencedBy, reference.table, reference.field)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+)
+
+// SessionRepo предоставляет доступ к сессиям и токенам обновления в хранилище [Store].
+type SessionRepo struct {
+	s *Store
+}
+
+// NewSessionRepo возвращает новый экземпляр [SessionRepo].
+func NewSessionRepo(s *Store) *SessionRepo {
+	return &SessionRepo{s}
+}
+
+// Create создает новый токен обновления, записывает время начала сессии для пользователя
+// и возвращает токен обновления с номером или ошибку. Если keep больше нуля, то незавершенные сессии
+// пользователя, кроме keep самых новых, завершаются, а их токены обновления удаляются;
+// номера завершенных сессий также возвращаются.
+func (sr *SessionRepo) Create(ctx context.Context, userID int, input *model.NewToken, keep int) (*model.Token, []int, error) {
+	sr.s.mu.Lock()
+	defer sr.s.mu.Unlock()
+	_, ok := sr.s.users[userID]
+	if err := checkRef(ok, "sessions", "user_id"); err != nil {
+		return nil, nil, fmt.Errorf("create session of user %v: %w", userID, err)
+	}
+	now := sr.s.now()
+	session := model.Session{ID: sr.s.nextID("sessions"), UserID: userID, LoggedInAt: now, LastUsedAt: now}
+	sr.s.sessions[session.ID] = session
+	token, err := sr.insertToken(session.ID, input)
+	if err != nil {
+		return nil, nil, err
+	}
+	var ended []int
+	if keep > 0 {
+		ended = sr.endExcessSessions(userID, keep)
+	}
+	return token, ended, nil
+}
+
+// GetUserFromSession возвращает пользователя по номеру его сессии или ошибку.
+func (sr *SessionRepo) GetUserFromSession(ctx context.Context, sessionID int) (*model.User, error) {
+	sr.s.mu.Lock()
+	defer sr.s.mu.Unlock()
+	session, ok := sr.s.sessions[sessionID]
+	if !ok {
+		return nil, fmt.Errorf("get session %v: %w", sessionID, errs.NotFound)
+	}
+	user, ok := sr.s.users[session.UserID]
+	if !ok || user.DeletedAt != nil {
+		return nil, fmt.Errorf("get user %v: %w", session.UserID, errs.NotFound)
+	}
+	return &user, nil
+}
+
+// PopByRefreshToken удаляет токен обновления из хранилища и возвращает всю информацию о нем или ошибку.
+// Если токен не был найден, то возвращается ошибка [errs.NotFound].
+func (sr *SessionRepo) PopByRefreshToken(ctx context.Context, refreshToken string) (*model.Token, error) {
+	sr.s.mu.Lock()
+	defer sr.s.mu.Unlock()
+	for tokenID, token := range sr.s.tokens {
+		if token.RefreshToken == refreshToken {
+			delete(sr.s.tokens, tokenID)
+			return &token, nil
+		}
+	}
+	return nil, fmt.Errorf("pop the refresh token: %w", errs.NotFound)
+}
+
+// GetSession возвращает сессию по ее номеру или ошибку.
+// Если сессия не найдена, то возвращается ошибка [errs.NotFound].
+func (sr *SessionRepo) GetSession(ctx context.Context, sessionID int) (*model.Session, error) {
+	sr.s.mu.Lock()
+	defer sr.s.mu.Unlock()
+	session, ok := sr.s.sessions[sessionID]
+	if !ok {
+		return nil, fmt.Errorf("get session %v: %w", sessionID, errs.NotFound)
+	}
+	return &session, nil
+}
+
+// UpdateRefreshToken создает новый токен обновления для сессии, записывает время ее последнего использования
+// и возвращает токен с номером или ошибку.
+func (sr *SessionRepo) UpdateRefreshToken(ctx context.Context, sessionID int, update *model.NewToken) (*model.Token, error) {
+	sr.s.mu.Lock()
+	defer sr.s.mu.Unlock()
+	session, ok := sr.s.sessions[sessionID]
+	if err := checkRef(ok, "tokens", "session_id"); err != nil {
+		return nil, fmt.Errorf("update token of session %v: %w", sessionID, err)
+	}
+	token, err := sr.insertToken(sessionID, update)
+	if err != nil {
+		return nil, err
+	}
+	session.LastUsedAt = sr.s.now()
+	sr.s.sessions[sessionID] = session
+	return token, nil
+}
+
+// EndSession записывает время окончания сессии и возвращает ошибку, если таковая есть.
+func (sr *SessionRepo) EndSession(ctx context.Context, sessionID int) error {
+	sr.s.mu.Lock()
+	defer sr.s.mu.Unlock()
+	session, ok := sr.s.sessions[sessionID]
+	if !ok {
+		return fmt.Errorf("end session %v: %w", sessionID, errs.NotFound)
+	}
+	now := sr.s.now()
+	session.LoggedOutAt = &now
+	sr.s.sessions[sessionID] = session
+	return nil
+}
+
+// endExcessSessions завершает незавершенные сессии пользователя userID, кроме keep самых новых,
+// удаляет их токены обновления и возвращает номера завершенных сессий. Вызывается под s.mu.
+func (sr *SessionRepo) endExcessSessions(userID int, keep int) []int {
+	var active []model.Session
+	for _, session := range sr.s.sessions {
+		if session.UserID == userID && session.LoggedOutAt == nil {
+			active = append(active, session)
+		}
+	}
+	if len(active) <= keep {
+		return nil
+	}
+	// Сначала самые новые сессии, как в postgres.
+	slices.SortFunc(active, func(a, b model.Session) int {
+		if c := b.LoggedInAt.Compare(a.LoggedInAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.ID, a.ID)
+	})
+	now := sr.s.now()
+	var ended []int
+	for _, session := range active[keep:] {
+		for tokenID, token := range sr.s.tokens {
+			if token.SessionID == session.ID {
+				delete(sr.s.tokens, tokenID)
+			}
+		}
+		session.LoggedOutAt = &now
+		sr.s.sessions[session.ID] = session
+		ended = append(ended, session.ID)
+	}
+	slices.Sort(ended)
+	return ended
+}
+
+// CountActive возвращает количество незавершенных сессий, токен обновления которых
+// действителен в момент now (в секундах unix), или ошибку.
+func (sr *SessionRepo) CountActive(ctx context.Context, now int) (int, error) {
+	sr.s.mu.Lock()
+	defer sr.s.mu.Unlock()
+	active := make(map[int]bool)
+	for _, token := range sr.s.tokens {
+		if session, ok := sr.s.sessions[token.SessionID]; ok && session.LoggedOutAt == nil && token.ExpiresAt > now {
+			active[token.SessionID] = true
+		}
+	}
+	return len(active), nil
+}
+
+// DeleteExpiredTokens удаляет токены обновления, истекшие к моменту now (в секундах unix),
+// и возвращает их количество или ошибку.
+func (sr *SessionRepo) DeleteExpiredTokens(ctx context.Context, now int) (int, error) {
+	sr.s.mu.Lock()
+	defer sr.s.mu.Unlock()
+	var deleted int
+	for tokenID, token := range sr.s.tokens {
+		if token.ExpiresAt <= now {
+			delete(sr.s.tokens, tokenID)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// EndStaleSessions завершает незавершенные сессии, у которых нет токена обновления, действительного
+// в момент now (в секундах unix), которые последний раз использовались раньше момента usedBefore
+// или начались раньше момента startedBefore. Токены завершенных сессий удаляются.
+// Возвращается количество завершенных сессий или ошибка.
+func (sr *SessionRepo) EndStaleSessions(ctx context.Context, now int, usedBefore, startedBefore time.Time) (int, error) {
+	sr.s.mu.Lock()
+	defer sr.s.mu.Unlock()
+	valid := make(map[int]bool)
+	for _, token := range sr.s.tokens {
+		if token.ExpiresAt > now {
+			valid[token.SessionID] = true
+		}
+	}
+	loggedOutAt := sr.s.now()
+	var ended int
+	for sessionID, session := range sr.s.sessions {
+		if session.LoggedOutAt != nil ||
+			(valid[sessionID] && !session.LastUsedAt.Before(usedBefore) && !session.LoggedInAt.Before(startedBefore)) {
+			continue
+		}
+		for tokenID, token := range sr.s.tokens {
+			if token.SessionID == sessionID {
+				delete(sr.s.tokens, tokenID)
+			}
+		}
+		session.LoggedOutAt = &loggedOutAt
+		sr.s.sessions[sessionID] = session
+		ended++
+	}
+	return ended, nil
+}
+
+// insertToken сохраняет токен обновления сессии. Вызывается под блокировкой.
+func (sr *SessionRepo) insertToken(sessionID int, input *model.NewToken) (*model.Token, error) {
+	for _, token := range sr.s.tokens {
+		if token.RefreshToken == input.RefreshToken {
+			return nil, fmt.Errorf("insert the token: %w", errs.NewConstraintError(errs.Conflict, "tokens", "refresh_token"))
+		}
+	}
+	token := model.Token{
+		ID:           sr.s.nextID("tokens"),
+		RefreshToken: input.RefreshToken,
+		ExpiresAt:    input.ExpiresAt,
+		SessionID:    sessionID,
+	}
+	sr.s.tokens[token.ID] = token
+	return &token, nil
+}
@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+)
+
+// SpecialtyRepo предоставляет доступ к специальностям в хранилище [Store].
+type SpecialtyRepo struct {
+	s *Store
+}
+
+// NewSpecialtyRepo возвращает новый экземпляр [SpecialtyRepo].
+func NewSpecialtyRepo(s *Store) *SpecialtyRepo {
+	return &SpecialtyRepo{s}
+}
+
+// Create сохраняет новую специальность и возвращает ее с номером или ошибку.
+func (sr *SpecialtyRepo) Create(ctx context.Context, input *model.NewSpecialty) (*model.Specialty, error) {
+	sr.s.mu.Lock()
+	defer sr.s.mu.Unlock()
+	_, ok := sr.s.departments[input.DepartmentID]
+	if err := checkRef(ok, "specialties", "department_id"); err != nil {
+		return nil, fmt.Errorf("create specialty: %w", err)
+	}
+	specialty := model.Specialty{ID: sr.s.nextID("specialties"), Name: input.Name, DepartmentID: input.DepartmentID, Version: 1}
+	sr.s.specialties[specialty.ID] = specialty
+	return &specialty, nil
+}
+
+// GetAll возвращает слайс всех специальностей или ошибку.
+func (sr *SpecialtyRepo) GetAll(ctx context.Context) ([]model.Specialty, error) {
+	sr.s.mu.Lock()
+	defer sr.s.mu.Unlock()
+	var specialties []model.Specialty
+	for _, ID := range sortedKeys(sr.s.specialties) {
+		if specialty := sr.s.specialties[ID]; specialty.DeletedAt == nil {
+			specialties = append(specialties, specialty)
+		}
+	}
+	return specialties, nil
+}
+
+// Get возвращает специальность по номеру или ошибку.
+// Если специальность с таким номером не нашлась, то возвращается ошибка [errs.NotFound].
+func (sr *SpecialtyRepo) Get(ctx context.Context, ID int) (*model.Specialty, error) {
+	sr.s.mu.Lock()
+	defer sr.s.mu.Unlock()
+	specialty, ok := sr.s.specialties[ID]
+	if !ok || specialty.DeletedAt != nil {
+		return nil, fmt.Errorf("get specialty %v: %w", ID, errs.NotFound)
+	}
+	return &specialty, nil
+}
+
+// Update обновляет специальность по номеру и возвращает ее с номером или ошибку.
+// Если специальность с таким номером не нашлась, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (sr *SpecialtyRepo) Update(ctx context.Context, ID, version int, update *model.NewSpecialty) (*model.Specialty, error) {
+	sr.s.mu.Lock()
+	defer sr.s.mu.Unlock()
+	specialty, ok := sr.s.specialties[ID]
+	if !ok || specialty.DeletedAt != nil {
+		return nil, fmt.Errorf("update specialty %v: %w", ID, errs.NotFound)
+	}
+	if err := checkVersion(specialty.Version, version); err != nil {
+		return nil, fmt.Errorf("update specialty %v: %w", ID, err)
+	}
+	_, ok = sr.s.departments[update.DepartmentID]
+	if err := checkRef(ok, "specialties", "department_id"); err != nil {
+		return nil, fmt.Errorf("update specialty %v: %w", ID, err)
+	}
+	specialty.Name = update.Name
+	specialty.DepartmentID = update.DepartmentID
+	specialty.Version++
+	sr.s.specialties[ID] = specialty
+	return &specialty, nil
+}
+
+// Patch изменяет указанные в патче поля специальности по номеру и возвращает ее с номером или ошибку.
+// Если специальность с таким номером не нашлась, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (sr *SpecialtyRepo) Patch(ctx context.Context, ID, version int, patch *model.SpecialtyPatch) (*model.Specialty, error) {
+	sr.s.mu.Lock()
+	defer sr.s.mu.Unlock()
+	specialty, ok := sr.s.specialties[ID]
+	if !ok || specialty.DeletedAt != nil {
+		return nil, fmt.Errorf("patch specialty %v: %w", ID, errs.NotFound)
+	}
+	if err := checkVersion(specialty.Version, version); err != nil {
+		return nil, fmt.Errorf("patch specialty %v: %w", ID, err)
+	}
+	patchValue(&specialty.Name, patch.Name)
+	patchValue(&specialty.DepartmentID, patch.DepartmentID)
+	_, ok = sr.s.departments[specialty.DepartmentID]
+	if err := checkRef(ok, "specialties", "department_id"); err != nil {
+		return nil, fmt.Errorf("patch specialty %v: %w", ID, err)
+	}
+	specialty.Version++
+	sr.s.specialties[ID] = specialty
+	return &specialty, nil
+}
+
+// Delete удаляет специальность по номеру в корзину и возвращает ошибку, если удаления не произошло.
+// Если специальность с таким номером не нашлась, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (sr *SpecialtyRepo) Delete(ctx context.Context, ID, version int) error {
+	sr.s.mu.Lock()
+	defer sr.s.mu.Unlock()
+	specialty, ok := sr.s.specialties[ID]
+	if !ok || specialty.DeletedAt != nil {
+		return fmt.Errorf("delete specialty %v: %w", ID, errs.NotFound)
+	}
+	if err := checkVersion(specialty.Version, version); err != nil {
+		return fmt.Errorf("delete specialty %v: %w", ID, err)
+	}
+	if err := sr.s.referencedBy("specialties", ID, false); err != nil {
+		return fmt.Errorf("delete specialty %v: %w", ID, err)
+	}
+	deletedAt := sr.s.now()
+	specialty.DeletedAt = &deletedAt
+	specialty.Version++
+	sr.s.specialties[ID] = specialty
+	return nil
+}
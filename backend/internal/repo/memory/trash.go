@@ -0,0 +1,174 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+)
+
+// TrashRepo предоставляет доступ к записям в корзине хранилища [Store].
+type TrashRepo struct {
+	s *Store
+}
+
+// NewTrashRepo возвращает новый экземпляр [TrashRepo].
+func NewTrashRepo(s *Store) *TrashRepo {
+	return &TrashRepo{s}
+}
+
+// GetAll возвращает слайс всех записей в корзине или ошибку.
+// Записи упорядочены от удаленных последними к удаленным первыми.
+func (tr *TrashRepo) GetAll(ctx context.Context) ([]model.TrashItem, error) {
+	tr.s.mu.Lock()
+	defer tr.s.mu.Unlock()
+	var items []model.TrashItem
+	add := func(entity string, ID int, name string, deletedAt *time.Time) {
+		if deletedAt != nil {
+			items = append(items, model.TrashItem{Entity: entity, ID: ID, Name: name, DeletedAt: *deletedAt})
+		}
+	}
+	for _, user := range tr.s.users {
+		name := strings.Join(slices.DeleteFunc([]string{user.Surname, user.Name, deref(user.Patronymic)}, func(s string) bool { return s == "" }), " ")
+		add(model.TrashUser, user.ID, name, user.DeletedAt)
+	}
+	for _, discipline := range tr.s.disciplines {
+		add(model.TrashDiscipline, discipline.ID, discipline.Name, discipline.DeletedAt)
+	}
+	for _, specialty := range tr.s.specialties {
+		add(model.TrashSpecialty, specialty.ID, specialty.Name, specialty.DeletedAt)
+	}
+	for _, department := range tr.s.departments {
+		add(model.TrashDepartment, department.ID, department.Name, department.DeletedAt)
+	}
+	slices.SortFunc(items, func(a, b model.TrashItem) int {
+		return cmp.Or(b.DeletedAt.Compare(a.DeletedAt), cmp.Compare(a.Entity, b.Entity), cmp.Compare(a.ID, b.ID))
+	})
+	return items, nil
+}
+
+// Restore возвращает запись сущности entity с номером ID из корзины.
+// Если записи нет в корзине или сущность не удаляется в корзину, то возвращается ошибка [errs.NotFound].
+// Если запись ссылается на запись, которая сама находится в корзине, то возвращается ошибка [errs.InvalidReference]:
+// сначала нужно восстановить ту запись.
+func (tr *TrashRepo) Restore(ctx context.Context, entity string, ID int) error {
+	tr.s.mu.Lock()
+	defer tr.s.mu.Unlock()
+	if err := tr.checkParents(entity, ID); err != nil {
+		return err
+	}
+	var restored bool
+	switch entity {
+	case model.TrashDepartment:
+		restored = restore(tr.s.departments, ID, func(d *model.Department) (**time.Time, *int) { return &d.DeletedAt, &d.Version })
+	case model.TrashSpecialty:
+		restored = restore(tr.s.specialties, ID, func(s *model.Specialty) (**time.Time, *int) { return &s.DeletedAt, &s.Version })
+	case model.TrashDiscipline:
+		restored = restore(tr.s.disciplines, ID, func(d *model.Discipline) (**time.Time, *int) { return &d.DeletedAt, &d.Version })
+	case model.TrashUser:
+		restored = restore(tr.s.users, ID, func(u *model.User) (**time.Time, *int) { return &u.DeletedAt, &u.Version })
+	}
+	if !restored {
+		return fmt.Errorf("restore %v %v: %w", entity, ID, errs.NotFound)
+	}
+	return nil
+}
+
+// checkParents проверяет, что запись сущности entity с номером ID не ссылается на записи в корзине.
+// Если ссылается, то возвращается [errs.InvalidReference]. Вызывается под блокировкой.
+func (tr *TrashRepo) checkParents(entity string, ID int) error {
+	deletedDepartment := func(departmentID int) bool {
+		department, ok := tr.s.departments[departmentID]
+		return ok && department.DeletedAt != nil
+	}
+	switch entity {
+	case model.TrashSpecialty:
+		if specialty, ok := tr.s.specialties[ID]; ok && deletedDepartment(specialty.DepartmentID) {
+			return fmt.Errorf("%w: %w", errs.NewConstraintError(errs.InvalidReference, "specialties", "department_id"), errs.InTrash)
+		}
+	case model.TrashDiscipline:
+		if discipline, ok := tr.s.disciplines[ID]; ok {
+			if specialty, ok := tr.s.specialties[discipline.SpecialtyID]; ok && specialty.DeletedAt != nil {
+				return fmt.Errorf("%w: %w", errs.NewConstraintError(errs.InvalidReference, "disciplines", "specialty_id"), errs.InTrash)
+			}
+		}
+	case model.TrashUser:
+		if user, ok := tr.s.users[ID]; ok && user.DepartmentID != nil && deletedDepartment(*user.DepartmentID) {
+			return fmt.Errorf("%w: %w", errs.NewConstraintError(errs.InvalidReference, "users", "department_id"), errs.InTrash)
+		}
+	}
+	return nil
+}
+
+// restore возвращает запись таблицы table с номером ID из корзины и увеличивает ее версию.
+// Поля времени удаления и версии записи возвращает fields. Если записи нет в корзине, то возвращается false.
+func restore[T any](table map[int]T, ID int, fields func(*T) (**time.Time, *int)) bool {
+	row, ok := table[ID]
+	if !ok {
+		return false
+	}
+	deletedAt, version := fields(&row)
+	if *deletedAt == nil {
+		return false
+	}
+	*deletedAt = nil
+	*version++
+	table[ID] = row
+	return true
+}
+
+// Purge окончательно удаляет записи, попавшие в корзину раньше момента before, и возвращает их количество или ошибку.
+// Вместе с пользователями удаляются их данные для входа, сессии и токены.
+// Записи, на которые еще ссылаются другие записи, остаются в корзине до удаления ссылающихся записей.
+func (tr *TrashRepo) Purge(ctx context.Context, before time.Time) (int, error) {
+	tr.s.mu.Lock()
+	defer tr.s.mu.Unlock()
+	expired := func(deletedAt *time.Time) bool {
+		return deletedAt != nil && deletedAt.Before(before)
+	}
+
+	purged := 0
+	for ID, user := range tr.s.users {
+		if !expired(user.DeletedAt) {
+			continue
+		}
+		for sessionID, session := range tr.s.sessions {
+			if session.UserID != ID {
+				continue
+			}
+			for tokenID, token := range tr.s.tokens {
+				if token.SessionID == sessionID {
+					delete(tr.s.tokens, tokenID)
+				}
+			}
+			delete(tr.s.sessions, sessionID)
+		}
+		delete(tr.s.credentials, ID)
+		delete(tr.s.users, ID)
+		purged++
+	}
+	for ID, discipline := range tr.s.disciplines {
+		if expired(discipline.DeletedAt) && tr.s.referencedBy("disciplines", ID, true) == nil {
+			delete(tr.s.disciplines, ID)
+			purged++
+		}
+	}
+	for ID, specialty := range tr.s.specialties {
+		if expired(specialty.DeletedAt) && tr.s.referencedBy("specialties", ID, true) == nil {
+			delete(tr.s.specialties, ID)
+			purged++
+		}
+	}
+	for ID, department := range tr.s.departments {
+		if expired(department.DeletedAt) && tr.s.referencedBy("departments", ID, true) == nil {
+			delete(tr.s.departments, ID)
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,470 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+)
+
+// UserRepo предоставляет доступ к пользователям и их данным для входа в хранилище [Store].
+type UserRepo struct {
+	s *Store
+}
+
+// NewUserRepo возвращает новый экземпляр [UserRepo].
+func NewUserRepo(s *Store) *UserRepo {
+	return &UserRepo{s}
+}
+
+// Create сохраняет пользователя и его данные для входа и возвращает пользователя с номером или ошибку.
+func (ur *UserRepo) Create(ctx context.Context, input *model.NewUser) (*model.User, error) {
+	ur.s.mu.Lock()
+	defer ur.s.mu.Unlock()
+	if err := ur.checkLogin(input.Login, 0); err != nil {
+		return nil, fmt.Errorf("create user: %w", err)
+	}
+	if err := ur.checkRefs(input); err != nil {
+		return nil, fmt.Errorf("create user: %w", err)
+	}
+	return ur.insert(input), nil
+}
+
+// CreateBatch сохраняет нескольких пользователей и их данные для входа
+// и возвращает пользователей с номерами в том же порядке или ошибку.
+// Если сохранить не удалось хотя бы одного пользователя, то не сохраняется ни один.
+func (ur *UserRepo) CreateBatch(ctx context.Context, inputs []model.NewUser) ([]model.User, error) {
+	ur.s.mu.Lock()
+	defer ur.s.mu.Unlock()
+	logins := make(map[string]bool, len(inputs))
+	for i := range inputs {
+		if logins[inputs[i].Login] {
+			return nil, fmt.Errorf("user %v: %w", inputs[i].Login, errs.NewConstraintError(errs.Conflict, "users_credentials", "login"))
+		}
+		if err := ur.checkLogin(inputs[i].Login, 0); err != nil {
+			return nil, fmt.Errorf("user %v: %w", inputs[i].Login, err)
+		}
+		if err := ur.checkRefs(&inputs[i]); err != nil {
+			return nil, fmt.Errorf("user %v: %w", inputs[i].Login, err)
+		}
+		logins[inputs[i].Login] = true
+	}
+	users := make([]model.User, 0, len(inputs))
+	for i := range inputs {
+		users = append(users, *ur.insert(&inputs[i]))
+	}
+	return users, nil
+}
+
+// insert сохраняет пользователя и его данные для входа. Вызывается под блокировкой.
+func (ur *UserRepo) insert(input *model.NewUser) *model.User {
+	user := model.User{
+		ID:           ur.s.nextID("users"),
+		Name:         input.Name,
+		Surname:      input.Surname,
+		Patronymic:   input.Patronymic,
+		RoleID:       input.RoleID,
+		GroupID:      input.GroupID,
+		Rank:         input.Rank,
+		Position:     input.Position,
+		DepartmentID: input.DepartmentID,
+		Email:        input.Email,
+		Phone:        input.Phone,
+		Preferences:  json.RawMessage(`{}`),
+		Version:      1,
+	}
+	ur.s.users[user.ID] = user
+	ur.s.credentials[user.ID] = model.UserCredentials{
+		ID:                 ur.s.nextID("users_credentials"),
+		Login:              input.Login,
+		PasswordHash:       input.Password,
+		UserID:             user.ID,
+		MustChangePassword: input.MustChangePassword,
+	}
+	return &user
+}
+
+// checkLogin проверяет, что логин не занят другим пользователем, кроме пользователя с номером exceptID.
+// Если логин занят пользователем в корзине, то ошибка дополняется [errs.InTrash]. Вызывается под блокировкой.
+func (ur *UserRepo) checkLogin(login string, exceptID int) error {
+	for userID, credentials := range ur.s.credentials {
+		if credentials.Login != login || userID == exceptID {
+			continue
+		}
+		err := errs.NewConstraintError(errs.Conflict, "users_credentials", "login")
+		if ur.s.users[userID].DeletedAt != nil {
+			return fmt.Errorf("login %v is taken by a user in the trash: %w: %w", login, err, errs.InTrash)
+		}
+		return fmt.Errorf("login %v is taken: %w", login, err)
+	}
+	return nil
+}
+
+// checkRefs проверяет, что роль, группа и кафедра пользователя существуют. Вызывается под блокировкой.
+func (ur *UserRepo) checkRefs(input *model.NewUser) error {
+	_, ok := ur.s.roles[input.RoleID]
+	if err := checkRef(ok, "users", "role_id"); err != nil {
+		return err
+	}
+	if input.GroupID != nil {
+		_, ok = ur.s.groups[*input.GroupID]
+		if err := checkRef(ok, "users", "group_id"); err != nil {
+			return err
+		}
+	}
+	if input.DepartmentID != nil {
+		_, ok = ur.s.departments[*input.DepartmentID]
+		if err := checkRef(ok, "users", "department_id"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetAll возвращает слайс пользователей, удовлетворяющих фильтру, или ошибку.
+// Пользователи упорядочены по фамилии, имени и отчеству.
+func (ur *UserRepo) GetAll(ctx context.Context, filter *model.UserFilter) ([]model.User, error) {
+	ur.s.mu.Lock()
+	defer ur.s.mu.Unlock()
+	var users []model.User
+	for _, user := range ur.s.users {
+		if user.DeletedAt == nil && matchUser(&user, filter) {
+			users = append(users, user)
+		}
+	}
+	slices.SortFunc(users, func(a, b model.User) int {
+		return cmp.Or(
+			cmp.Compare(a.Surname, b.Surname),
+			cmp.Compare(a.Name, b.Name),
+			cmp.Compare(deref(a.Patronymic), deref(b.Patronymic)),
+			cmp.Compare(a.ID, b.ID),
+		)
+	})
+	if filter.Offset > 0 {
+		users = users[min(filter.Offset, len(users)):]
+	}
+	if filter.Limit > 0 {
+		users = users[:min(filter.Limit, len(users))]
+	}
+	return users, nil
+}
+
+// matchUser проверяет, удовлетворяет ли пользователь фильтру.
+func matchUser(user *model.User, filter *model.UserFilter) bool {
+	if filter.Query != nil && *filter.Query != "" {
+		fields := []string{user.Surname, user.Name, deref(user.Patronymic), deref(user.Rank),
+			deref(user.Position), deref(user.Email), deref(user.Phone)}
+		haystack := strings.ToLower(strings.Join(fields, " "))
+		if !strings.Contains(haystack, strings.ToLower(*filter.Query)) {
+			return false
+		}
+	}
+	return equalPtr(filter.Rank, user.Rank) &&
+		(filter.RoleID == nil || *filter.RoleID == user.RoleID) &&
+		equalPtr(filter.GroupID, user.GroupID) &&
+		equalPtr(filter.DepartmentID, user.DepartmentID)
+}
+
+// GetByID возвращает пользователя по номеру или ошибку.
+// Если пользователь с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
+func (ur *UserRepo) GetByID(ctx context.Context, ID int) (*model.User, error) {
+	ur.s.mu.Lock()
+	defer ur.s.mu.Unlock()
+	user, ok := ur.s.users[ID]
+	if !ok || user.DeletedAt != nil {
+		return nil, fmt.Errorf("get user %v: %w", ID, errs.NotFound)
+	}
+	return &user, nil
+}
+
+// GetCredentialsByLogin возвращает данные пользователя для входа по логину или ошибку.
+// Если пользователь с таким логином не нашелся или находится в корзине, то возвращается ошибка [errs.InvalidLogin].
+func (ur *UserRepo) GetCredentialsByLogin(ctx context.Context, login string) (*model.UserCredentials, error) {
+	ur.s.mu.Lock()
+	defer ur.s.mu.Unlock()
+	for _, credentials := range ur.s.credentials {
+		if credentials.Login == login && ur.s.users[credentials.UserID].DeletedAt == nil {
+			return &credentials, nil
+		}
+	}
+	return nil, fmt.Errorf("get credentials of %v: %w", login, errs.InvalidLogin)
+}
+
+// LoginTaken сообщает, занят ли логин пользователем, в том числе пользователем в корзине, или возвращает ошибку.
+func (ur *UserRepo) LoginTaken(ctx context.Context, login string) (bool, error) {
+	ur.s.mu.Lock()
+	defer ur.s.mu.Unlock()
+	return ur.checkLogin(login, 0) != nil, nil
+}
+
+// GetCredentialsByUserID возвращает данные пользователя для входа по номеру пользователя или ошибку.
+// Если пользователь с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
+func (ur *UserRepo) GetCredentialsByUserID(ctx context.Context, userID int) (*model.UserCredentials, error) {
+	ur.s.mu.Lock()
+	defer ur.s.mu.Unlock()
+	credentials, ok := ur.s.credentials[userID]
+	if !ok {
+		return nil, fmt.Errorf("get credentials of user %v: %w", userID, errs.NotFound)
+	}
+	return &credentials, nil
+}
+
+// UpdatePassword записывает новый хэш пароля пользователя и признак одноразового пароля.
+// Если пользователь с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
+func (ur *UserRepo) UpdatePassword(ctx context.Context, userID int, passwordHash string, mustChange bool) error {
+	ur.s.mu.Lock()
+	defer ur.s.mu.Unlock()
+	credentials, ok := ur.s.credentials[userID]
+	if !ok {
+		return fmt.Errorf("update password of user %v: %w", userID, errs.NotFound)
+	}
+	credentials.PasswordHash = passwordHash
+	credentials.MustChangePassword = mustChange
+	ur.s.credentials[userID] = credentials
+	return nil
+}
+
+// GetRoleByName возвращает роль по ее названию или ошибку.
+// Если роль с таким названием не нашлась, то возвращается ошибка [errs.NotFound].
+func (ur *UserRepo) GetRoleByName(ctx context.Context, name string) (*model.Role, error) {
+	ur.s.mu.Lock()
+	defer ur.s.mu.Unlock()
+	for _, role := range ur.s.roles {
+		if role.Name == name {
+			return &role, nil
+		}
+	}
+	return nil, fmt.Errorf("get role %v: %w", name, errs.NotFound)
+}
+
+// GetRole возвращает название роли пользователя или ошибку.
+// Если пользователь с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
+func (ur *UserRepo) GetRole(ctx context.Context, userID int) (string, error) {
+	ur.s.mu.Lock()
+	defer ur.s.mu.Unlock()
+	user, ok := ur.s.users[userID]
+	if !ok || user.DeletedAt != nil {
+		return "", fmt.Errorf("get role of user %v: %w", userID, errs.NotFound)
+	}
+	role, ok := ur.s.roles[user.RoleID]
+	if !ok {
+		return "", fmt.Errorf("get role %v: %w", user.RoleID, errs.NotFound)
+	}
+	return role.Name, nil
+}
+
+// Update обновляет пользователя и его данные для входа по номеру и возвращает его или ошибку.
+// Если пользователь с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (ur *UserRepo) Update(ctx context.Context, ID, version int, update *model.NewUser) (*model.User, error) {
+	ur.s.mu.Lock()
+	defer ur.s.mu.Unlock()
+	user, ok := ur.s.users[ID]
+	if !ok || user.DeletedAt != nil {
+		return nil, fmt.Errorf("update user %v: %w", ID, errs.NotFound)
+	}
+	if err := checkVersion(user.Version, version); err != nil {
+		return nil, fmt.Errorf("update user %v: %w", ID, err)
+	}
+	if err := ur.checkLogin(update.Login, ID); err != nil {
+		return nil, fmt.Errorf("update user %v: %w", ID, err)
+	}
+	if err := ur.checkRefs(update); err != nil {
+		return nil, fmt.Errorf("update user %v: %w", ID, err)
+	}
+	user.Surname = update.Surname
+	user.Name = update.Name
+	user.Patronymic = update.Patronymic
+	user.RoleID = update.RoleID
+	user.GroupID = update.GroupID
+	user.Rank = update.Rank
+	user.Position = update.Position
+	user.DepartmentID = update.DepartmentID
+	user.Email = update.Email
+	user.Phone = update.Phone
+	user.Version++
+	ur.s.users[ID] = user
+
+	credentials := ur.s.credentials[ID]
+	credentials.Login = update.Login
+	credentials.PasswordHash = update.Password
+	ur.s.credentials[ID] = credentials
+	return &user, nil
+}
+
+// Patch изменяет указанные в патче поля пользователя и его данных для входа по номеру и возвращает его или ошибку.
+// Если пользователь с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (ur *UserRepo) Patch(ctx context.Context, ID, version int, patch *model.UserPatch) (*model.User, error) {
+	ur.s.mu.Lock()
+	defer ur.s.mu.Unlock()
+	user, ok := ur.s.users[ID]
+	if !ok || user.DeletedAt != nil {
+		return nil, fmt.Errorf("patch user %v: %w", ID, errs.NotFound)
+	}
+	if err := checkVersion(user.Version, version); err != nil {
+		return nil, fmt.Errorf("patch user %v: %w", ID, err)
+	}
+	if patch.Login.Set {
+		if err := ur.checkLogin(patch.Login.Value, ID); err != nil {
+			return nil, fmt.Errorf("patch user %v: %w", ID, err)
+		}
+	}
+	patchValue(&user.Surname, patch.Surname)
+	patchValue(&user.Name, patch.Name)
+	patchNullable(&user.Patronymic, patch.Patronymic)
+	patchValue(&user.RoleID, patch.RoleID)
+	patchNullable(&user.GroupID, patch.GroupID)
+	patchNullable(&user.Rank, patch.Rank)
+	patchNullable(&user.Position, patch.Position)
+	patchNullable(&user.DepartmentID, patch.DepartmentID)
+	patchNullable(&user.Email, patch.Email)
+	patchNullable(&user.Phone, patch.Phone)
+	refs := &model.NewUser{RoleID: user.RoleID, GroupID: user.GroupID, DepartmentID: user.DepartmentID}
+	if err := ur.checkRefs(refs); err != nil {
+		return nil, fmt.Errorf("patch user %v: %w", ID, err)
+	}
+	user.Version++
+	ur.s.users[ID] = user
+
+	credentials := ur.s.credentials[ID]
+	patchValue(&credentials.Login, patch.Login)
+	patchValue(&credentials.PasswordHash, patch.Password)
+	ur.s.credentials[ID] = credentials
+	return &user, nil
+}
+
+// Delete удаляет пользователя по номеру в корзину, завершает его сессии и удаляет токены обновления.
+// Данные для входа остаются до окончательного удаления пользователя из корзины.
+// Если пользователь с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (ur *UserRepo) Delete(ctx context.Context, ID, version int) error {
+	ur.s.mu.Lock()
+	defer ur.s.mu.Unlock()
+	user, ok := ur.s.users[ID]
+	if !ok || user.DeletedAt != nil {
+		return fmt.Errorf("delete user %v: %w", ID, errs.NotFound)
+	}
+	if err := checkVersion(user.Version, version); err != nil {
+		return fmt.Errorf("delete user %v: %w", ID, err)
+	}
+	now := ur.s.now()
+	for sessionID, session := range ur.s.sessions {
+		if session.UserID != ID {
+			continue
+		}
+		for tokenID, token := range ur.s.tokens {
+			if token.SessionID == sessionID {
+				delete(ur.s.tokens, tokenID)
+			}
+		}
+		if session.LoggedOutAt == nil {
+			session.LoggedOutAt = &now
+			ur.s.sessions[sessionID] = session
+		}
+	}
+	user.DeletedAt = &now
+	user.Version++
+	ur.s.users[ID] = user
+	return nil
+}
+
+// GetProfile возвращает профиль пользователя по номеру или ошибку.
+// Если пользователь с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
+func (ur *UserRepo) GetProfile(ctx context.Context, ID int) (*model.Profile, error) {
+	ur.s.mu.Lock()
+	defer ur.s.mu.Unlock()
+	user, ok := ur.s.users[ID]
+	if !ok || user.DeletedAt != nil {
+		return nil, fmt.Errorf("get profile of user %v: %w", ID, errs.NotFound)
+	}
+	profile := &model.Profile{
+		User:               user,
+		RoleName:           ur.s.roles[user.RoleID].Name,
+		MustChangePassword: ur.s.credentials[ID].MustChangePassword,
+	}
+	departmentID := user.DepartmentID
+	if user.GroupID != nil {
+		if group, ok := ur.s.groups[*user.GroupID]; ok {
+			profile.Group = &group
+			if specialty, ok := ur.s.specialties[group.SpecialtyID]; ok {
+				profile.Specialty = &specialty
+				if departmentID == nil {
+					departmentID = &specialty.DepartmentID
+				}
+			}
+		}
+	}
+	if departmentID != nil {
+		if department, ok := ur.s.departments[*departmentID]; ok {
+			profile.Department = &department
+		}
+	}
+	return profile, nil
+}
+
+// UpdateProfile обновляет заполненные поля профиля пользователя по номеру и возвращает его или ошибку.
+// Пустая строка удаляет значение поля.
+// Если пользователь с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+func (ur *UserRepo) UpdateProfile(ctx context.Context, ID int, update *model.ProfileUpdate) (*model.User, error) {
+	ur.s.mu.Lock()
+	defer ur.s.mu.Unlock()
+	user, ok := ur.s.users[ID]
+	if !ok || user.DeletedAt != nil {
+		return nil, fmt.Errorf("update profile of user %v: %w", ID, errs.NotFound)
+	}
+	if update.Email != nil {
+		user.Email = nullString(*update.Email)
+	}
+	if update.Phone != nil {
+		user.Phone = nullString(*update.Phone)
+	}
+	if update.Preferences != nil {
+		user.Preferences = *update.Preferences
+	}
+	user.Version++
+	ur.s.users[ID] = user
+	return &user, nil
+}
+
+// UpdatePhoto записывает путь к фотографии профиля пользователя по номеру и возвращает его или ошибку.
+// Если путь равен nil, то фотография удаляется из профиля.
+// Если пользователь с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+func (ur *UserRepo) UpdatePhoto(ctx context.Context, ID int, photoPath *string) (*model.User, error) {
+	ur.s.mu.Lock()
+	defer ur.s.mu.Unlock()
+	user, ok := ur.s.users[ID]
+	if !ok || user.DeletedAt != nil {
+		return nil, fmt.Errorf("update photo of user %v: %w", ID, errs.NotFound)
+	}
+	user.PhotoPath = photoPath
+	user.Version++
+	ur.s.users[ID] = user
+	return &user, nil
+}
+
+// deref возвращает значение строки по указателю или пустую строку.
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// equalPtr проверяет условие фильтра want: незаполненное условие подходит любому значению.
+func equalPtr[T comparable](want, got *T) bool {
+	return want == nil || got != nil && *want == *got
+}
+
+// nullString возвращает nil для пустой строки, как NULLIF в базе данных.
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
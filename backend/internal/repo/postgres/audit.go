@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Количество записей журнала аудита, возвращаемых по умолчанию.
+const defaultAuditLimit = 100
+
+// AuditRepo предоставляет доступ к базе данных журнала аудита.
+type AuditRepo struct {
+	db *sqlx.DB
+}
+
+// NewAuditRepo создает новый экземпляр [AuditRepo].
+func NewAuditRepo(db *sqlx.DB) *AuditRepo {
+	return &AuditRepo{db}
+}
+
+// Create сохраняет новую запись журнала аудита и возвращает ее с номером или ошибку.
+func (ar *AuditRepo) Create(ctx context.Context, input *model.NewAuditEvent) (*model.AuditEvent, error) {
+	event := new(model.AuditEvent)
+	query := `
+		INSERT INTO audit_events (actor_id, action, entity, entity_id, before, after, ip)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING audit_event_id, actor_id, action, entity, entity_id, before, after, ip, created_at
+	`
+	if err := ar.db.GetContext(ctx, event, query,
+		input.ActorID, input.Action, input.Entity, input.EntityID, nullJSON(input.Before), nullJSON(input.After), input.IP); err != nil {
+		return nil, fmt.Errorf("INSERT audit event: %w: %w", errs.Internal, err)
+	}
+	return event, nil
+}
+
+// GetAll возвращает слайс записей журнала аудита, удовлетворяющих фильтру, или ошибку.
+// Записи упорядочены от новых к старым.
+func (ar *AuditRepo) GetAll(ctx context.Context, filter *model.AuditFilter) ([]model.AuditEvent, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.ActorID != nil {
+		addCondition("actor_id = $%d", *filter.ActorID)
+	}
+	if filter.Action != nil {
+		addCondition("action = $%d", *filter.Action)
+	}
+	if filter.Entity != nil {
+		addCondition("entity = $%d", *filter.Entity)
+	}
+	if filter.EntityID != nil {
+		addCondition("entity_id = $%d", *filter.EntityID)
+	}
+	if filter.From != nil {
+		addCondition("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("created_at <= $%d", *filter.To)
+	}
+
+	query := `SELECT * FROM audit_events`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	limit := filter.Limit
+	if limit == 0 {
+		limit = defaultAuditLimit
+	}
+	args = append(args, limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY created_at DESC, audit_event_id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	events := []model.AuditEvent{}
+	if err := ar.db.SelectContext(ctx, &events, query, args...); err != nil {
+		return nil, fmt.Errorf("SELECT audit events: %w: %w", errs.Internal, err)
+	}
+	return events, nil
+}
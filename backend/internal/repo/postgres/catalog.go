@@ -0,0 +1,172 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// CatalogRepo предоставляет доступ к иерархии каталога:
+// кафедры, специальности, предметы, разделы и занятия.
+type CatalogRepo struct {
+	db *sqlx.DB
+}
+
+// NewCatalogRepo возвращает новый экземпляр [CatalogRepo].
+func NewCatalogRepo(db *sqlx.DB) *CatalogRepo {
+	return &CatalogRepo{db}
+}
+
+// Строки уровней каталога вместе с количеством дочерних элементов.
+type (
+	catalogDepartmentRow struct {
+		model.Department
+		ChildCount int `db:"child_count"`
+	}
+	catalogSpecialtyRow struct {
+		model.Specialty
+		ChildCount int `db:"child_count"`
+	}
+	catalogDisciplineRow struct {
+		model.Discipline
+		ChildCount int `db:"child_count"`
+	}
+	catalogChapterRow struct {
+		model.Chapter
+		ChildCount int `db:"child_count"`
+	}
+	catalogLessonRow struct {
+		model.CatalogLesson
+		ChildCount int `db:"child_count"`
+	}
+)
+
+// Запросы уровней каталога. Каждый уровень читается одним запросом
+// с подсчетом дочерних элементов, поэтому число запросов не зависит от размера каталога.
+const (
+	catalogDepartmentsQuery = `
+		SELECT d.*, COUNT(s.specialty_id) AS child_count
+		FROM departments d
+		LEFT JOIN specialties s ON s.department_id = d.department_id AND s.deleted_at IS NULL
+		WHERE d.deleted_at IS NULL
+		GROUP BY d.department_id
+		ORDER BY d.name, d.department_id
+	`
+	catalogSpecialtiesQuery = `
+		SELECT s.*, COUNT(d.discipline_id) AS child_count
+		FROM specialties s
+		LEFT JOIN disciplines d ON d.specialty_id = s.specialty_id AND d.deleted_at IS NULL
+		WHERE s.deleted_at IS NULL
+		GROUP BY s.specialty_id
+		ORDER BY s.name, s.specialty_id
+	`
+	catalogDisciplinesQuery = `
+		SELECT d.*, COUNT(c.chapter_id) AS child_count
+		FROM disciplines d
+		LEFT JOIN chapters c USING(discipline_id)
+		WHERE d.deleted_at IS NULL
+		GROUP BY d.discipline_id
+		ORDER BY d.name, d.discipline_id
+	`
+	catalogChaptersQuery = `
+		SELECT c.*, COUNT(l.lesson_id) AS child_count
+		FROM chapters c
+		LEFT JOIN lessons l USING(chapter_id)
+		GROUP BY c.chapter_id
+		ORDER BY c.module, c.chapter_id
+	`
+	catalogLessonsQuery = `
+		SELECT l.lesson_id, l.name, l.chapter_id, l.type_id, COUNT(lm.material_id) AS child_count
+		FROM lessons l
+		LEFT JOIN lesson_materials lm USING(lesson_id)
+		GROUP BY l.lesson_id
+		ORDER BY l.lesson_id
+	`
+)
+
+// GetTree возвращает дерево каталога глубиной depth уровней, начиная с кафедр, или ошибку.
+// Если counts истинно, то к узлам добавляется количество их дочерних элементов.
+func (cr *CatalogRepo) GetTree(ctx context.Context, depth int, counts bool) ([]model.CatalogDepartment, error) {
+	var departments []catalogDepartmentRow
+	if err := cr.db.SelectContext(ctx, &departments, catalogDepartmentsQuery); err != nil {
+		return nil, fmt.Errorf("SELECT catalog departments: %w: %w", errs.Internal, err)
+	}
+	var specialties []catalogSpecialtyRow
+	if depth >= model.CatalogSpecialties {
+		if err := cr.db.SelectContext(ctx, &specialties, catalogSpecialtiesQuery); err != nil {
+			return nil, fmt.Errorf("SELECT catalog specialties: %w: %w", errs.Internal, err)
+		}
+	}
+	var disciplines []catalogDisciplineRow
+	if depth >= model.CatalogDisciplines {
+		if err := cr.db.SelectContext(ctx, &disciplines, catalogDisciplinesQuery); err != nil {
+			return nil, fmt.Errorf("SELECT catalog disciplines: %w: %w", errs.Internal, err)
+		}
+	}
+	var chapters []catalogChapterRow
+	if depth >= model.CatalogChapters {
+		if err := cr.db.SelectContext(ctx, &chapters, catalogChaptersQuery); err != nil {
+			return nil, fmt.Errorf("SELECT catalog chapters: %w: %w", errs.Internal, err)
+		}
+	}
+	var lessons []catalogLessonRow
+	if depth >= model.CatalogLessons {
+		if err := cr.db.SelectContext(ctx, &lessons, catalogLessonsQuery); err != nil {
+			return nil, fmt.Errorf("SELECT catalog lessons: %w: %w", errs.Internal, err)
+		}
+	}
+
+	count := func(n int) *int {
+		if !counts {
+			return nil
+		}
+		return &n
+	}
+
+	lessonsByChapter := make(map[int][]model.CatalogLesson)
+	for _, row := range lessons {
+		lesson := row.CatalogLesson
+		lesson.MaterialCount = count(row.ChildCount)
+		lessonsByChapter[lesson.ChapterID] = append(lessonsByChapter[lesson.ChapterID], lesson)
+	}
+	chaptersByDiscipline := make(map[int][]model.CatalogChapter)
+	for _, row := range chapters {
+		chapter := model.CatalogChapter{
+			Chapter:     row.Chapter,
+			LessonCount: count(row.ChildCount),
+			Lessons:     lessonsByChapter[row.ID],
+		}
+		chaptersByDiscipline[row.DisciplineID] = append(chaptersByDiscipline[row.DisciplineID], chapter)
+	}
+	disciplinesBySpecialty := make(map[int][]model.CatalogDiscipline)
+	for _, row := range disciplines {
+		discipline := model.CatalogDiscipline{
+			Discipline:   row.Discipline,
+			ChapterCount: count(row.ChildCount),
+			Chapters:     chaptersByDiscipline[row.ID],
+		}
+		disciplinesBySpecialty[row.SpecialtyID] = append(disciplinesBySpecialty[row.SpecialtyID], discipline)
+	}
+	specialtiesByDepartment := make(map[int][]model.CatalogSpecialty)
+	for _, row := range specialties {
+		specialty := model.CatalogSpecialty{
+			Specialty:       row.Specialty,
+			DisciplineCount: count(row.ChildCount),
+			Disciplines:     disciplinesBySpecialty[row.ID],
+		}
+		specialtiesByDepartment[row.DepartmentID] = append(specialtiesByDepartment[row.DepartmentID], specialty)
+	}
+	tree := make([]model.CatalogDepartment, 0, len(departments))
+	for _, row := range departments {
+		tree = append(tree, model.CatalogDepartment{
+			Department:     row.Department,
+			SpecialtyCount: count(row.ChildCount),
+			Specialties:    specialtiesByDepartment[row.ID],
+		})
+	}
+	return tree, nil
+}
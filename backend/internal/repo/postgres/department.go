@@ -28,10 +28,10 @@ func (dr *DepartmentRepo) Create(ctx context.Context, input *model.NewDepartment
 	query := `
 		INSERT INTO departments (name)
 		VALUES ($1)
-		RETURNING department_id, name 
+		RETURNING department_id, name, version
 	`
 	if err := dr.db.GetContext(ctx, department, query, input.Name); err != nil {
-		return nil, fmt.Errorf("INSERT department: %w: %w", errs.Internal, err)
+		return nil, fmt.Errorf("INSERT department: %w", dbError(err, "departments"))
 	}
 	return department, nil
 }
@@ -40,7 +40,7 @@ func (dr *DepartmentRepo) Create(ctx context.Context, input *model.NewDepartment
 // Если база данных пуста, то возвращается ошибка [errs.Empty].
 func (dr *DepartmentRepo) GetAll(ctx context.Context) ([]model.Department, error) {
 	var departments []model.Department
-	query := `SELECT * FROM departments`
+	query := `SELECT * FROM departments WHERE deleted_at IS NULL`
 	if err := dr.db.SelectContext(ctx, &departments, query); err != nil {
 		baseErr := errs.Internal
 		if errors.Is(err, sql.ErrNoRows) {
@@ -55,7 +55,7 @@ func (dr *DepartmentRepo) GetAll(ctx context.Context) ([]model.Department, error
 // Если кафедра с таким номером не нашлась, то возвращается ошибка [errs.NotFound].
 func (dr *DepartmentRepo) Get(ctx context.Context, ID int) (*model.Department, error) {
 	department := new(model.Department)
-	query := `SELECT * FROM departments WHERE department_id = $1`
+	query := `SELECT * FROM departments WHERE department_id = $1 AND deleted_at IS NULL`
 	if err := dr.db.GetContext(ctx, department, query, ID); err != nil {
 		baseErr := errs.Internal
 		if errors.Is(err, sql.ErrNoRows) {
@@ -68,27 +68,42 @@ func (dr *DepartmentRepo) Get(ctx context.Context, ID int) (*model.Department, e
 
 // Update обновляет кафедру по номеру и возвращает обновленную кафедру с номером или ошибку.
 // Если кафедра с таким номером не нашлась, то возращается ошибка [errs.NotFound].
-func (dr *DepartmentRepo) Update(ctx context.Context, ID int, update *model.NewDepartment) (*model.Department, error) {
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (dr *DepartmentRepo) Update(ctx context.Context, ID, version int, update *model.NewDepartment) (*model.Department, error) {
 	department := new(model.Department)
 	query := `
 		UPDATE departments
-		SET name = $1
-		WHERE department_id = $2
-		RETURNING department_id, name
+		SET name = $1,
+			version = version + 1
+		WHERE department_id = $2 AND ($3 = 0 OR version = $3) AND deleted_at IS NULL
+		RETURNING department_id, name, version
 	`
-	if err := dr.db.GetContext(ctx, department, query, update.Name, ID); err != nil {
-		return nil, fmt.Errorf("UPDATE department: %w: %w", errs.NotFound, err)
+	if err := dr.db.GetContext(ctx, department, query, update.Name, ID, version); err != nil {
+		return nil, fmt.Errorf("UPDATE department: %w", updateError(ctx, dr.db, err, "departments", "department_id", ID, version))
 	}
 	return department, nil
 }
 
-// Delete удаляет кафедру по номеру и возвращает ошибку, если удаления не произошло.
+// Patch изменяет указанные в патче поля кафедры по номеру и возвращает обновленную кафедру или ошибку.
 // Если кафедра с таким номером не нашлась, то возращается ошибка [errs.NotFound].
-func (dr *DepartmentRepo) Delete(ctx context.Context, ID int) error {
-	query := `DELETE FROM departments WHERE department_id = $1 CASCADE`
-	_, err := dr.db.ExecContext(ctx, query, ID)
-	if err != nil {
-		return fmt.Errorf("DELETE department: %w: %w", errs.NotFound, err)
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (dr *DepartmentRepo) Patch(ctx context.Context, ID, version int, patch *model.DepartmentPatch) (*model.Department, error) {
+	set := new(patchSet)
+	set.add("name", patch.Name)
+	query, args := set.update("departments", "department_id", "department_id, name, version", ID, version)
+	department := new(model.Department)
+	if err := dr.db.GetContext(ctx, department, query, args...); err != nil {
+		return nil, fmt.Errorf("UPDATE department: %w", updateError(ctx, dr.db, err, "departments", "department_id", ID, version))
+	}
+	return department, nil
+}
+
+// Delete удаляет кафедру по номеру в корзину и возвращает ошибку, если удаления не произошло.
+// Если кафедра с таким номером не нашлась, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (dr *DepartmentRepo) Delete(ctx context.Context, ID, version int) error {
+	if err := moveToTrash(ctx, dr.db, "departments", "department_id", ID, version); err != nil {
+		return fmt.Errorf("DELETE department: %w", err)
 	}
-	return err
+	return nil
 }
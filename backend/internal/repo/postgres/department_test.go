@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+)
+
+func TestDepartmentRepo(t *testing.T) {
+	f := newFixtures(t)
+	repo := NewDepartmentRepo(f.db)
+
+	created, err := repo.Create(f.ctx, &model.NewDepartment{Name: "Кафедра связи"})
+	expectNoErr(t, err)
+	if created.ID == 0 || created.Name != "Кафедра связи" {
+		t.Errorf("Create: got %+v", created)
+	}
+
+	got, err := repo.Get(f.ctx, created.ID)
+	expectNoErr(t, err)
+	if *got != *created {
+		t.Errorf("Get: got %+v, want %+v", got, created)
+	}
+	_, err = repo.Get(f.ctx, created.ID+1)
+	expectErr(t, err, errs.NotFound)
+
+	all, err := repo.GetAll(f.ctx)
+	expectNoErr(t, err)
+	if len(all) != 1 || all[0] != *created {
+		t.Errorf("GetAll: got %+v", all)
+	}
+
+	updated, err := repo.Update(f.ctx, created.ID, 0, &model.NewDepartment{Name: "Кафедра радиотехники"})
+	expectNoErr(t, err)
+	if updated.ID != created.ID || updated.Name != "Кафедра радиотехники" {
+		t.Errorf("Update: got %+v", updated)
+	}
+	_, err = repo.Update(f.ctx, created.ID+1, 0, &model.NewDepartment{Name: "Кафедра"})
+	expectErr(t, err, errs.NotFound)
+
+	expectNoErr(t, repo.Delete(f.ctx, created.ID, 0))
+	expectErr(t, repo.Delete(f.ctx, created.ID, 0), errs.NotFound)
+	_, err = repo.Get(f.ctx, created.ID)
+	expectErr(t, err, errs.NotFound)
+}
+
+func TestDepartmentRepoDeleteReferenced(t *testing.T) {
+	f := newFixtures(t)
+	repo := NewDepartmentRepo(f.db)
+	department := f.department("Кафедра")
+	f.specialty("Специальность", department.ID)
+
+	expectConstraint(t, repo.Delete(f.ctx, department.ID, 0), errs.ReferencedBy, "department_id")
+	_, err := repo.Get(f.ctx, department.ID)
+	expectNoErr(t, err)
+}
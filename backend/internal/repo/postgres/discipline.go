@@ -28,10 +28,10 @@ func (dr *DisciplineRepo) Create(ctx context.Context, input *model.NewDiscipline
 	query := `
 		INSERT INTO disciplines (name, specialty_id)
 		VALUES ($1, $2)
-		RETURNING discipline_id, name, specialty_id
+		RETURNING discipline_id, name, specialty_id, version
 	`
 	if err := dr.db.GetContext(ctx, discipline, query, input.Name, input.SpecialtyID); err != nil {
-		return nil, fmt.Errorf("INSERT discipline: %w: %w", errs.Internal, err)
+		return nil, fmt.Errorf("INSERT discipline: %w", dbError(err, "disciplines"))
 	}
 	return discipline, nil
 }
@@ -40,7 +40,7 @@ func (dr *DisciplineRepo) Create(ctx context.Context, input *model.NewDiscipline
 // Если база данных пуста, то возвращается ошибка [errs.Empty].
 func (dr *DisciplineRepo) GetAll(ctx context.Context) ([]model.Discipline, error) {
 	var disciplines []model.Discipline
-	query := `SELECT * FROM disciplines`
+	query := `SELECT * FROM disciplines WHERE deleted_at IS NULL`
 	if err := dr.db.SelectContext(ctx, &disciplines, query); err != nil {
 		baseErr := errs.Internal
 		if errors.Is(err, sql.ErrNoRows) {
@@ -55,7 +55,7 @@ func (dr *DisciplineRepo) GetAll(ctx context.Context) ([]model.Discipline, error
 // Если предмета с таким номером не нашлось, то возвращается ошибка [errs.NotFound].
 func (dr *DisciplineRepo) Get(ctx context.Context, ID int) (*model.Discipline, error) {
 	discipline := new(model.Discipline)
-	query := `SELECT * FROM disciplines WHERE discipline_id = $1`
+	query := `SELECT * FROM disciplines WHERE discipline_id = $1 AND deleted_at IS NULL`
 	if err := dr.db.GetContext(ctx, discipline, query, ID); err != nil {
 		baseErr := errs.Internal
 		if errors.Is(err, sql.ErrNoRows) {
@@ -68,27 +68,44 @@ func (dr *DisciplineRepo) Get(ctx context.Context, ID int) (*model.Discipline, e
 
 // Update обновляет предмет по номеру и возвращает его с номером.
 // Если предмета с таким номером не нашлось, то возвращается ошибка [errs.NotFound].
-func (dr *DisciplineRepo) Update(ctx context.Context, ID int, update *model.NewDiscipline) (*model.Discipline, error) {
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (dr *DisciplineRepo) Update(ctx context.Context, ID, version int, update *model.NewDiscipline) (*model.Discipline, error) {
 	discipline := new(model.Discipline)
 	query := `
 		UPDATE disciplines
 		SET name = $1,
-			specialty_id = $2
-		WHERE discipline_id = $3
-		RETURNING discipline_id, name, specialty_id
+			specialty_id = $2,
+			version = version + 1
+		WHERE discipline_id = $3 AND ($4 = 0 OR version = $4) AND deleted_at IS NULL
+		RETURNING discipline_id, name, specialty_id, version
 	`
-	if err := dr.db.GetContext(ctx, discipline, query, update.Name, update.SpecialtyID); err != nil {
-		return nil, fmt.Errorf("UPDATE discipline: %w: %w", errs.NotFound, err)
+	if err := dr.db.GetContext(ctx, discipline, query, update.Name, update.SpecialtyID, ID, version); err != nil {
+		return nil, fmt.Errorf("UPDATE discipline: %w", updateError(ctx, dr.db, err, "disciplines", "discipline_id", ID, version))
 	}
 	return discipline, nil
 }
 
-// Delete удаляет предмет по номеру и возвращает ошибку, если удаления не произошло.
+// Patch изменяет указанные в патче поля предмета по номеру и возвращает его или ошибку.
 // Если предмета с таким номером не нашлось, то возвращается ошибка [errs.NotFound].
-func (dr *DisciplineRepo) Delete(ctx context.Context, ID int) error {
-	query := `DELETE FROM disciplines WHERE discipline_id = $1`
-	if _, err := dr.db.ExecContext(ctx, query, ID); err != nil {
-		return fmt.Errorf("DELETE discipline: %w: %w", errs.NotFound, err)
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (dr *DisciplineRepo) Patch(ctx context.Context, ID, version int, patch *model.DisciplinePatch) (*model.Discipline, error) {
+	set := new(patchSet)
+	set.add("name", patch.Name)
+	set.add("specialty_id", patch.SpecialtyID)
+	query, args := set.update("disciplines", "discipline_id", "discipline_id, name, specialty_id, version", ID, version)
+	discipline := new(model.Discipline)
+	if err := dr.db.GetContext(ctx, discipline, query, args...); err != nil {
+		return nil, fmt.Errorf("UPDATE discipline: %w", updateError(ctx, dr.db, err, "disciplines", "discipline_id", ID, version))
+	}
+	return discipline, nil
+}
+
+// Delete удаляет предмет по номеру в корзину и возвращает ошибку, если удаления не произошло.
+// Если предмета с таким номером не нашлось, то возвращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (dr *DisciplineRepo) Delete(ctx context.Context, ID, version int) error {
+	if err := moveToTrash(ctx, dr.db, "disciplines", "discipline_id", ID, version); err != nil {
+		return fmt.Errorf("DELETE discipline: %w", err)
 	}
 	return nil
 }
@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+)
+
+func TestDisciplineRepo(t *testing.T) {
+	f := newFixtures(t)
+	repo := NewDisciplineRepo(f.db)
+	department := f.department("Кафедра")
+	specialty := f.specialty("Связь", department.ID)
+	other := f.specialty("Радиотехника", department.ID)
+
+	created, err := repo.Create(f.ctx, &model.NewDiscipline{Name: "Теория связи", SpecialtyID: specialty.ID})
+	expectNoErr(t, err)
+	if created.ID == 0 || created.Name != "Теория связи" || created.SpecialtyID != specialty.ID {
+		t.Errorf("Create: got %+v", created)
+	}
+
+	got, err := repo.Get(f.ctx, created.ID)
+	expectNoErr(t, err)
+	if *got != *created {
+		t.Errorf("Get: got %+v, want %+v", got, created)
+	}
+	_, err = repo.Get(f.ctx, created.ID+1)
+	expectErr(t, err, errs.NotFound)
+
+	all, err := repo.GetAll(f.ctx)
+	expectNoErr(t, err)
+	if len(all) != 1 || all[0] != *created {
+		t.Errorf("GetAll: got %+v", all)
+	}
+
+	updated, err := repo.Update(f.ctx, created.ID, 0, &model.NewDiscipline{Name: "Антенны", SpecialtyID: other.ID})
+	expectNoErr(t, err)
+	if updated.ID != created.ID || updated.Name != "Антенны" || updated.SpecialtyID != other.ID {
+		t.Errorf("Update: got %+v", updated)
+	}
+	_, err = repo.Update(f.ctx, created.ID+1, 0, &model.NewDiscipline{Name: "Антенны", SpecialtyID: other.ID})
+	expectErr(t, err, errs.NotFound)
+
+	expectNoErr(t, repo.Delete(f.ctx, created.ID, 0))
+	expectErr(t, repo.Delete(f.ctx, created.ID, 0), errs.NotFound)
+	_, err = repo.Get(f.ctx, created.ID)
+	expectErr(t, err, errs.NotFound)
+}
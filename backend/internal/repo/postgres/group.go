@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/foreverd34d/aumsu-elib/internal/errs"
 	"github.com/foreverd34d/aumsu-elib/internal/model"
@@ -28,20 +29,21 @@ func (gs *GroupRepo) Create(ctx context.Context, input *model.NewGroup) (*model.
 	query := `
 		INSERT INTO groups (name, specialty_id)
 		VALUES ($1, $2)
-		RETURNING group_id, name, specialty_id
+		RETURNING group_id, name, specialty_id, version
 	`
 	if err := gs.db.GetContext(ctx, group, query, input.Name, input.SpecialtyID); err != nil {
-		return nil, fmt.Errorf("INSERT group: %w: %w", errs.Internal, err)
+		return nil, fmt.Errorf("INSERT group: %w", dbError(err, "groups"))
 	}
 	return group, nil
 }
 
-// GetAll возвращает слайс всех групп или ошибку.
+// GetAll возвращает слайс групп, удовлетворяющих фильтру, или ошибку.
+// Группы из архива возвращаются, только если это указано в фильтре.
 // Если база данных пуста, то возвращается ошибка [errs.Empty].
-func (gs *GroupRepo) GetAll(ctx context.Context) ([]model.Group, error) {
+func (gs *GroupRepo) GetAll(ctx context.Context, filter *model.GroupFilter) ([]model.Group, error) {
 	var groups []model.Group
-	query := `SELECT * FROM groups`
-	if err := gs.db.SelectContext(ctx, &groups, query); err != nil {
+	query := `SELECT * FROM groups WHERE archived_at IS NULL OR $1 ORDER BY name, group_id`
+	if err := gs.db.SelectContext(ctx, &groups, query, filter.Archived); err != nil {
 		baseErr := errs.Internal
 		if errors.Is(err, sql.ErrNoRows) {
 			baseErr = errs.Empty
@@ -66,30 +68,201 @@ func (gs *GroupRepo) Get(ctx context.Context, ID int) (*model.Group, error) {
 	return group, nil
 }
 
+// GetByName возвращает группу не из архива по названию или ошибку.
+// Если группа с таким названием не нашлась, то возвращается ошибка [errs.NotFound].
+func (gs *GroupRepo) GetByName(ctx context.Context, name string) (*model.Group, error) {
+	group := new(model.Group)
+	query := `SELECT * FROM groups WHERE name = $1 AND archived_at IS NULL`
+	if err := gs.db.GetContext(ctx, group, query, name); err != nil {
+		baseErr := errs.Internal
+		if errors.Is(err, sql.ErrNoRows) {
+			baseErr = errs.NotFound
+		}
+		return nil, fmt.Errorf("SELECT group by name: %w: %w", baseErr, err)
+	}
+	return group, nil
+}
+
 // Update обновляет группу по номеру и возвращает обновленную группу с номером или ошибку.
 // Если группа с таким номером не нашлась, то возращается ошибка [errs.NotFound].
-func (gs *GroupRepo) Update(ctx context.Context, ID int, update *model.NewGroup) (*model.Group, error) {
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (gs *GroupRepo) Update(ctx context.Context, ID, version int, update *model.NewGroup) (*model.Group, error) {
 	group := new(model.Group)
 	query := `
 		UPDATE groups
 		SET name = $1,
-			specialty_id = $2
-		WHERE group_id = $3
-		RETURNING group_id, name, specialty_id
+			specialty_id = $2,
+			version = version + 1
+		WHERE group_id = $3 AND ($4 = 0 OR version = $4)
+		RETURNING *
 	`
-	if err := gs.db.GetContext(ctx, group, query, update.Name, update.SpecialtyID, ID); err != nil {
-		return nil, fmt.Errorf("UPDATE group: %w: %w", errs.NotFound, err)
+	if err := gs.db.GetContext(ctx, group, query, update.Name, update.SpecialtyID, ID, version); err != nil {
+		return nil, fmt.Errorf("UPDATE group: %w", updateError(ctx, gs.db, err, "groups", "group_id", ID, version))
+	}
+	return group, nil
+}
+
+// Patch изменяет указанные в патче поля группы по номеру и возвращает обновленную группу или ошибку.
+// Если группа с таким номером не нашлась, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (gs *GroupRepo) Patch(ctx context.Context, ID, version int, patch *model.GroupPatch) (*model.Group, error) {
+	set := new(patchSet)
+	set.add("name", patch.Name)
+	set.add("specialty_id", patch.SpecialtyID)
+	query, args := set.update("groups", "group_id", "*", ID, version)
+	group := new(model.Group)
+	if err := gs.db.GetContext(ctx, group, query, args...); err != nil {
+		return nil, fmt.Errorf("UPDATE group: %w", updateError(ctx, gs.db, err, "groups", "group_id", ID, version))
 	}
 	return group, nil
 }
 
 // Delete удаляет группу по номеру и возвращает ошибку, если удаления не произошло.
 // Если группа с таким номером не нашлась, то возращается ошибка [errs.NotFound].
-func (gs *GroupRepo) Delete(ctx context.Context, ID int) error {
-	query := `DELETE FROM groups WHERE group_id = $1 CASCADE`
-	_, err := gs.db.ExecContext(ctx, query, ID)
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (gs *GroupRepo) Delete(ctx context.Context, ID, version int) error {
+	query := `DELETE FROM groups WHERE group_id = $1 AND ($2 = 0 OR version = $2)`
+	result, err := gs.db.ExecContext(ctx, query, ID, version)
 	if err != nil {
-		return fmt.Errorf("DELETE group: %w: %w", errs.NotFound, err)
+		return fmt.Errorf("DELETE group: %w", dbError(err, "groups"))
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("DELETE group: %w", missingRow(ctx, gs.db, "groups", "group_id", ID, version))
+	}
+	return nil
+}
+
+// ApplyRollover выполняет переход на новый учебный год в одной транзакции:
+// переводит студентов сливаемых взводов и отправляет исходные взводы в архив,
+// переименовывает взводы, отправляет выпускаемые взводы в архив и отключает учетные записи
+// их студентов, завершая их сессии. Если хотя бы одно изменение не удалось, то не применяется ни одно.
+// Возвращает измененные взводы после перехода и номера отключенных студентов или ошибку.
+// Взводы из versions блокируются до конца транзакции; если версия взвода отличается от указанной
+// или взвод уже находится в архиве, то возвращается ошибка [errs.VersionMismatch].
+func (gs *GroupRepo) ApplyRollover(ctx context.Context, rollover *model.Rollover, versions map[int]int) (*model.AppliedRollover, error) {
+	txCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	tx, err := gs.db.BeginTxx(txCtx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w: %w", errs.Internal, err)
+	}
+
+	// Взводы блокируются в порядке номеров, чтобы одновременные переходы не ждали друг друга по кругу.
+	groupIDs := make([]int, 0, len(versions))
+	for groupID := range versions {
+		groupIDs = append(groupIDs, groupID)
+	}
+	slices.Sort(groupIDs)
+	for _, groupID := range groupIDs {
+		var group model.Group
+		query := `SELECT * FROM groups WHERE group_id = $1 FOR UPDATE`
+		if err := tx.GetContext(ctx, &group, query, groupID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, fmt.Errorf("SELECT group %v: %w", groupID, errs.VersionMismatch)
+			}
+			return nil, fmt.Errorf("SELECT group %v: %w: %w", groupID, errs.Internal, err)
+		}
+		if group.Version != versions[groupID] || group.ArchivedAt != nil {
+			return nil, fmt.Errorf("group %v changed: %w", groupID, errs.VersionMismatch)
+		}
+	}
+
+	for _, merge := range rollover.Merges {
+		studentsQuery := `UPDATE users SET group_id = $1, version = version + 1 WHERE group_id = $2`
+		if _, err := tx.ExecContext(ctx, studentsQuery, merge.TargetID, merge.SourceID); err != nil {
+			return nil, fmt.Errorf("UPDATE students of group %v: %w: %w", merge.SourceID, errs.Internal, err)
+		}
+		if err := archiveGroup(ctx, tx, merge.SourceID); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, promotion := range rollover.Promotions {
+		query := `
+			UPDATE groups
+			SET name = $1,
+				specialty_id = COALESCE($2, specialty_id),
+				version = version + 1
+			WHERE group_id = $3
+		`
+		if _, err := tx.ExecContext(ctx, query, promotion.NewName, promotion.SpecialtyID, promotion.GroupID); err != nil {
+			return nil, fmt.Errorf("UPDATE group %v: %w", promotion.GroupID, dbError(err, "groups"))
+		}
+	}
+
+	var deactivated []int
+	for _, groupID := range rollover.Archives {
+		if err := archiveGroup(ctx, tx, groupID); err != nil {
+			return nil, err
+		}
+		usersQuery := `
+			UPDATE users
+			SET deactivated_at = CURRENT_TIMESTAMP,
+				version = version + 1
+			WHERE group_id = $1 AND deactivated_at IS NULL
+			RETURNING user_id
+		`
+		var students []int
+		if err := tx.SelectContext(ctx, &students, usersQuery, groupID); err != nil {
+			return nil, fmt.Errorf("UPDATE students of group %v: %w: %w", groupID, errs.Internal, err)
+		}
+		deactivated = append(deactivated, students...)
+		tokensQuery := `
+			DELETE FROM tokens
+			WHERE session_id IN (
+				SELECT s.session_id
+				FROM sessions s
+				JOIN users u USING(user_id)
+				WHERE u.group_id = $1
+			)
+		`
+		if _, err := tx.ExecContext(ctx, tokensQuery, groupID); err != nil {
+			return nil, fmt.Errorf("DELETE tokens of group %v: %w: %w", groupID, errs.Internal, err)
+		}
+		sessionsQuery := `
+			UPDATE sessions
+			SET logged_out_at = CURRENT_TIMESTAMP
+			WHERE logged_out_at IS NULL
+				AND user_id IN (SELECT user_id FROM users WHERE group_id = $1)
+		`
+		if _, err := tx.ExecContext(ctx, sessionsQuery, groupID); err != nil {
+			return nil, fmt.Errorf("UPDATE sessions of group %v: %w: %w", groupID, errs.Internal, err)
+		}
+	}
+
+	applied := &model.AppliedRollover{Deactivated: deactivated}
+	var changed []int
+	for _, merge := range rollover.Merges {
+		changed = append(changed, merge.SourceID)
+	}
+	for _, promotion := range rollover.Promotions {
+		changed = append(changed, promotion.GroupID)
+	}
+	changed = append(changed, rollover.Archives...)
+	for _, groupID := range changed {
+		var group model.Group
+		if err := tx.GetContext(ctx, &group, `SELECT * FROM groups WHERE group_id = $1`, groupID); err != nil {
+			return nil, fmt.Errorf("SELECT group %v: %w", groupID, dbError(err, "groups"))
+		}
+		applied.Groups = append(applied.Groups, group)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit changes: %w: %w", errs.Internal, err)
+	}
+	return applied, nil
+}
+
+// archiveGroup отправляет взвод в архив в рамках транзакции tx.
+func archiveGroup(ctx context.Context, tx *sqlx.Tx, groupID int) error {
+	query := `
+		UPDATE groups
+		SET archived_at = CURRENT_TIMESTAMP,
+			version = version + 1
+		WHERE group_id = $1 AND archived_at IS NULL
+	`
+	if _, err := tx.ExecContext(ctx, query, groupID); err != nil {
+		return fmt.Errorf("UPDATE group %v: %w: %w", groupID, errs.Internal, err)
 	}
 	return nil
 }
@@ -0,0 +1,147 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+)
+
+func TestGroupRepo(t *testing.T) {
+	f := newFixtures(t)
+	repo := NewGroupRepo(f.db)
+	department := f.department("Кафедра")
+	specialty := f.specialty("Связь", department.ID)
+	other := f.specialty("Радиотехника", department.ID)
+
+	created, err := repo.Create(f.ctx, &model.NewGroup{Name: "1101", SpecialtyID: specialty.ID})
+	expectNoErr(t, err)
+	if created.ID == 0 || created.Name != "1101" || created.SpecialtyID != specialty.ID || created.ArchivedAt != nil {
+		t.Errorf("Create: got %+v", created)
+	}
+
+	got, err := repo.Get(f.ctx, created.ID)
+	expectNoErr(t, err)
+	if got.ID != created.ID || got.Name != created.Name || got.SpecialtyID != created.SpecialtyID {
+		t.Errorf("Get: got %+v, want %+v", got, created)
+	}
+	_, err = repo.Get(f.ctx, created.ID+1)
+	expectErr(t, err, errs.NotFound)
+
+	got, err = repo.GetByName(f.ctx, "1101")
+	expectNoErr(t, err)
+	if got.ID != created.ID {
+		t.Errorf("GetByName: got %+v, want %+v", got, created)
+	}
+	_, err = repo.GetByName(f.ctx, "1102")
+	expectErr(t, err, errs.NotFound)
+
+	updated, err := repo.Update(f.ctx, created.ID, 0, &model.NewGroup{Name: "1201", SpecialtyID: other.ID})
+	expectNoErr(t, err)
+	if updated.ID != created.ID || updated.Name != "1201" || updated.SpecialtyID != other.ID {
+		t.Errorf("Update: got %+v", updated)
+	}
+	_, err = repo.Update(f.ctx, created.ID+1, 0, &model.NewGroup{Name: "1201", SpecialtyID: other.ID})
+	expectErr(t, err, errs.NotFound)
+
+	all, err := repo.GetAll(f.ctx, &model.GroupFilter{})
+	expectNoErr(t, err)
+	if len(all) != 1 || all[0].ID != created.ID {
+		t.Errorf("GetAll: got %+v", all)
+	}
+
+	expectNoErr(t, repo.Delete(f.ctx, created.ID, 0))
+	expectErr(t, repo.Delete(f.ctx, created.ID, 0), errs.NotFound)
+	_, err = repo.Get(f.ctx, created.ID)
+	expectErr(t, err, errs.NotFound)
+}
+
+func TestGroupRepoDeleteReferenced(t *testing.T) {
+	f := newFixtures(t)
+	repo := NewGroupRepo(f.db)
+	group := f.groupWithSpecialty("1101")
+	f.user("student", "student", &group.ID)
+
+	expectConstraint(t, repo.Delete(f.ctx, group.ID, 0), errs.ReferencedBy, "group_id")
+	_, err := repo.Get(f.ctx, group.ID)
+	expectNoErr(t, err)
+}
+
+func TestGroupRepoApplyRollover(t *testing.T) {
+	f := newFixtures(t)
+	repo := NewGroupRepo(f.db)
+	users := NewUserRepo(f.db)
+	sessions := NewSessionRepo(f.db)
+
+	graduates := f.groupWithSpecialty("1501")
+	juniors := f.group("1101", graduates.SpecialtyID)
+	source := f.group("1102", graduates.SpecialtyID)
+	newSpecialty := f.specialty("Радиотехника", f.department("Кафедра радиотехники").ID)
+
+	graduate := f.user("graduate", "student", &graduates.ID)
+	moved := f.user("moved", "student", &source.ID)
+	graduateToken := f.session(graduate.ID, token("graduate"))
+
+	rollover := &model.Rollover{
+		Promotions: []model.GroupPromotion{{GroupID: juniors.ID, NewName: "1201", SpecialtyID: &newSpecialty.ID}},
+		Merges:     []model.GroupMerge{{SourceID: source.ID, TargetID: juniors.ID}},
+		Archives:   []int{graduates.ID},
+	}
+	versions := map[int]int{graduates.ID: graduates.Version, juniors.ID: juniors.Version, source.ID: source.Version}
+	applied, err := repo.ApplyRollover(f.ctx, rollover, versions)
+	expectNoErr(t, err)
+	if len(applied.Deactivated) != 1 || applied.Deactivated[0] != graduate.ID {
+		t.Errorf("ApplyRollover: got deactivated students %v, want [%v]", applied.Deactivated, graduate.ID)
+	}
+	if len(applied.Groups) != 3 || applied.Groups[0].ID != source.ID || applied.Groups[0].ArchivedAt == nil || applied.Groups[1].Name != "1201" {
+		t.Errorf("ApplyRollover: got changed groups %+v", applied.Groups)
+	}
+	// План, проверенный по прежним версиям взводов, повторно не применяется.
+	_, err = repo.ApplyRollover(f.ctx, rollover, versions)
+	expectErr(t, err, errs.VersionMismatch)
+
+	promoted, err := repo.Get(f.ctx, juniors.ID)
+	expectNoErr(t, err)
+	if promoted.Name != "1201" || promoted.SpecialtyID != newSpecialty.ID || promoted.ArchivedAt != nil {
+		t.Errorf("promoted group: got %+v", promoted)
+	}
+	for _, ID := range []int{source.ID, graduates.ID} {
+		group, err := repo.Get(f.ctx, ID)
+		expectNoErr(t, err)
+		if group.ArchivedAt == nil {
+			t.Errorf("group %v is not archived", group.Name)
+		}
+	}
+
+	active, err := repo.GetAll(f.ctx, &model.GroupFilter{})
+	expectNoErr(t, err)
+	if len(active) != 1 || active[0].ID != juniors.ID {
+		t.Errorf("GetAll: got %+v, want only the promoted group", active)
+	}
+	all, err := repo.GetAll(f.ctx, &model.GroupFilter{Archived: true})
+	expectNoErr(t, err)
+	if len(all) != 3 {
+		t.Errorf("GetAll with archived groups: got %+v", all)
+	}
+	_, err = repo.GetByName(f.ctx, "1501")
+	expectErr(t, err, errs.NotFound)
+
+	movedUser, err := users.GetByID(f.ctx, moved.ID)
+	expectNoErr(t, err)
+	if movedUser.GroupID == nil || *movedUser.GroupID != juniors.ID || movedUser.DeactivatedAt != nil {
+		t.Errorf("merged student: got %+v", movedUser)
+	}
+	graduateUser, err := users.GetByID(f.ctx, graduate.ID)
+	expectNoErr(t, err)
+	if graduateUser.DeactivatedAt == nil {
+		t.Error("graduate is not deactivated")
+	}
+	_, err = sessions.PopByRefreshToken(f.ctx, graduateToken.RefreshToken)
+	expectErr(t, err, errs.NotFound)
+
+	var session model.Session
+	expectNoErr(t, f.db.GetContext(f.ctx, &session, `SELECT * FROM sessions WHERE session_id = $1`, graduateToken.SessionID))
+	if session.LoggedOutAt == nil {
+		t.Error("graduate's session is not ended")
+	}
+}
@@ -0,0 +1,144 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Количество записей истории запусков задач, возвращаемых по умолчанию.
+const defaultJobRunLimit = 100
+
+// JobRepo предоставляет доступ к базе данных истории запусков задач обслуживания.
+type JobRepo struct {
+	db *sqlx.DB
+}
+
+// NewJobRepo возвращает новый экземпляр [JobRepo].
+func NewJobRepo(db *sqlx.DB) *JobRepo {
+	return &JobRepo{db}
+}
+
+// CreateRun сохраняет начало запуска задачи и возвращает запись с номером или ошибку.
+func (jr *JobRepo) CreateRun(ctx context.Context, input *model.NewJobRun) (*model.JobRun, error) {
+	run := new(model.JobRun)
+	query := `
+		INSERT INTO job_runs (job, triggered_by, actor_id, started_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING *
+	`
+	if err := jr.db.GetContext(ctx, run, query, input.Job, input.TriggeredBy, input.ActorID, input.StartedAt); err != nil {
+		return nil, fmt.Errorf("INSERT job run: %w: %w", errs.Internal, err)
+	}
+	return run, nil
+}
+
+// FinishRun записывает итог запуска с номером ID и возвращает обновленную запись или ошибку.
+// Если запуск не найден, то возвращается ошибка [errs.NotFound].
+func (jr *JobRepo) FinishRun(ctx context.Context, ID int, result *model.JobRunResult) (*model.JobRun, error) {
+	run := new(model.JobRun)
+	query := `
+		UPDATE job_runs
+		SET status = $1, affected = $2, error = $3, finished_at = $4
+		WHERE job_run_id = $5
+		RETURNING *
+	`
+	if err := jr.db.GetContext(ctx, run, query, result.Status, result.Affected, result.Error, result.FinishedAt, ID); err != nil {
+		baseErr := errs.Internal
+		if errors.Is(err, sql.ErrNoRows) {
+			baseErr = errs.NotFound
+		}
+		return nil, fmt.Errorf("UPDATE job run %v: %w: %w", ID, baseErr, err)
+	}
+	return run, nil
+}
+
+// GetRuns возвращает слайс записей истории, удовлетворяющих фильтру, от новых к старым или ошибку.
+func (jr *JobRepo) GetRuns(ctx context.Context, filter *model.JobRunFilter) ([]model.JobRun, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.Job != nil {
+		addCondition("job = $%d", *filter.Job)
+	}
+	if filter.Status != nil {
+		addCondition("status = $%d", *filter.Status)
+	}
+
+	query := `SELECT * FROM job_runs`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	limit := filter.Limit
+	if limit == 0 {
+		limit = defaultJobRunLimit
+	}
+	args = append(args, limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY started_at DESC, job_run_id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	runs := []model.JobRun{}
+	if err := jr.db.SelectContext(ctx, &runs, query, args...); err != nil {
+		return nil, fmt.Errorf("SELECT job runs: %w: %w", errs.Internal, err)
+	}
+	return runs, nil
+}
+
+// GetLastRuns возвращает последний запуск каждой задачи, которая хоть раз запускалась, или ошибку.
+func (jr *JobRepo) GetLastRuns(ctx context.Context) ([]model.JobRun, error) {
+	query := `
+		SELECT DISTINCT ON (job) *
+		FROM job_runs
+		ORDER BY job, started_at DESC, job_run_id DESC
+	`
+	runs := []model.JobRun{}
+	if err := jr.db.SelectContext(ctx, &runs, query); err != nil {
+		return nil, fmt.Errorf("SELECT last job runs: %w: %w", errs.Internal, err)
+	}
+	return runs, nil
+}
+
+// DeleteRunsBefore удаляет завершенные запуски, начавшиеся раньше момента before,
+// и возвращает их количество или ошибку.
+func (jr *JobRepo) DeleteRunsBefore(ctx context.Context, before time.Time) (int, error) {
+	result, err := jr.db.ExecContext(ctx, `DELETE FROM job_runs WHERE started_at < $1 AND status <> 'running'`, before)
+	if err != nil {
+		return 0, fmt.Errorf("DELETE job runs: %w: %w", errs.Internal, err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("count deleted job runs: %w: %w", errs.Internal, err)
+	}
+	return int(deleted), nil
+}
+
+// FailUnfinishedRuns записывает итог result во все незавершенные запуски задачи job
+// и возвращает их количество или ошибку.
+func (jr *JobRepo) FailUnfinishedRuns(ctx context.Context, job string, result *model.JobRunResult) (int, error) {
+	query := `
+		UPDATE job_runs
+		SET status = $1, affected = $2, error = $3, finished_at = $4
+		WHERE job = $5 AND status = 'running'
+	`
+	updated, err := jr.db.ExecContext(ctx, query, result.Status, result.Affected, result.Error, result.FinishedAt, job)
+	if err != nil {
+		return 0, fmt.Errorf("UPDATE unfinished runs of job %v: %w: %w", job, errs.Internal, err)
+	}
+	failed, err := updated.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("count unfinished runs of job %v: %w: %w", job, errs.Internal, err)
+	}
+	return int(failed), nil
+}
@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestJobRepo(t *testing.T) {
+	f := newFixtures(t)
+	repo := NewJobRepo(f.db)
+	admin := f.user("admin", "admin", nil)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	old, err := repo.CreateRun(f.ctx, &model.NewJobRun{Job: "trash-purge", TriggeredBy: model.JobTriggerSchedule, StartedAt: now.Add(-48 * time.Hour)})
+	expectNoErr(t, err)
+	if old.ID == 0 || old.Status != model.JobRunning || old.FinishedAt != nil || !old.StartedAt.Equal(now.Add(-48*time.Hour)) {
+		t.Errorf("CreateRun: got %+v", old)
+	}
+	msg := "disk is full"
+	finished, err := repo.FinishRun(f.ctx, old.ID, &model.JobRunResult{Status: model.JobFailed, Error: &msg, FinishedAt: now.Add(-47 * time.Hour)})
+	expectNoErr(t, err)
+	if finished.Status != model.JobFailed || finished.Error == nil || *finished.Error != msg || finished.FinishedAt == nil {
+		t.Errorf("FinishRun: got %+v", finished)
+	}
+	_, err = repo.FinishRun(f.ctx, old.ID+100, &model.JobRunResult{Status: model.JobSucceeded, FinishedAt: now})
+	expectErr(t, err, errs.NotFound)
+
+	manual, err := repo.CreateRun(f.ctx, &model.NewJobRun{Job: "trash-purge", TriggeredBy: model.JobTriggerManual, ActorID: &admin.ID, StartedAt: now})
+	expectNoErr(t, err)
+	_, err = repo.FinishRun(f.ctx, manual.ID, &model.JobRunResult{Status: model.JobSucceeded, Affected: 3, FinishedAt: now})
+	expectNoErr(t, err)
+	running, err := repo.CreateRun(f.ctx, &model.NewJobRun{Job: "expired-tokens", TriggeredBy: model.JobTriggerSchedule, StartedAt: now.Add(-72 * time.Hour)})
+	expectNoErr(t, err)
+
+	runs, err := repo.GetRuns(f.ctx, &model.JobRunFilter{})
+	expectNoErr(t, err)
+	if len(runs) != 3 || runs[0].ID != manual.ID || runs[1].ID != old.ID || runs[2].ID != running.ID {
+		t.Errorf("GetRuns: got %+v", runs)
+	}
+	job, status := "trash-purge", model.JobSucceeded
+	runs, err = repo.GetRuns(f.ctx, &model.JobRunFilter{Job: &job, Status: &status})
+	expectNoErr(t, err)
+	if len(runs) != 1 || runs[0].Affected != 3 || runs[0].ActorID == nil || *runs[0].ActorID != admin.ID {
+		t.Errorf("GetRuns with filter: got %+v", runs)
+	}
+
+	last, err := repo.GetLastRuns(f.ctx)
+	expectNoErr(t, err)
+	if len(last) != 2 || last[0].ID != running.ID || last[1].ID != manual.ID {
+		t.Errorf("GetLastRuns: got %+v", last)
+	}
+
+	interrupted := "interrupted"
+	failed, err := repo.FailUnfinishedRuns(f.ctx, "trash-purge", &model.JobRunResult{Status: model.JobFailed, Error: &interrupted, FinishedAt: now})
+	expectNoErr(t, err)
+	if failed != 0 {
+		t.Errorf("FailUnfinishedRuns without unfinished runs: got %v", failed)
+	}
+
+	deleted, err := repo.DeleteRunsBefore(f.ctx, now.Add(-time.Hour))
+	expectNoErr(t, err)
+	if deleted != 1 {
+		t.Errorf("DeleteRunsBefore: got %v deleted runs, want 1", deleted)
+	}
+	runs, err = repo.GetRuns(f.ctx, &model.JobRunFilter{})
+	expectNoErr(t, err)
+	if len(runs) != 2 {
+		t.Errorf("GetRuns after delete: got %+v, want the manual and the running runs", runs)
+	}
+
+	failed, err = repo.FailUnfinishedRuns(f.ctx, "expired-tokens", &model.JobRunResult{Status: model.JobFailed, Error: &interrupted, FinishedAt: now})
+	expectNoErr(t, err)
+	if failed != 1 {
+		t.Errorf("FailUnfinishedRuns: got %v failed runs, want 1", failed)
+	}
+	status = model.JobRunning
+	runs, err = repo.GetRuns(f.ctx, &model.JobRunFilter{Status: &status})
+	expectNoErr(t, err)
+	if len(runs) != 0 {
+		t.Errorf("GetRuns running after FailUnfinishedRuns: got %+v", runs)
+	}
+}
+
+func TestLocker(t *testing.T) {
+	if testDSN == "" {
+		t.Skipf("%v is not set", testDSNEnv)
+	}
+	// Блокировки уровня сеанса не откатываются вместе с транзакцией, поэтому экземпляры
+	// сервера представлены отдельными подключениями без откатываемой транзакции.
+	connect := func() *sqlx.DB {
+		db, err := sqlx.Connect("postgres", testDSN)
+		if err != nil {
+			t.Fatalf("connect: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+	first, second := NewLocker(connect()), NewLocker(connect())
+	ctx := context.Background()
+	name := "elib:test"
+
+	tryLock := func(locker *Locker, want bool) {
+		t.Helper()
+		held, err := locker.TryLock(ctx, name)
+		expectNoErr(t, err)
+		if held != want {
+			t.Fatalf("TryLock: got %v, want %v", held, want)
+		}
+	}
+	tryLock(first, true)
+	tryLock(first, true)
+	tryLock(second, false)
+	expectNoErr(t, first.Unlock(ctx, name))
+	expectNoErr(t, first.Unlock(ctx, name))
+	tryLock(second, true)
+	tryLock(first, false)
+	expectNoErr(t, second.Unlock(ctx, name))
+}
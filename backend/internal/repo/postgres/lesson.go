@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// LessonRepo предоставляет доступ к базе данных с занятиями.
+type LessonRepo struct {
+	db *sqlx.DB
+}
+
+// NewLessonRepo возвращает новый экземпляр [LessonRepo].
+func NewLessonRepo(db *sqlx.DB) *LessonRepo {
+	return &LessonRepo{db}
+}
+
+// Get возвращает занятие по номеру без материалов или ошибку.
+// Если занятие с таким номером не нашлось, то возвращается ошибка [errs.NotFound].
+func (lr *LessonRepo) Get(ctx context.Context, ID int) (*model.Lesson, error) {
+	lesson := new(model.Lesson)
+	query := `SELECT * FROM lessons WHERE lesson_id = $1`
+	if err := lr.db.GetContext(ctx, lesson, query, ID); err != nil {
+		baseErr := errs.Internal
+		if errors.Is(err, sql.ErrNoRows) {
+			baseErr = errs.NotFound
+		}
+		return nil, fmt.Errorf("SELECT lesson: %w: %w", baseErr, err)
+	}
+	return lesson, nil
+}
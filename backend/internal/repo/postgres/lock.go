@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Locker предоставляет блокировки, общие для всех экземпляров сервера, на основе
+// advisory-блокировок PostgreSQL уровня сеанса. Каждая захваченная блокировка удерживает
+// отдельное соединение пула: если соединение разорвется, то база данных освободит блокировку сама.
+type Locker struct {
+	db *sqlx.DB
+
+	mu    sync.Mutex
+	conns map[string]*sql.Conn // соединения, удерживающие блокировки, по названию блокировки
+}
+
+// NewLocker возвращает новый экземпляр [Locker].
+func NewLocker(db *sqlx.DB) *Locker {
+	return &Locker{db: db, conns: make(map[string]*sql.Conn)}
+}
+
+// TryLock пытается захватить блокировку с названием name, не дожидаясь ее освобождения,
+// и сообщает, удерживает ли ее этот экземпляр. Если блокировка уже захвачена этим экземпляром,
+// то проверяется, что удерживающее ее соединение живо; иначе блокировка захватывается заново.
+func (l *Locker) TryLock(ctx context.Context, name string) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if conn, ok := l.conns[name]; ok {
+		if err := conn.PingContext(ctx); err == nil {
+			return true, nil
+		}
+		// Вместе с соединением база данных освободила и блокировку.
+		conn.Close()
+		delete(l.conns, name)
+	}
+
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("get connection for lock %v: %w: %w", name, errs.Internal, err)
+	}
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, lockKey(name)).Scan(&acquired); err != nil {
+		conn.Close()
+		return false, fmt.Errorf("try lock %v: %w: %w", name, errs.Internal, err)
+	}
+	if !acquired {
+		conn.Close()
+		return false, nil
+	}
+	l.conns[name] = conn
+	return true, nil
+}
+
+// Unlock освобождает блокировку с названием name, если она удерживается этим экземпляром.
+func (l *Locker) Unlock(ctx context.Context, name string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	conn, ok := l.conns[name]
+	if !ok {
+		return nil
+	}
+	delete(l.conns, name)
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, lockKey(name)); err != nil {
+		return fmt.Errorf("unlock %v: %w: %w", name, errs.Internal, err)
+	}
+	return nil
+}
+
+// lockKey возвращает ключ advisory-блокировки для ее названия.
+func lockKey(name string) int64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(name))
+	return int64(hash.Sum64())
+}
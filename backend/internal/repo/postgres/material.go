@@ -0,0 +1,267 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// MaterialRepo предоставляет доступ к базе данных с материалами
+// и их связями с занятиями и книгами.
+type MaterialRepo struct {
+	db *sqlx.DB
+}
+
+// NewMaterialRepo возвращает новый экземпляр [MaterialRepo].
+func NewMaterialRepo(db *sqlx.DB) *MaterialRepo {
+	return &MaterialRepo{db}
+}
+
+// Create сохраняет материал с путем к его файлу в базе данных и возвращает материал с номером или ошибку.
+func (mr *MaterialRepo) Create(ctx context.Context, input *model.NewMaterial, filePath string) (*model.Material, error) {
+	material := new(model.Material)
+	query := `
+		INSERT INTO materials (name, filepath, type_id)
+		VALUES ($1, $2, $3)
+		RETURNING material_id, name, filepath, type_id, version
+	`
+	if err := mr.db.GetContext(ctx, material, query, input.Name, filePath, input.TypeID); err != nil {
+		return nil, fmt.Errorf("INSERT material: %w", dbError(err, "materials"))
+	}
+	return material, nil
+}
+
+// GetAll возвращает слайс всех материалов или ошибку.
+// Если база данных пуста, то возвращается ошибка [errs.Empty].
+func (mr *MaterialRepo) GetAll(ctx context.Context) ([]model.Material, error) {
+	var materials []model.Material
+	query := `SELECT * FROM materials ORDER BY name, material_id`
+	if err := mr.db.SelectContext(ctx, &materials, query); err != nil {
+		baseErr := errs.Internal
+		if errors.Is(err, sql.ErrNoRows) {
+			baseErr = errs.Empty
+		}
+		return nil, fmt.Errorf("SELECT materials: %w: %w", baseErr, err)
+	}
+	return materials, nil
+}
+
+// Get возвращает материал по номеру или ошибку.
+// Если материал с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
+func (mr *MaterialRepo) Get(ctx context.Context, ID int) (*model.Material, error) {
+	material := new(model.Material)
+	query := `SELECT * FROM materials WHERE material_id = $1`
+	if err := mr.db.GetContext(ctx, material, query, ID); err != nil {
+		baseErr := errs.Internal
+		if errors.Is(err, sql.ErrNoRows) {
+			baseErr = errs.NotFound
+		}
+		return nil, fmt.Errorf("SELECT material: %w: %w", baseErr, err)
+	}
+	return material, nil
+}
+
+// Update обновляет название и вид материала по номеру и возвращает материал с номером или ошибку.
+// Если материал с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (mr *MaterialRepo) Update(ctx context.Context, ID, version int, update *model.NewMaterial) (*model.Material, error) {
+	material := new(model.Material)
+	query := `
+		UPDATE materials
+		SET name = $1,
+			type_id = $2,
+			version = version + 1
+		WHERE material_id = $3 AND ($4 = 0 OR version = $4)
+		RETURNING material_id, name, filepath, type_id, version
+	`
+	if err := mr.db.GetContext(ctx, material, query, update.Name, update.TypeID, ID, version); err != nil {
+		return nil, fmt.Errorf("UPDATE material: %w", updateError(ctx, mr.db, err, "materials", "material_id", ID, version))
+	}
+	return material, nil
+}
+
+// Patch изменяет указанные в патче название и вид материала по номеру и возвращает материал или ошибку.
+// Если материал с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (mr *MaterialRepo) Patch(ctx context.Context, ID, version int, patch *model.MaterialPatch) (*model.Material, error) {
+	set := new(patchSet)
+	set.add("name", patch.Name)
+	set.add("type_id", patch.TypeID)
+	query, args := set.update("materials", "material_id", "material_id, name, filepath, type_id, version", ID, version)
+	material := new(model.Material)
+	if err := mr.db.GetContext(ctx, material, query, args...); err != nil {
+		return nil, fmt.Errorf("UPDATE material: %w", updateError(ctx, mr.db, err, "materials", "material_id", ID, version))
+	}
+	return material, nil
+}
+
+// UpdateFile заменяет путь к файлу материала по номеру и возвращает материал с номером или ошибку.
+// Если материал с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+func (mr *MaterialRepo) UpdateFile(ctx context.Context, ID int, filePath string) (*model.Material, error) {
+	material := new(model.Material)
+	query := `
+		UPDATE materials
+		SET filepath = $1,
+			version = version + 1
+		WHERE material_id = $2
+		RETURNING material_id, name, filepath, type_id, version
+	`
+	if err := mr.db.GetContext(ctx, material, query, filePath, ID); err != nil {
+		baseErr := errs.Internal
+		if errors.Is(err, sql.ErrNoRows) {
+			baseErr = errs.NotFound
+		}
+		return nil, fmt.Errorf("UPDATE material file: %w: %w", baseErr, err)
+	}
+	return material, nil
+}
+
+// Delete удаляет материал по номеру вместе с его связями с занятиями и книгами
+// и возвращает ошибку, если удаления не произошло.
+// Если материал с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (mr *MaterialRepo) Delete(ctx context.Context, ID, version int) error {
+	txCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	tx, err := mr.db.BeginTxx(txCtx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w: %w", errs.Internal, err)
+	}
+
+	for _, query := range []string{
+		`DELETE FROM lesson_materials WHERE material_id = $1`,
+		`DELETE FROM material_books WHERE material_id = $1`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, ID); err != nil {
+			return fmt.Errorf("DELETE material links: %w: %w", errs.Internal, err)
+		}
+	}
+	query := `DELETE FROM materials WHERE material_id = $1 AND ($2 = 0 OR version = $2)`
+	result, err := tx.ExecContext(ctx, query, ID, version)
+	if err != nil {
+		return fmt.Errorf("DELETE material: %w: %w", errs.Internal, err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("DELETE material: %w", missingRow(ctx, tx, "materials", "material_id", ID, version))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit changes: %w: %w", errs.Internal, err)
+	}
+	return nil
+}
+
+// GetByLesson возвращает слайс материалов, прикрепленных к занятию, или ошибку.
+func (mr *MaterialRepo) GetByLesson(ctx context.Context, lessonID int) ([]model.Material, error) {
+	materials := []model.Material{}
+	query := `
+		SELECT m.*
+		FROM materials m
+		JOIN lesson_materials lm USING(material_id)
+		WHERE lm.lesson_id = $1
+		ORDER BY m.name, m.material_id
+	`
+	if err := mr.db.SelectContext(ctx, &materials, query, lessonID); err != nil {
+		return nil, fmt.Errorf("SELECT lesson materials: %w: %w", errs.Internal, err)
+	}
+	return materials, nil
+}
+
+// GetByBook возвращает слайс материалов, прикрепленных к книге, или ошибку.
+func (mr *MaterialRepo) GetByBook(ctx context.Context, bookID int) ([]model.Material, error) {
+	materials := []model.Material{}
+	query := `
+		SELECT m.*
+		FROM materials m
+		JOIN material_books mb USING(material_id)
+		WHERE mb.book_id = $1
+		ORDER BY m.name, m.material_id
+	`
+	if err := mr.db.SelectContext(ctx, &materials, query, bookID); err != nil {
+		return nil, fmt.Errorf("SELECT book materials: %w: %w", errs.Internal, err)
+	}
+	return materials, nil
+}
+
+// AttachToLesson прикрепляет материал к занятию. Повторное прикрепление ничего не меняет.
+// Если материал или занятие не нашлись, то возвращается ошибка [errs.NotFound].
+func (mr *MaterialRepo) AttachToLesson(ctx context.Context, materialID, lessonID int) error {
+	query := `
+		INSERT INTO lesson_materials (material_id, lesson_id)
+		SELECT m.material_id, l.lesson_id
+		FROM materials m, lessons l
+		WHERE m.material_id = $1 AND l.lesson_id = $2
+		ON CONFLICT (lesson_id, material_id) DO NOTHING
+		RETURNING id
+	`
+	existsQuery := `SELECT EXISTS(SELECT 1 FROM lesson_materials WHERE material_id = $1 AND lesson_id = $2)`
+	return mr.attach(ctx, query, existsQuery, "lesson", materialID, lessonID)
+}
+
+// DetachFromLesson открепляет материал от занятия.
+// Если материал не был прикреплен к занятию, то возвращается ошибка [errs.NotFound].
+func (mr *MaterialRepo) DetachFromLesson(ctx context.Context, materialID, lessonID int) error {
+	query := `DELETE FROM lesson_materials WHERE material_id = $1 AND lesson_id = $2`
+	return mr.detach(ctx, query, "lesson", materialID, lessonID)
+}
+
+// AttachToBook прикрепляет материал к книге. Повторное прикрепление ничего не меняет.
+// Если материал или книга не нашлись, то возвращается ошибка [errs.NotFound].
+func (mr *MaterialRepo) AttachToBook(ctx context.Context, materialID, bookID int) error {
+	query := `
+		INSERT INTO material_books (material_id, book_id)
+		SELECT m.material_id, b.book_id
+		FROM materials m, books b
+		WHERE m.material_id = $1 AND b.book_id = $2
+		ON CONFLICT (book_id, material_id) DO NOTHING
+		RETURNING id
+	`
+	existsQuery := `SELECT EXISTS(SELECT 1 FROM material_books WHERE material_id = $1 AND book_id = $2)`
+	return mr.attach(ctx, query, existsQuery, "book", materialID, bookID)
+}
+
+// DetachFromBook открепляет материал от книги.
+// Если материал не был прикреплен к книге, то возвращается ошибка [errs.NotFound].
+func (mr *MaterialRepo) DetachFromBook(ctx context.Context, materialID, bookID int) error {
+	query := `DELETE FROM material_books WHERE material_id = $1 AND book_id = $2`
+	return mr.detach(ctx, query, "book", materialID, bookID)
+}
+
+// attach выполняет запрос прикрепления материала, а если строка не вставлена, то по запросу existsQuery
+// отличает уже существующую связь от отсутствующих материала или владельца.
+func (mr *MaterialRepo) attach(ctx context.Context, query, existsQuery, owner string, materialID, ownerID int) error {
+	var linkID int
+	err := mr.db.GetContext(ctx, &linkID, query, materialID, ownerID)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("INSERT %v material: %w: %w", owner, errs.Internal, err)
+	}
+	var linked bool
+	if err := mr.db.GetContext(ctx, &linked, existsQuery, materialID, ownerID); err != nil {
+		return fmt.Errorf("SELECT %v material: %w: %w", owner, errs.Internal, err)
+	}
+	if !linked {
+		return fmt.Errorf("attach material %v to %v %v: %w", materialID, owner, ownerID, errs.NotFound)
+	}
+	return nil
+}
+
+// detach выполняет запрос открепления материала.
+func (mr *MaterialRepo) detach(ctx context.Context, query, owner string, materialID, ownerID int) error {
+	result, err := mr.db.ExecContext(ctx, query, materialID, ownerID)
+	if err != nil {
+		return fmt.Errorf("DELETE %v material: %w: %w", owner, errs.Internal, err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("detach material %v from %v %v: %w", materialID, owner, ownerID, errs.NotFound)
+	}
+	return nil
+}
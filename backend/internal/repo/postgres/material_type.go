@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// MaterialTypeRepo предоставляет доступ к базе данных с видами материалов.
+type MaterialTypeRepo struct {
+	db *sqlx.DB
+}
+
+// NewMaterialTypeRepo возвращает новый экземпляр [MaterialTypeRepo].
+func NewMaterialTypeRepo(db *sqlx.DB) *MaterialTypeRepo {
+	return &MaterialTypeRepo{db}
+}
+
+// Create сохраняет вид материалов в базе данных и возвращает его с номером или ошибку.
+func (mr *MaterialTypeRepo) Create(ctx context.Context, input *model.NewMaterialType) (*model.MaterialType, error) {
+	materialType := new(model.MaterialType)
+	query := `
+		INSERT INTO material_types (name)
+		VALUES ($1)
+		RETURNING type_id, name, version
+	`
+	if err := mr.db.GetContext(ctx, materialType, query, input.Name); err != nil {
+		return nil, fmt.Errorf("INSERT material type: %w", dbError(err, "material_types"))
+	}
+	return materialType, nil
+}
+
+// GetAll возвращает слайс всех видов материалов или ошибку.
+// Если база данных пуста, то возвращается ошибка [errs.Empty].
+func (mr *MaterialTypeRepo) GetAll(ctx context.Context) ([]model.MaterialType, error) {
+	var materialTypes []model.MaterialType
+	query := `SELECT * FROM material_types ORDER BY name, type_id`
+	if err := mr.db.SelectContext(ctx, &materialTypes, query); err != nil {
+		baseErr := errs.Internal
+		if errors.Is(err, sql.ErrNoRows) {
+			baseErr = errs.Empty
+		}
+		return nil, fmt.Errorf("SELECT material types: %w: %w", baseErr, err)
+	}
+	return materialTypes, nil
+}
+
+// Get возвращает вид материалов по номеру или ошибку.
+// Если вид материалов с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
+func (mr *MaterialTypeRepo) Get(ctx context.Context, ID int) (*model.MaterialType, error) {
+	materialType := new(model.MaterialType)
+	query := `SELECT * FROM material_types WHERE type_id = $1`
+	if err := mr.db.GetContext(ctx, materialType, query, ID); err != nil {
+		baseErr := errs.Internal
+		if errors.Is(err, sql.ErrNoRows) {
+			baseErr = errs.NotFound
+		}
+		return nil, fmt.Errorf("SELECT material type: %w: %w", baseErr, err)
+	}
+	return materialType, nil
+}
+
+// Update обновляет вид материалов по номеру и возвращает его с номером или ошибку.
+// Если вид материалов с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (mr *MaterialTypeRepo) Update(ctx context.Context, ID, version int, update *model.NewMaterialType) (*model.MaterialType, error) {
+	materialType := new(model.MaterialType)
+	query := `
+		UPDATE material_types
+		SET name = $1,
+			version = version + 1
+		WHERE type_id = $2 AND ($3 = 0 OR version = $3)
+		RETURNING type_id, name, version
+	`
+	if err := mr.db.GetContext(ctx, materialType, query, update.Name, ID, version); err != nil {
+		return nil, fmt.Errorf("UPDATE material type: %w", updateError(ctx, mr.db, err, "material_types", "type_id", ID, version))
+	}
+	return materialType, nil
+}
+
+// Patch изменяет указанные в патче поля вида материалов по номеру и возвращает его или ошибку.
+// Если вид материалов с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (mr *MaterialTypeRepo) Patch(ctx context.Context, ID, version int, patch *model.MaterialTypePatch) (*model.MaterialType, error) {
+	set := new(patchSet)
+	set.add("name", patch.Name)
+	query, args := set.update("material_types", "type_id", "type_id, name, version", ID, version)
+	materialType := new(model.MaterialType)
+	if err := mr.db.GetContext(ctx, materialType, query, args...); err != nil {
+		return nil, fmt.Errorf("UPDATE material type: %w", updateError(ctx, mr.db, err, "material_types", "type_id", ID, version))
+	}
+	return materialType, nil
+}
+
+// Delete удаляет вид материалов по номеру и возвращает ошибку, если удаления не произошло.
+// Если вид материалов с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (mr *MaterialTypeRepo) Delete(ctx context.Context, ID, version int) error {
+	query := `DELETE FROM material_types WHERE type_id = $1 AND ($2 = 0 OR version = $2)`
+	result, err := mr.db.ExecContext(ctx, query, ID, version)
+	if err != nil {
+		return fmt.Errorf("DELETE material type: %w", dbError(err, "material_types"))
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("DELETE material type: %w", missingRow(ctx, mr.db, "material_types", "type_id", ID, version))
+	}
+	return nil
+}
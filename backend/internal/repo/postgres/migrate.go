@@ -0,0 +1,170 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// migrationFiles содержит миграции схемы базы данных. Файл миграции называется
+// <версия>_<название>.sql, версии идут подряд с 1. Примененные миграции не изменяются:
+// каждое изменение схемы добавляется новой миграцией.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLock — название блокировки, под которой применяются миграции,
+// чтобы несколько экземпляров сервера не применяли их одновременно.
+const migrationLock = "elib:migrate"
+
+// migration представляет миграцию схемы базы данных.
+type migration struct {
+	version int    // версия схемы после миграции
+	name    string // название файла
+	sql     string // запросы миграции
+}
+
+// migrations содержит все миграции в порядке версий.
+var migrations = mustLoadMigrations(migrationFiles)
+
+// LatestVersion возвращает версию схемы базы данных, которую ожидают репозитории.
+func LatestVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// mustLoadMigrations читает миграции из files и проверяет, что их версии идут подряд с 1.
+// Если это не так, то вызывается паника: миграции встроены в программу и проверяются тестами.
+func mustLoadMigrations(files fs.FS) []migration {
+	names, err := fs.Glob(files, "migrations/*.sql")
+	if err != nil {
+		panic(fmt.Sprintf("postgres: list migrations: %v", err))
+	}
+	var loaded []migration
+	for _, name := range names {
+		name = path.Base(name)
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			panic(fmt.Sprintf("postgres: migration %v has no version", name))
+		}
+		sql, err := fs.ReadFile(files, "migrations/"+name)
+		if err != nil {
+			panic(fmt.Sprintf("postgres: read migration %v: %v", name, err))
+		}
+		loaded = append(loaded, migration{version: version, name: name, sql: string(sql)})
+	}
+	slices.SortFunc(loaded, func(a, b migration) int { return a.version - b.version })
+	for i, m := range loaded {
+		if m.version != i+1 {
+			panic(fmt.Sprintf("postgres: migration %v has version %v, want %v", m.name, m.version, i+1))
+		}
+	}
+	if len(loaded) == 0 {
+		panic("postgres: no migrations")
+	}
+	return loaded
+}
+
+// Migrate применяет к базе данных db миграции, которые еще не были применены, в одной транзакции
+// и возвращает их количество или ошибку. Примененные миграции записываются в таблицу schema_migrations.
+// База данных, созданная до появления миграций, считается находящейся в версии 1.
+func Migrate(ctx context.Context, db *sqlx.DB) (int, error) {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin migration: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, lockKey(migrationLock)); err != nil {
+		return 0, fmt.Errorf("lock migrations: %w", err)
+	}
+	query := `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version integer PRIMARY KEY,
+			name text NOT NULL,
+			applied_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return 0, fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	var current int
+	if err := tx.GetContext(ctx, &current, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`); err != nil {
+		return 0, fmt.Errorf("select schema version: %w", err)
+	}
+	if current == 0 {
+		var existing bool
+		if err := tx.GetContext(ctx, &existing, `SELECT to_regclass('departments') IS NOT NULL`); err != nil {
+			return 0, fmt.Errorf("check existing schema: %w", err)
+		}
+		if existing {
+			if err := recordMigration(ctx, tx, migrations[0]); err != nil {
+				return 0, err
+			}
+			current = migrations[0].version
+		}
+	}
+
+	if current >= len(migrations) {
+		return 0, nil
+	}
+	applied := 0
+	for _, m := range migrations[current:] {
+		if _, err := tx.ExecContext(ctx, m.sql); err != nil {
+			return 0, fmt.Errorf("apply migration %v: %w", m.name, err)
+		}
+		if err := recordMigration(ctx, tx, m); err != nil {
+			return 0, err
+		}
+		applied++
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit migrations: %w", err)
+	}
+	return applied, nil
+}
+
+// recordMigration записывает миграцию m в таблицу schema_migrations.
+func recordMigration(ctx context.Context, tx *sqlx.Tx, m migration) error {
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.version, m.name); err != nil {
+		return fmt.Errorf("record migration %v: %w", m.name, err)
+	}
+	return nil
+}
+
+// SchemaVersion возвращает версию схемы базы данных db — последнюю примененную миграцию — или ошибку.
+// Если миграции не применялись, то возвращается 0.
+func SchemaVersion(ctx context.Context, db sqlx.QueryerContext) (int, error) {
+	var exists bool
+	if err := sqlx.GetContext(ctx, db, &exists, `SELECT to_regclass('schema_migrations') IS NOT NULL`); err != nil {
+		return 0, fmt.Errorf("check schema_migrations: %w", err)
+	}
+	if !exists {
+		return 0, nil
+	}
+	var version int
+	if err := sqlx.GetContext(ctx, db, &version, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`); err != nil {
+		return 0, fmt.Errorf("select schema version: %w", err)
+	}
+	return version, nil
+}
+
+// CheckSchema проверяет, что к базе данных db применены все миграции, которые ожидают репозитории.
+// Схема новее ожидаемой не считается ошибкой: при обновлении миграции применяются раньше,
+// чем перезапускаются экземпляры сервера со старой версией.
+func CheckSchema(ctx context.Context, db *sqlx.DB) error {
+	version, err := SchemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+	if latest := LatestVersion(); version < latest {
+		return fmt.Errorf("database schema is at version %v, want %v: apply migrations", version, latest)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	if LatestVersion() != len(migrations) {
+		t.Errorf("LatestVersion: got %v, want %v", LatestVersion(), len(migrations))
+	}
+	files := fstest.MapFS{
+		"migrations/0002_second.sql": {Data: []byte("SELECT 2")},
+		"migrations/0001_first.sql":  {Data: []byte("SELECT 1")},
+	}
+	loaded := mustLoadMigrations(files)
+	if len(loaded) != 2 || loaded[0].name != "0001_first.sql" || loaded[1].sql != "SELECT 2" {
+		t.Errorf("mustLoadMigrations: got %+v", loaded)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("mustLoadMigrations with a gap: got no panic")
+		}
+	}()
+	mustLoadMigrations(fstest.MapFS{"migrations/0002_second.sql": {Data: []byte("SELECT 2")}})
+}
+
+func TestCheckSchema(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	expectNoErr(t, CheckSchema(ctx, db))
+	version, err := SchemaVersion(ctx, db)
+	expectNoErr(t, err)
+	if version != LatestVersion() {
+		t.Errorf("SchemaVersion: got %v, want %v", version, LatestVersion())
+	}
+
+	applied, err := Migrate(ctx, db)
+	expectNoErr(t, err)
+	if applied != 0 {
+		t.Errorf("Migrate of an up-to-date schema: got %v applied migrations, want 0", applied)
+	}
+
+	if _, err := db.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = $1", LatestVersion()); err != nil {
+		t.Fatalf("delete migration: %v", err)
+	}
+	if err := CheckSchema(ctx, db); err == nil {
+		t.Error("CheckSchema without the latest migration: got no error")
+	}
+}
@@ -1,3 +1,5 @@
+-- Исходная схема базы данных.
+
 CREATE TABLE departments (
     department_id SERIAL PRIMARY KEY,
     name VARCHAR(50) NOT NULL
@@ -0,0 +1,102 @@
+-- Версии и корзина справочников, профили и отключение пользователей, архив взводов,
+-- время использования сессий, уникальные связи материалов, журнал аудита и история задач.
+-- Миграция повторяема: ее можно применить и к базе данных, созданной до появления миграций,
+-- в которой часть изменений уже есть.
+
+ALTER TABLE departments
+    ADD COLUMN IF NOT EXISTS deleted_at timestamp,
+    ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+
+ALTER TABLE specialties
+    ADD COLUMN IF NOT EXISTS deleted_at timestamp,
+    ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+
+ALTER TABLE groups
+    ADD COLUMN IF NOT EXISTS archived_at timestamp,
+    ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS rank varchar(30),
+    ADD COLUMN IF NOT EXISTS position varchar(100),
+    ADD COLUMN IF NOT EXISTS department_id integer REFERENCES departments,
+    ADD COLUMN IF NOT EXISTS email varchar(100),
+    ADD COLUMN IF NOT EXISTS phone varchar(16),
+    ADD COLUMN IF NOT EXISTS photo_filepath text,
+    ADD COLUMN IF NOT EXISTS preferences jsonb NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS deactivated_at timestamp,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamp,
+    ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS users_full_name_idx ON users (surname, name, patronymic);
+
+ALTER TABLE users_credentials
+    ADD COLUMN IF NOT EXISTS must_change_password boolean NOT NULL DEFAULT false;
+
+ALTER TABLE sessions
+    ADD COLUMN IF NOT EXISTS last_used_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS sessions_user_id_idx ON sessions (user_id) WHERE logged_out_at IS NULL;
+
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_schema = current_schema() AND table_name = 'disciplines' AND column_name = 'discipine_id') THEN
+        ALTER TABLE disciplines RENAME COLUMN discipine_id TO discipline_id;
+    END IF;
+END $$;
+ALTER TABLE disciplines
+    ADD COLUMN IF NOT EXISTS deleted_at timestamp,
+    ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+
+ALTER TABLE material_types
+    ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+
+ALTER TABLE materials
+    ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'lesson_materials_lesson_id_material_id_key'
+                   AND connamespace = current_schema()::regnamespace) THEN
+        ALTER TABLE lesson_materials ADD UNIQUE (lesson_id, material_id);
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'material_books_book_id_material_id_key'
+                   AND connamespace = current_schema()::regnamespace) THEN
+        ALTER TABLE material_books ADD UNIQUE (book_id, material_id);
+    END IF;
+END $$;
+
+CREATE TABLE IF NOT EXISTS audit_events (
+    audit_event_id serial PRIMARY KEY,
+    actor_id integer,
+    action varchar(10) NOT NULL CHECK(action IN ('create', 'update', 'delete')),
+    entity varchar(30) NOT NULL,
+    entity_id integer NOT NULL,
+    before jsonb,
+    after jsonb,
+    ip varchar(45),
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_created_at_idx ON audit_events (created_at);
+CREATE INDEX IF NOT EXISTS audit_events_entity_idx ON audit_events (entity, entity_id);
+
+CREATE TABLE IF NOT EXISTS job_runs (
+    job_run_id serial PRIMARY KEY,
+    job varchar(50) NOT NULL,
+    triggered_by varchar(10) NOT NULL CHECK(triggered_by IN ('schedule', 'manual')),
+    actor_id integer,
+    status varchar(10) NOT NULL DEFAULT 'running' CHECK(status IN ('running', 'succeeded', 'failed')),
+    affected integer NOT NULL DEFAULT 0,
+    error text,
+    started_at timestamp NOT NULL,
+    finished_at timestamp
+);
+
+CREATE INDEX IF NOT EXISTS job_runs_job_started_at_idx ON job_runs (job, started_at);
+
+-- Роли, на которые ссылаются пользователи, в новой базе данных.
+INSERT INTO roles (name)
+SELECT name FROM (VALUES (1, 'student'), (2, 'teacher'), (3, 'manager'), (4, 'admin')) AS default_roles (position, name)
+WHERE NOT EXISTS (SELECT 1 FROM roles)
+ORDER BY position;
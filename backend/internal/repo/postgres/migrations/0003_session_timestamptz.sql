@@ -0,0 +1,8 @@
+-- Время сессий хранится с часовым поясом, чтобы сравнение с текущим временем сервера
+-- не зависело от часового пояса соединения с базой данных. Записанное время
+-- считается заданным в часовом поясе соединения, в котором оно и записывалось.
+
+ALTER TABLE sessions
+    ALTER COLUMN logged_in_at TYPE timestamptz USING logged_in_at AT TIME ZONE current_setting('TimeZone'),
+    ALTER COLUMN logged_out_at TYPE timestamptz USING logged_out_at AT TIME ZONE current_setting('TimeZone'),
+    ALTER COLUMN last_used_at TYPE timestamptz USING last_used_at AT TIME ZONE current_setting('TimeZone');
@@ -0,0 +1,6 @@
+-- Время запусков задач хранится с часовым поясом, как и время сессий. Записанное время
+-- задавалось сервером в UTC.
+
+ALTER TABLE job_runs
+    ALTER COLUMN started_at TYPE timestamptz USING started_at AT TIME ZONE 'UTC',
+    ALTER COLUMN finished_at TYPE timestamptz USING finished_at AT TIME ZONE 'UTC';
@@ -2,10 +2,27 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+
+	"github.com/XSAM/otelsql"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+)
+
+// Коды ошибок PostgreSQL, которые преобразуются в ошибки пакета [errs].
+const (
+	uniqueViolation     pq.ErrorCode = "23505"
+	foreignKeyViolation pq.ErrorCode = "23503"
 )
 
 // Config представляет параметры конфигурации для подключения к базе данных.
@@ -13,18 +30,173 @@ type Config struct {
 	Host     string
 	Port     int
 	User     string
-	Password *string
+	Password string // пароль (пусто — без пароля)
 	DBName   string
 	SSLMode  string
+
+	MaxOpenConns    int           // максимальное количество соединений (0 — без ограничения)
+	MaxIdleConns    int           // максимальное количество простаивающих соединений
+	ConnMaxLifetime time.Duration // время жизни соединения (0 — без ограничения)
+	ConnMaxIdleTime time.Duration // время простоя соединения до закрытия (0 — без ограничения)
 }
 
-// NewDB возвращает новый экземляр базы данных Postgres или ошибку.
+// DSN возвращает строку подключения в виде пар ключ=значение.
+// Значения заключаются в кавычки, поэтому могут содержать пробелы, кавычки и обратную косую черту.
+func (cfg Config) DSN() string {
+	params := []struct{ key, value string }{
+		{"host", cfg.Host},
+		{"port", strconv.Itoa(cfg.Port)},
+		{"user", cfg.User},
+		{"password", cfg.Password},
+		{"dbname", cfg.DBName},
+		{"sslmode", cfg.SSLMode},
+	}
+	pairs := make([]string, 0, len(params))
+	for _, param := range params {
+		if param.value != "" {
+			pairs = append(pairs, param.key+"="+quoteDSNValue(param.value))
+		}
+	}
+	return strings.Join(pairs, " ")
+}
+
+// quoteDSNValue заключает значение строки подключения в одинарные кавычки,
+// экранируя кавычки и обратную косую черту.
+func quoteDSNValue(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// NewDB возвращает новый экземляр базы данных Postgres с настроенным пулом соединений или ошибку.
 // Подключение к базе данных устанавливается сразу, вызывать Ping не нужно.
+// Каждый запрос к базе данных записывается в отдельный span OpenTelemetry.
 func NewDB(ctx context.Context, cfg Config) (*sqlx.DB, error) {
-	connectString := fmt.Sprintf("host=%v port=%v user=%v dbname=%v sslmode=%v",
-		cfg.Host, cfg.Port, cfg.User, cfg.DBName, cfg.SSLMode)
-	if cfg.Password != nil {
-		connectString += fmt.Sprintf("password=%v", *cfg.Password)
+	sqlDB, err := otelsql.Open("postgres", cfg.DSN(), tracingOptions...)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	db := sqlx.NewDb(sqlDB, "postgres")
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// nullJSON возвращает nil для пустого json, чтобы в базу данных записывался NULL.
+func nullJSON(raw []byte) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
+
+// nullRawJSON возвращает nil для отсутствующего json, чтобы в базу данных записывался NULL.
+func nullRawJSON(raw *json.RawMessage) any {
+	if raw == nil {
+		return nil
+	}
+	return nullJSON(*raw)
+}
+
+// dbError возвращает ошибку err, возникшую при изменении таблицы table, обернутую в ошибку пакета [errs].
+// Нарушение уникальности превращается в [errs.Conflict]. Нарушение внешнего ключа превращается
+// в [errs.InvalidReference], если несуществующий ресурс указан в самой таблице table,
+// или в [errs.ReferencedBy], если на изменяемую строку ссылается другая таблица.
+// Для этих ошибок возвращается [errs.ConstraintError] с названием ограничения и поля.
+// Если строка для изменения не нашлась, то возвращается [errs.NotFound], иначе — [errs.Internal].
+func dbError(err error, table string) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %w", errs.NotFound, err)
+	}
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return fmt.Errorf("%w: %w", errs.Internal, err)
+	}
+
+	var kind error
+	suffix := "_fkey"
+	switch pqErr.Code {
+	case uniqueViolation:
+		kind, suffix = errs.Conflict, "_key"
+	case foreignKeyViolation:
+		kind = errs.ReferencedBy
+		if pqErr.Table == table {
+			kind = errs.InvalidReference
+		}
+	default:
+		return fmt.Errorf("%w: %w", errs.Internal, err)
+	}
+	// Ограничения в схеме названы по умолчанию: <таблица>_<поле>_key или <таблица>_<поле>_fkey.
+	field := strings.TrimSuffix(strings.TrimPrefix(pqErr.Constraint, pqErr.Table+"_"), suffix)
+	return &errs.ConstraintError{
+		Kind:       kind,
+		Constraint: pqErr.Constraint,
+		Table:      pqErr.Table,
+		Field:      field,
+		Err:        err,
+	}
+}
+
+// missingRow возвращает ошибку для строки таблицы table с ключом key, равным ID,
+// которую не нашел запрос с условием на версию version (0 — любая версия). Строки в корзине считаются отсутствующими.
+// Если строка существует, но ее версия отличается, то возвращается [errs.VersionMismatch], иначе — [errs.NotFound].
+func missingRow(ctx context.Context, db sqlx.QueryerContext, table, key string, ID, version int) error {
+	if version == 0 {
+		return errs.NotFound
+	}
+	var exists bool
+	query := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %v WHERE %v = $1)`, table, key)
+	if hasTrash(table) {
+		query = fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %v WHERE %v = $1 AND deleted_at IS NULL)`, table, key)
+	}
+	if err := sqlx.GetContext(ctx, db, &exists, query, ID); err != nil {
+		return fmt.Errorf("%w: %w", errs.Internal, err)
+	}
+	if exists {
+		return errs.VersionMismatch
+	}
+	return errs.NotFound
+}
+
+// updateError возвращает ошибку err, возникшую при изменении строки таблицы table с ключом key, равным ID,
+// и версией version, обернутую в ошибку пакета [errs], как это делают [missingRow] и [dbError].
+func updateError(ctx context.Context, db sqlx.QueryerContext, err error, table, key string, ID, version int) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return missingRow(ctx, db, table, key, ID, version)
+	}
+	return dbError(err, table)
+}
+
+// patchSet собирает изменения столбцов по полям патча, указанным в запросе.
+// Столбцы, поля которых не указаны, не изменяются.
+type patchSet struct {
+	columns []string // присваивания новых значений столбцам
+	args    []any    // новые значения столбцов
+}
+
+// add добавляет изменение столбца column, если поле field указано в патче.
+func (ps *patchSet) add(column string, field model.PatchField) {
+	if !field.IsSet() {
+		return
+	}
+	ps.args = append(ps.args, field.PatchValue())
+	ps.columns = append(ps.columns, fmt.Sprintf("%v = $%v", column, len(ps.args)))
+}
+
+// update возвращает запрос с аргументами, который изменяет столбцы строки таблицы table с ключом key, равным ID,
+// и версией version (0 — любая версия), увеличивает ее версию и возвращает столбцы returning.
+// Строки в корзине не изменяются.
+func (ps *patchSet) update(table, key, returning string, ID, version int) (string, []any) {
+	columns := append(slices.Clip(ps.columns), "version = version + 1")
+	args := append(slices.Clip(ps.args), ID, version)
+	condition := fmt.Sprintf("%v = $%v AND ($%v = 0 OR version = $%v)", key, len(args)-1, len(args), len(args))
+	if hasTrash(table) {
+		condition += " AND deleted_at IS NULL"
 	}
-	return sqlx.ConnectContext(ctx, "postgres", connectString)
+	query := fmt.Sprintf(`UPDATE %v SET %v WHERE %v RETURNING %v`, table, strings.Join(columns, ", "), condition, returning)
+	return query, args
 }
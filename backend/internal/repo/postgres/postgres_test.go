@@ -0,0 +1,344 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Тесты репозиториев выполняются на настоящей базе данных PostgreSQL,
+// строка подключения к которой передается в переменной окружения testDSNEnv, например:
+//
+//	ELIB_TEST_DSN="host=localhost user=postgres dbname=elib_test sslmode=disable" go test ./internal/repo/postgres
+//
+// Перед запуском тестов в базе данных создается временная схема, к которой применяются миграции [Migrate].
+// Каждый тест работает в своей транзакции, которая откатывается по его завершении,
+// поэтому тесты не видят данных друг друга. После тестов схема удаляется.
+// Если переменная окружения не задана, то тесты пропускаются.
+const testDSNEnv = "ELIB_TEST_DSN" // переменная окружения со строкой подключения
+
+// testDSN содержит строку подключения к временной схеме, если тесты на базе данных включены.
+var testDSN string
+
+func TestMain(m *testing.M) {
+	os.Exit(runTests(m))
+}
+
+// runTests создает временную схему, если задана строка подключения, запускает тесты и удаляет схему.
+func runTests(m *testing.M) int {
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		return m.Run()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	db, err := sqlx.ConnectContext(ctx, "postgres", dsn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "connect to %v: %v\n", testDSNEnv, err)
+		return 1
+	}
+	defer db.Close()
+
+	schema := fmt.Sprintf("elib_test_%v_%v", os.Getpid(), time.Now().UnixNano())
+	if _, err := db.ExecContext(ctx, "CREATE SCHEMA "+schema); err != nil {
+		fmt.Fprintf(os.Stderr, "create schema: %v\n", err)
+		return 1
+	}
+	defer db.ExecContext(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+
+	testDSN = withSearchPath(dsn, schema)
+	if err := applySchema(ctx, testDSN); err != nil {
+		fmt.Fprintf(os.Stderr, "apply migrations: %v\n", err)
+		return 1
+	}
+	return m.Run()
+}
+
+// withSearchPath добавляет к строке подключения dsn параметр search_path со схемой schema.
+// Поддерживаются строки подключения в виде url и в виде пар ключ=значение.
+func withSearchPath(dsn, schema string) string {
+	if strings.Contains(dsn, "://") {
+		separator := "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+		return dsn + separator + "search_path=" + schema
+	}
+	return dsn + " search_path=" + schema
+}
+
+// applySchema применяет к базе данных все миграции.
+func applySchema(ctx context.Context, dsn string) error {
+	db, err := sqlx.ConnectContext(ctx, "postgres", dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	_, err = Migrate(ctx, db)
+	return err
+}
+
+// newTestDB возвращает подключение к временной схеме, все изменения в котором откатываются по завершении теста.
+// Если строка подключения не задана, то тест пропускается.
+func newTestDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	if testDSN == "" {
+		t.Skipf("%v is not set", testDSNEnv)
+	}
+	connector, err := pq.NewConnector(testDSN)
+	if err != nil {
+		t.Fatalf("create connector: %v", err)
+	}
+	db := sql.OpenDB(&rollbackConnector{connector})
+	// Все запросы теста должны выполняться в одном соединении, иначе они попадут в разные транзакции.
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	t.Cleanup(func() { db.Close() })
+	return sqlx.NewDb(db, "postgres")
+}
+
+// rollbackConnector открывает соединения, в которых сразу начинается транзакция,
+// откатываемая при закрытии соединения.
+type rollbackConnector struct {
+	driver.Connector
+}
+
+func (rc *rollbackConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := rc.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rbConn := &rollbackConn{Conn: conn}
+	if err := rbConn.exec(ctx, "BEGIN"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return rbConn, nil
+}
+
+// rollbackConn представляет соединение внутри внешней транзакции теста.
+// Транзакции репозиториев превращаются в точки сохранения внешней транзакции.
+// Каждый запрос вне транзакции репозитория тоже выполняется после точки сохранения,
+// чтобы ошибка запроса, которую проверяет тест, не прерывала внешнюю транзакцию.
+type rollbackConn struct {
+	driver.Conn
+	inTx bool // выполняется транзакция репозитория
+}
+
+// exec выполняет запрос без параметров в соединении.
+func (rc *rollbackConn) exec(ctx context.Context, query string) error {
+	_, err := rc.Conn.(driver.ExecerContext).ExecContext(ctx, query, nil)
+	return err
+}
+
+// guard выполняет запрос run после точки сохранения, если не выполняется транзакция репозитория,
+// и откатывается к ней при ошибке.
+func (rc *rollbackConn) guard(ctx context.Context, run func() error) error {
+	if rc.inTx {
+		return run()
+	}
+	if err := rc.exec(ctx, "SAVEPOINT statement"); err != nil {
+		return err
+	}
+	if err := run(); err != nil {
+		return errors.Join(err, rc.exec(context.Background(), "ROLLBACK TO SAVEPOINT statement"))
+	}
+	return nil
+}
+
+func (rc *rollbackConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (result driver.Result, err error) {
+	err = rc.guard(ctx, func() error {
+		result, err = rc.Conn.(driver.ExecerContext).ExecContext(ctx, query, args)
+		return err
+	})
+	return result, err
+}
+
+func (rc *rollbackConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (rows driver.Rows, err error) {
+	err = rc.guard(ctx, func() error {
+		rows, err = rc.Conn.(driver.QueryerContext).QueryContext(ctx, query, args)
+		return err
+	})
+	return rows, err
+}
+
+func (rc *rollbackConn) Begin() (driver.Tx, error) {
+	return rc.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (rc *rollbackConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if rc.inTx {
+		return nil, errors.New("nested transactions are not supported")
+	}
+	if err := rc.exec(ctx, "SAVEPOINT tx"); err != nil {
+		return nil, err
+	}
+	rc.inTx = true
+	return &savepointTx{rc}, nil
+}
+
+// ResetSession и IsValid нужны, чтобы database/sql не закрывал соединение при откате транзакции
+// по отмене контекста, которым завершаются транзакции репозиториев при ошибках.
+func (rc *rollbackConn) ResetSession(ctx context.Context) error {
+	return rc.Conn.(driver.SessionResetter).ResetSession(ctx)
+}
+
+func (rc *rollbackConn) IsValid() bool {
+	return rc.Conn.(driver.Validator).IsValid()
+}
+
+func (rc *rollbackConn) Close() error {
+	return errors.Join(rc.exec(context.Background(), "ROLLBACK"), rc.Conn.Close())
+}
+
+// savepointTx представляет транзакцию репозитория как точку сохранения внешней транзакции.
+type savepointTx struct {
+	conn *rollbackConn
+}
+
+func (st *savepointTx) Commit() error {
+	st.conn.inTx = false
+	return st.conn.exec(context.Background(), "RELEASE SAVEPOINT tx")
+}
+
+func (st *savepointTx) Rollback() error {
+	st.conn.inTx = false
+	return st.conn.exec(context.Background(), "ROLLBACK TO SAVEPOINT tx")
+}
+
+// fixtures создает связанные записи, нужные тестам репозиториев.
+type fixtures struct {
+	t   *testing.T
+	ctx context.Context
+	db  *sqlx.DB
+}
+
+// newFixtures возвращает подключение к временной схеме и помощник для создания записей в нем.
+func newFixtures(t *testing.T) *fixtures {
+	return &fixtures{t: t, ctx: context.Background(), db: newTestDB(t)}
+}
+
+func (f *fixtures) department(name string) *model.Department {
+	f.t.Helper()
+	department, err := NewDepartmentRepo(f.db).Create(f.ctx, &model.NewDepartment{Name: name})
+	if err != nil {
+		f.t.Fatalf("create department: %v", err)
+	}
+	return department
+}
+
+func (f *fixtures) specialty(name string, departmentID int) *model.Specialty {
+	f.t.Helper()
+	specialty, err := NewSpecialtyRepo(f.db).Create(f.ctx, &model.NewSpecialty{Name: name, DepartmentID: departmentID})
+	if err != nil {
+		f.t.Fatalf("create specialty: %v", err)
+	}
+	return specialty
+}
+
+func (f *fixtures) group(name string, specialtyID int) *model.Group {
+	f.t.Helper()
+	group, err := NewGroupRepo(f.db).Create(f.ctx, &model.NewGroup{Name: name, SpecialtyID: specialtyID})
+	if err != nil {
+		f.t.Fatalf("create group: %v", err)
+	}
+	return group
+}
+
+// groupWithSpecialty создает взвод name вместе с кафедрой и специальностью.
+func (f *fixtures) groupWithSpecialty(name string) *model.Group {
+	f.t.Helper()
+	return f.group(name, f.specialty("Специальность", f.department("Кафедра").ID).ID)
+}
+
+// user создает пользователя с логином login, ролью roleName и взводом groupID, если он не nil.
+func (f *fixtures) user(login, roleName string, groupID *int) *model.User {
+	f.t.Helper()
+	repo := NewUserRepo(f.db)
+	role, err := repo.GetRoleByName(f.ctx, roleName)
+	if err != nil {
+		f.t.Fatalf("get role %v: %v", roleName, err)
+	}
+	user, err := repo.Create(f.ctx, &model.NewUser{
+		Name:     "Иван",
+		Surname:  "Иванов",
+		Login:    login,
+		Password: strings.Repeat("a", 64),
+		RoleID:   role.ID,
+		GroupID:  groupID,
+	})
+	if err != nil {
+		f.t.Fatalf("create user %v: %v", login, err)
+	}
+	return user
+}
+
+// session начинает сессию пользователя и возвращает ее токен обновления.
+func (f *fixtures) session(userID int, refreshToken string) *model.Token {
+	f.t.Helper()
+	token, _, err := NewSessionRepo(f.db).Create(f.ctx, userID, &model.NewToken{
+		RefreshToken: refreshToken,
+		ExpiresAt:    int(time.Now().Add(time.Hour).Unix()),
+	}, 0)
+	if err != nil {
+		f.t.Fatalf("create session: %v", err)
+	}
+	return token
+}
+
+// expectErr проверяет, что ошибка err является ошибкой target.
+func expectErr(t *testing.T, err, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Errorf("got error %v, want %v", err, target)
+	}
+}
+
+// expectConstraint проверяет, что ошибка err является нарушением ограничения вида kind для поля field.
+func expectConstraint(t *testing.T, err, kind error, field string) {
+	t.Helper()
+	var ce *errs.ConstraintError
+	if !errors.Is(err, kind) || !errors.As(err, &ce) || ce.Field != field {
+		t.Errorf("got error %v, want %v for field %v", err, kind, field)
+	}
+}
+
+// expectNoErr завершает тест, если произошла ошибка err.
+func expectNoErr(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// token возвращает токен обновления длиной 64 символа, начинающийся с prefix.
+func token(prefix string) string {
+	return prefix + strings.Repeat("0", 64-len(prefix))
+}
+
+func TestConfigDSN(t *testing.T) {
+	cfg := Config{Host: "db", Port: 5432, User: "elib", DBName: "aumsu", SSLMode: "disable"}
+	if got, want := cfg.DSN(), `host='db' port='5432' user='elib' dbname='aumsu' sslmode='disable'`; got != want {
+		t.Errorf("without password: got %v, want %v", got, want)
+	}
+	cfg.Password = `p@ss 'word\`
+	if _, err := pq.NewConnector(cfg.DSN()); err != nil {
+		t.Fatalf("parse DSN %v: %v", cfg.DSN(), err)
+	}
+	if got, want := cfg.DSN(), `host='db' port='5432' user='elib' password='p@ss \'word\\' dbname='aumsu' sslmode='disable'`; got != want {
+		t.Errorf("with password: got %v, want %v", got, want)
+	}
+}
@@ -24,13 +24,20 @@ func NewSessionRepo(db *sqlx.DB) *SessionRepo {
 }
 
 // Create создает новый токен обновления, записывает время начала сессии для пользователя
-// и возвращает токен обновления с номером или ошибку.
-func (sr *SessionRepo) Create(ctx context.Context, userID int, input *model.NewToken) (*model.Token, error) {
+// и возвращает токен обновления с номером или ошибку. Если keep больше нуля, то в той же транзакции
+// незавершенные сессии пользователя, кроме keep самых новых, завершаются, а их токены обновления удаляются;
+// номера завершенных сессий также возвращаются. Сессии одного пользователя создаются по очереди,
+// поэтому одновременные входы не оставляют больше keep сессий.
+func (sr *SessionRepo) Create(ctx context.Context, userID int, input *model.NewToken, keep int) (*model.Token, []int, error) {
 	txCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
 	tx, err := sr.db.BeginTxx(txCtx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("begin the transaction: %w: %w", errs.Internal, err)
+		return nil, nil, fmt.Errorf("begin the transaction: %w: %w", errs.Internal, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, lockKey(sessionsLock(userID))); err != nil {
+		return nil, nil, fmt.Errorf("lock sessions of user %v: %w: %w", userID, errs.Internal, err)
 	}
 
 	var sessionID int
@@ -40,7 +47,7 @@ func (sr *SessionRepo) Create(ctx context.Context, userID int, input *model.NewT
 		RETURNING session_id
 	`
 	if err = tx.GetContext(ctx, &sessionID, sessionQuery, userID); err != nil {
-		return nil, fmt.Errorf("insert the session: %w: %w", errs.Internal, err)
+		return nil, nil, fmt.Errorf("insert the session: %w", dbError(err, "sessions"))
 	}
 
 	token := new(model.Token)
@@ -50,13 +57,25 @@ func (sr *SessionRepo) Create(ctx context.Context, userID int, input *model.NewT
 		RETURNING token_id, refresh_token, expires_at, session_id
 	`
 	if err := tx.GetContext(ctx, token, tokenQuery, input.RefreshToken, input.ExpiresAt, sessionID); err != nil {
-		return nil, fmt.Errorf("insert the token: %w: %w", errs.Internal, err)
+		return nil, nil, fmt.Errorf("insert the token: %w", dbError(err, "tokens"))
+	}
+
+	var ended []int
+	if keep > 0 {
+		if ended, err = endExcessSessions(ctx, tx, userID, keep); err != nil {
+			return nil, nil, err
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("commit the changes: %w: %w", errs.Internal, err)
+		return nil, nil, fmt.Errorf("commit the changes: %w: %w", errs.Internal, err)
 	}
-	return token, nil
+	return token, ended, nil
+}
+
+// sessionsLock возвращает название блокировки, под которой создаются сессии пользователя userID.
+func sessionsLock(userID int) string {
+	return fmt.Sprintf("elib:sessions:%d", userID)
 }
 
 // PopByRefreshToken удаляет токен обновления из базы данных и возвращает всю информацию о нем или ошибку.
@@ -69,21 +88,48 @@ func (sr *SessionRepo) PopByRefreshToken(ctx context.Context, refreshToken strin
 		RETURNING token_id, refresh_token, expires_at, session_id
 	`
 	if err := sr.db.GetContext(ctx, token, tokenQuery, refreshToken); err != nil {
-		return nil, fmt.Errorf("pop the refresh token: %w: %w", errs.NotFound, err)
+		baseErr := errs.Internal
+		if errors.Is(err, sql.ErrNoRows) {
+			baseErr = errs.NotFound
+		}
+		return nil, fmt.Errorf("pop the refresh token: %w: %w", baseErr, err)
 	}
 	return token, nil
 }
 
-// UpdateRefreshToken создает новый токен обновления для сессии и возвращает токен с номером или ошибку.
+// GetSession возвращает сессию по ее номеру или ошибку.
+// Если сессия не найдена, то возвращается ошибка [errs.NotFound].
+func (sr *SessionRepo) GetSession(ctx context.Context, sessionID int) (*model.Session, error) {
+	session := new(model.Session)
+	query := `
+		SELECT session_id, logged_in_at, logged_out_at, last_used_at, user_id
+		FROM sessions
+		WHERE session_id = $1
+	`
+	if err := sr.db.GetContext(ctx, session, query, sessionID); err != nil {
+		baseErr := errs.Internal
+		if errors.Is(err, sql.ErrNoRows) {
+			baseErr = errs.NotFound
+		}
+		return nil, fmt.Errorf("get the session: %w: %w", baseErr, err)
+	}
+	return session, nil
+}
+
+// UpdateRefreshToken создает новый токен обновления для сессии, записывает время ее последнего использования
+// и возвращает токен с номером или ошибку.
 func (sr *SessionRepo) UpdateRefreshToken(ctx context.Context, sessionID int, update *model.NewToken) (*model.Token, error) {
 	token := new(model.Token)
 	query := `
+		WITH used AS (
+			UPDATE sessions SET last_used_at = CURRENT_TIMESTAMP WHERE session_id = $3
+		)
 		INSERT INTO tokens (refresh_token, expires_at, session_id)
 		VALUES ($1, $2, $3)
 		RETURNING token_id, refresh_token, expires_at, session_id
 	`
 	if err := sr.db.GetContext(ctx, token, query, update.RefreshToken, update.ExpiresAt, sessionID); err != nil {
-		return nil, fmt.Errorf("update the refresh token: %w: %w", errs.Internal, err)
+		return nil, fmt.Errorf("update the refresh token: %w", dbError(err, "tokens"))
 	}
 	return token, nil
 }
@@ -92,16 +138,101 @@ func (sr *SessionRepo) UpdateRefreshToken(ctx context.Context, sessionID int, up
 func (sr *SessionRepo) EndSession(ctx context.Context, sessionID int) error {
 	query := `
 		UPDATE sessions
-		SET logged_out_at = $1
-		WHERE session_id = $2
+		SET logged_out_at = CURRENT_TIMESTAMP
+		WHERE session_id = $1
 	`
-	_, err := sr.db.ExecContext(ctx, query, time.Now(), sessionID)
+	_, err := sr.db.ExecContext(ctx, query, sessionID)
 	if err != nil {
 		return fmt.Errorf("end the session: %w: %w", errs.Internal, err)
 	}
 	return nil
 }
 
+// endExcessSessions завершает в транзакции tx незавершенные сессии пользователя userID, кроме keep самых новых,
+// удаляет их токены обновления и возвращает номера завершенных сессий или ошибку.
+func endExcessSessions(ctx context.Context, tx *sqlx.Tx, userID int, keep int) ([]int, error) {
+	var ended []int
+	query := `
+		WITH ended AS (
+			UPDATE sessions
+			SET logged_out_at = CURRENT_TIMESTAMP
+			WHERE session_id IN (
+				SELECT session_id
+				FROM sessions
+				WHERE user_id = $1 AND logged_out_at IS NULL
+				ORDER BY logged_in_at DESC, session_id DESC
+				OFFSET $2
+				FOR UPDATE
+			)
+			RETURNING session_id
+		), deleted AS (
+			DELETE FROM tokens WHERE session_id IN (SELECT session_id FROM ended)
+		)
+		SELECT session_id FROM ended ORDER BY session_id
+	`
+	if err := tx.SelectContext(ctx, &ended, query, userID, keep); err != nil {
+		return nil, fmt.Errorf("end excess sessions: %w: %w", errs.Internal, err)
+	}
+	return ended, nil
+}
+
+// CountActive возвращает количество незавершенных сессий, токен обновления которых
+// действителен в момент now (в секундах unix), или ошибку.
+func (sr *SessionRepo) CountActive(ctx context.Context, now int) (int, error) {
+	var count int
+	query := `
+		SELECT count(*)
+		FROM sessions s
+		WHERE s.logged_out_at IS NULL
+			AND EXISTS (SELECT 1 FROM tokens t WHERE t.session_id = s.session_id AND t.expires_at > $1)
+	`
+	if err := sr.db.GetContext(ctx, &count, query, now); err != nil {
+		return 0, fmt.Errorf("count active sessions: %w: %w", errs.Internal, err)
+	}
+	return count, nil
+}
+
+// DeleteExpiredTokens удаляет токены обновления, истекшие к моменту now (в секундах unix),
+// и возвращает их количество или ошибку.
+func (sr *SessionRepo) DeleteExpiredTokens(ctx context.Context, now int) (int, error) {
+	result, err := sr.db.ExecContext(ctx, `DELETE FROM tokens WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, fmt.Errorf("delete expired tokens: %w: %w", errs.Internal, err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("count deleted tokens: %w: %w", errs.Internal, err)
+	}
+	return int(deleted), nil
+}
+
+// EndStaleSessions завершает незавершенные сессии, у которых нет токена обновления, действительного
+// в момент now (в секундах unix), которые последний раз использовались раньше момента usedBefore
+// или начались раньше момента startedBefore. Токены завершенных сессий удаляются.
+// Возвращается количество завершенных сессий или ошибка.
+func (sr *SessionRepo) EndStaleSessions(ctx context.Context, now int, usedBefore, startedBefore time.Time) (int, error) {
+	var ended int
+	query := `
+		WITH ended AS (
+			UPDATE sessions s
+			SET logged_out_at = CURRENT_TIMESTAMP
+			WHERE s.logged_out_at IS NULL AND (
+				NOT EXISTS (SELECT 1 FROM tokens t WHERE t.session_id = s.session_id AND t.expires_at > $1)
+				OR s.last_used_at < $2
+				OR s.logged_in_at < $3
+			)
+			RETURNING s.session_id
+		), deleted AS (
+			DELETE FROM tokens WHERE session_id IN (SELECT session_id FROM ended)
+		)
+		SELECT count(*) FROM ended
+	`
+	if err := sr.db.GetContext(ctx, &ended, query, now, usedBefore, startedBefore); err != nil {
+		return 0, fmt.Errorf("end stale sessions: %w: %w", errs.Internal, err)
+	}
+	return ended, nil
+}
+
 // GetUserFromSession возвращает пользователя по номеру его сессии или ошибку.
 func (sr *SessionRepo) GetUserFromSession(ctx context.Context, sessionID int) (*model.User, error) {
 	user := new(model.User)
@@ -109,7 +240,7 @@ func (sr *SessionRepo) GetUserFromSession(ctx context.Context, sessionID int) (*
 		SELECT u.*
 		FROM users u
 		JOIN sessions s USING(user_id)
-		WHERE s.session_id = $1
+		WHERE s.session_id = $1 AND u.deleted_at IS NULL
 	`
 	if err := sr.db.GetContext(ctx, user, query, sessionID); err != nil {
 		baseErr := errs.Internal
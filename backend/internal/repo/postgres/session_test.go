@@ -0,0 +1,164 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+)
+
+func TestSessionRepo(t *testing.T) {
+	f := newFixtures(t)
+	repo := NewSessionRepo(f.db)
+	user := f.user("admin", "admin", nil)
+
+	expiresAt := int(time.Now().Add(time.Hour).Unix())
+	created, _, err := repo.Create(f.ctx, user.ID, &model.NewToken{RefreshToken: token("first"), ExpiresAt: expiresAt}, 0)
+	expectNoErr(t, err)
+	if created.ID == 0 || created.SessionID == 0 || created.RefreshToken != token("first") || created.ExpiresAt != expiresAt {
+		t.Errorf("Create: got %+v", created)
+	}
+
+	sessionUser, err := repo.GetUserFromSession(f.ctx, created.SessionID)
+	expectNoErr(t, err)
+	if sessionUser.ID != user.ID {
+		t.Errorf("GetUserFromSession: got user %v, want %v", sessionUser.ID, user.ID)
+	}
+	_, err = repo.GetUserFromSession(f.ctx, created.SessionID+1)
+	expectErr(t, err, errs.NotFound)
+
+	popped, err := repo.PopByRefreshToken(f.ctx, token("first"))
+	expectNoErr(t, err)
+	if *popped != *created {
+		t.Errorf("PopByRefreshToken: got %+v, want %+v", popped, created)
+	}
+	_, err = repo.PopByRefreshToken(f.ctx, token("first"))
+	expectErr(t, err, errs.NotFound)
+
+	rotated, err := repo.UpdateRefreshToken(f.ctx, created.SessionID, &model.NewToken{RefreshToken: token("second"), ExpiresAt: expiresAt})
+	expectNoErr(t, err)
+	if rotated.SessionID != created.SessionID || rotated.RefreshToken != token("second") {
+		t.Errorf("UpdateRefreshToken: got %+v", rotated)
+	}
+	popped, err = repo.PopByRefreshToken(f.ctx, token("second"))
+	expectNoErr(t, err)
+	if *popped != *rotated {
+		t.Errorf("PopByRefreshToken after rotation: got %+v, want %+v", popped, rotated)
+	}
+
+	other, _, err := repo.Create(f.ctx, user.ID, &model.NewToken{RefreshToken: token("other"), ExpiresAt: expiresAt - 2*3600}, 0)
+	expectNoErr(t, err)
+	active, err := repo.CountActive(f.ctx, int(time.Now().Unix()))
+	expectNoErr(t, err)
+	if active != 0 {
+		t.Errorf("CountActive without valid tokens: got %v, want 0", active)
+	}
+	_, err = repo.UpdateRefreshToken(f.ctx, created.SessionID, &model.NewToken{RefreshToken: token("third"), ExpiresAt: expiresAt})
+	expectNoErr(t, err)
+	active, err = repo.CountActive(f.ctx, other.ExpiresAt-1)
+	expectNoErr(t, err)
+	if active != 2 {
+		t.Errorf("CountActive before expiry: got %v, want 2", active)
+	}
+
+	expectNoErr(t, repo.EndSession(f.ctx, created.SessionID))
+	active, err = repo.CountActive(f.ctx, int(time.Now().Unix()))
+	expectNoErr(t, err)
+	if active != 0 {
+		t.Errorf("CountActive after EndSession: got %v, want 0", active)
+	}
+	var session model.Session
+	expectNoErr(t, f.db.GetContext(f.ctx, &session, `SELECT * FROM sessions WHERE session_id = $1`, created.SessionID))
+	if session.UserID != user.ID || session.LoggedOutAt == nil {
+		t.Errorf("EndSession: got %+v", session)
+	}
+}
+
+func TestSessionRepoCreateForMissingUser(t *testing.T) {
+	f := newFixtures(t)
+	repo := NewSessionRepo(f.db)
+
+	_, _, err := repo.Create(f.ctx, 1, &model.NewToken{RefreshToken: token("token"), ExpiresAt: int(time.Now().Unix())}, 0)
+	expectConstraint(t, err, errs.InvalidReference, "user_id")
+	_, err = repo.PopByRefreshToken(f.ctx, token("token"))
+	expectErr(t, err, errs.NotFound)
+}
+
+func TestSessionRepoCreateEvicts(t *testing.T) {
+	f := newFixtures(t)
+	repo := NewSessionRepo(f.db)
+	user := f.user("admin", "admin", nil)
+	expiresAt := int(time.Now().Add(time.Hour).Unix())
+
+	var sessionIDs []int
+	for _, name := range []string{"first", "second"} {
+		created, ended, err := repo.Create(f.ctx, user.ID, &model.NewToken{RefreshToken: token(name), ExpiresAt: expiresAt}, 3)
+		expectNoErr(t, err)
+		if len(ended) != 0 {
+			t.Errorf("Create within limit: ended %v", ended)
+		}
+		sessionIDs = append(sessionIDs, created.SessionID)
+	}
+	session, err := repo.GetSession(f.ctx, sessionIDs[0])
+	expectNoErr(t, err)
+	if session.UserID != user.ID || session.LoggedOutAt != nil || session.LastUsedAt.IsZero() {
+		t.Errorf("GetSession: got %+v", session)
+	}
+	_, err = repo.GetSession(f.ctx, sessionIDs[1]+1)
+	expectErr(t, err, errs.NotFound)
+
+	created, ended, err := repo.Create(f.ctx, user.ID, &model.NewToken{RefreshToken: token("third"), ExpiresAt: expiresAt}, 1)
+	expectNoErr(t, err)
+	if len(ended) != 2 || ended[0] != sessionIDs[0] || ended[1] != sessionIDs[1] {
+		t.Errorf("Create over limit: ended %v, want %v", ended, sessionIDs)
+	}
+	sessionIDs = append(sessionIDs, created.SessionID)
+	_, err = repo.PopByRefreshToken(f.ctx, token("first"))
+	expectErr(t, err, errs.NotFound)
+	_, err = repo.PopByRefreshToken(f.ctx, token("third"))
+	expectNoErr(t, err)
+	session, err = repo.GetSession(f.ctx, sessionIDs[1])
+	expectNoErr(t, err)
+	if session.LoggedOutAt == nil {
+		t.Errorf("evicted session: got %+v", session)
+	}
+}
+
+func TestSessionRepoCleanup(t *testing.T) {
+	f := newFixtures(t)
+	repo := NewSessionRepo(f.db)
+	user := f.user("admin", "admin", nil)
+	now := time.Now()
+
+	expired, _, err := repo.Create(f.ctx, user.ID, &model.NewToken{RefreshToken: token("expired"), ExpiresAt: int(now.Add(-time.Hour).Unix())}, 0)
+	expectNoErr(t, err)
+	active, _, err := repo.Create(f.ctx, user.ID, &model.NewToken{RefreshToken: token("active"), ExpiresAt: int(now.Add(time.Hour).Unix())}, 0)
+	expectNoErr(t, err)
+
+	deleted, err := repo.DeleteExpiredTokens(f.ctx, int(now.Unix()))
+	expectNoErr(t, err)
+	if deleted != 1 {
+		t.Errorf("DeleteExpiredTokens: got %v deleted tokens, want 1", deleted)
+	}
+
+	// Без ограничений завершается только сессия без действительного токена.
+	ended, err := repo.EndStaleSessions(f.ctx, int(now.Unix()), time.Time{}, time.Time{})
+	expectNoErr(t, err)
+	if ended != 1 {
+		t.Errorf("EndStaleSessions: got %v ended sessions, want 1", ended)
+	}
+	session, err := repo.GetSession(f.ctx, expired.SessionID)
+	expectNoErr(t, err)
+	if session.LoggedOutAt == nil {
+		t.Errorf("session without tokens: got %+v, want ended", session)
+	}
+
+	ended, err = repo.EndStaleSessions(f.ctx, int(now.Unix()), now.UTC().Add(time.Hour), time.Time{})
+	expectNoErr(t, err)
+	if ended != 1 {
+		t.Errorf("EndStaleSessions for idle sessions: got %v ended sessions, want 1", ended)
+	}
+	_, err = repo.PopByRefreshToken(f.ctx, active.RefreshToken)
+	expectErr(t, err, errs.NotFound)
+}
@@ -28,10 +28,10 @@ func (sr *SpecialtyRepo) Create(ctx context.Context, input *model.NewSpecialty)
 	query := `
 		INSERT INTO specialties (name, department_id)
 		VALUES ($1, $2)
-		RETURNING specialty_id, name, department_id
+		RETURNING specialty_id, name, department_id, version
 	`
 	if err := sr.db.GetContext(ctx, specialty, query, input.Name, input.DepartmentID); err != nil {
-		return nil, fmt.Errorf("INSERT specialty: %w: %w", errs.Internal, err)
+		return nil, fmt.Errorf("INSERT specialty: %w", dbError(err, "specialties"))
 	}
 	return specialty, nil
 }
@@ -41,7 +41,7 @@ func (sr *SpecialtyRepo) Create(ctx context.Context, input *model.NewSpecialty)
 // Если база данных пуста, то возвращается ошибка [errs.Empty].
 func (sr *SpecialtyRepo) GetAll(ctx context.Context) ([]model.Specialty, error) {
 	var specialties []model.Specialty
-	query := `SELECT * FROM specialties`
+	query := `SELECT * FROM specialties WHERE deleted_at IS NULL`
 	if err := sr.db.SelectContext(ctx, &specialties, query); err != nil {
 		baseErr := errs.Internal
 		if errors.Is(err, sql.ErrNoRows) {
@@ -56,7 +56,7 @@ func (sr *SpecialtyRepo) GetAll(ctx context.Context) ([]model.Specialty, error)
 // Если специальность с таким номером не нашлась, то возвращается ошибка [errs.NotFound].
 func (sr *SpecialtyRepo) Get(ctx context.Context, ID int) (*model.Specialty, error) {
 	specialty := new(model.Specialty)
-	query := `SELECT * FROM specialties WHERE specialty_id = $1`
+	query := `SELECT * FROM specialties WHERE specialty_id = $1 AND deleted_at IS NULL`
 	if err := sr.db.GetContext(ctx, specialty, query, ID); err != nil {
 		baseErr := errs.Internal
 		if errors.Is(err, sql.ErrNoRows) {
@@ -69,28 +69,44 @@ func (sr *SpecialtyRepo) Get(ctx context.Context, ID int) (*model.Specialty, err
 
 // Update обновляет специальность по номеру и возвращает ее с номером или ошибку.
 // Если специальность с таким номером не нашлась, то возращается ошибка [errs.NotFound].
-func (sr *SpecialtyRepo) Update(ctx context.Context, ID int, update *model.NewSpecialty) (*model.Specialty, error) {
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (sr *SpecialtyRepo) Update(ctx context.Context, ID, version int, update *model.NewSpecialty) (*model.Specialty, error) {
 	specialty := new(model.Specialty)
 	query := `
 		UPDATE specialties
 		SET name = $1,
-			department_id = $2
-		WHERE specialty_id = $3
-		RETURNING specialty_id, name, department_id
+			department_id = $2,
+			version = version + 1
+		WHERE specialty_id = $3 AND ($4 = 0 OR version = $4) AND deleted_at IS NULL
+		RETURNING specialty_id, name, department_id, version
 	`
-	if err := sr.db.GetContext(ctx, specialty, query, update.Name, update.DepartmentID, ID); err != nil {
-		return nil, fmt.Errorf("UPDATE specialty: %w: %w", errs.Internal, err)
+	if err := sr.db.GetContext(ctx, specialty, query, update.Name, update.DepartmentID, ID, version); err != nil {
+		return nil, fmt.Errorf("UPDATE specialty: %w", updateError(ctx, sr.db, err, "specialties", "specialty_id", ID, version))
 	}
 	return specialty, nil
 }
 
-// Delete удаляет специальность по номеру и возвращает ошибку, если удаления не произошло.
+// Patch изменяет указанные в патче поля специальности по номеру и возвращает ее или ошибку.
 // Если специальность с таким номером не нашлась, то возращается ошибка [errs.NotFound].
-func (sr *SpecialtyRepo) Delete(ctx context.Context, ID int) error {
-	query := `DELETE FROM specialties WHERE specialty_id = $1 CASCADE`
-	_, err := sr.db.ExecContext(ctx, query, ID)
-	if err != nil {
-		return fmt.Errorf("DELETE specialty: %w: %w", errs.Internal, err)
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (sr *SpecialtyRepo) Patch(ctx context.Context, ID, version int, patch *model.SpecialtyPatch) (*model.Specialty, error) {
+	set := new(patchSet)
+	set.add("name", patch.Name)
+	set.add("department_id", patch.DepartmentID)
+	query, args := set.update("specialties", "specialty_id", "specialty_id, name, department_id, version", ID, version)
+	specialty := new(model.Specialty)
+	if err := sr.db.GetContext(ctx, specialty, query, args...); err != nil {
+		return nil, fmt.Errorf("UPDATE specialty: %w", updateError(ctx, sr.db, err, "specialties", "specialty_id", ID, version))
+	}
+	return specialty, nil
+}
+
+// Delete удаляет специальность по номеру в корзину и возвращает ошибку, если удаления не произошло.
+// Если специальность с таким номером не нашлась, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (sr *SpecialtyRepo) Delete(ctx context.Context, ID, version int) error {
+	if err := moveToTrash(ctx, sr.db, "specialties", "specialty_id", ID, version); err != nil {
+		return fmt.Errorf("DELETE specialty: %w", err)
 	}
-	return err
+	return nil
 }
@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+)
+
+func TestSpecialtyRepo(t *testing.T) {
+	f := newFixtures(t)
+	repo := NewSpecialtyRepo(f.db)
+	department := f.department("Кафедра")
+	other := f.department("Другая кафедра")
+
+	created, err := repo.Create(f.ctx, &model.NewSpecialty{Name: "Связь", DepartmentID: department.ID})
+	expectNoErr(t, err)
+	if created.ID == 0 || created.Name != "Связь" || created.DepartmentID != department.ID {
+		t.Errorf("Create: got %+v", created)
+	}
+	_, err = repo.Create(f.ctx, &model.NewSpecialty{Name: "Связь", DepartmentID: other.ID + 1})
+	if err == nil {
+		t.Error("Create: specialty of a missing department was created")
+	}
+
+	got, err := repo.Get(f.ctx, created.ID)
+	expectNoErr(t, err)
+	if *got != *created {
+		t.Errorf("Get: got %+v, want %+v", got, created)
+	}
+	_, err = repo.Get(f.ctx, created.ID+1)
+	expectErr(t, err, errs.NotFound)
+
+	all, err := repo.GetAll(f.ctx)
+	expectNoErr(t, err)
+	if len(all) != 1 || all[0] != *created {
+		t.Errorf("GetAll: got %+v", all)
+	}
+
+	updated, err := repo.Update(f.ctx, created.ID, 0, &model.NewSpecialty{Name: "Радиотехника", DepartmentID: other.ID})
+	expectNoErr(t, err)
+	if updated.ID != created.ID || updated.Name != "Радиотехника" || updated.DepartmentID != other.ID {
+		t.Errorf("Update: got %+v", updated)
+	}
+	_, err = repo.Update(f.ctx, created.ID+1, 0, &model.NewSpecialty{Name: "Связь", DepartmentID: other.ID})
+	expectErr(t, err, errs.NotFound)
+
+	expectNoErr(t, repo.Delete(f.ctx, created.ID, 0))
+	expectErr(t, repo.Delete(f.ctx, created.ID, 0), errs.NotFound)
+	_, err = repo.Get(f.ctx, created.ID)
+	expectErr(t, err, errs.NotFound)
+}
+
+func TestSpecialtyRepoDeleteReferenced(t *testing.T) {
+	f := newFixtures(t)
+	repo := NewSpecialtyRepo(f.db)
+	group := f.groupWithSpecialty("1101")
+
+	expectConstraint(t, repo.Delete(f.ctx, group.SpecialtyID, 0), errs.ReferencedBy, "specialty_id")
+	_, err := repo.Get(f.ctx, group.SpecialtyID)
+	expectNoErr(t, err)
+}
+
+func TestSpecialtyRepoVersion(t *testing.T) {
+	f := newFixtures(t)
+	repo := NewSpecialtyRepo(f.db)
+	department := f.department("Кафедра")
+	created, err := repo.Create(f.ctx, &model.NewSpecialty{Name: "Связь", DepartmentID: department.ID})
+	expectNoErr(t, err)
+	if created.Version != 1 {
+		t.Fatalf("Create: got version %v, want 1", created.Version)
+	}
+
+	update := &model.NewSpecialty{Name: "Радиотехника", DepartmentID: department.ID}
+	updated, err := repo.Update(f.ctx, created.ID, created.Version, update)
+	expectNoErr(t, err)
+	if updated.Version != created.Version+1 {
+		t.Errorf("Update: got version %v, want %v", updated.Version, created.Version+1)
+	}
+	_, err = repo.Update(f.ctx, created.ID, created.Version, update)
+	expectErr(t, err, errs.VersionMismatch)
+	_, err = repo.Update(f.ctx, created.ID+1, created.Version, update)
+	expectErr(t, err, errs.NotFound)
+
+	expectErr(t, repo.Delete(f.ctx, created.ID, created.Version), errs.VersionMismatch)
+	expectNoErr(t, repo.Delete(f.ctx, created.ID, updated.Version))
+}
@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+
+	"github.com/XSAM/otelsql"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.18.0"
+)
+
+// tracingOptions содержит параметры трассировки запросов к базе данных.
+// Span запроса называется по виду запроса и таблице (например, SELECT users) и содержит текст запроса.
+var tracingOptions = []otelsql.Option{
+	otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+	otelsql.WithSpanNameFormatter(func(ctx context.Context, method otelsql.Method, query string) string {
+		if name := statementName(query); name != "" {
+			return name
+		}
+		return string(method)
+	}),
+	otelsql.WithAttributesGetter(func(ctx context.Context, method otelsql.Method, query string, args []driver.NamedValue) []attribute.KeyValue {
+		operation, table := parseStatement(query)
+		var attrs []attribute.KeyValue
+		if operation != "" {
+			attrs = append(attrs, semconv.DBOperationKey.String(operation))
+		}
+		if table != "" {
+			attrs = append(attrs, semconv.DBSQLTableKey.String(table))
+		}
+		return attrs
+	}),
+	otelsql.WithSpanOptions(otelsql.SpanOptions{
+		DisableErrSkip:       true,
+		OmitConnResetSession: true,
+		OmitRows:             true,
+	}),
+}
+
+// statementName возвращает название запроса query для span: вид запроса и основную таблицу.
+// Если запрос пуст, то возвращается пустая строка.
+func statementName(query string) string {
+	operation, table := parseStatement(query)
+	if table == "" {
+		return operation
+	}
+	return operation + " " + table
+}
+
+// parseStatement возвращает вид запроса query (SELECT, INSERT, UPDATE, DELETE и т.д.) и основную таблицу:
+// первую таблицу после FROM или INTO, не являющуюся подзапросом, или таблицу после UPDATE.
+func parseStatement(query string) (operation, table string) {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "", ""
+	}
+	operation = strings.ToUpper(fields[0])
+	if operation == "UPDATE" && len(fields) > 1 {
+		return operation, strings.Trim(fields[1], "(),;")
+	}
+	for i := 1; i < len(fields)-1; i++ {
+		keyword := strings.ToUpper(fields[i])
+		if (keyword == "FROM" || keyword == "INTO") && !strings.HasPrefix(fields[i+1], "(") {
+			return operation, strings.Trim(fields[i+1], "(),;")
+		}
+	}
+	return operation, ""
+}
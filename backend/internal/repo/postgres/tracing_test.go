@@ -0,0 +1,24 @@
+package postgres
+
+import "testing"
+
+func TestStatementName(t *testing.T) {
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{"", ""},
+		{"\n\t\tSELECT * FROM users WHERE user_id = $1", "SELECT users"},
+		{"SELECT EXISTS (SELECT 1 FROM departments WHERE department_id = $1)", "SELECT departments"},
+		{"SELECT count(*) FROM (SELECT 1 FROM tokens) t", "SELECT tokens"},
+		{"insert into sessions (user_id) values ($1)", "INSERT sessions"},
+		{"UPDATE materials SET name = $1", "UPDATE materials"},
+		{"DELETE FROM tokens WHERE refresh_token = $1", "DELETE tokens"},
+		{"SELECT 1", "SELECT"},
+	}
+	for _, c := range cases {
+		if got := statementName(c.query); got != c.want {
+			t.Errorf("statementName(%q): got %q, want %q", c.query, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// trashTable описывает таблицу, строки которой удаляются в корзину:
+// вместо удаления в столбец deleted_at записывается время удаления, и запросы репозиториев перестают видеть строку.
+type trashTable struct {
+	entity string // название сущности
+	table  string // название таблицы
+	key    string // первичный ключ
+	name   string // выражение для названия строки в корзине
+}
+
+// trashTables содержит таблицы, строки которых удаляются в корзину, в порядке окончательного удаления:
+// строки таблицы удаляются раньше строк, на которые они могут ссылаться.
+var trashTables = []trashTable{
+	{model.TrashUser, "users", "user_id", "concat_ws(' ', surname, name, patronymic)"},
+	{model.TrashDiscipline, "disciplines", "discipline_id", "name"},
+	{model.TrashSpecialty, "specialties", "specialty_id", "name"},
+	{model.TrashDepartment, "departments", "department_id", "name"},
+}
+
+// reference описывает ссылку на строку из столбца field таблицы table.
+type reference struct {
+	table string // таблица, которая ссылается на строку
+	field string // столбец со ссылкой
+}
+
+// references содержит ссылки на строки таблиц, удаляемых в корзину, из других таблиц.
+var references = map[string][]reference{
+	"departments": {{"specialties", "department_id"}, {"users", "department_id"}},
+	"specialties": {{"groups", "specialty_id"}, {"disciplines", "specialty_id"}},
+	"disciplines": {{"chapters", "discipline_id"}},
+}
+
+// hasTrash сообщает, удаляются ли строки таблицы table в корзину.
+func hasTrash(table string) bool {
+	return slices.ContainsFunc(trashTables, func(tt trashTable) bool { return tt.table == table })
+}
+
+// moveToTrash переносит строку таблицы table с ключом key, равным ID, и версией version (0 — любая версия) в корзину.
+// Если на строку ссылаются строки других таблиц, кроме находящихся в корзине, то возвращается [errs.ReferencedBy].
+// Если строка не нашлась или уже находится в корзине, то возвращается [errs.NotFound].
+func moveToTrash(ctx context.Context, db sqlx.ExtContext, table, key string, ID, version int) error {
+	for _, ref := range references[table] {
+		query := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %v WHERE %v = $1)`, ref.table, ref.field)
+		if hasTrash(ref.table) {
+			query = fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %v WHERE %v = $1 AND deleted_at IS NULL)`, ref.table, ref.field)
+		}
+		var referenced bool
+		if err := sqlx.GetContext(ctx, db, &referenced, query, ID); err != nil {
+			return fmt.Errorf("%w: %w", errs.Internal, err)
+		}
+		if referenced {
+			return errs.NewConstraintError(errs.ReferencedBy, ref.table, ref.field)
+		}
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE %v
+		SET deleted_at = CURRENT_TIMESTAMP,
+			version = version + 1
+		WHERE %v = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2)
+	`, table, key)
+	result, err := db.ExecContext(ctx, query, ID, version)
+	if err != nil {
+		return dbError(err, table)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return missingRow(ctx, db, table, key, ID, version)
+	}
+	return nil
+}
+
+// TrashRepo предоставляет доступ к записям в корзине.
+type TrashRepo struct {
+	db *sqlx.DB
+}
+
+// NewTrashRepo создает новый экземпляр [TrashRepo].
+func NewTrashRepo(db *sqlx.DB) *TrashRepo {
+	return &TrashRepo{db}
+}
+
+// GetAll возвращает слайс всех записей в корзине или ошибку.
+// Записи упорядочены от удаленных последними к удаленным первыми.
+func (tr *TrashRepo) GetAll(ctx context.Context) ([]model.TrashItem, error) {
+	selects := make([]string, 0, len(trashTables))
+	for _, tt := range trashTables {
+		selects = append(selects, fmt.Sprintf(`SELECT '%v' AS entity, %v AS id, %v AS name, deleted_at FROM %v WHERE deleted_at IS NOT NULL`,
+			tt.entity, tt.key, tt.name, tt.table))
+	}
+	query := strings.Join(selects, " UNION ALL ") + " ORDER BY deleted_at DESC, entity, id"
+	var items []model.TrashItem
+	if err := tr.db.SelectContext(ctx, &items, query); err != nil {
+		return nil, fmt.Errorf("SELECT trash: %w: %w", errs.Internal, err)
+	}
+	return items, nil
+}
+
+// Restore возвращает запись сущности entity с номером ID из корзины.
+// Если записи нет в корзине или сущность не удаляется в корзину, то возвращается ошибка [errs.NotFound].
+// Если запись ссылается на запись, которая сама находится в корзине, то возвращается ошибка [errs.InvalidReference]:
+// сначала нужно восстановить ту запись.
+func (tr *TrashRepo) Restore(ctx context.Context, entity string, ID int) error {
+	i := slices.IndexFunc(trashTables, func(tt trashTable) bool { return tt.entity == entity })
+	if i < 0 {
+		return fmt.Errorf("restore %v: %w", entity, errs.NotFound)
+	}
+	tt := trashTables[i]
+
+	txCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	tx, err := tr.db.BeginTxx(txCtx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w: %w", errs.Internal, err)
+	}
+	if err := checkParents(ctx, tx, tt, ID); err != nil {
+		return err
+	}
+	query := fmt.Sprintf(`
+		UPDATE %v
+		SET deleted_at = NULL,
+			version = version + 1
+		WHERE %v = $1 AND deleted_at IS NOT NULL
+	`, tt.table, tt.key)
+	result, err := tx.ExecContext(ctx, query, ID)
+	if err != nil {
+		return fmt.Errorf("UPDATE %v: %w: %w", tt.table, errs.Internal, err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("UPDATE %v: %w", tt.table, errs.NotFound)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit changes: %w: %w", errs.Internal, err)
+	}
+	return nil
+}
+
+// checkParents проверяет, что строка таблицы tt с номером ID не ссылается на строки в корзине,
+// и блокирует строки, на которые она ссылается, до конца транзакции, чтобы их не удалили в корзину одновременно.
+// Если строка ссылается на строку в корзине, то возвращается [errs.InvalidReference].
+func checkParents(ctx context.Context, tx *sqlx.Tx, tt trashTable, ID int) error {
+	for _, parent := range trashTables {
+		for _, ref := range references[parent.table] {
+			if ref.table != tt.table {
+				continue
+			}
+			query := fmt.Sprintf(`
+				SELECT p.deleted_at IS NOT NULL
+				FROM %v c
+				JOIN %v p ON p.%v = c.%v
+				WHERE c.%v = $1
+				FOR SHARE OF p
+			`, tt.table, parent.table, parent.key, ref.field, tt.key)
+			var deleted []bool
+			if err := tx.SelectContext(ctx, &deleted, query, ID); err != nil {
+				return fmt.Errorf("SELECT %v of %v: %w: %w", ref.field, tt.table, errs.Internal, err)
+			}
+			if slices.Contains(deleted, true) {
+				return fmt.Errorf("%w: %w", errs.NewConstraintError(errs.InvalidReference, tt.table, ref.field), errs.InTrash)
+			}
+		}
+	}
+	return nil
+}
+
+// Purge окончательно удаляет записи, попавшие в корзину раньше момента before, и возвращает их количество или ошибку.
+// Вместе с пользователями удаляются их данные для входа, сессии и токены.
+// Записи, на которые еще ссылаются другие записи, остаются в корзине до удаления ссылающихся записей.
+func (tr *TrashRepo) Purge(ctx context.Context, before time.Time) (int, error) {
+	txCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	tx, err := tr.db.BeginTxx(txCtx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w: %w", errs.Internal, err)
+	}
+
+	userQueries := []string{
+		`DELETE FROM tokens WHERE session_id IN (
+			SELECT session_id FROM sessions JOIN users USING(user_id) WHERE users.deleted_at < $1
+		)`,
+		`DELETE FROM sessions WHERE user_id IN (SELECT user_id FROM users WHERE deleted_at < $1)`,
+		`DELETE FROM users_credentials WHERE user_id IN (SELECT user_id FROM users WHERE deleted_at < $1)`,
+	}
+	for _, query := range userQueries {
+		if _, err := tx.ExecContext(ctx, query, before); err != nil {
+			return 0, fmt.Errorf("DELETE data of purged users: %w: %w", errs.Internal, err)
+		}
+	}
+
+	purged := 0
+	for _, tt := range trashTables {
+		conditions := []string{"deleted_at < $1"}
+		for _, ref := range references[tt.table] {
+			conditions = append(conditions, fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %v r WHERE r.%v = t.%v)", ref.table, ref.field, tt.key))
+		}
+		query := fmt.Sprintf(`DELETE FROM %v t WHERE %v`, tt.table, strings.Join(conditions, " AND "))
+		result, err := tx.ExecContext(ctx, query, before)
+		if err != nil {
+			return 0, fmt.Errorf("DELETE %v: %w: %w", tt.table, errs.Internal, err)
+		}
+		rows, _ := result.RowsAffected()
+		if rows > 0 {
+			slog.DebugContext(ctx, "purged trash table", slog.String("table", tt.table), slog.Int64("rows", rows))
+		}
+		purged += int(rows)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit changes: %w: %w", errs.Internal, err)
+	}
+	return purged, nil
+}
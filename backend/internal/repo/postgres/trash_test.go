@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+)
+
+func TestTrashRepo(t *testing.T) {
+	f := newFixtures(t)
+	repo := NewTrashRepo(f.db)
+	departments := NewDepartmentRepo(f.db)
+	specialties := NewSpecialtyRepo(f.db)
+	department := f.department("Кафедра")
+	specialty := f.specialty("Специальность", department.ID)
+
+	expectConstraint(t, departments.Delete(f.ctx, department.ID, 0), errs.ReferencedBy, "department_id")
+	expectNoErr(t, specialties.Delete(f.ctx, specialty.ID, 0))
+	expectNoErr(t, departments.Delete(f.ctx, department.ID, 0))
+	_, err := departments.Get(f.ctx, department.ID)
+	expectErr(t, err, errs.NotFound)
+
+	items, err := repo.GetAll(f.ctx)
+	expectNoErr(t, err)
+	if len(items) != 2 {
+		t.Fatalf("GetAll: got %+v, want 2 items", items)
+	}
+
+	err = repo.Restore(f.ctx, model.TrashSpecialty, specialty.ID)
+	expectConstraint(t, err, errs.InvalidReference, "department_id")
+	expectErr(t, err, errs.InTrash)
+	expectNoErr(t, repo.Restore(f.ctx, model.TrashDepartment, department.ID))
+	expectErr(t, repo.Restore(f.ctx, model.TrashDepartment, department.ID), errs.NotFound)
+	expectErr(t, repo.Restore(f.ctx, "group", department.ID), errs.NotFound)
+	restored, err := departments.Get(f.ctx, department.ID)
+	expectNoErr(t, err)
+	if restored.Version != department.Version+2 {
+		t.Errorf("Restore: got version %v, want %v", restored.Version, department.Version+2)
+	}
+
+	purged, err := repo.Purge(f.ctx, time.Now().Add(24*time.Hour))
+	expectNoErr(t, err)
+	if purged != 1 {
+		t.Errorf("Purge: got %v purged rows, want 1", purged)
+	}
+	_, err = specialties.Get(f.ctx, specialty.ID)
+	expectErr(t, err, errs.NotFound)
+	expectNoErr(t, departments.Delete(f.ctx, department.ID, 0))
+}
+
+func TestTrashRepoUser(t *testing.T) {
+	f := newFixtures(t)
+	repo := NewTrashRepo(f.db)
+	users := NewUserRepo(f.db)
+	user := f.user("trashed", "student", nil)
+	f.session(user.ID, token("trash"))
+
+	expectNoErr(t, users.Delete(f.ctx, user.ID, 0))
+	_, err := users.GetCredentialsByLogin(f.ctx, "trashed")
+	expectErr(t, err, errs.InvalidLogin)
+	taken, err := users.LoginTaken(f.ctx, "trashed")
+	expectNoErr(t, err)
+	if !taken {
+		t.Errorf("LoginTaken of a user in the trash: got false, want true")
+	}
+	_, err = users.Create(f.ctx, &model.NewUser{Name: "Петр", Surname: "Петров", Login: "trashed", Password: "hash", RoleID: user.RoleID})
+	expectConstraint(t, err, errs.Conflict, "login")
+	expectErr(t, err, errs.InTrash)
+
+	purged, err := repo.Purge(f.ctx, time.Now().Add(24*time.Hour))
+	expectNoErr(t, err)
+	if purged != 1 {
+		t.Errorf("Purge: got %v purged rows, want 1", purged)
+	}
+	_, err = users.GetCredentialsByUserID(f.ctx, user.ID)
+	expectErr(t, err, errs.NotFound)
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/foreverd34d/aumsu-elib/internal/errs"
 	"github.com/foreverd34d/aumsu-elib/internal/model"
@@ -31,38 +32,110 @@ func (ur *UserRepo) Create(ctx context.Context, input *model.NewUser) (*model.Us
 		return nil, fmt.Errorf("begin transaction: %w: %w", errs.Internal, err)
 	}
 
+	user, err := insertUser(ctx, tx, input)
+	if err != nil {
+		return nil, ur.loginError(ctx, err, input.Login)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit changes: %w: %w", errs.Internal, err)
+	}
+	return user, nil
+}
+
+// CreateBatch сохраняет нескольких пользователей и их данные для входа в одной транзакции
+// и возвращает пользователей с номерами в том же порядке или ошибку.
+// Если сохранить не удалось хотя бы одного пользователя, то не сохраняется ни один.
+func (ur *UserRepo) CreateBatch(ctx context.Context, inputs []model.NewUser) ([]model.User, error) {
+	txCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	tx, err := ur.db.BeginTxx(txCtx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w: %w", errs.Internal, err)
+	}
+
+	users := make([]model.User, 0, len(inputs))
+	for i := range inputs {
+		user, err := insertUser(ctx, tx, &inputs[i])
+		if err != nil {
+			return nil, fmt.Errorf("user %v: %w", inputs[i].Login, ur.loginError(ctx, err, inputs[i].Login))
+		}
+		users = append(users, *user)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit changes: %w: %w", errs.Internal, err)
+	}
+	return users, nil
+}
+
+// insertUser сохраняет пользователя и его данные для входа в рамках транзакции tx.
+func insertUser(ctx context.Context, tx *sqlx.Tx, input *model.NewUser) (*model.User, error) {
 	user := new(model.User)
 	userQuery := `
-		INSERT INTO users (surname, name, patronymic, role_id, group_id)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING user_id, surname, name, patronymic, role_id, group_id
+		INSERT INTO users (surname, name, patronymic, role_id, group_id, rank, position, department_id, email, phone)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING *
 	`
-	if err := tx.GetContext(ctx, user, userQuery, input.Surname, input.Name, input.Patronymic, input.RoleID, input.GroupID); err != nil {
-		return nil, fmt.Errorf("INSERT user: %w: %w", errs.Internal, err)
+	if err := tx.GetContext(ctx, user, userQuery, input.Surname, input.Name, input.Patronymic, input.RoleID, input.GroupID,
+		input.Rank, input.Position, input.DepartmentID, input.Email, input.Phone); err != nil {
+		return nil, fmt.Errorf("INSERT user: %w", dbError(err, "users"))
 	}
 
-	credentials := new(model.UserCredentials)
 	credentialsQuery := `
-		INSERT INTO users_credentials (login, password_hash)
-		VALUES ($1, $2)
-		RETURNING user_credentials_id, login, password_hash
+		INSERT INTO users_credentials (login, password_hash, user_id, must_change_password)
+		VALUES ($1, $2, $3, $4)
 	`
-	if err := tx.GetContext(ctx, credentials, credentialsQuery, input.Login, input.Password); err != nil {
-		return nil, fmt.Errorf("INSERT user's credentials: %w: %w", errs.Internal, err)
-	}
-
-	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("commit changes: %w: %w", errs.Internal, err)
+	if _, err := tx.ExecContext(ctx, credentialsQuery, input.Login, input.Password, user.ID, input.MustChangePassword); err != nil {
+		return nil, fmt.Errorf("INSERT user's credentials: %w", dbError(err, "users_credentials"))
 	}
 	return user, nil
 }
 
-// GetAll возвращает слайс всех пользователей или ошибку.
+// GetAll возвращает слайс пользователей, удовлетворяющих фильтру, или ошибку.
+// Пользователи упорядочены по фамилии, имени и отчеству.
 // Если база данных пуста, то возвращается ошибка [errs.Empty].
-func (ur *UserRepo) GetAll(ctx context.Context) ([]model.User, error) {
+func (ur *UserRepo) GetAll(ctx context.Context, filter *model.UserFilter) ([]model.User, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.Query != nil && *filter.Query != "" {
+		addCondition(`concat_ws(' ', surname, name, patronymic, rank, position, email, phone) ILIKE '%%' || $%d || '%%'`, *filter.Query)
+	}
+	if filter.Rank != nil {
+		addCondition("rank = $%d", *filter.Rank)
+	}
+	if filter.RoleID != nil {
+		addCondition("role_id = $%d", *filter.RoleID)
+	}
+	if filter.GroupID != nil {
+		addCondition("group_id = $%d", *filter.GroupID)
+	}
+	if filter.DepartmentID != nil {
+		addCondition("department_id = $%d", *filter.DepartmentID)
+	}
+
+	query := `SELECT * FROM users WHERE deleted_at IS NULL`
+	for _, condition := range conditions {
+		query += " AND " + condition
+	}
+	query += " ORDER BY surname, name, patronymic, user_id"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
 	var users []model.User
-	query := `SELECT * FROM users`
-	if err := ur.db.SelectContext(ctx, &users, query); err != nil {
+	if err := ur.db.SelectContext(ctx, &users, query, args...); err != nil {
 		baseErr := errs.Internal
 		if errors.Is(err, sql.ErrNoRows) {
 			baseErr = errs.Empty
@@ -76,7 +149,7 @@ func (ur *UserRepo) GetAll(ctx context.Context) ([]model.User, error) {
 // Если пользователь с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
 func (ur *UserRepo) GetByID(ctx context.Context, ID int) (*model.User, error) {
 	user := new(model.User)
-	query := `SELECT * FROM users WHERE user_id = $1`
+	query := `SELECT * FROM users WHERE user_id = $1 AND deleted_at IS NULL`
 	if err := ur.db.GetContext(ctx, user, query, ID); err != nil {
 		baseErr := errs.Internal
 		if errors.Is(err, sql.ErrNoRows) {
@@ -88,10 +161,15 @@ func (ur *UserRepo) GetByID(ctx context.Context, ID int) (*model.User, error) {
 }
 
 // GetCredentialsByLogin возвращает данные пользователя для входа по логину или ошибку.
-// Если пользователь с таким логином не нашелся, то возвращается ошибка [errs.InvalidLogin].
+// Если пользователь с таким логином не нашелся или находится в корзине, то возвращается ошибка [errs.InvalidLogin].
 func (ur *UserRepo) GetCredentialsByLogin(ctx context.Context, login string) (*model.UserCredentials, error) {
 	credentials := new(model.UserCredentials)
-	query := `SELECT * FROM users_credentials WHERE login = $1`
+	query := `
+		SELECT c.*
+		FROM users_credentials c
+		JOIN users u USING(user_id)
+		WHERE c.login = $1 AND u.deleted_at IS NULL
+	`
 	if err := ur.db.GetContext(ctx, credentials, query, login); err != nil {
 		baseErr := errs.Internal
 		if errors.Is(err, sql.ErrNoRows) {
@@ -102,6 +180,87 @@ func (ur *UserRepo) GetCredentialsByLogin(ctx context.Context, login string) (*m
 	return credentials, nil
 }
 
+// LoginTaken сообщает, занят ли логин пользователем, в том числе пользователем в корзине, или возвращает ошибку.
+func (ur *UserRepo) LoginTaken(ctx context.Context, login string) (bool, error) {
+	var taken bool
+	query := `SELECT EXISTS (SELECT 1 FROM users_credentials WHERE login = $1)`
+	if err := ur.db.GetContext(ctx, &taken, query, login); err != nil {
+		return false, fmt.Errorf("SELECT login: %w: %w", errs.Internal, err)
+	}
+	return taken, nil
+}
+
+// loginError дополняет ошибку err нарушения уникальности логина login ошибкой [errs.InTrash],
+// если логин занят пользователем в корзине. Транзакция с ошибкой прервана, поэтому проверка идет вне ее.
+func (ur *UserRepo) loginError(ctx context.Context, err error, login string) error {
+	var ce *errs.ConstraintError
+	if !errors.As(err, &ce) || ce.Kind != errs.Conflict || ce.Field != "login" {
+		return err
+	}
+	var trashed bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM users_credentials c
+			JOIN users u USING(user_id)
+			WHERE c.login = $1 AND u.deleted_at IS NOT NULL
+		)
+	`
+	if checkErr := ur.db.GetContext(ctx, &trashed, query, login); checkErr != nil || !trashed {
+		return err
+	}
+	return fmt.Errorf("%w: %w", err, errs.InTrash)
+}
+
+// GetCredentialsByUserID возвращает данные пользователя для входа по номеру пользователя или ошибку.
+// Если пользователь с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
+func (ur *UserRepo) GetCredentialsByUserID(ctx context.Context, userID int) (*model.UserCredentials, error) {
+	credentials := new(model.UserCredentials)
+	query := `SELECT * FROM users_credentials WHERE user_id = $1`
+	if err := ur.db.GetContext(ctx, credentials, query, userID); err != nil {
+		baseErr := errs.Internal
+		if errors.Is(err, sql.ErrNoRows) {
+			baseErr = errs.NotFound
+		}
+		return nil, fmt.Errorf("SELECT user's credentials: %w: %w", baseErr, err)
+	}
+	return credentials, nil
+}
+
+// UpdatePassword записывает новый хэш пароля пользователя и признак одноразового пароля.
+// Если пользователь с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
+func (ur *UserRepo) UpdatePassword(ctx context.Context, userID int, passwordHash string, mustChange bool) error {
+	query := `
+		UPDATE users_credentials
+		SET password_hash = $1,
+			must_change_password = $2
+		WHERE user_id = $3
+	`
+	result, err := ur.db.ExecContext(ctx, query, passwordHash, mustChange, userID)
+	if err != nil {
+		return fmt.Errorf("UPDATE user's password: %w: %w", errs.Internal, err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("UPDATE user's password: %w", errs.NotFound)
+	}
+	return nil
+}
+
+// GetRoleByName возвращает роль по ее названию или ошибку.
+// Если роль с таким названием не нашлась, то возвращается ошибка [errs.NotFound].
+func (ur *UserRepo) GetRoleByName(ctx context.Context, name string) (*model.Role, error) {
+	role := new(model.Role)
+	query := `SELECT * FROM roles WHERE name = $1`
+	if err := ur.db.GetContext(ctx, role, query, name); err != nil {
+		baseErr := errs.Internal
+		if errors.Is(err, sql.ErrNoRows) {
+			baseErr = errs.NotFound
+		}
+		return nil, fmt.Errorf("SELECT role: %w: %w", baseErr, err)
+	}
+	return role, nil
+}
+
 // GetRole возвращает название роли пользователя или ошибку.
 // Если пользователь с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
 func (ur *UserRepo) GetRole(ctx context.Context, userID int) (string, error) {
@@ -110,7 +269,7 @@ func (ur *UserRepo) GetRole(ctx context.Context, userID int) (string, error) {
 		SELECT r.name
 		FROM roles r
 		JOIN users u USING(role_id)
-		WHERE u.user_id = $1
+		WHERE u.user_id = $1 AND u.deleted_at IS NULL
 	`
 	if err := ur.db.GetContext(ctx, &roleName, query, userID); err != nil {
 		baseErr := errs.Internal
@@ -124,7 +283,8 @@ func (ur *UserRepo) GetRole(ctx context.Context, userID int) (string, error) {
 
 // Update обновляет пользователя и его данные для входа по номеру и возвращает его или ошибку.
 // Если пользователь с таким номером не нашелся, то возращается ошибка [errs.NotFound].
-func (ur *UserRepo) Update(ctx context.Context, ID int, update *model.NewUser) (*model.User, error) {
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (ur *UserRepo) Update(ctx context.Context, ID, version int, update *model.NewUser) (*model.User, error) {
 	txCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
 	tx, err := ur.db.BeginTxx(txCtx, nil)
@@ -138,25 +298,31 @@ func (ur *UserRepo) Update(ctx context.Context, ID int, update *model.NewUser) (
 		SET surname = $1,
 			name = $2,
 			patronymic = $3,
-			role_id = $6,
-			group_id = $7
-		WHERE user_id = $8
-		RETURNING surname, name, patronymic, login, password_hash, role_id, group_id
+			role_id = $4,
+			group_id = $5,
+			rank = $6,
+			position = $7,
+			department_id = $8,
+			email = $9,
+			phone = $10,
+			version = version + 1
+		WHERE user_id = $11 AND ($12 = 0 OR version = $12) AND deleted_at IS NULL
+		RETURNING *
 	`
 	if err := tx.GetContext(ctx, updatedUser, userQuery,
-		update.Surname, update.Name, update.Patronymic, update.Login, update.Password, update.RoleID, update.GroupID, ID); err != nil {
-		return nil, fmt.Errorf("UPDATE user: %w: %w", errs.NotFound, err)
+		update.Surname, update.Name, update.Patronymic, update.RoleID, update.GroupID,
+		update.Rank, update.Position, update.DepartmentID, update.Email, update.Phone, ID, version); err != nil {
+		return nil, fmt.Errorf("UPDATE user: %w", updateError(ctx, tx, err, "users", "user_id", ID, version))
 	}
 
 	credentialsQuery := `
-		UPDATE credentials
+		UPDATE users_credentials
 		SET login = $1,
 			password_hash = $2
 		WHERE user_id = $3
 	`
-	_, err = tx.ExecContext(ctx, credentialsQuery, update.Login, update.Password)
-	if err != nil {
-		return nil, fmt.Errorf("UPDATE user's credentials: %w: %w", errs.NotFound, err)
+	if _, err := tx.ExecContext(ctx, credentialsQuery, update.Login, update.Password, ID); err != nil {
+		return nil, fmt.Errorf("UPDATE user's credentials: %w", ur.loginError(ctx, dbError(err, "users_credentials"), update.Login))
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -166,13 +332,191 @@ func (ur *UserRepo) Update(ctx context.Context, ID int, update *model.NewUser) (
 	return updatedUser, nil
 }
 
-// Delete удаляет пользователя по номеру и возвращает ошибку, если удаления не произошло.
+// Patch изменяет указанные в патче поля пользователя и его данных для входа по номеру и возвращает его или ошибку.
+// Если пользователь с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (ur *UserRepo) Patch(ctx context.Context, ID, version int, patch *model.UserPatch) (*model.User, error) {
+	txCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	tx, err := ur.db.BeginTxx(txCtx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w: %w", errs.Internal, err)
+	}
+
+	userSet := new(patchSet)
+	userSet.add("surname", patch.Surname)
+	userSet.add("name", patch.Name)
+	userSet.add("patronymic", patch.Patronymic)
+	userSet.add("role_id", patch.RoleID)
+	userSet.add("group_id", patch.GroupID)
+	userSet.add("rank", patch.Rank)
+	userSet.add("position", patch.Position)
+	userSet.add("department_id", patch.DepartmentID)
+	userSet.add("email", patch.Email)
+	userSet.add("phone", patch.Phone)
+	userQuery, args := userSet.update("users", "user_id", "*", ID, version)
+	patchedUser := new(model.User)
+	if err := tx.GetContext(ctx, patchedUser, userQuery, args...); err != nil {
+		return nil, fmt.Errorf("UPDATE user: %w", updateError(ctx, tx, err, "users", "user_id", ID, version))
+	}
+
+	credentialsSet := new(patchSet)
+	credentialsSet.add("login", patch.Login)
+	credentialsSet.add("password_hash", patch.Password)
+	if len(credentialsSet.columns) > 0 {
+		credentialsQuery := fmt.Sprintf(`UPDATE users_credentials SET %v WHERE user_id = $%v`,
+			strings.Join(credentialsSet.columns, ", "), len(credentialsSet.args)+1)
+		if _, err := tx.ExecContext(ctx, credentialsQuery, append(credentialsSet.args, ID)...); err != nil {
+			return nil, fmt.Errorf("UPDATE user's credentials: %w", ur.loginError(ctx, dbError(err, "users_credentials"), patch.Login.Value))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit changes: %w: %w", errs.Internal, err)
+	}
+	return patchedUser, nil
+}
+
+// Delete удаляет пользователя по номеру в корзину, завершает его сессии и удаляет токены обновления
+// и возвращает ошибку, если удаления не произошло. Данные для входа остаются до окончательного удаления
+// пользователя из корзины, поэтому его логин остается занятым.
 // Если пользователь с таким номером не нашелся, то возращается ошибка [errs.NotFound].
-func (ur *UserRepo) Delete(ctx context.Context, ID int) error {
-	query := `DELETE FROM users WHERE user_id = $1 CASCADE`
-	_, err := ur.db.ExecContext(ctx, query, ID)
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (ur *UserRepo) Delete(ctx context.Context, ID, version int) error {
+	txCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	tx, err := ur.db.BeginTxx(txCtx, nil)
 	if err != nil {
-		return fmt.Errorf("DELETE the user: %w: %w", errs.NotFound, err)
+		return fmt.Errorf("begin transaction: %w: %w", errs.Internal, err)
+	}
+
+	if err := moveToTrash(ctx, tx, "users", "user_id", ID, version); err != nil {
+		return fmt.Errorf("DELETE the user: %w", err)
+	}
+	tokensQuery := `
+		DELETE FROM tokens
+		WHERE session_id IN (SELECT session_id FROM sessions WHERE user_id = $1)
+	`
+	if _, err := tx.ExecContext(ctx, tokensQuery, ID); err != nil {
+		return fmt.Errorf("DELETE user's tokens: %w: %w", errs.Internal, err)
 	}
-	return err
+	sessionsQuery := `
+		UPDATE sessions
+		SET logged_out_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND logged_out_at IS NULL
+	`
+	if _, err := tx.ExecContext(ctx, sessionsQuery, ID); err != nil {
+		return fmt.Errorf("UPDATE user's sessions: %w: %w", errs.Internal, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit changes: %w: %w", errs.Internal, err)
+	}
+	return nil
+}
+
+// profileRow представляет строку результата запроса профиля пользователя.
+type profileRow struct {
+	model.User
+	RoleName       string  `db:"role_name"`
+	MustChange     bool    `db:"must_change_password"`
+	GroupName      *string `db:"group_name"`
+	SpecialtyID    *int    `db:"specialty_id"`
+	SpecialtyName  *string `db:"specialty_name"`
+	SpecialtyDepID *int    `db:"specialty_department_id"`
+	DepartmentID   *int    `db:"profile_department_id"`
+	DepartmentName *string `db:"department_name"`
+}
+
+// GetProfile возвращает профиль пользователя по номеру или ошибку.
+// Если пользователь с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
+func (ur *UserRepo) GetProfile(ctx context.Context, ID int) (*model.Profile, error) {
+	row := new(profileRow)
+	query := `
+		SELECT u.*,
+			r.name AS role_name,
+			COALESCE(c.must_change_password, false) AS must_change_password,
+			g.name AS group_name,
+			s.specialty_id,
+			s.name AS specialty_name,
+			s.department_id AS specialty_department_id,
+			d.department_id AS profile_department_id,
+			d.name AS department_name
+		FROM users u
+		JOIN roles r USING(role_id)
+		LEFT JOIN users_credentials c USING(user_id)
+		LEFT JOIN groups g USING(group_id)
+		LEFT JOIN specialties s ON s.specialty_id = g.specialty_id
+		LEFT JOIN departments d ON d.department_id = COALESCE(u.department_id, s.department_id)
+		WHERE u.user_id = $1 AND u.deleted_at IS NULL
+	`
+	if err := ur.db.GetContext(ctx, row, query, ID); err != nil {
+		baseErr := errs.Internal
+		if errors.Is(err, sql.ErrNoRows) {
+			baseErr = errs.NotFound
+		}
+		return nil, fmt.Errorf("SELECT user's profile: %w: %w", baseErr, err)
+	}
+
+	profile := &model.Profile{
+		User:               row.User,
+		RoleName:           row.RoleName,
+		MustChangePassword: row.MustChange,
+	}
+	if row.GroupID != nil && row.GroupName != nil && row.SpecialtyID != nil {
+		profile.Group = &model.Group{ID: *row.GroupID, Name: *row.GroupName, SpecialtyID: *row.SpecialtyID}
+	}
+	if row.SpecialtyID != nil && row.SpecialtyName != nil && row.SpecialtyDepID != nil {
+		profile.Specialty = &model.Specialty{ID: *row.SpecialtyID, Name: *row.SpecialtyName, DepartmentID: *row.SpecialtyDepID}
+	}
+	if row.DepartmentID != nil && row.DepartmentName != nil {
+		profile.Department = &model.Department{ID: *row.DepartmentID, Name: *row.DepartmentName}
+	}
+	return profile, nil
+}
+
+// UpdateProfile обновляет заполненные поля профиля пользователя по номеру и возвращает его или ошибку.
+// Пустая строка удаляет значение поля.
+// Если пользователь с таким номером не нашелся или находится в корзине, то возращается ошибка [errs.NotFound].
+func (ur *UserRepo) UpdateProfile(ctx context.Context, ID int, update *model.ProfileUpdate) (*model.User, error) {
+	user := new(model.User)
+	query := `
+		UPDATE users
+		SET email = CASE WHEN $1::text IS NULL THEN email ELSE NULLIF($1, '') END,
+			phone = CASE WHEN $2::text IS NULL THEN phone ELSE NULLIF($2, '') END,
+			preferences = COALESCE($3::jsonb, preferences),
+			version = version + 1
+		WHERE user_id = $4 AND deleted_at IS NULL
+		RETURNING *
+	`
+	if err := ur.db.GetContext(ctx, user, query, update.Email, update.Phone, nullRawJSON(update.Preferences), ID); err != nil {
+		baseErr := errs.Internal
+		if errors.Is(err, sql.ErrNoRows) {
+			baseErr = errs.NotFound
+		}
+		return nil, fmt.Errorf("UPDATE user's profile: %w: %w", baseErr, err)
+	}
+	return user, nil
+}
+
+// UpdatePhoto записывает путь к фотографии профиля пользователя по номеру и возвращает его или ошибку.
+// Если путь равен nil, то фотография удаляется из профиля.
+// Если пользователь с таким номером не нашелся или находится в корзине, то возращается ошибка [errs.NotFound].
+func (ur *UserRepo) UpdatePhoto(ctx context.Context, ID int, photoPath *string) (*model.User, error) {
+	user := new(model.User)
+	query := `
+		UPDATE users
+		SET photo_filepath = $1,
+			version = version + 1
+		WHERE user_id = $2 AND deleted_at IS NULL
+		RETURNING *
+	`
+	if err := ur.db.GetContext(ctx, user, query, photoPath, ID); err != nil {
+		baseErr := errs.Internal
+		if errors.Is(err, sql.ErrNoRows) {
+			baseErr = errs.NotFound
+		}
+		return nil, fmt.Errorf("UPDATE user's photo: %w: %w", baseErr, err)
+	}
+	return user, nil
 }
@@ -0,0 +1,275 @@
+package postgres
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+)
+
+// newUserInput возвращает данные нового пользователя с логином login и ролью roleID.
+func newUserInput(login string, roleID int) model.NewUser {
+	return model.NewUser{
+		Name:     "Петр",
+		Surname:  "Петров",
+		Login:    login,
+		Password: strings.Repeat("b", 64),
+		RoleID:   roleID,
+	}
+}
+
+func TestUserRepoCreateAndGet(t *testing.T) {
+	f := newFixtures(t)
+	repo := NewUserRepo(f.db)
+	group := f.groupWithSpecialty("1101")
+
+	student, err := repo.GetRoleByName(f.ctx, "student")
+	expectNoErr(t, err)
+	_, err = repo.GetRoleByName(f.ctx, "rector")
+	expectErr(t, err, errs.NotFound)
+
+	input := newUserInput("petrov", student.ID)
+	input.GroupID = &group.ID
+	input.MustChangePassword = true
+	created, err := repo.Create(f.ctx, &input)
+	expectNoErr(t, err)
+	if created.ID == 0 || created.Surname != "Петров" || created.GroupID == nil || *created.GroupID != group.ID {
+		t.Errorf("Create: got %+v", created)
+	}
+	if string(created.Preferences) != "{}" {
+		t.Errorf("Create: got preferences %s, want {}", created.Preferences)
+	}
+	duplicate := newUserInput("petrov", student.ID)
+	_, err = repo.Create(f.ctx, &duplicate)
+	expectConstraint(t, err, errs.Conflict, "login")
+	missingGroup := newUserInput("sidorov", student.ID)
+	missingGroupID := group.ID + 1
+	missingGroup.GroupID = &missingGroupID
+	_, err = repo.Create(f.ctx, &missingGroup)
+	expectConstraint(t, err, errs.InvalidReference, "group_id")
+
+	got, err := repo.GetByID(f.ctx, created.ID)
+	expectNoErr(t, err)
+	if got.ID != created.ID || got.Name != created.Name {
+		t.Errorf("GetByID: got %+v", got)
+	}
+	_, err = repo.GetByID(f.ctx, created.ID+1)
+	expectErr(t, err, errs.NotFound)
+
+	credentials, err := repo.GetCredentialsByLogin(f.ctx, "petrov")
+	expectNoErr(t, err)
+	if credentials.UserID != created.ID || credentials.PasswordHash != input.Password || !credentials.MustChangePassword {
+		t.Errorf("GetCredentialsByLogin: got %+v", credentials)
+	}
+	_, err = repo.GetCredentialsByLogin(f.ctx, "ivanov")
+	expectErr(t, err, errs.InvalidLogin)
+
+	byUser, err := repo.GetCredentialsByUserID(f.ctx, created.ID)
+	expectNoErr(t, err)
+	if *byUser != *credentials {
+		t.Errorf("GetCredentialsByUserID: got %+v, want %+v", byUser, credentials)
+	}
+	_, err = repo.GetCredentialsByUserID(f.ctx, created.ID+1)
+	expectErr(t, err, errs.NotFound)
+
+	role, err := repo.GetRole(f.ctx, created.ID)
+	expectNoErr(t, err)
+	if role != "student" {
+		t.Errorf("GetRole: got %q, want student", role)
+	}
+	_, err = repo.GetRole(f.ctx, created.ID+1)
+	expectErr(t, err, errs.NotFound)
+}
+
+func TestUserRepoCreateBatch(t *testing.T) {
+	f := newFixtures(t)
+	repo := NewUserRepo(f.db)
+	student, err := repo.GetRoleByName(f.ctx, "student")
+	expectNoErr(t, err)
+
+	users, err := repo.CreateBatch(f.ctx, []model.NewUser{newUserInput("first", student.ID), newUserInput("second", student.ID)})
+	expectNoErr(t, err)
+	if len(users) != 2 || users[0].ID == 0 || users[0].ID == users[1].ID {
+		t.Fatalf("CreateBatch: got %+v", users)
+	}
+
+	// Если один пользователь не сохранился, то не сохраняется ни один.
+	_, err = repo.CreateBatch(f.ctx, []model.NewUser{newUserInput("third", student.ID), newUserInput("first", student.ID)})
+	expectConstraint(t, err, errs.Conflict, "login")
+	_, err = repo.GetCredentialsByLogin(f.ctx, "third")
+	expectErr(t, err, errs.InvalidLogin)
+}
+
+func TestUserRepoGetAll(t *testing.T) {
+	f := newFixtures(t)
+	repo := NewUserRepo(f.db)
+	group := f.groupWithSpecialty("1101")
+	student := f.user("student", "student", &group.ID)
+	teacher := f.user("teacher", "teacher", nil)
+	rank := "майор"
+	input := newUserInput("teacher", teacher.RoleID)
+	input.Surname = "Сидоров"
+	input.Rank = &rank
+	teacher, err := repo.Update(f.ctx, teacher.ID, 0, &input)
+	expectNoErr(t, err)
+
+	query := "Сидор"
+	tests := []struct {
+		name   string
+		filter model.UserFilter
+		want   []int
+	}{
+		{"all", model.UserFilter{}, []int{student.ID, teacher.ID}},
+		{"query", model.UserFilter{Query: &query}, []int{teacher.ID}},
+		{"rank", model.UserFilter{Rank: &rank}, []int{teacher.ID}},
+		{"role", model.UserFilter{RoleID: &student.RoleID}, []int{student.ID}},
+		{"group", model.UserFilter{GroupID: &group.ID}, []int{student.ID}},
+		{"limit", model.UserFilter{Limit: 1}, []int{student.ID}},
+		{"offset", model.UserFilter{Offset: 1}, []int{teacher.ID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, err := repo.GetAll(f.ctx, &tt.filter)
+			expectNoErr(t, err)
+			var got []int
+			for _, user := range users {
+				got = append(got, user.ID)
+			}
+			if len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) || (len(got) > 1 && got[1] != tt.want[1]) {
+				t.Errorf("GetAll: got users %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserRepoUpdate(t *testing.T) {
+	f := newFixtures(t)
+	repo := NewUserRepo(f.db)
+	user := f.user("teacher", "teacher", nil)
+	department := f.department("Кафедра")
+
+	input := newUserInput("sidorov", user.RoleID)
+	input.Surname = "Сидоров"
+	input.DepartmentID = &department.ID
+	updated, err := repo.Update(f.ctx, user.ID, 0, &input)
+	expectNoErr(t, err)
+	if updated.ID != user.ID || updated.Surname != "Сидоров" || updated.DepartmentID == nil || *updated.DepartmentID != department.ID {
+		t.Errorf("Update: got %+v", updated)
+	}
+	credentials, err := repo.GetCredentialsByUserID(f.ctx, user.ID)
+	expectNoErr(t, err)
+	if credentials.Login != "sidorov" || credentials.PasswordHash != input.Password {
+		t.Errorf("Update: got credentials %+v", credentials)
+	}
+	_, err = repo.Update(f.ctx, user.ID+1, 0, &input)
+	expectErr(t, err, errs.NotFound)
+
+	expectNoErr(t, repo.UpdatePassword(f.ctx, user.ID, strings.Repeat("c", 64), true))
+	credentials, err = repo.GetCredentialsByUserID(f.ctx, user.ID)
+	expectNoErr(t, err)
+	if credentials.PasswordHash != strings.Repeat("c", 64) || !credentials.MustChangePassword {
+		t.Errorf("UpdatePassword: got credentials %+v", credentials)
+	}
+	expectErr(t, repo.UpdatePassword(f.ctx, user.ID+1, strings.Repeat("c", 64), false), errs.NotFound)
+}
+
+func TestUserRepoPatch(t *testing.T) {
+	f := newFixtures(t)
+	repo := NewUserRepo(f.db)
+	group := f.groupWithSpecialty("1101")
+	user := f.user("student", "student", &group.ID)
+
+	// Патч изменяет только указанные столбцы: фамилию и логин, а взвод удаляет.
+	patch := new(model.UserPatch)
+	expectNoErr(t, json.Unmarshal([]byte(`{"surname": "Сидоров", "login": "sidorov", "groupID": null}`), patch))
+	patched, err := repo.Patch(f.ctx, user.ID, user.Version, patch)
+	expectNoErr(t, err)
+	if patched.Surname != "Сидоров" || patched.Name != user.Name || patched.GroupID != nil || patched.Version != user.Version+1 {
+		t.Errorf("Patch: got %+v", patched)
+	}
+	credentials, err := repo.GetCredentialsByUserID(f.ctx, user.ID)
+	expectNoErr(t, err)
+	if credentials.Login != "sidorov" || credentials.PasswordHash != strings.Repeat("a", 64) {
+		t.Errorf("Patch: got credentials %+v", credentials)
+	}
+
+	_, err = repo.Patch(f.ctx, user.ID, user.Version, patch)
+	expectErr(t, err, errs.VersionMismatch)
+	_, err = repo.Patch(f.ctx, user.ID+1, 0, patch)
+	expectErr(t, err, errs.NotFound)
+
+	other := f.user("other", "student", nil)
+	_, err = repo.Patch(f.ctx, other.ID, 0, patch)
+	expectConstraint(t, err, errs.Conflict, "login")
+}
+
+func TestUserRepoDelete(t *testing.T) {
+	f := newFixtures(t)
+	repo := NewUserRepo(f.db)
+	user := f.user("teacher", "teacher", nil)
+	session := f.session(user.ID, token("teacher"))
+
+	expectNoErr(t, repo.Delete(f.ctx, user.ID, 0))
+	_, err := repo.GetByID(f.ctx, user.ID)
+	expectErr(t, err, errs.NotFound)
+	_, err = repo.GetCredentialsByLogin(f.ctx, "teacher")
+	expectErr(t, err, errs.InvalidLogin)
+	_, err = NewSessionRepo(f.db).PopByRefreshToken(f.ctx, session.RefreshToken)
+	expectErr(t, err, errs.NotFound)
+	expectErr(t, repo.Delete(f.ctx, user.ID, 0), errs.NotFound)
+}
+
+func TestUserRepoProfile(t *testing.T) {
+	f := newFixtures(t)
+	repo := NewUserRepo(f.db)
+	group := f.groupWithSpecialty("1101")
+	student := f.user("student", "student", &group.ID)
+
+	profile, err := repo.GetProfile(f.ctx, student.ID)
+	expectNoErr(t, err)
+	if profile.RoleName != "student" || profile.Group == nil || profile.Group.ID != group.ID ||
+		profile.Specialty == nil || profile.Specialty.ID != group.SpecialtyID || profile.Department == nil {
+		t.Errorf("GetProfile: got %+v", profile)
+	}
+	_, err = repo.GetProfile(f.ctx, student.ID+1)
+	expectErr(t, err, errs.NotFound)
+
+	email := "student@example.com"
+	preferences := json.RawMessage(`{"theme": "dark"}`)
+	updated, err := repo.UpdateProfile(f.ctx, student.ID, &model.ProfileUpdate{Email: &email, Preferences: &preferences})
+	expectNoErr(t, err)
+	if updated.Email == nil || *updated.Email != email || string(updated.Preferences) != `{"theme": "dark"}` {
+		t.Errorf("UpdateProfile: got %+v", updated)
+	}
+	// Незаполненные поля не изменяются, пустая строка удаляет значение.
+	empty := ""
+	updated, err = repo.UpdateProfile(f.ctx, student.ID, &model.ProfileUpdate{Email: &empty})
+	expectNoErr(t, err)
+	if updated.Email != nil || string(updated.Preferences) != `{"theme": "dark"}` {
+		t.Errorf("UpdateProfile with an empty email: got %+v", updated)
+	}
+	_, err = repo.UpdateProfile(f.ctx, student.ID+1, &model.ProfileUpdate{Email: &email})
+	expectErr(t, err, errs.NotFound)
+
+	photoPath := "photos/student.png"
+	updated, err = repo.UpdatePhoto(f.ctx, student.ID, &photoPath)
+	expectNoErr(t, err)
+	if updated.PhotoPath == nil || *updated.PhotoPath != photoPath {
+		t.Errorf("UpdatePhoto: got %+v", updated)
+	}
+	updated, err = repo.UpdatePhoto(f.ctx, student.ID, nil)
+	expectNoErr(t, err)
+	if updated.PhotoPath != nil {
+		t.Errorf("UpdatePhoto with nil path: got %+v", updated)
+	}
+	_, err = repo.UpdatePhoto(f.ctx, student.ID+1, nil)
+	expectErr(t, err, errs.NotFound)
+
+	expectNoErr(t, repo.Delete(f.ctx, student.ID, 0))
+	_, err = repo.UpdateProfile(f.ctx, student.ID, &model.ProfileUpdate{Email: &email})
+	expectErr(t, err, errs.NotFound)
+	_, err = repo.UpdatePhoto(f.ctx, student.ID, &photoPath)
+	expectErr(t, err, errs.NotFound)
+}
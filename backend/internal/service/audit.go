@@ -0,0 +1,181 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/logging"
+	"github.com/foreverd34d/aumsu-elib/internal/metrics"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+	"github.com/foreverd34d/aumsu-elib/internal/tracing"
+)
+
+// AuditRepo определяет методы хранилища журнала аудита.
+type AuditRepo interface {
+	// Create сохраняет новую запись журнала аудита и возвращает ее с номером или ошибку.
+	Create(ctx context.Context, input *model.NewAuditEvent) (*model.AuditEvent, error)
+
+	// GetAll возвращает слайс записей журнала аудита, удовлетворяющих фильтру, или ошибку.
+	// Записи упорядочены от новых к старым.
+	GetAll(ctx context.Context, filter *model.AuditFilter) ([]model.AuditEvent, error)
+}
+
+// AuditService реализует методы для чтения журнала аудита
+// и реализует интерфейс [handler.AuditService].
+type AuditService struct {
+	repo AuditRepo
+}
+
+// NewAuditService возвращает новый экземпляр [AuditService].
+func NewAuditService(repo AuditRepo) *AuditService {
+	return &AuditService{repo}
+}
+
+// GetAll возвращает слайс записей журнала аудита, удовлетворяющих фильтру, или ошибку.
+func (as *AuditService) GetAll(ctx context.Context, filter *model.AuditFilter) ([]model.AuditEvent, error) {
+	ctx, span := tracing.Start(ctx, "AuditService.GetAll")
+	defer span.End()
+	events, err := as.repo.GetAll(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("get audit events: %w", err)
+	}
+	return events, nil
+}
+
+// Названия сущностей в журнале аудита.
+const (
+	auditUser       = "user"
+	auditGroup      = "group"
+	auditSpecialty  = "specialty"
+	auditDepartment = "department"
+	auditDiscipline = "discipline"
+	auditSession    = "session"
+
+	auditMaterialType   = "material_type"
+	auditMaterial       = "material"
+	auditLessonMaterial = "lesson_material"
+	auditMaterialBook   = "material_book"
+)
+
+// actorKey является ключом контекста для хранения инициатора запроса.
+type actorKey struct{}
+
+// WithActor возвращает копию контекста с инициатором запроса,
+// который будет записан в журнал аудита при изменяющих операциях.
+func WithActor(ctx context.Context, actor model.Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// actorFrom возвращает инициатора запроса из контекста.
+// Если инициатор не был записан в контекст, то возвращается пустой [model.Actor].
+func actorFrom(ctx context.Context) model.Actor {
+	actor, _ := ctx.Value(actorKey{}).(model.Actor)
+	return actor
+}
+
+// withActorUser возвращает копию контекста, в которой инициатором запроса указан пользователь с номером userID.
+// Используется там, где пользователь становится известен только в ходе операции, например при входе в систему.
+func withActorUser(ctx context.Context, userID int) context.Context {
+	actor := actorFrom(ctx)
+	actor.UserID = &userID
+	return WithActor(ctx, actor)
+}
+
+// auditFailures считает записи журнала аудита, которые не удалось сохранить, по названию сущности.
+var auditFailures = metrics.Default.NewCounter("elib_audit_failures_total", "Total number of audit events that couldn't be recorded by entity.", "entity")
+
+// recordAudit записывает изменяющую операцию в журнал аудита.
+// before и after представляют состояние сущности до и после операции
+// (nil при создании и удалении соответственно), в журнал попадают только различающиеся поля.
+//
+// Запись делается после того, как операция уже выполнена, поэтому ошибка записи не возвращается
+// вызывающему, а только записывается в журнал сервера и учитывается в метрике elib_audit_failures_total.
+func recordAudit(ctx context.Context, repo AuditRepo, action model.AuditAction, entity string, entityID int, before, after any) {
+	if err := createAuditEvent(ctx, repo, action, entity, entityID, before, after); err != nil {
+		auditFailures.Inc(entity)
+		slog.ErrorContext(ctx, "couldn't record audit event", slog.String("action", string(action)),
+			slog.String("entity", entity), slog.Int("entity_id", entityID), logging.Err(err))
+	}
+}
+
+// createAuditEvent сохраняет запись журнала аудита об операции и возвращает ошибку, если таковая есть.
+func createAuditEvent(ctx context.Context, repo AuditRepo, action model.AuditAction, entity string, entityID int, before, after any) error {
+	beforeDiff, afterDiff, err := diffJSON(before, after)
+	if err != nil {
+		return fmt.Errorf("diff %v %v: %w: %w", entity, entityID, errs.Internal, err)
+	}
+	actor := actorFrom(ctx)
+	event := &model.NewAuditEvent{
+		ActorID:  actor.UserID,
+		Action:   action,
+		Entity:   entity,
+		EntityID: entityID,
+		Before:   beforeDiff,
+		After:    afterDiff,
+	}
+	if actor.IP != "" {
+		event.IP = &actor.IP
+	}
+	if _, err := repo.Create(ctx, event); err != nil {
+		return fmt.Errorf("record audit event for %v %v: %w", entity, entityID, err)
+	}
+	return nil
+}
+
+// diffJSON сериализует обе сущности в json-объекты и оставляет в них только различающиеся поля.
+// Если одна из сущностей равна nil, то другая возвращается целиком.
+func diffJSON(before, after any) (json.RawMessage, json.RawMessage, error) {
+	beforeFields, err := toJSONFields(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	afterFields, err := toJSONFields(after)
+	if err != nil {
+		return nil, nil, err
+	}
+	if beforeFields != nil && afterFields != nil {
+		for key, value := range beforeFields {
+			if afterValue, ok := afterFields[key]; ok && bytes.Equal(value, afterValue) {
+				delete(beforeFields, key)
+				delete(afterFields, key)
+			}
+		}
+	}
+	beforeDiff, err := fromJSONFields(beforeFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	afterDiff, err := fromJSONFields(afterFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	return beforeDiff, afterDiff, nil
+}
+
+// toJSONFields сериализует сущность в словарь полей json-объекта.
+func toJSONFields(entity any) (map[string]json.RawMessage, error) {
+	if entity == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// fromJSONFields сериализует словарь полей обратно в json-объект.
+func fromJSONFields(fields map[string]json.RawMessage) (json.RawMessage, error) {
+	if fields == nil {
+		return nil, nil
+	}
+	return json.Marshal(fields)
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+	"github.com/foreverd34d/aumsu-elib/internal/tracing"
+)
+
+// CatalogRepo определяет методы хранилища иерархии каталога.
+type CatalogRepo interface {
+	// GetTree возвращает дерево каталога глубиной depth уровней, начиная с кафедр, или ошибку.
+	// Если counts истинно, то к узлам добавляется количество их дочерних элементов.
+	GetTree(ctx context.Context, depth int, counts bool) ([]model.CatalogDepartment, error)
+}
+
+// CatalogService реализует методы для чтения каталога
+// и реализует интерфейс [handler.CatalogService].
+type CatalogService struct {
+	repo CatalogRepo
+}
+
+// NewCatalogService возвращает новый экземпляр [CatalogService].
+func NewCatalogService(repo CatalogRepo) *CatalogService {
+	return &CatalogService{repo}
+}
+
+// GetTree возвращает дерево каталога от кафедр до занятий с учетом параметров фильтра или ошибку.
+// Если глубина не указана, то возвращаются все уровни.
+func (cs *CatalogService) GetTree(ctx context.Context, filter *model.CatalogFilter) ([]model.CatalogDepartment, error) {
+	ctx, span := tracing.Start(ctx, "CatalogService.GetTree")
+	defer span.End()
+	depth := filter.Depth
+	if depth == 0 {
+		depth = model.CatalogLessons
+	}
+	tree, err := cs.repo.GetTree(ctx, depth, filter.Counts)
+	if err != nil {
+		return nil, fmt.Errorf("get catalog tree: %w", err)
+	}
+	return tree, nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/foreverd34d/aumsu-elib/internal/model"
+	"github.com/foreverd34d/aumsu-elib/internal/tracing"
 )
 
 // DepartmentRepo определяет методы хранилища кафедр.
@@ -22,35 +23,48 @@ type DepartmentRepo interface {
 
 	// Update обновляет кафедру по номеру и возвращает обновленную кафедру с номером или ошибку.
 	// Если кафедра с таким номером не нашлась, то возращается ошибка [errs.NotFound].
-	Update(ctx context.Context, ID int, update *model.NewDepartment) (*model.Department, error)
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Update(ctx context.Context, ID, version int, update *model.NewDepartment) (*model.Department, error)
 
-	// Delete удаляет кафедру по номеру и возвращает ошибку, если удаления не произошло.
+	// Patch изменяет указанные в патче поля кафедры по номеру и возвращает обновленную кафедру с номером или ошибку.
 	// Если кафедра с таким номером не нашлась, то возращается ошибка [errs.NotFound].
-	Delete(ctx context.Context, ID int) error
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Patch(ctx context.Context, ID, version int, patch *model.DepartmentPatch) (*model.Department, error)
+
+	// Delete удаляет кафедру по номеру в корзину и возвращает ошибку, если удаления не произошло.
+	// Если кафедра с таким номером не нашлась, то возращается ошибка [errs.NotFound].
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Delete(ctx context.Context, ID, version int) error
 }
 
 // DepartmentService реализует методы для работы с кафедрами
 // и реализует интерфейс [handler.departmentService].
 type DepartmentService struct {
-	repo DepartmentRepo
+	repo  DepartmentRepo
+	audit AuditRepo
 }
 
 // NewDepartmentService возвращает новый экземпляр [DepartmentService].
-func NewDepartmentService(repo DepartmentRepo) *DepartmentService {
-	return &DepartmentService{repo}
+func NewDepartmentService(repo DepartmentRepo, audit AuditRepo) *DepartmentService {
+	return &DepartmentService{repo, audit}
 }
 
 // Create создает новую кафедру и возвращает ее с порядковым номером или ошибку.
 func (ds *DepartmentService) Create(ctx context.Context, input *model.NewDepartment) (*model.Department, error) {
+	ctx, span := tracing.Start(ctx, "DepartmentService.Create")
+	defer span.End()
 	department, err := ds.repo.Create(ctx, input)
 	if err != nil {
 		return nil, fmt.Errorf("create new department: %w", err)
 	}
+	recordAudit(ctx, ds.audit, model.AuditCreate, auditDepartment, department.ID, nil, department)
 	return department, nil
 }
 
 // GetAll возвращает слайс всех кафедр или ошибку. Если кафедр нет, то возвращается ошибка [errs.Empty].
 func (ds *DepartmentService) GetAll(ctx context.Context) ([]model.Department, error) {
+	ctx, span := tracing.Start(ctx, "DepartmentService.GetAll")
+	defer span.End()
 	departments, err := ds.repo.GetAll(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("get departments: %w", err)
@@ -60,6 +74,8 @@ func (ds *DepartmentService) GetAll(ctx context.Context) ([]model.Department, er
 
 // Get возвращает кафедру по номеру или ошибку. Если кафедра с таким номером не нашлась, то возвращается ошибка [errs.NotFound].
 func (ds *DepartmentService) Get(ctx context.Context, ID int) (*model.Department, error) {
+	ctx, span := tracing.Start(ctx, "DepartmentService.Get")
+	defer span.End()
 	department, err := ds.repo.Get(ctx, ID)
 	if err != nil {
 		return nil, fmt.Errorf("get the department with ID %v: %w", ID, err)
@@ -69,20 +85,54 @@ func (ds *DepartmentService) Get(ctx context.Context, ID int) (*model.Department
 
 // Update обновляет кафедру по номеру и возвращает обновленную кафедру с номером или ошибку.
 // Если кафедра с таким номером не нашлась, то возращается ошибка [errs.NotFound]
-func (ds *DepartmentService) Update(ctx context.Context, ID int, update *model.NewDepartment) (*model.Department, error) {
-	department, err := ds.repo.Update(ctx, ID, update)
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (ds *DepartmentService) Update(ctx context.Context, ID, version int, update *model.NewDepartment) (*model.Department, error) {
+	ctx, span := tracing.Start(ctx, "DepartmentService.Update")
+	defer span.End()
+	before, err := ds.repo.Get(ctx, ID)
+	if err != nil {
+		return nil, fmt.Errorf("get the department with ID %v: %w", ID, err)
+	}
+	department, err := ds.repo.Update(ctx, ID, version, update)
 	if err != nil {
 		return nil, fmt.Errorf("update the department with ID %v: %w", ID, err)
 	}
-	return department, err
+	recordAudit(ctx, ds.audit, model.AuditUpdate, auditDepartment, ID, before, department)
+	return department, nil
 }
 
-// Delete удаляет кафедру по номеру и возвращает ошибку, если удаления не произошло.
+// Patch изменяет указанные в патче поля кафедры по номеру и возвращает обновленную кафедру с номером или ошибку.
 // Если кафедра с таким номером не нашлась, то возращается ошибка [errs.NotFound]
-func (ds *DepartmentService) Delete(ctx context.Context, ID int) error {
-	err := ds.repo.Delete(ctx, ID)
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (ds *DepartmentService) Patch(ctx context.Context, ID, version int, patch *model.DepartmentPatch) (*model.Department, error) {
+	ctx, span := tracing.Start(ctx, "DepartmentService.Patch")
+	defer span.End()
+	before, err := ds.repo.Get(ctx, ID)
+	if err != nil {
+		return nil, fmt.Errorf("get the department with ID %v: %w", ID, err)
+	}
+	department, err := ds.repo.Patch(ctx, ID, version, patch)
+	if err != nil {
+		return nil, fmt.Errorf("patch the department with ID %v: %w", ID, err)
+	}
+	recordAudit(ctx, ds.audit, model.AuditUpdate, auditDepartment, ID, before, department)
+	return department, nil
+}
+
+// Delete удаляет кафедру по номеру в корзину и возвращает ошибку, если удаления не произошло.
+// Если кафедра с таким номером не нашлась, то возращается ошибка [errs.NotFound]
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (ds *DepartmentService) Delete(ctx context.Context, ID, version int) error {
+	ctx, span := tracing.Start(ctx, "DepartmentService.Delete")
+	defer span.End()
+	before, err := ds.repo.Get(ctx, ID)
+	if err != nil {
+		return fmt.Errorf("get the department with ID %v: %w", ID, err)
+	}
+	err = ds.repo.Delete(ctx, ID, version)
 	if err != nil {
 		return fmt.Errorf("delete department with ID %v: %w", ID, err)
 	}
+	recordAudit(ctx, ds.audit, model.AuditDelete, auditDepartment, ID, before, nil)
 	return nil
 }
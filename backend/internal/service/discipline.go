@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/foreverd34d/aumsu-elib/internal/model"
+	"github.com/foreverd34d/aumsu-elib/internal/tracing"
 )
 
 // DisciplineRepo определяет методы хранилища предметов.
@@ -22,35 +23,48 @@ type DisciplineRepo interface {
 
 	// Update обновляет предмет по номеру и возвращает его с номером.
 	// Если предмета с таким номером не нашлось, то возвращается ошибка [errs.NotFound].
-	Update(ctx context.Context, ID int, update *model.NewDiscipline) (*model.Discipline, error)
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Update(ctx context.Context, ID, version int, update *model.NewDiscipline) (*model.Discipline, error)
 
-	// Delete удаляет предмет по номеру и возвращает ошибку, если удаления не произошло.
+	// Patch изменяет указанные в патче поля предмета по номеру и возвращает его с номером.
 	// Если предмета с таким номером не нашлось, то возвращается ошибка [errs.NotFound].
-	Delete(ctx context.Context, ID int) error
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Patch(ctx context.Context, ID, version int, patch *model.DisciplinePatch) (*model.Discipline, error)
+
+	// Delete удаляет предмет по номеру в корзину и возвращает ошибку, если удаления не произошло.
+	// Если предмета с таким номером не нашлось, то возвращается ошибка [errs.NotFound].
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Delete(ctx context.Context, ID, version int) error
 }
 
 // DisciplineService реализует методы для работы с предметами
 // и реализует интерфейс [handler.DisciplineService].
 type DisciplineService struct {
-	repo DisciplineRepo
+	repo  DisciplineRepo
+	audit AuditRepo
 }
 
 // NewDisciplineService возвращает новый экземпляр [DisciplineService].
-func NewDisciplineService(repo DisciplineRepo) *DisciplineService {
-	return &DisciplineService{repo}
+func NewDisciplineService(repo DisciplineRepo, audit AuditRepo) *DisciplineService {
+	return &DisciplineService{repo, audit}
 }
 
 // Create создает новый предмет и возвращает ее с номером.
 func (ds *DisciplineService) Create(ctx context.Context, input *model.NewDiscipline) (*model.Discipline, error) {
+	ctx, span := tracing.Start(ctx, "DisciplineService.Create")
+	defer span.End()
 	discipline, err := ds.repo.Create(ctx, input)
 	if err != nil {
 		return nil, fmt.Errorf("create new discipline: %w", err)
 	}
+	recordAudit(ctx, ds.audit, model.AuditCreate, auditDiscipline, discipline.ID, nil, discipline)
 	return discipline, nil
 }
 
 // GetAll возвращает слайс всех предметов. Если предметов нет, то возвращается ошибка [errs.Empty].
 func (ds *DisciplineService) GetAll(ctx context.Context) ([]model.Discipline, error) {
+	ctx, span := tracing.Start(ctx, "DisciplineService.GetAll")
+	defer span.End()
 	disciplines, err := ds.repo.GetAll(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("get all disciplines: %w", err)
@@ -60,6 +74,8 @@ func (ds *DisciplineService) GetAll(ctx context.Context) ([]model.Discipline, er
 
 // Get возвращает предмет по номеру. Если предмета с таким номером не нашлось, то возращается ошибка [errs.NotFound].
 func (ds *DisciplineService) Get(ctx context.Context, ID int) (*model.Discipline, error) {
+	ctx, span := tracing.Start(ctx, "DisciplineService.Get")
+	defer span.End()
 	discipline, err := ds.repo.Get(ctx, ID)
 	if err != nil {
 		return nil, fmt.Errorf("get discipline: %w", err)
@@ -69,19 +85,53 @@ func (ds *DisciplineService) Get(ctx context.Context, ID int) (*model.Discipline
 
 // Update обновляет предмет по номеру и возвращает обновленный предмет с номером.
 // Если предмет с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
-func (ds *DisciplineService) Update(ctx context.Context, ID int, update *model.NewDiscipline) (*model.Discipline, error) {
-	discipline, err := ds.repo.Update(ctx, ID, update)
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (ds *DisciplineService) Update(ctx context.Context, ID, version int, update *model.NewDiscipline) (*model.Discipline, error) {
+	ctx, span := tracing.Start(ctx, "DisciplineService.Update")
+	defer span.End()
+	before, err := ds.repo.Get(ctx, ID)
+	if err != nil {
+		return nil, fmt.Errorf("get the discipline with ID %v: %w", ID, err)
+	}
+	discipline, err := ds.repo.Update(ctx, ID, version, update)
 	if err != nil {
 		return nil, fmt.Errorf("update discipline: %w", err)
 	}
+	recordAudit(ctx, ds.audit, model.AuditUpdate, auditDiscipline, ID, before, discipline)
+	return discipline, nil
+}
+
+// Patch изменяет указанные в патче поля предмета по номеру и возвращает обновленный предмет с номером.
+// Если предмет с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (ds *DisciplineService) Patch(ctx context.Context, ID, version int, patch *model.DisciplinePatch) (*model.Discipline, error) {
+	ctx, span := tracing.Start(ctx, "DisciplineService.Patch")
+	defer span.End()
+	before, err := ds.repo.Get(ctx, ID)
+	if err != nil {
+		return nil, fmt.Errorf("get the discipline with ID %v: %w", ID, err)
+	}
+	discipline, err := ds.repo.Patch(ctx, ID, version, patch)
+	if err != nil {
+		return nil, fmt.Errorf("patch discipline: %w", err)
+	}
+	recordAudit(ctx, ds.audit, model.AuditUpdate, auditDiscipline, ID, before, discipline)
 	return discipline, nil
 }
 
-// Delete удаляет предмет по номеру и возвращает ошибку, если удаления не произошло.
+// Delete удаляет предмет по номеру в корзину и возвращает ошибку, если удаления не произошло.
 // Если предмета с таким номером не нашлось, то возращается ошибка [errs.NotFound].
-func (ds *DisciplineService) Delete(ctx context.Context, ID int) error {
-	if err := ds.repo.Delete(ctx, ID); err != nil {
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (ds *DisciplineService) Delete(ctx context.Context, ID, version int) error {
+	ctx, span := tracing.Start(ctx, "DisciplineService.Delete")
+	defer span.End()
+	before, err := ds.repo.Get(ctx, ID)
+	if err != nil {
+		return fmt.Errorf("get the discipline with ID %v: %w", ID, err)
+	}
+	if err := ds.repo.Delete(ctx, ID, version); err != nil {
 		return fmt.Errorf("delete discipline: %w", err)
 	}
+	recordAudit(ctx, ds.audit, model.AuditDelete, auditDiscipline, ID, before, nil)
 	return nil
 }
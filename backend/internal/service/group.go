@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/foreverd34d/aumsu-elib/internal/model"
+	"github.com/foreverd34d/aumsu-elib/internal/tracing"
 )
 
 // GroupRepo определяет методы хранилища взводов.
@@ -12,46 +13,70 @@ type GroupRepo interface {
 	// Create сохраняет новую группу в хранилище и возвращает группу с номером или ошибку.
 	Create(ctx context.Context, input *model.NewGroup) (*model.Group, error)
 
-	// GetAll возвращает слайс всех групп или ошибку.
+	// GetAll возвращает слайс групп, удовлетворяющих фильтру, или ошибку.
 	// Если база данных пуста, то возвращается ошибка [errs.Empty].
-	GetAll(ctx context.Context) ([]model.Group, error)
+	GetAll(ctx context.Context, filter *model.GroupFilter) ([]model.Group, error)
 
 	// Get возвращает группу по номеру или ошибку.
 	// Если группа с таким номером не нашлась, то возвращается ошибка [errs.NotFound].
 	Get(ctx context.Context, ID int) (*model.Group, error)
 
+	// GetByName возвращает группу не из архива по названию или ошибку.
+	// Если группа с таким названием не нашлась, то возвращается ошибка [errs.NotFound].
+	GetByName(ctx context.Context, name string) (*model.Group, error)
+
 	// Update обновляет группу по номеру и возвращает обновленную группу с номером или ошибку.
 	// Если группа с таким номером не нашлась, то возращается ошибка [errs.NotFound].
-	Update(ctx context.Context, ID int, update *model.NewGroup) (*model.Group, error)
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Update(ctx context.Context, ID, version int, update *model.NewGroup) (*model.Group, error)
+
+	// Patch изменяет указанные в патче поля группы по номеру и возвращает обновленную группу с номером или ошибку.
+	// Если группа с таким номером не нашлась, то возращается ошибка [errs.NotFound].
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Patch(ctx context.Context, ID, version int, patch *model.GroupPatch) (*model.Group, error)
 
 	// Delete удаляет группу по номеру и возвращает ошибку, если удаления не произошло.
 	// Если группа с таким номером не нашлась, то возращается ошибка [errs.NotFound].
-	Delete(ctx context.Context, ID int) error
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Delete(ctx context.Context, ID, version int) error
+
+	// ApplyRollover выполняет переход на новый учебный год в одной транзакции
+	// и возвращает измененные взводы и номера студентов, чьи учетные записи отключены, или ошибку.
+	// Если хотя бы одно изменение не удалось, то не применяется ни одно.
+	// Если версия взвода из versions отличается от указанной или взвод уже находится в архиве,
+	// то возвращается ошибка [errs.VersionMismatch].
+	ApplyRollover(ctx context.Context, rollover *model.Rollover, versions map[int]int) (*model.AppliedRollover, error)
 }
 
 // GroupService определяет методы для работы с группами
 // и реализует интерфейс [handler.groupService].
 type GroupService struct {
-	repo GroupRepo
+	repo  GroupRepo
+	audit AuditRepo
 }
 
 // NewGroupService возвращает новый экземпляр [GroupService]
-func NewGroupService(repo GroupRepo) *GroupService {
-	return &GroupService{repo}
+func NewGroupService(repo GroupRepo, audit AuditRepo) *GroupService {
+	return &GroupService{repo, audit}
 }
 
 // Create создает новую группу и возвращает ее с номером или ошибку.
 func (gs *GroupService) Create(ctx context.Context, input *model.NewGroup) (*model.Group, error) {
+	ctx, span := tracing.Start(ctx, "GroupService.Create")
+	defer span.End()
 	group, err := gs.repo.Create(ctx, input)
 	if err != nil {
 		return nil, fmt.Errorf("create new group: %w", err)
 	}
+	recordAudit(ctx, gs.audit, model.AuditCreate, auditGroup, group.ID, nil, group)
 	return group, nil
 }
 
-// GetAll возвращает слайс всех групп или ошибку. Если групп нет, то возвращается ошибка [errs.Empty].
-func (gs *GroupService) GetAll(ctx context.Context) ([]model.Group, error) {
-	groups, err := gs.repo.GetAll(ctx)
+// GetAll возвращает слайс групп, удовлетворяющих фильтру, или ошибку. Если групп нет, то возвращается ошибка [errs.Empty].
+func (gs *GroupService) GetAll(ctx context.Context, filter *model.GroupFilter) ([]model.Group, error) {
+	ctx, span := tracing.Start(ctx, "GroupService.GetAll")
+	defer span.End()
+	groups, err := gs.repo.GetAll(ctx, filter)
 	if err != nil {
 		return nil, fmt.Errorf("get all groups: %w", err)
 	}
@@ -60,6 +85,8 @@ func (gs *GroupService) GetAll(ctx context.Context) ([]model.Group, error) {
 
 // Get возвращает группу по номеру или ошибку. Если группа с таким номером не нашлась, то возвращается ошибка [errs.NotFound].
 func (gs *GroupService) Get(ctx context.Context, ID int) (*model.Group, error) {
+	ctx, span := tracing.Start(ctx, "GroupService.Get")
+	defer span.End()
 	group, err := gs.repo.Get(ctx, ID)
 	if err != nil {
 		return nil, fmt.Errorf("get the group with ID %v: %w", ID, err)
@@ -69,19 +96,53 @@ func (gs *GroupService) Get(ctx context.Context, ID int) (*model.Group, error) {
 
 // Update обновляет группу по номеру и возвращает обновленную группу с номером или ошибку.
 // Если группа с таким номером не нашлась, то возращается ошибка [errs.NotFound].
-func (gs *GroupService) Update(ctx context.Context, ID int, update *model.NewGroup) (*model.Group, error) {
-	group, err := gs.repo.Update(ctx, ID, update)
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (gs *GroupService) Update(ctx context.Context, ID, version int, update *model.NewGroup) (*model.Group, error) {
+	ctx, span := tracing.Start(ctx, "GroupService.Update")
+	defer span.End()
+	before, err := gs.repo.Get(ctx, ID)
+	if err != nil {
+		return nil, fmt.Errorf("get the group with ID %v: %w", ID, err)
+	}
+	group, err := gs.repo.Update(ctx, ID, version, update)
 	if err != nil {
 		return nil, fmt.Errorf("update the group with ID %v: %w", ID, err)
 	}
+	recordAudit(ctx, gs.audit, model.AuditUpdate, auditGroup, ID, before, group)
+	return group, nil
+}
+
+// Patch изменяет указанные в патче поля группы по номеру и возвращает обновленную группу с номером или ошибку.
+// Если группа с таким номером не нашлась, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (gs *GroupService) Patch(ctx context.Context, ID, version int, patch *model.GroupPatch) (*model.Group, error) {
+	ctx, span := tracing.Start(ctx, "GroupService.Patch")
+	defer span.End()
+	before, err := gs.repo.Get(ctx, ID)
+	if err != nil {
+		return nil, fmt.Errorf("get the group with ID %v: %w", ID, err)
+	}
+	group, err := gs.repo.Patch(ctx, ID, version, patch)
+	if err != nil {
+		return nil, fmt.Errorf("patch the group with ID %v: %w", ID, err)
+	}
+	recordAudit(ctx, gs.audit, model.AuditUpdate, auditGroup, ID, before, group)
 	return group, nil
 }
 
 // Delete удаляет группу по номеру и возвращает ошибку, если удаления не произошло.
 // Если группа с таким номером не нашлась, то возращается ошибка [errs.NotFound].
-func (gs *GroupService) Delete(ctx context.Context, ID int) error {
-	if err := gs.repo.Delete(ctx, ID); err != nil {
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (gs *GroupService) Delete(ctx context.Context, ID, version int) error {
+	ctx, span := tracing.Start(ctx, "GroupService.Delete")
+	defer span.End()
+	before, err := gs.repo.Get(ctx, ID)
+	if err != nil {
+		return fmt.Errorf("get the group with ID %v: %w", ID, err)
+	}
+	if err := gs.repo.Delete(ctx, ID, version); err != nil {
 		return fmt.Errorf("delete the group with ID %v: %w", ID, err)
 	}
+	recordAudit(ctx, gs.audit, model.AuditDelete, auditGroup, ID, before, nil)
 	return nil
 }
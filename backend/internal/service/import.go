@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+	"github.com/foreverd34d/aumsu-elib/internal/tracing"
+)
+
+const (
+	studentRoleName        = "student" // название роли студента
+	maxNameLength          = 30        // максимальная длина фамилии, имени и отчества
+	maxLoginLength         = 30        // максимальная длина логина
+	oneTimePasswordLength  = 10        // длина одноразового пароля
+	oneTimePasswordSymbols = "abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+// ImportService реализует массовый импорт студентов из списков взводов
+// и реализует интерфейс [handler.ImportService].
+type ImportService struct {
+	users  UserRepo
+	groups GroupRepo
+	audit  AuditRepo
+}
+
+// NewImportService возвращает новый экземпляр [ImportService].
+func NewImportService(users UserRepo, groups GroupRepo, audit AuditRepo) *ImportService {
+	return &ImportService{
+		users:  users,
+		groups: groups,
+		audit:  audit,
+	}
+}
+
+// ImportStudents проверяет строки списка студентов и создает учетные записи для новых студентов
+// с сгенерированными логинами и одноразовыми паролями. Студенты, которые уже есть во взводе,
+// пропускаются, поэтому повторный импорт того же списка ничего не меняет.
+// Если хотя бы одна строка содержит ошибки или dryRun истинно, то ничего не создается,
+// а отчет описывает, что произошло бы при импорте.
+func (is *ImportService) ImportStudents(ctx context.Context, rows []model.RosterRow, dryRun bool) (*model.ImportReport, error) {
+	ctx, span := tracing.Start(ctx, "ImportService.ImportStudents")
+	defer span.End()
+	role, err := is.users.GetRoleByName(ctx, studentRoleName)
+	if err != nil {
+		return nil, fmt.Errorf("get student role: %w", err)
+	}
+
+	importer := &rosterImporter{
+		service:  is,
+		groups:   make(map[string]*model.Group),
+		students: make(map[int][]model.User),
+		lines:    make(map[string]int),
+		logins:   make(map[string]bool),
+	}
+	report := &model.ImportReport{DryRun: dryRun, Rows: make([]model.ImportRowResult, 0, len(rows))}
+	var (
+		inputs     []model.NewUser
+		newResults []int
+	)
+	for _, row := range rows {
+		result, input, err := importer.checkRow(ctx, row, role.ID, dryRun)
+		if err != nil {
+			return nil, fmt.Errorf("check line %v: %w", row.Line, err)
+		}
+		switch result.Status {
+		case model.ImportNew:
+			report.Created++
+			inputs = append(inputs, *input)
+			newResults = append(newResults, len(report.Rows))
+		case model.ImportExisting:
+			report.Existing++
+		case model.ImportInvalid:
+			report.Invalid++
+		}
+		report.Rows = append(report.Rows, *result)
+	}
+
+	if dryRun || report.Invalid > 0 || len(inputs) == 0 {
+		return report, nil
+	}
+
+	users, err := is.users.CreateBatch(ctx, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("repo: create students: %w", err)
+	}
+	for i, user := range users {
+		result := &report.Rows[newResults[i]]
+		result.Status = model.ImportCreated
+		result.UserID = &user.ID
+		recordAudit(ctx, is.audit, model.AuditCreate, auditUser, user.ID, nil, user)
+	}
+	return report, nil
+}
+
+// rosterImporter хранит состояние одного импорта: найденные взводы, их студентов,
+// уже встреченные строки и занятые логины.
+type rosterImporter struct {
+	service  *ImportService
+	groups   map[string]*model.Group // взводы по названию (nil, если взвод не найден)
+	students map[int][]model.User    // студенты по номеру взвода
+	lines    map[string]int          // номера строк по ключу студента
+	logins   map[string]bool         // логины, выданные в этом импорте
+}
+
+// checkRow проверяет строку списка и возвращает результат ее импорта,
+// а для новых студентов также данные для создания учетной записи.
+// При пробном импорте пароли не генерируются, так как они не будут сохранены.
+func (ri *rosterImporter) checkRow(ctx context.Context, row model.RosterRow, roleID int, dryRun bool) (*model.ImportRowResult, *model.NewUser, error) {
+	result := &model.ImportRowResult{RosterRow: row}
+	if row.Surname == "" {
+		result.Errors = append(result.Errors, "не указана фамилия")
+	} else if utf8.RuneCountInString(row.Surname) > maxNameLength {
+		result.Errors = append(result.Errors, fmt.Sprintf("фамилия длиннее %v символов", maxNameLength))
+	}
+	if row.Name == "" {
+		result.Errors = append(result.Errors, "не указано имя")
+	} else if utf8.RuneCountInString(row.Name) > maxNameLength {
+		result.Errors = append(result.Errors, fmt.Sprintf("имя длиннее %v символов", maxNameLength))
+	}
+	if row.Patronymic != nil && utf8.RuneCountInString(*row.Patronymic) > maxNameLength {
+		result.Errors = append(result.Errors, fmt.Sprintf("отчество длиннее %v символов", maxNameLength))
+	}
+
+	var group *model.Group
+	if row.GroupName == "" {
+		result.Errors = append(result.Errors, "не указан взвод")
+	} else {
+		var err error
+		group, err = ri.group(ctx, row.GroupName)
+		if err != nil {
+			return nil, nil, err
+		}
+		if group == nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("взвод %q не найден", row.GroupName))
+		}
+	}
+	if len(result.Errors) > 0 {
+		result.Status = model.ImportInvalid
+		return result, nil, nil
+	}
+
+	key := studentKey(row.Surname, row.Name, row.Patronymic, group.ID)
+	if line, ok := ri.lines[key]; ok {
+		result.Status = model.ImportInvalid
+		result.Errors = append(result.Errors, fmt.Sprintf("повторяет строку %v", line))
+		return result, nil, nil
+	}
+	ri.lines[key] = row.Line
+
+	students, err := ri.groupStudents(ctx, group.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, student := range students {
+		if studentKey(student.Surname, student.Name, student.Patronymic, group.ID) == key {
+			result.Status = model.ImportExisting
+			result.UserID = &student.ID
+			return result, nil, nil
+		}
+	}
+
+	login, err := ri.uniqueLogin(ctx, row.Surname, row.Name, row.Patronymic)
+	if err != nil {
+		return nil, nil, err
+	}
+	result.Status = model.ImportNew
+	result.Login = login
+	input := &model.NewUser{
+		Name:               row.Name,
+		Surname:            row.Surname,
+		Patronymic:         row.Patronymic,
+		Login:              login,
+		RoleID:             roleID,
+		GroupID:            &group.ID,
+		MustChangePassword: true,
+	}
+	if !dryRun {
+		password, err := generateOneTimePassword()
+		if err != nil {
+			return nil, nil, err
+		}
+		result.Password = password
+		input.Password = hashPassword(password)
+	}
+	return result, input, nil
+}
+
+// group возвращает взвод по названию, запоминая результат поиска.
+// Если взвод не найден, то возвращается nil без ошибки.
+func (ri *rosterImporter) group(ctx context.Context, name string) (*model.Group, error) {
+	key := strings.ToUpper(name)
+	if group, ok := ri.groups[key]; ok {
+		return group, nil
+	}
+	group, err := ri.service.groups.GetByName(ctx, name)
+	if err != nil && !errors.Is(err, errs.NotFound) {
+		return nil, fmt.Errorf("repo: get group %v: %w", name, err)
+	}
+	ri.groups[key] = group
+	return group, nil
+}
+
+// groupStudents возвращает пользователей взвода, запоминая результат.
+func (ri *rosterImporter) groupStudents(ctx context.Context, groupID int) ([]model.User, error) {
+	if students, ok := ri.students[groupID]; ok {
+		return students, nil
+	}
+	students, err := ri.service.users.GetAll(ctx, &model.UserFilter{GroupID: &groupID})
+	if err != nil && !errors.Is(err, errs.Empty) {
+		return nil, fmt.Errorf("repo: get students of group %v: %w", groupID, err)
+	}
+	ri.students[groupID] = students
+	return students, nil
+}
+
+// uniqueLogin генерирует логин из транслитерированных фамилии и инициалов
+// и добавляет к нему номер, если такой логин уже занят.
+func (ri *rosterImporter) uniqueLogin(ctx context.Context, surname, name string, patronymic *string) (string, error) {
+	initials := transliterate(firstRune(name))
+	if patronymic != nil {
+		initials += transliterate(firstRune(*patronymic))
+	}
+	base := transliterate(surname)
+	if base == "" {
+		base = "student"
+	}
+	if initials != "" {
+		base += "." + initials
+	}
+	for suffix := 1; ; suffix++ {
+		var suffixText string
+		if suffix > 1 {
+			suffixText = strconv.Itoa(suffix)
+		}
+		login := base
+		if len(login)+len(suffixText) > maxLoginLength {
+			login = login[:maxLoginLength-len(suffixText)]
+		}
+		login += suffixText
+		if ri.logins[login] {
+			continue
+		}
+		// Логин пользователя в корзине остается занятым до его окончательного удаления.
+		taken, err := ri.service.users.LoginTaken(ctx, login)
+		if err != nil {
+			return "", fmt.Errorf("repo: check login %v: %w", login, err)
+		}
+		if !taken {
+			ri.logins[login] = true
+			return login, nil
+		}
+	}
+}
+
+// studentKey возвращает ключ для сравнения студентов без учета регистра и пробелов по краям.
+func studentKey(surname, name string, patronymic *string, groupID int) string {
+	parts := []string{surname, name, "", strconv.Itoa(groupID)}
+	if patronymic != nil {
+		parts[2] = *patronymic
+	}
+	for i := range parts {
+		parts[i] = strings.ToLower(strings.TrimSpace(parts[i]))
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// firstRune возвращает первый символ строки.
+func firstRune(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if size == 0 || r == utf8.RuneError {
+		return ""
+	}
+	return string(r)
+}
+
+// translitTable сопоставляет буквы русского алфавита латинским по ГОСТ Р 52535.1-2006.
+var translitTable = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "i", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "tc",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "iu",
+	'я': "ia",
+}
+
+// transliterate переводит строку в нижний регистр латиницей, отбрасывая символы,
+// которые не являются буквами или цифрами.
+func transliterate(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		default:
+			b.WriteString(translitTable[r])
+		}
+	}
+	return b.String()
+}
+
+// generateOneTimePassword возвращает случайный пароль из легко различимых символов.
+func generateOneTimePassword() (string, error) {
+	password := make([]byte, oneTimePasswordLength)
+	max := big.NewInt(int64(len(oneTimePasswordSymbols)))
+	for i := range password {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("generate password: %w: %w", errs.Internal, err)
+		}
+		password[i] = oneTimePasswordSymbols[n.Int64()]
+	}
+	return string(password), nil
+}
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/logging"
+	"github.com/foreverd34d/aumsu-elib/internal/metrics"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+	"github.com/foreverd34d/aumsu-elib/internal/tracing"
+)
+
+// JobRepo определяет методы хранилища истории запусков задач обслуживания.
+type JobRepo interface {
+	// CreateRun сохраняет начало запуска задачи и возвращает запись с номером или ошибку.
+	CreateRun(ctx context.Context, input *model.NewJobRun) (*model.JobRun, error)
+
+	// FinishRun записывает итог запуска с номером ID и возвращает обновленную запись или ошибку.
+	// Если запуск не найден, то возвращается ошибка [errs.NotFound].
+	FinishRun(ctx context.Context, ID int, result *model.JobRunResult) (*model.JobRun, error)
+
+	// GetRuns возвращает слайс записей истории, удовлетворяющих фильтру, от новых к старым или ошибку.
+	GetRuns(ctx context.Context, filter *model.JobRunFilter) ([]model.JobRun, error)
+
+	// GetLastRuns возвращает последний запуск каждой задачи, которая хоть раз запускалась, или ошибку.
+	GetLastRuns(ctx context.Context) ([]model.JobRun, error)
+
+	// DeleteRunsBefore удаляет завершенные запуски, начавшиеся раньше момента before,
+	// и возвращает их количество или ошибку.
+	DeleteRunsBefore(ctx context.Context, before time.Time) (int, error)
+
+	// FailUnfinishedRuns записывает итог result во все незавершенные запуски задачи job
+	// и возвращает их количество или ошибку.
+	FailUnfinishedRuns(ctx context.Context, job string, result *model.JobRunResult) (int, error)
+}
+
+// Locker определяет методы блокировок, общих для всех экземпляров сервера.
+type Locker interface {
+	// TryLock пытается захватить блокировку с названием name, не дожидаясь ее освобождения,
+	// и сообщает, удерживает ли ее этот экземпляр. Если блокировка уже захвачена этим экземпляром,
+	// то проверяется, что она все еще удерживается.
+	TryLock(ctx context.Context, name string) (bool, error)
+
+	// Unlock освобождает блокировку с названием name, если она удерживается этим экземпляром.
+	Unlock(ctx context.Context, name string) error
+}
+
+// Job описывает периодическую задачу обслуживания.
+type Job struct {
+	Name        string        // название, уникальное среди задач
+	Description string        // описание
+	Interval    time.Duration // период запуска по расписанию
+
+	// Run выполняет задачу и возвращает количество обработанных записей или ошибку.
+	Run func(ctx context.Context) (int, error)
+}
+
+// schedulerLock — название блокировки, которую удерживает экземпляр сервера, выполняющий задачи по расписанию.
+const schedulerLock = "elib:scheduler"
+
+// jobLockPrefix — начало названия блокировки, которую удерживает экземпляр сервера, выполняющий задачу.
+const jobLockPrefix = "elib:job:"
+
+// interruptedRunError — ошибка, которая записывается в запуски, прерванные остановкой экземпляра сервера.
+const interruptedRunError = "interrupted: the server stopped before the run finished"
+
+// jobRuns считает запуски задач обслуживания по названию задачи и состоянию.
+var jobRuns = metrics.Default.NewCounter("elib_job_runs_total", "Total number of maintenance job runs by job and status.", "job", "status")
+
+// JobService запускает задачи обслуживания по расписанию и вручную, ведет историю запусков
+// и реализует интерфейс [handler.JobService].
+//
+// По расписанию задачи выполняет только один экземпляр сервера — тот, что захватил блокировку планировщика.
+// Срок следующего запуска отсчитывается от начала последнего запуска из истории, поэтому при смене
+// экземпляра, выполняющего задачи, расписание сохраняется. Каждый запуск, в том числе ручной, удерживает
+// блокировку задачи, поэтому одна задача не выполняется двумя экземплярами одновременно. Захватив блокировку
+// планировщика, экземпляр отмечает ошибкой запуски, которые остались незавершенными после остановки
+// другого экземпляра.
+type JobService struct {
+	repo   JobRepo
+	locker Locker
+	jobs   []Job
+
+	mu      sync.Mutex
+	running map[string]bool // задачи, которые сейчас выполняются этим экземпляром
+}
+
+// NewJobService возвращает новый экземпляр [JobService] без задач.
+func NewJobService(repo JobRepo, locker Locker) *JobService {
+	return &JobService{
+		repo:    repo,
+		locker:  locker,
+		running: make(map[string]bool),
+	}
+}
+
+// Add добавляет задачу job. Задачи нужно добавить до вызова [JobService.Schedule].
+// Если задача с таким названием уже есть, то вызывается паника.
+func (js *JobService) Add(job Job) {
+	if slices.ContainsFunc(js.jobs, func(other Job) bool { return other.Name == job.Name }) {
+		panic(fmt.Sprintf("service: job %v is already added", job.Name))
+	}
+	js.jobs = append(js.jobs, job)
+}
+
+// GetAll возвращает слайс всех задач с их последними запусками или ошибку.
+func (js *JobService) GetAll(ctx context.Context) ([]model.Job, error) {
+	ctx, span := tracing.Start(ctx, "JobService.GetAll")
+	defer span.End()
+	lastRuns, err := js.lastRuns(ctx)
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]model.Job, 0, len(js.jobs))
+	for _, job := range js.jobs {
+		info := model.Job{Name: job.Name, Description: job.Description, Interval: job.Interval.String()}
+		if last, ok := lastRuns[job.Name]; ok {
+			nextRunAt := last.StartedAt.Add(job.Interval)
+			info.LastRun, info.NextRunAt = &last, &nextRunAt
+		}
+		jobs = append(jobs, info)
+	}
+	return jobs, nil
+}
+
+// GetRuns возвращает слайс записей истории запусков, удовлетворяющих фильтру, или ошибку.
+func (js *JobService) GetRuns(ctx context.Context, filter *model.JobRunFilter) ([]model.JobRun, error) {
+	ctx, span := tracing.Start(ctx, "JobService.GetRuns")
+	defer span.End()
+	runs, err := js.repo.GetRuns(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("repo: get job runs: %w", err)
+	}
+	return runs, nil
+}
+
+// Run вручную запускает задачу с названием name, дожидается ее завершения и возвращает запись о запуске.
+// Ошибка самой задачи не возвращается, а записывается в запуск.
+// Если задачи нет, то возвращается ошибка [errs.NotFound], если она уже выполняется этим или другим
+// экземпляром сервера — [errs.Conflict].
+func (js *JobService) Run(ctx context.Context, name string) (*model.JobRun, error) {
+	ctx, span := tracing.Start(ctx, "JobService.Run")
+	defer span.End()
+	i := slices.IndexFunc(js.jobs, func(job Job) bool { return job.Name == name })
+	if i < 0 {
+		return nil, fmt.Errorf("run job %v: %w", name, errs.NotFound)
+	}
+	return js.run(ctx, js.jobs[i], model.JobTriggerManual)
+}
+
+// Schedule каждые tick проверяет, удерживает ли этот экземпляр блокировку планировщика, пытаясь захватить ее,
+// и если удерживает, то по очереди запускает задачи, срок запуска которых наступил.
+// Возвращается после завершения контекста ctx, освобождая блокировку.
+func (js *JobService) Schedule(ctx context.Context, tick time.Duration) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	leader := false
+	defer func() {
+		if leader {
+			if err := js.locker.Unlock(context.WithoutCancel(ctx), schedulerLock); err != nil {
+				slog.WarnContext(ctx, "couldn't release scheduler lock", logging.Err(err))
+			}
+		}
+	}()
+	for {
+		held, err := js.locker.TryLock(ctx, schedulerLock)
+		if err != nil {
+			slog.WarnContext(ctx, "couldn't acquire scheduler lock", logging.Err(err))
+		}
+		if held != leader {
+			slog.InfoContext(ctx, "scheduler leadership changed", slog.Bool("leader", held))
+			leader = held
+			if leader {
+				js.failInterrupted(ctx)
+			}
+		}
+		if leader {
+			js.runDue(ctx)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runDue запускает задачи, с начала последнего запуска которых прошло не меньше их периода.
+func (js *JobService) runDue(ctx context.Context) {
+	lastRuns, err := js.lastRuns(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "couldn't get last job runs", logging.Err(err))
+		return
+	}
+	now := time.Now()
+	for _, job := range js.jobs {
+		if ctx.Err() != nil {
+			return
+		}
+		if last, ok := lastRuns[job.Name]; ok && now.Before(last.StartedAt.Add(job.Interval)) {
+			continue
+		}
+		if _, err := js.run(ctx, job, model.JobTriggerSchedule); errors.Is(err, errs.Conflict) {
+			slog.InfoContext(ctx, "job is already running", slog.String("job", job.Name))
+		} else if err != nil {
+			slog.ErrorContext(ctx, "couldn't run job", slog.String("job", job.Name), logging.Err(err))
+		}
+	}
+}
+
+// failInterrupted отмечает ошибкой незавершенные запуски задач, которые не выполняются ни одним
+// экземпляром сервера, то есть были прерваны остановкой экземпляра.
+func (js *JobService) failInterrupted(ctx context.Context) {
+	for _, job := range js.jobs {
+		release, err := js.acquire(ctx, job.Name)
+		if errors.Is(err, errs.Conflict) {
+			continue
+		}
+		if err != nil {
+			slog.WarnContext(ctx, "couldn't check interrupted job runs", slog.String("job", job.Name), logging.Err(err))
+			continue
+		}
+		msg := interruptedRunError
+		result := &model.JobRunResult{Status: model.JobFailed, Error: &msg, FinishedAt: time.Now().UTC()}
+		failed, err := js.repo.FailUnfinishedRuns(ctx, job.Name, result)
+		release()
+		if err != nil {
+			slog.ErrorContext(ctx, "couldn't fail interrupted job runs", slog.String("job", job.Name), logging.Err(err))
+		} else if failed > 0 {
+			slog.WarnContext(ctx, "interrupted job runs failed", slog.String("job", job.Name), slog.Int("runs", failed))
+		}
+	}
+}
+
+// lastRuns возвращает последние запуски задач по их названию.
+func (js *JobService) lastRuns(ctx context.Context) (map[string]model.JobRun, error) {
+	runs, err := js.repo.GetLastRuns(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("repo: get last job runs: %w", err)
+	}
+	lastRuns := make(map[string]model.JobRun, len(runs))
+	for _, run := range runs {
+		lastRuns[run.Job] = run
+	}
+	return lastRuns, nil
+}
+
+// acquire отмечает задачу name выполняющейся этим экземпляром, захватывает ее блокировку
+// и возвращает функцию, которая освобождает задачу. Если задача уже выполняется этим
+// или другим экземпляром, то возвращается ошибка [errs.Conflict].
+func (js *JobService) acquire(ctx context.Context, name string) (func(), error) {
+	js.mu.Lock()
+	if js.running[name] {
+		js.mu.Unlock()
+		return nil, fmt.Errorf("job %v is already running: %w", name, errs.Conflict)
+	}
+	js.running[name] = true
+	js.mu.Unlock()
+	done := func() {
+		js.mu.Lock()
+		delete(js.running, name)
+		js.mu.Unlock()
+	}
+
+	held, err := js.locker.TryLock(ctx, jobLockPrefix+name)
+	if err != nil {
+		done()
+		return nil, fmt.Errorf("lock job %v: %w", name, err)
+	}
+	if !held {
+		done()
+		return nil, fmt.Errorf("job %v is running on another instance: %w", name, errs.Conflict)
+	}
+	return func() {
+		if err := js.locker.Unlock(context.WithoutCancel(ctx), jobLockPrefix+name); err != nil {
+			slog.WarnContext(ctx, "couldn't release job lock", slog.String("job", name), logging.Err(err))
+		}
+		done()
+	}, nil
+}
+
+// run выполняет задачу job, записывая запуск в историю. Одна задача не выполняется дважды
+// одновременно: если она уже выполняется этим или другим экземпляром, то возвращается ошибка [errs.Conflict].
+func (js *JobService) run(ctx context.Context, job Job, trigger string) (*model.JobRun, error) {
+	release, err := js.acquire(ctx, job.Name)
+	if err != nil {
+		return nil, fmt.Errorf("run job %v: %w", job.Name, err)
+	}
+	defer release()
+
+	input := &model.NewJobRun{Job: job.Name, TriggeredBy: trigger, StartedAt: time.Now().UTC()}
+	if trigger == model.JobTriggerManual {
+		input.ActorID = actorFrom(ctx).UserID
+	}
+	run, err := js.repo.CreateRun(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("repo: create run of job %v: %w", job.Name, err)
+	}
+
+	jobCtx, span := tracing.Start(ctx, "job "+job.Name)
+	affected, jobErr := job.Run(jobCtx)
+	tracing.RecordError(span, jobErr)
+	span.End()
+
+	result := &model.JobRunResult{Status: model.JobSucceeded, Affected: affected, FinishedAt: time.Now().UTC()}
+	if jobErr != nil {
+		msg := jobErr.Error()
+		result.Status, result.Error = model.JobFailed, &msg
+		slog.ErrorContext(ctx, "job failed", slog.String("job", job.Name), slog.String("trigger", trigger), logging.Err(jobErr))
+	} else {
+		slog.InfoContext(ctx, "job finished", slog.String("job", job.Name), slog.String("trigger", trigger), slog.Int("affected", affected))
+	}
+	jobRuns.Inc(job.Name, result.Status)
+	// Итог записывается, даже если контекст завершился во время выполнения задачи.
+	finished, err := js.repo.FinishRun(context.WithoutCancel(ctx), run.ID, result)
+	if err != nil {
+		return nil, fmt.Errorf("repo: finish run %v of job %v: %w", run.ID, job.Name, err)
+	}
+	return finished, nil
+}
+
+// PruneRuns удаляет из истории завершенные запуски, начавшиеся раньше, чем retention назад,
+// и возвращает их количество или ошибку.
+func (js *JobService) PruneRuns(ctx context.Context, retention time.Duration) (int, error) {
+	ctx, span := tracing.Start(ctx, "JobService.PruneRuns")
+	defer span.End()
+	deleted, err := js.repo.DeleteRunsBefore(ctx, time.Now().UTC().Add(-retention))
+	if err != nil {
+		return 0, fmt.Errorf("repo: delete job runs: %w", err)
+	}
+	return deleted, nil
+}
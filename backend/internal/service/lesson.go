@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+	"github.com/foreverd34d/aumsu-elib/internal/tracing"
+)
+
+// LessonRepo определяет методы хранилища занятий.
+type LessonRepo interface {
+	// Get возвращает занятие по номеру без материалов или ошибку.
+	// Если занятие с таким номером не нашлось, то возвращается ошибка [errs.NotFound].
+	Get(ctx context.Context, ID int) (*model.Lesson, error)
+}
+
+// LessonService реализует методы для работы с занятиями
+// и реализует интерфейс [handler.LessonService].
+type LessonService struct {
+	repo      LessonRepo
+	materials MaterialRepo
+}
+
+// NewLessonService возвращает новый экземпляр [LessonService].
+func NewLessonService(repo LessonRepo, materials MaterialRepo) *LessonService {
+	return &LessonService{repo, materials}
+}
+
+// Get возвращает занятие по номеру вместе с прикрепленными к нему материалами или ошибку.
+// Если занятие с таким номером не нашлось, то возвращается ошибка [errs.NotFound].
+func (ls *LessonService) Get(ctx context.Context, ID int) (*model.Lesson, error) {
+	ctx, span := tracing.Start(ctx, "LessonService.Get")
+	defer span.End()
+	lesson, err := ls.repo.Get(ctx, ID)
+	if err != nil {
+		return nil, fmt.Errorf("get the lesson with ID %v: %w", ID, err)
+	}
+	lesson.Materials, err = ls.materials.GetByLesson(ctx, ID)
+	if err != nil {
+		return nil, fmt.Errorf("get materials of the lesson with ID %v: %w", ID, err)
+	}
+	return lesson, nil
+}
+
+// GetMaterials возвращает слайс материалов, прикрепленных к занятию, или ошибку.
+// Если занятие с таким номером не нашлось, то возвращается ошибка [errs.NotFound].
+func (ls *LessonService) GetMaterials(ctx context.Context, ID int) ([]model.Material, error) {
+	ctx, span := tracing.Start(ctx, "LessonService.GetMaterials")
+	defer span.End()
+	lesson, err := ls.Get(ctx, ID)
+	if err != nil {
+		return nil, err
+	}
+	return lesson.Materials, nil
+}
@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"strings"
+
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+	"github.com/foreverd34d/aumsu-elib/internal/tracing"
+)
+
+// Тип содержимого файлов материалов с неизвестным расширением.
+const defaultMaterialContentType = "application/octet-stream"
+
+// MaterialRepo определяет методы хранилища материалов и их связей с занятиями и книгами.
+type MaterialRepo interface {
+	// Create сохраняет материал с путем к его файлу в хранилище и возвращает материал с номером или ошибку.
+	Create(ctx context.Context, input *model.NewMaterial, filePath string) (*model.Material, error)
+
+	// GetAll возвращает слайс всех материалов или ошибку.
+	// Если база данных пуста, то возвращается ошибка [errs.Empty].
+	GetAll(ctx context.Context) ([]model.Material, error)
+
+	// Get возвращает материал по номеру или ошибку.
+	// Если материал с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
+	Get(ctx context.Context, ID int) (*model.Material, error)
+
+	// Update обновляет название и вид материала по номеру и возвращает материал с номером или ошибку.
+	// Если материал с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Update(ctx context.Context, ID, version int, update *model.NewMaterial) (*model.Material, error)
+
+	// Patch изменяет указанные в патче название и вид материала по номеру и возвращает материал с номером или ошибку.
+	// Если материал с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Patch(ctx context.Context, ID, version int, patch *model.MaterialPatch) (*model.Material, error)
+
+	// UpdateFile заменяет путь к файлу материала по номеру и возвращает материал с номером или ошибку.
+	// Если материал с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+	UpdateFile(ctx context.Context, ID int, filePath string) (*model.Material, error)
+
+	// Delete удаляет материал по номеру вместе с его связями с занятиями и книгами.
+	// Если материал с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Delete(ctx context.Context, ID, version int) error
+
+	// GetByLesson возвращает слайс материалов, прикрепленных к занятию, или ошибку.
+	GetByLesson(ctx context.Context, lessonID int) ([]model.Material, error)
+
+	// GetByBook возвращает слайс материалов, прикрепленных к книге, или ошибку.
+	GetByBook(ctx context.Context, bookID int) ([]model.Material, error)
+
+	// AttachToLesson прикрепляет материал к занятию. Повторное прикрепление ничего не меняет.
+	// Если материал или занятие не нашлись, то возвращается ошибка [errs.NotFound].
+	AttachToLesson(ctx context.Context, materialID, lessonID int) error
+
+	// DetachFromLesson открепляет материал от занятия.
+	// Если материал не был прикреплен к занятию, то возвращается ошибка [errs.NotFound].
+	DetachFromLesson(ctx context.Context, materialID, lessonID int) error
+
+	// AttachToBook прикрепляет материал к книге. Повторное прикрепление ничего не меняет.
+	// Если материал или книга не нашлись, то возвращается ошибка [errs.NotFound].
+	AttachToBook(ctx context.Context, materialID, bookID int) error
+
+	// DetachFromBook открепляет материал от книги.
+	// Если материал не был прикреплен к книге, то возвращается ошибка [errs.NotFound].
+	DetachFromBook(ctx context.Context, materialID, bookID int) error
+}
+
+// MaterialService реализует методы для работы с материалами
+// и реализует интерфейс [handler.MaterialService].
+type MaterialService struct {
+	repo    MaterialRepo
+	audit   AuditRepo
+	storage FileStorage
+}
+
+// NewMaterialService возвращает новый экземпляр [MaterialService].
+func NewMaterialService(repo MaterialRepo, audit AuditRepo, storage FileStorage) *MaterialService {
+	return &MaterialService{
+		repo:    repo,
+		audit:   audit,
+		storage: storage,
+	}
+}
+
+// materialFileAudit представляет изменение файла материала в журнале аудита.
+type materialFileAudit struct {
+	File string `json:"file"`
+}
+
+// materialLinkAudit представляет связь материала с занятием или книгой в журнале аудита.
+type materialLinkAudit struct {
+	MaterialID int `json:"materialID"`
+	OwnerID    int `json:"ownerID"`
+}
+
+// Create сохраняет файл материала с именем fileName и создает материал.
+// Возвращается материал с номером или ошибка.
+func (ms *MaterialService) Create(ctx context.Context, input *model.NewMaterial, file io.Reader, fileName string) (*model.Material, error) {
+	ctx, span := tracing.Start(ctx, "MaterialService.Create")
+	defer span.End()
+	filePath := materialFilePath(fileName)
+	if err := ms.storage.Save(ctx, filePath, file); err != nil {
+		return nil, fmt.Errorf("storage: save material file: %w", err)
+	}
+	material, err := ms.repo.Create(ctx, input, filePath)
+	if err != nil {
+		removeFile(ctx, ms.storage, filePath)
+		return nil, fmt.Errorf("create new material: %w", err)
+	}
+	recordAudit(ctx, ms.audit, model.AuditCreate, auditMaterial, material.ID, nil, material)
+	return material, nil
+}
+
+// GetAll возвращает слайс всех материалов или ошибку.
+// Если материалов нет, то возвращается ошибка [errs.Empty].
+func (ms *MaterialService) GetAll(ctx context.Context) ([]model.Material, error) {
+	ctx, span := tracing.Start(ctx, "MaterialService.GetAll")
+	defer span.End()
+	materials, err := ms.repo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get all materials: %w", err)
+	}
+	return materials, nil
+}
+
+// Get возвращает материал по номеру или ошибку.
+// Если материал с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
+func (ms *MaterialService) Get(ctx context.Context, ID int) (*model.Material, error) {
+	ctx, span := tracing.Start(ctx, "MaterialService.Get")
+	defer span.End()
+	material, err := ms.repo.Get(ctx, ID)
+	if err != nil {
+		return nil, fmt.Errorf("get the material with ID %v: %w", ID, err)
+	}
+	return material, nil
+}
+
+// Update обновляет название и вид материала по номеру и возвращает материал с номером или ошибку.
+// Если материал с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (ms *MaterialService) Update(ctx context.Context, ID, version int, update *model.NewMaterial) (*model.Material, error) {
+	ctx, span := tracing.Start(ctx, "MaterialService.Update")
+	defer span.End()
+	before, err := ms.repo.Get(ctx, ID)
+	if err != nil {
+		return nil, fmt.Errorf("get the material with ID %v: %w", ID, err)
+	}
+	material, err := ms.repo.Update(ctx, ID, version, update)
+	if err != nil {
+		return nil, fmt.Errorf("update material: %w", err)
+	}
+	recordAudit(ctx, ms.audit, model.AuditUpdate, auditMaterial, ID, before, material)
+	return material, nil
+}
+
+// Patch изменяет указанные в патче название и вид материала по номеру и возвращает материал с номером или ошибку.
+// Если материал с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (ms *MaterialService) Patch(ctx context.Context, ID, version int, patch *model.MaterialPatch) (*model.Material, error) {
+	ctx, span := tracing.Start(ctx, "MaterialService.Patch")
+	defer span.End()
+	before, err := ms.repo.Get(ctx, ID)
+	if err != nil {
+		return nil, fmt.Errorf("get the material with ID %v: %w", ID, err)
+	}
+	material, err := ms.repo.Patch(ctx, ID, version, patch)
+	if err != nil {
+		return nil, fmt.Errorf("patch material: %w", err)
+	}
+	recordAudit(ctx, ms.audit, model.AuditUpdate, auditMaterial, ID, before, material)
+	return material, nil
+}
+
+// SetFile заменяет файл материала по номеру файлом с именем fileName.
+// Если материал с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+func (ms *MaterialService) SetFile(ctx context.Context, ID int, file io.Reader, fileName string) error {
+	ctx, span := tracing.Start(ctx, "MaterialService.SetFile")
+	defer span.End()
+	before, err := ms.repo.Get(ctx, ID)
+	if err != nil {
+		return fmt.Errorf("get the material with ID %v: %w", ID, err)
+	}
+	filePath := materialFilePath(fileName)
+	if err := ms.storage.Save(ctx, filePath, file); err != nil {
+		return fmt.Errorf("storage: save file of the material with ID %v: %w", ID, err)
+	}
+	if _, err := ms.repo.UpdateFile(ctx, ID, filePath); err != nil {
+		removeFile(ctx, ms.storage, filePath)
+		return fmt.Errorf("update file of the material with ID %v: %w", ID, err)
+	}
+	removeFile(ctx, ms.storage, before.FilePath)
+	recordAudit(ctx, ms.audit, model.AuditUpdate, auditMaterial, ID,
+		materialFileAudit{before.FilePath}, materialFileAudit{filePath})
+	return nil
+}
+
+// GetFile открывает файл материала по номеру и возвращает его вместе с типом содержимого.
+// Если материал с таким номером или его файл не нашелся, то возвращается ошибка [errs.NotFound].
+func (ms *MaterialService) GetFile(ctx context.Context, ID int) (file io.ReadCloser, contentType string, err error) {
+	ctx, span := tracing.Start(ctx, "MaterialService.GetFile")
+	defer span.End()
+	material, err := ms.repo.Get(ctx, ID)
+	if err != nil {
+		return nil, "", fmt.Errorf("get the material with ID %v: %w", ID, err)
+	}
+	file, err = ms.storage.Open(ctx, material.FilePath)
+	if err != nil {
+		return nil, "", fmt.Errorf("storage: open file of the material with ID %v: %w", ID, err)
+	}
+	contentType = mime.TypeByExtension(path.Ext(material.FilePath))
+	if contentType == "" {
+		contentType = defaultMaterialContentType
+	}
+	return file, contentType, nil
+}
+
+// Delete удаляет материал по номеру вместе с его файлом и связями с занятиями и книгами.
+// Если материал с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (ms *MaterialService) Delete(ctx context.Context, ID, version int) error {
+	ctx, span := tracing.Start(ctx, "MaterialService.Delete")
+	defer span.End()
+	before, err := ms.repo.Get(ctx, ID)
+	if err != nil {
+		return fmt.Errorf("get the material with ID %v: %w", ID, err)
+	}
+	if err := ms.repo.Delete(ctx, ID, version); err != nil {
+		return fmt.Errorf("delete material: %w", err)
+	}
+	if err := ms.storage.Delete(ctx, before.FilePath); err != nil {
+		return fmt.Errorf("storage: delete file of the material with ID %v: %w", ID, err)
+	}
+	recordAudit(ctx, ms.audit, model.AuditDelete, auditMaterial, ID, before, nil)
+	return nil
+}
+
+// GetByBook возвращает слайс материалов, прикрепленных к книге, или ошибку.
+func (ms *MaterialService) GetByBook(ctx context.Context, bookID int) ([]model.Material, error) {
+	ctx, span := tracing.Start(ctx, "MaterialService.GetByBook")
+	defer span.End()
+	materials, err := ms.repo.GetByBook(ctx, bookID)
+	if err != nil {
+		return nil, fmt.Errorf("get materials of the book with ID %v: %w", bookID, err)
+	}
+	return materials, nil
+}
+
+// AttachToLesson прикрепляет материал к занятию. Повторное прикрепление ничего не меняет.
+// Если материал или занятие не нашлись, то возвращается ошибка [errs.NotFound].
+func (ms *MaterialService) AttachToLesson(ctx context.Context, materialID, lessonID int) error {
+	ctx, span := tracing.Start(ctx, "MaterialService.AttachToLesson")
+	defer span.End()
+	if err := ms.repo.AttachToLesson(ctx, materialID, lessonID); err != nil {
+		return fmt.Errorf("attach material: %w", err)
+	}
+	recordAudit(ctx, ms.audit, model.AuditCreate, auditLessonMaterial, lessonID,
+		nil, materialLinkAudit{materialID, lessonID})
+	return nil
+}
+
+// DetachFromLesson открепляет материал от занятия.
+// Если материал не был прикреплен к занятию, то возвращается ошибка [errs.NotFound].
+func (ms *MaterialService) DetachFromLesson(ctx context.Context, materialID, lessonID int) error {
+	ctx, span := tracing.Start(ctx, "MaterialService.DetachFromLesson")
+	defer span.End()
+	if err := ms.repo.DetachFromLesson(ctx, materialID, lessonID); err != nil {
+		return fmt.Errorf("detach material: %w", err)
+	}
+	recordAudit(ctx, ms.audit, model.AuditDelete, auditLessonMaterial, lessonID,
+		materialLinkAudit{materialID, lessonID}, nil)
+	return nil
+}
+
+// AttachToBook прикрепляет материал к книге. Повторное прикрепление ничего не меняет.
+// Если материал или книга не нашлись, то возвращается ошибка [errs.NotFound].
+func (ms *MaterialService) AttachToBook(ctx context.Context, materialID, bookID int) error {
+	ctx, span := tracing.Start(ctx, "MaterialService.AttachToBook")
+	defer span.End()
+	if err := ms.repo.AttachToBook(ctx, materialID, bookID); err != nil {
+		return fmt.Errorf("attach material: %w", err)
+	}
+	recordAudit(ctx, ms.audit, model.AuditCreate, auditMaterialBook, bookID,
+		nil, materialLinkAudit{materialID, bookID})
+	return nil
+}
+
+// DetachFromBook открепляет материал от книги.
+// Если материал не был прикреплен к книге, то возвращается ошибка [errs.NotFound].
+func (ms *MaterialService) DetachFromBook(ctx context.Context, materialID, bookID int) error {
+	ctx, span := tracing.Start(ctx, "MaterialService.DetachFromBook")
+	defer span.End()
+	if err := ms.repo.DetachFromBook(ctx, materialID, bookID); err != nil {
+		return fmt.Errorf("detach material: %w", err)
+	}
+	recordAudit(ctx, ms.audit, model.AuditDelete, auditMaterialBook, bookID,
+		materialLinkAudit{materialID, bookID}, nil)
+	return nil
+}
+
+// materialFilePath возвращает новый путь к файлу материала в хранилище
+// с расширением исходного файла fileName.
+func materialFilePath(fileName string) string {
+	ext := strings.ToLower(path.Ext(fileName))
+	if len(ext) > 10 || strings.ContainsAny(ext, `/\ `) {
+		ext = ""
+	}
+	return fmt.Sprintf("materials/%s%s", generateRefreshToken()[:16], ext)
+}
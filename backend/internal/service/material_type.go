@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+	"github.com/foreverd34d/aumsu-elib/internal/tracing"
+)
+
+// MaterialTypeRepo определяет методы хранилища видов материалов.
+type MaterialTypeRepo interface {
+	// Create сохраняет вид материалов в хранилище и возвращает его с номером или ошибку.
+	Create(ctx context.Context, input *model.NewMaterialType) (*model.MaterialType, error)
+
+	// GetAll возвращает слайс всех видов материалов или ошибку.
+	// Если база данных пуста, то возвращается ошибка [errs.Empty].
+	GetAll(ctx context.Context) ([]model.MaterialType, error)
+
+	// Get возвращает вид материалов по номеру или ошибку.
+	// Если вид материалов с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
+	Get(ctx context.Context, ID int) (*model.MaterialType, error)
+
+	// Update обновляет вид материалов по номеру и возвращает его с номером или ошибку.
+	// Если вид материалов с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Update(ctx context.Context, ID, version int, update *model.NewMaterialType) (*model.MaterialType, error)
+
+	// Patch изменяет указанные в патче поля вида материалов по номеру и возвращает его с номером или ошибку.
+	// Если вид материалов с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Patch(ctx context.Context, ID, version int, patch *model.MaterialTypePatch) (*model.MaterialType, error)
+
+	// Delete удаляет вид материалов по номеру и возвращает ошибку, если удаления не произошло.
+	// Если вид материалов с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Delete(ctx context.Context, ID, version int) error
+}
+
+// MaterialTypeService реализует методы для работы с видами материалов
+// и реализует интерфейс [handler.MaterialTypeService].
+type MaterialTypeService struct {
+	repo  MaterialTypeRepo
+	audit AuditRepo
+}
+
+// NewMaterialTypeService возвращает новый экземпляр [MaterialTypeService].
+func NewMaterialTypeService(repo MaterialTypeRepo, audit AuditRepo) *MaterialTypeService {
+	return &MaterialTypeService{repo, audit}
+}
+
+// Create создает новый вид материалов и возвращает его с номером или ошибку.
+func (ms *MaterialTypeService) Create(ctx context.Context, input *model.NewMaterialType) (*model.MaterialType, error) {
+	ctx, span := tracing.Start(ctx, "MaterialTypeService.Create")
+	defer span.End()
+	materialType, err := ms.repo.Create(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("create new material type: %w", err)
+	}
+	recordAudit(ctx, ms.audit, model.AuditCreate, auditMaterialType, materialType.ID, nil, materialType)
+	return materialType, nil
+}
+
+// GetAll возвращает слайс всех видов материалов или ошибку.
+// Если видов материалов нет, то возвращается ошибка [errs.Empty].
+func (ms *MaterialTypeService) GetAll(ctx context.Context) ([]model.MaterialType, error) {
+	ctx, span := tracing.Start(ctx, "MaterialTypeService.GetAll")
+	defer span.End()
+	materialTypes, err := ms.repo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get all material types: %w", err)
+	}
+	return materialTypes, nil
+}
+
+// Get возвращает вид материалов по номеру или ошибку.
+// Если вид материалов с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
+func (ms *MaterialTypeService) Get(ctx context.Context, ID int) (*model.MaterialType, error) {
+	ctx, span := tracing.Start(ctx, "MaterialTypeService.Get")
+	defer span.End()
+	materialType, err := ms.repo.Get(ctx, ID)
+	if err != nil {
+		return nil, fmt.Errorf("get the material type with ID %v: %w", ID, err)
+	}
+	return materialType, nil
+}
+
+// Update обновляет вид материалов по номеру и возвращает его с номером или ошибку.
+// Если вид материалов с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (ms *MaterialTypeService) Update(ctx context.Context, ID, version int, update *model.NewMaterialType) (*model.MaterialType, error) {
+	ctx, span := tracing.Start(ctx, "MaterialTypeService.Update")
+	defer span.End()
+	before, err := ms.repo.Get(ctx, ID)
+	if err != nil {
+		return nil, fmt.Errorf("get the material type with ID %v: %w", ID, err)
+	}
+	materialType, err := ms.repo.Update(ctx, ID, version, update)
+	if err != nil {
+		return nil, fmt.Errorf("update material type: %w", err)
+	}
+	recordAudit(ctx, ms.audit, model.AuditUpdate, auditMaterialType, ID, before, materialType)
+	return materialType, nil
+}
+
+// Patch изменяет указанные в патче поля вида материалов по номеру и возвращает его с номером или ошибку.
+// Если вид материалов с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (ms *MaterialTypeService) Patch(ctx context.Context, ID, version int, patch *model.MaterialTypePatch) (*model.MaterialType, error) {
+	ctx, span := tracing.Start(ctx, "MaterialTypeService.Patch")
+	defer span.End()
+	before, err := ms.repo.Get(ctx, ID)
+	if err != nil {
+		return nil, fmt.Errorf("get the material type with ID %v: %w", ID, err)
+	}
+	materialType, err := ms.repo.Patch(ctx, ID, version, patch)
+	if err != nil {
+		return nil, fmt.Errorf("patch material type: %w", err)
+	}
+	recordAudit(ctx, ms.audit, model.AuditUpdate, auditMaterialType, ID, before, materialType)
+	return materialType, nil
+}
+
+// Delete удаляет вид материалов по номеру и возвращает ошибку, если удаления не произошло.
+// Если вид материалов с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (ms *MaterialTypeService) Delete(ctx context.Context, ID, version int) error {
+	ctx, span := tracing.Start(ctx, "MaterialTypeService.Delete")
+	defer span.End()
+	before, err := ms.repo.Get(ctx, ID)
+	if err != nil {
+		return fmt.Errorf("get the material type with ID %v: %w", ID, err)
+	}
+	if err := ms.repo.Delete(ctx, ID, version); err != nil {
+		return fmt.Errorf("delete material type: %w", err)
+	}
+	recordAudit(ctx, ms.audit, model.AuditDelete, auditMaterialType, ID, before, nil)
+	return nil
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+	"github.com/foreverd34d/aumsu-elib/internal/tracing"
+)
+
+// photoExtensions сопоставляет допустимые типы фотографий профиля с расширениями файлов.
+var photoExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// photoAudit представляет изменение фотографии профиля в журнале аудита.
+type photoAudit struct {
+	Photo *string `json:"photo"`
+}
+
+// GetProfile возвращает профиль пользователя по номеру или ошибку.
+// Если пользователь с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
+func (us *UserService) GetProfile(ctx context.Context, ID int) (*model.Profile, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetProfile")
+	defer span.End()
+	profile, err := us.repo.GetProfile(ctx, ID)
+	if err != nil {
+		return nil, fmt.Errorf("repo: get profile of the user with ID %v: %w", ID, err)
+	}
+	return profile, nil
+}
+
+// UpdateProfile обновляет заполненные поля профиля пользователя по номеру и возвращает его или ошибку.
+// Если пользователь с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+func (us *UserService) UpdateProfile(ctx context.Context, ID int, update *model.ProfileUpdate) (*model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateProfile")
+	defer span.End()
+	before, err := us.repo.GetByID(ctx, ID)
+	if err != nil {
+		return nil, fmt.Errorf("repo: get user with ID %v: %w", ID, err)
+	}
+	user, err := us.repo.UpdateProfile(ctx, ID, update)
+	if err != nil {
+		return nil, fmt.Errorf("repo: update profile of the user with ID %v: %w", ID, err)
+	}
+	recordAudit(ctx, us.audit, model.AuditUpdate, auditUser, ID, before, user)
+	return user, nil
+}
+
+// SetPhoto сохраняет фотографию профиля пользователя по номеру, заменяя предыдущую.
+// Допускаются изображения в форматах JPEG, PNG и WebP, иначе возвращается ошибка [errs.UnsupportedMedia].
+// Если пользователь с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+func (us *UserService) SetPhoto(ctx context.Context, ID int, photo io.Reader) error {
+	ctx, span := tracing.Start(ctx, "UserService.SetPhoto")
+	defer span.End()
+	before, err := us.repo.GetByID(ctx, ID)
+	if err != nil {
+		return fmt.Errorf("repo: get user with ID %v: %w", ID, err)
+	}
+
+	reader := bufio.NewReaderSize(photo, 512)
+	head, err := reader.Peek(512)
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("read photo: %w: %w", errs.Internal, err)
+	}
+	contentType := http.DetectContentType(head)
+	ext, ok := photoExtensions[contentType]
+	if !ok {
+		return fmt.Errorf("photo of type %v: %w", contentType, errs.UnsupportedMedia)
+	}
+
+	photoPath := fmt.Sprintf("photos/%d-%s%s", ID, generateRefreshToken()[:16], ext)
+	if err := us.storage.Save(ctx, photoPath, reader); err != nil {
+		return fmt.Errorf("storage: save photo of the user with ID %v: %w", ID, err)
+	}
+	if _, err := us.repo.UpdatePhoto(ctx, ID, &photoPath); err != nil {
+		removeFile(ctx, us.storage, photoPath)
+		return fmt.Errorf("repo: update photo of the user with ID %v: %w", ID, err)
+	}
+	if before.PhotoPath != nil {
+		removeFile(ctx, us.storage, *before.PhotoPath)
+	}
+	recordAudit(ctx, us.audit, model.AuditUpdate, auditUser, ID,
+		photoAudit{before.PhotoPath}, photoAudit{&photoPath})
+	return nil
+}
+
+// GetPhoto открывает фотографию профиля пользователя по номеру и возвращает ее вместе с типом содержимого.
+// Если пользователь с таким номером не нашелся или у него нет фотографии, то возвращается ошибка [errs.NotFound].
+func (us *UserService) GetPhoto(ctx context.Context, ID int) (photo io.ReadCloser, contentType string, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetPhoto")
+	defer span.End()
+	user, err := us.repo.GetByID(ctx, ID)
+	if err != nil {
+		return nil, "", fmt.Errorf("repo: get user with ID %v: %w", ID, err)
+	}
+	if user.PhotoPath == nil {
+		return nil, "", fmt.Errorf("photo of the user with ID %v: %w", ID, errs.NotFound)
+	}
+	photo, err = us.storage.Open(ctx, *user.PhotoPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("storage: open photo of the user with ID %v: %w", ID, err)
+	}
+	return photo, mime.TypeByExtension(path.Ext(*user.PhotoPath)), nil
+}
+
+// DeletePhoto удаляет фотографию профиля пользователя по номеру.
+// Если пользователь с таким номером не нашелся или у него нет фотографии, то возвращается ошибка [errs.NotFound].
+func (us *UserService) DeletePhoto(ctx context.Context, ID int) error {
+	ctx, span := tracing.Start(ctx, "UserService.DeletePhoto")
+	defer span.End()
+	before, err := us.repo.GetByID(ctx, ID)
+	if err != nil {
+		return fmt.Errorf("repo: get user with ID %v: %w", ID, err)
+	}
+	if before.PhotoPath == nil {
+		return fmt.Errorf("photo of the user with ID %v: %w", ID, errs.NotFound)
+	}
+	if _, err := us.repo.UpdatePhoto(ctx, ID, nil); err != nil {
+		return fmt.Errorf("repo: delete photo of the user with ID %v: %w", ID, err)
+	}
+	// Пользователь уже остался без фотографии, поэтому ошибка удаления файла только записывается в журнал.
+	removeFile(ctx, us.storage, *before.PhotoPath)
+	recordAudit(ctx, us.audit, model.AuditUpdate, auditUser, ID, photoAudit{before.PhotoPath}, photoAudit{nil})
+	return nil
+}
+
+// ChangePassword меняет пароль пользователя по номеру после проверки действующего пароля.
+// Одноразовый пароль после смены перестает быть таковым.
+// Если действующий пароль не совпадает с сохраненным, то возвращается ошибка [errs.InvalidPassword].
+func (us *UserService) ChangePassword(ctx context.Context, ID int, change *model.PasswordChange) error {
+	ctx, span := tracing.Start(ctx, "UserService.ChangePassword")
+	defer span.End()
+	credentials, err := us.repo.GetCredentialsByUserID(ctx, ID)
+	if err != nil {
+		return fmt.Errorf("repo: get credentials of the user with ID %v: %w", ID, err)
+	}
+	if credentials.PasswordHash != hashPassword(change.CurrentPassword) {
+		return fmt.Errorf("change password of the user with ID %v: %w", ID, errs.InvalidPassword)
+	}
+	if err := us.repo.UpdatePassword(ctx, ID, hashPassword(change.NewPassword), false); err != nil {
+		return fmt.Errorf("repo: update password of the user with ID %v: %w", ID, err)
+	}
+	recordAudit(ctx, us.audit, model.AuditUpdate, auditUser, ID, nil, passwordAudit{Password: "changed"})
+	return nil
+}
+
+// passwordAudit представляет смену пароля в журнале аудита без самого пароля.
+type passwordAudit struct {
+	Password string `json:"password"`
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+	"github.com/foreverd34d/aumsu-elib/internal/tracing"
+)
+
+// RolloverService реализует переход на новый учебный год: перевод взводов на следующий курс,
+// слияние взводов и выпуск с отключением учетных записей студентов.
+// Реализует интерфейс [handler.RolloverService].
+type RolloverService struct {
+	groups      GroupRepo
+	users       UserRepo
+	specialties SpecialtyRepo
+	audit       AuditRepo
+}
+
+// NewRolloverService возвращает новый экземпляр [RolloverService].
+func NewRolloverService(groups GroupRepo, users UserRepo, specialties SpecialtyRepo, audit AuditRepo) *RolloverService {
+	return &RolloverService{
+		groups:      groups,
+		users:       users,
+		specialties: specialties,
+		audit:       audit,
+	}
+}
+
+// Preview проверяет изменения взводов и возвращает план перехода на новый учебный год
+// без записи в базу данных. Ошибки изменений перечисляются в плане.
+func (rs *RolloverService) Preview(ctx context.Context, rollover *model.Rollover) (*model.RolloverPlan, error) {
+	ctx, span := tracing.Start(ctx, "RolloverService.Preview")
+	defer span.End()
+	planner := &rolloverPlanner{
+		service: rs,
+		groups:  make(map[int]*model.Group),
+		roles:   make(map[int]string),
+		plan: &model.RolloverPlan{
+			Promotions: []model.PlannedPromotion{},
+			Merges:     []model.PlannedMerge{},
+			Archives:   []model.PlannedArchive{},
+		},
+	}
+	if err := planner.build(ctx, rollover); err != nil {
+		return nil, err
+	}
+	return planner.plan, nil
+}
+
+// Apply проверяет изменения взводов и, если ошибок нет, применяет их в одной транзакции.
+// Возвращается план перехода; если в нем есть ошибки, то ничего не изменяется.
+// Если взводы плана изменились до применения, то возвращается ошибка [errs.VersionMismatch].
+func (rs *RolloverService) Apply(ctx context.Context, rollover *model.Rollover) (*model.RolloverPlan, error) {
+	ctx, span := tracing.Start(ctx, "RolloverService.Apply")
+	defer span.End()
+	plan, err := rs.Preview(ctx, rollover)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.Errors) > 0 {
+		return plan, nil
+	}
+
+	var changed []model.Group
+	for _, promotion := range plan.Promotions {
+		changed = append(changed, promotion.Group)
+	}
+	for _, merge := range plan.Merges {
+		changed = append(changed, merge.Source)
+	}
+	for _, archive := range plan.Archives {
+		changed = append(changed, archive.Group)
+	}
+	// План проверен по взводам в этих версиях, поэтому применяется, только если они не изменились.
+	versions := make(map[int]int)
+	for _, group := range changed {
+		versions[group.ID] = group.Version
+	}
+	for _, merge := range plan.Merges {
+		versions[merge.Target.ID] = merge.Target.Version
+	}
+	applied, err := rs.groups.ApplyRollover(ctx, rollover, versions)
+	if err != nil {
+		return nil, fmt.Errorf("apply rollover: %w", err)
+	}
+	after := make(map[int]model.Group, len(applied.Groups))
+	for _, group := range applied.Groups {
+		after[group.ID] = group
+	}
+	for _, before := range changed {
+		recordAudit(ctx, rs.audit, model.AuditUpdate, auditGroup, before.ID, before, after[before.ID])
+	}
+	for _, userID := range applied.Deactivated {
+		recordAudit(ctx, rs.audit, model.AuditUpdate, auditUser, userID, deactivationAudit{false}, deactivationAudit{true})
+	}
+	return plan, nil
+}
+
+// deactivationAudit представляет отключение учетной записи студента при выпуске в журнале аудита.
+type deactivationAudit struct {
+	Deactivated bool `json:"deactivated"`
+}
+
+// rolloverPlanner хранит состояние построения плана перехода на новый учебный год.
+type rolloverPlanner struct {
+	service *RolloverService
+	groups  map[int]*model.Group // проверенные взводы по номеру
+	roles   map[int]string       // изменение, в котором участвует взвод, по номеру взвода
+	plan    *model.RolloverPlan
+}
+
+// build проверяет все изменения и заполняет план.
+func (rp *rolloverPlanner) build(ctx context.Context, rollover *model.Rollover) error {
+	for _, merge := range rollover.Merges {
+		source, sourceOK, err := rp.useGroup(ctx, merge.SourceID, "слияние")
+		if err != nil {
+			return err
+		}
+		target, targetOK, err := rp.group(ctx, merge.TargetID)
+		if err != nil {
+			return err
+		}
+		if !sourceOK || !targetOK {
+			continue
+		}
+		if source.ID == target.ID {
+			rp.addError("взвод %v нельзя слить сам с собой", source.Name)
+			continue
+		}
+		students, err := rp.students(ctx, source.ID)
+		if err != nil {
+			return err
+		}
+		rp.plan.Merges = append(rp.plan.Merges, model.PlannedMerge{Source: *source, Target: *target, Students: students})
+	}
+
+	for _, promotion := range rollover.Promotions {
+		group, ok, err := rp.useGroup(ctx, promotion.GroupID, "перевод")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if promotion.SpecialtyID != nil {
+			if _, err := rp.service.specialties.Get(ctx, *promotion.SpecialtyID); err != nil {
+				if !errors.Is(err, errs.NotFound) {
+					return fmt.Errorf("repo: get specialty %v: %w", *promotion.SpecialtyID, err)
+				}
+				rp.addError("специальность %v не найдена", *promotion.SpecialtyID)
+				continue
+			}
+		}
+		students, err := rp.students(ctx, group.ID)
+		if err != nil {
+			return err
+		}
+		rp.plan.Promotions = append(rp.plan.Promotions, model.PlannedPromotion{
+			Group:       *group,
+			NewName:     promotion.NewName,
+			SpecialtyID: promotion.SpecialtyID,
+			Students:    students,
+		})
+	}
+
+	for _, groupID := range rollover.Archives {
+		group, ok, err := rp.useGroup(ctx, groupID, "выпуск")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		students, err := rp.students(ctx, group.ID)
+		if err != nil {
+			return err
+		}
+		rp.plan.Archives = append(rp.plan.Archives, model.PlannedArchive{Group: *group, Students: students})
+	}
+
+	for _, merge := range rollover.Merges {
+		if role := rp.roles[merge.TargetID]; role != "" && role != "перевод" {
+			rp.addError("взвод %v не может принять студентов: он участвует в изменении «%v»", rp.groupName(merge.TargetID), role)
+		}
+	}
+	return rp.checkNames(ctx)
+}
+
+// useGroup возвращает взвод, участвующий в изменении role, и запоминает это.
+// Если взвод не найден, находится в архиве или уже участвует в другом изменении, то в план добавляется ошибка.
+func (rp *rolloverPlanner) useGroup(ctx context.Context, groupID int, role string) (*model.Group, bool, error) {
+	group, ok, err := rp.group(ctx, groupID)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	if previous, used := rp.roles[groupID]; used {
+		rp.addError("взвод %v участвует в нескольких изменениях: «%v» и «%v»", group.Name, previous, role)
+		return nil, false, nil
+	}
+	rp.roles[groupID] = role
+	return group, true, nil
+}
+
+// group возвращает взвод не из архива по номеру, запоминая результат.
+// Если взвод не найден или находится в архиве, то в план добавляется ошибка.
+func (rp *rolloverPlanner) group(ctx context.Context, groupID int) (*model.Group, bool, error) {
+	group, ok := rp.groups[groupID]
+	if !ok {
+		var err error
+		group, err = rp.service.groups.Get(ctx, groupID)
+		if err != nil && !errors.Is(err, errs.NotFound) {
+			return nil, false, fmt.Errorf("repo: get group %v: %w", groupID, err)
+		}
+		rp.groups[groupID] = group
+		switch {
+		case group == nil:
+			rp.addError("взвод %v не найден", groupID)
+		case group.ArchivedAt != nil:
+			rp.addError("взвод %v уже находится в архиве", group.Name)
+		}
+	}
+	if group == nil || group.ArchivedAt != nil {
+		return nil, false, nil
+	}
+	return group, true, nil
+}
+
+// groupName возвращает название проверенного взвода или его номер, если взвод не найден.
+func (rp *rolloverPlanner) groupName(groupID int) string {
+	if group := rp.groups[groupID]; group != nil {
+		return group.Name
+	}
+	return fmt.Sprint(groupID)
+}
+
+// students возвращает количество студентов взвода.
+func (rp *rolloverPlanner) students(ctx context.Context, groupID int) (int, error) {
+	users, err := rp.service.users.GetAll(ctx, &model.UserFilter{GroupID: &groupID})
+	if err != nil && !errors.Is(err, errs.Empty) {
+		return 0, fmt.Errorf("repo: get students of group %v: %w", groupID, err)
+	}
+	return len(users), nil
+}
+
+// checkNames проверяет, что после перехода названия взводов не из архива не повторяются.
+func (rp *rolloverPlanner) checkNames(ctx context.Context) error {
+	groups, err := rp.service.groups.GetAll(ctx, &model.GroupFilter{})
+	if err != nil && !errors.Is(err, errs.Empty) {
+		return fmt.Errorf("repo: get groups: %w", err)
+	}
+	names := make(map[int]string, len(groups))
+	for _, group := range groups {
+		names[group.ID] = group.Name
+	}
+	for _, merge := range rp.plan.Merges {
+		delete(names, merge.Source.ID)
+	}
+	for _, archive := range rp.plan.Archives {
+		delete(names, archive.Group.ID)
+	}
+	for _, promotion := range rp.plan.Promotions {
+		names[promotion.Group.ID] = promotion.NewName
+	}
+
+	owners := make(map[string]int, len(names))
+	for _, group := range groups {
+		name, ok := names[group.ID]
+		if !ok {
+			continue
+		}
+		key := strings.ToUpper(name)
+		if owner, taken := owners[key]; taken {
+			rp.addError("после перехода взводы %v и %v будут называться %v", rp.plannedName(owner, groups), group.Name, name)
+			continue
+		}
+		owners[key] = group.ID
+	}
+	return nil
+}
+
+// plannedName возвращает текущее название взвода по номеру из слайса groups.
+func (rp *rolloverPlanner) plannedName(groupID int, groups []model.Group) string {
+	for _, group := range groups {
+		if group.ID == groupID {
+			return group.Name
+		}
+	}
+	return fmt.Sprint(groupID)
+}
+
+// addError добавляет ошибку в план.
+func (rp *rolloverPlanner) addError(format string, args ...any) {
+	rp.plan.Errors = append(rp.plan.Errors, fmt.Sprintf(format, args...))
+}
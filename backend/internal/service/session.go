@@ -5,12 +5,17 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"log/slog"
 	"strconv"
 	"time"
 
 	"github.com/foreverd34d/aumsu-elib/internal/errs"
+	"github.com/foreverd34d/aumsu-elib/internal/logging"
+	"github.com/foreverd34d/aumsu-elib/internal/metrics"
 	"github.com/foreverd34d/aumsu-elib/internal/model"
+	"github.com/foreverd34d/aumsu-elib/internal/tracing"
 
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -18,8 +23,10 @@ import (
 // SessionRepo определяет методы хранилища токенов и сессий.
 type SessionRepo interface {
 	// Create создает новый токен обновления, записывает время начала сессии для пользователя
-	// и возвращает токен обновления с номером или ошибку.
-	Create(ctx context.Context, userID int, input *model.NewToken) (*model.Token, error)
+	// и возвращает токен обновления с номером или ошибку. Если keep больше нуля, то вместе с созданием
+	// незавершенные сессии пользователя, кроме keep самых новых, завершаются, а их токены обновления удаляются;
+	// номера завершенных сессий также возвращаются.
+	Create(ctx context.Context, userID int, input *model.NewToken, keep int) (*model.Token, []int, error)
 
 	// GetUserFromSession возвращает пользователя по номеру его сессии или ошибку.
 	GetUserFromSession(ctx context.Context, sessionID int) (*model.User, error)
@@ -28,27 +35,116 @@ type SessionRepo interface {
 	// Если токен не был найден, то возвращается ошибка [errs.NotFound].
 	PopByRefreshToken(ctx context.Context, refreshToken string) (*model.Token, error)
 
-	// UpdateRefreshToken создает новый токен обновления для сессии и возвращает токен с номером или ошибку.
+	// GetSession возвращает сессию по ее номеру или ошибку.
+	// Если сессия не найдена, то возвращается ошибка [errs.NotFound].
+	GetSession(ctx context.Context, sessionID int) (*model.Session, error)
+
+	// UpdateRefreshToken создает новый токен обновления для сессии, записывает время ее последнего использования
+	// и возвращает токен с номером или ошибку.
 	UpdateRefreshToken(ctx context.Context, sessionID int, update *model.NewToken) (*model.Token, error)
 
 	// EndSession записывает время окончания сессии и возвращает ошибку, если таковая есть.
 	EndSession(ctx context.Context, sessionID int) error
+
+	// CountActive возвращает количество незавершенных сессий, токен обновления которых
+	// действителен в момент now (в секундах unix), или ошибку.
+	CountActive(ctx context.Context, now int) (int, error)
+
+	// DeleteExpiredTokens удаляет токены обновления, истекшие к моменту now (в секундах unix),
+	// и возвращает их количество или ошибку.
+	DeleteExpiredTokens(ctx context.Context, now int) (int, error)
+
+	// EndStaleSessions завершает незавершенные сессии, у которых нет токена обновления, действительного
+	// в момент now (в секундах unix), которые последний раз использовались раньше момента usedBefore
+	// или начались раньше момента startedBefore. Токены завершенных сессий удаляются.
+	// Возвращается количество завершенных сессий или ошибка.
+	EndStaleSessions(ctx context.Context, now int, usedBefore, startedBefore time.Time) (int, error)
+}
+
+// TokenLifetimes представляет сроки действия токенов сессии.
+type TokenLifetimes struct {
+	AccessTTL  time.Duration // срок действия jwt токена доступа
+	RefreshTTL time.Duration // срок действия токена обновления
+}
+
+// SessionPolicy представляет ограничения сессий: сроки действия токенов, в том числе для отдельных ролей,
+// время простоя, максимальную длительность сессии и количество одновременных сессий пользователя.
+// Нулевые ограничения не действуют.
+type SessionPolicy struct {
+	TokenLifetimes                                   // сроки действия токенов по умолчанию
+	Roles          map[model.UserRole]TokenLifetimes // сроки действия токенов для ролей; нулевые сроки берутся по умолчанию
+	IdleTimeout    time.Duration                     // сессия завершается, если токены не обновлялись дольше этого времени
+	MaxAge         time.Duration                     // сессия завершается по истечении этого времени после входа, даже если токены обновлялись
+	MaxSessions    int                               // максимальное количество одновременных сессий пользователя; при входе сверх него завершаются самые старые
+}
+
+// DefaultSessionPolicy возвращает политику сессий по умолчанию:
+// 15 минут для jwt токена, 30 дней для токена обновления и без других ограничений.
+func DefaultSessionPolicy() SessionPolicy {
+	return SessionPolicy{
+		TokenLifetimes: TokenLifetimes{
+			AccessTTL:  15 * time.Minute,
+			RefreshTTL: 30 * 24 * time.Hour,
+		},
+	}
+}
+
+// lifetimes возвращает сроки действия токенов для роли role.
+func (sp SessionPolicy) lifetimes(role model.UserRole) TokenLifetimes {
+	lifetimes := sp.TokenLifetimes
+	if roleLifetimes, ok := sp.Roles[role]; ok {
+		if roleLifetimes.AccessTTL > 0 {
+			lifetimes.AccessTTL = roleLifetimes.AccessTTL
+		}
+		if roleLifetimes.RefreshTTL > 0 {
+			lifetimes.RefreshTTL = roleLifetimes.RefreshTTL
+		}
+	}
+	return lifetimes
+}
+
+// refreshExpiry возвращает время истечения токена обновления со сроком действия ttl, выданного в момент now
+// в сессии, начавшейся в момент loggedInAt. Токен не переживает максимальную длительность сессии.
+func (sp SessionPolicy) refreshExpiry(loggedInAt, now time.Time, ttl time.Duration) time.Time {
+	expiresAt := now.Add(ttl)
+	if sp.MaxAge > 0 && loggedInAt.Add(sp.MaxAge).Before(expiresAt) {
+		expiresAt = loggedInAt.Add(sp.MaxAge)
+	}
+	return expiresAt
+}
+
+// expiredReason возвращает причину, по которой сессия session истекла в момент now,
+// или пустую строку, если сессию можно продлить.
+func (sp SessionPolicy) expiredReason(session *model.Session, now time.Time) string {
+	switch {
+	case session.LoggedOutAt != nil:
+		return "ended"
+	case sp.MaxAge > 0 && now.Sub(session.LoggedInAt) >= sp.MaxAge:
+		return "max_age"
+	case sp.IdleTimeout > 0 && now.Sub(session.LastUsedAt) >= sp.IdleTimeout:
+		return "idle"
+	}
+	return ""
 }
 
 // SessionService реализует методы для работы с токенами и сессиями
 // и реализует интерфейс [handler.SessionService].
 type SessionService struct {
-	user UserRepo
-	session SessionRepo
+	user       UserRepo
+	session    SessionRepo
+	audit      AuditRepo
 	signingKey []byte
+	policy     SessionPolicy
 }
 
-// NewSessionService возвращает новый экземпляр [SessionService].
-func NewSessionService(user UserRepo, session SessionRepo, signingKey []byte) *SessionService {
+// NewSessionService возвращает новый экземпляр [SessionService] с политикой сессий policy.
+func NewSessionService(user UserRepo, session SessionRepo, audit AuditRepo, signingKey []byte, policy SessionPolicy) *SessionService {
 	return &SessionService{
-		user: user,
-		session: session,
+		user:       user,
+		session:    session,
+		audit:      audit,
 		signingKey: signingKey,
+		policy:     policy,
 	}
 }
 
@@ -56,6 +152,10 @@ func NewSessionService(user UserRepo, session SessionRepo, signingKey []byte) *S
 // Если имя пользователя не найдено или пароль не совпадает с сохраненным,
 // то возвращается ошибка [errs.InvalidLogin] или [errs.InvalidPassword].
 func (ss *SessionService) Create(ctx context.Context, credentials *model.Credentials) (jwt string, refreshToken *model.Token, err error) {
+	ctx, span := tracing.Start(ctx, "SessionService.Create")
+	defer span.End()
+	defer func() { logins.Inc(loginResult(err)) }()
+
 	dbCredentials, err := ss.user.GetCredentialsByLogin(ctx, credentials.Username)
 	if err != nil {
 		err = fmt.Errorf("get the user %s: %w", credentials.Username, err)
@@ -63,33 +163,67 @@ func (ss *SessionService) Create(ctx context.Context, credentials *model.Credent
 	}
 
 	if dbCredentials.PasswordHash != hashPassword(credentials.Password) {
+		slog.WarnContext(ctx, "login with invalid password", slog.Int("login_user_id", dbCredentials.UserID))
 		err = fmt.Errorf("create a session: %w", errs.InvalidPassword)
 		return
 	}
 
+	user, err := ss.user.GetByID(ctx, dbCredentials.UserID)
+	if err != nil {
+		err = fmt.Errorf("get the user %v: %w", dbCredentials.UserID, err)
+		return
+	}
+	if user.DeactivatedAt != nil {
+		slog.WarnContext(ctx, "login to deactivated account", slog.Int("login_user_id", user.ID))
+		err = fmt.Errorf("create a session for user %v: %w", user.ID, errs.Deactivated)
+		return
+	}
+
 	role, err := ss.user.GetRole(ctx, dbCredentials.UserID)
 	if err != nil {
 		err = fmt.Errorf("get user role: %w", err)
 		return
 	}
+	lifetimes := ss.policy.lifetimes(getRoleFromName(role))
 
-	refreshToken, err = ss.session.Create(ctx, dbCredentials.UserID, createNewToken())
+	now := time.Now()
+	refreshToken, evicted, err := ss.session.Create(ctx, dbCredentials.UserID, createNewToken(ss.policy.refreshExpiry(now, now, lifetimes.RefreshTTL)), ss.policy.MaxSessions)
 	if err != nil {
 		err = fmt.Errorf("create new session: %w", err)
 		return
 	}
 
-	jwt, err = createJWT(dbCredentials.UserID, role, ss.signingKey)
+	jwt, err = createJWT(dbCredentials.UserID, role, dbCredentials.MustChangePassword, ss.signingKey, lifetimes.AccessTTL)
 	if err != nil {
 		err = fmt.Errorf("create a jwt token: %w", err)
+		return
 	}
+
+	auditCtx := withActorUser(ctx, dbCredentials.UserID)
+	slog.InfoContext(ctx, "session created", slog.Int("session_id", refreshToken.SessionID), slog.Int("login_user_id", dbCredentials.UserID))
+	after := &model.Session{ID: refreshToken.SessionID, UserID: dbCredentials.UserID, LoggedInAt: now, LastUsedAt: now}
+	recordAudit(auditCtx, ss.audit, model.AuditCreate, auditSession, refreshToken.SessionID, nil, after)
+	ss.recordEvicted(auditCtx, dbCredentials.UserID, evicted)
 	return
 }
 
+// recordEvicted записывает в журнал завершение сессий evicted пользователя userID, которые при входе
+// превысили максимальное количество сессий. Выданные в этих сессиях jwt токены действуют до истечения своего срока.
+func (ss *SessionService) recordEvicted(ctx context.Context, userID int, evicted []int) {
+	for _, sessionID := range evicted {
+		slog.InfoContext(ctx, "session evicted", slog.Int("session_id", sessionID), slog.Int("login_user_id", userID))
+		recordAudit(ctx, ss.audit, model.AuditUpdate, auditSession, sessionID,
+			sessionEndAudit{}, sessionEndAudit{Ended: true, Reason: sessionEvicted})
+	}
+}
+
 // Update создает новую пару токенов по токену обновления. Сессия при этом не кончается,
 // а старый токен обновления становится невалидным.
-// Если токен обновления истек, то возвращается ошибка [errs.RefreshExpired].
+// Если токен обновления истек, сессия завершена, простаивала дольше допустимого
+// или превысила максимальную длительность, то возвращается ошибка [errs.RefreshExpired].
 func (ss *SessionService) Update(ctx context.Context, refreshToken string) (newjwt string, newRefreshToken *model.Token, err error) {
+	ctx, span := tracing.Start(ctx, "SessionService.Update")
+	defer span.End()
 	token, err := ss.session.PopByRefreshToken(ctx, refreshToken)
 	if err != nil {
 		err = fmt.Errorf("pop the refresh token %v: %w", refreshToken, err)
@@ -97,48 +231,160 @@ func (ss *SessionService) Update(ctx context.Context, refreshToken string) (newj
 	}
 
 	if token.ExpiresAt < int(time.Now().Unix()) {
-		ss.session.EndSession(ctx, token.SessionID)
+		ss.endSession(ctx, token.SessionID)
 		err = fmt.Errorf("update the session %v: %w", token.SessionID, errs.RefreshExpired)
 		return
 	}
 
-	newRefreshToken, err = ss.session.UpdateRefreshToken(ctx, token.SessionID, createNewToken())
+	session, err := ss.session.GetSession(ctx, token.SessionID)
 	if err != nil {
-		ss.session.EndSession(ctx, token.SessionID)
-		err = fmt.Errorf("update the refresh token %v: %w", token.ID, err)
+		err = fmt.Errorf("get the session %v: %w", token.SessionID, err)
+		return
+	}
+	now := time.Now()
+	if reason := ss.policy.expiredReason(session, now); reason != "" {
+		slog.InfoContext(ctx, "session expired", slog.Int("session_id", session.ID), slog.String("reason", reason))
+		ss.endSession(ctx, token.SessionID)
+		err = fmt.Errorf("update the session %v: %w", token.SessionID, errs.RefreshExpired)
 		return
 	}
 
 	user, err := ss.session.GetUserFromSession(ctx, token.SessionID)
 	if err != nil {
-		ss.session.EndSession(ctx, token.SessionID)
+		ss.endSession(ctx, token.SessionID)
 		err = fmt.Errorf("get user from session %v: %w", token.SessionID, err)
 		return
 	}
+	if user.DeactivatedAt != nil {
+		ss.endSession(ctx, token.SessionID)
+		err = fmt.Errorf("update the session %v: %w", token.SessionID, errs.Deactivated)
+		return
+	}
 
 	role, err := ss.user.GetRole(ctx, user.ID)
 	if err != nil {
-		ss.session.EndSession(ctx, token.SessionID)
+		ss.endSession(ctx, token.SessionID)
 		err = fmt.Errorf("get a role for user %v: %w", user.ID, err)
 		return
 	}
 
-	newjwt, err = createJWT(user.ID, role, ss.signingKey)
+	lifetimes := ss.policy.lifetimes(getRoleFromName(role))
+
+	credentials, err := ss.user.GetCredentialsByUserID(ctx, user.ID)
+	if err != nil {
+		ss.endSession(ctx, token.SessionID)
+		err = fmt.Errorf("get credentials of user %v: %w", user.ID, err)
+		return
+	}
+
+	newRefreshToken, err = ss.session.UpdateRefreshToken(ctx, token.SessionID, createNewToken(ss.policy.refreshExpiry(session.LoggedInAt, now, lifetimes.RefreshTTL)))
 	if err != nil {
-		ss.session.EndSession(ctx, token.SessionID)
+		ss.endSession(ctx, token.SessionID)
+		err = fmt.Errorf("update the refresh token %v: %w", token.ID, err)
+		return
+	}
+
+	newjwt, err = createJWT(user.ID, role, credentials.MustChangePassword, ss.signingKey, lifetimes.AccessTTL)
+	if err != nil {
+		ss.endSession(ctx, token.SessionID)
 		err = fmt.Errorf("create the JWT with userID %v: %w", user.ID, err)
+		return
 	}
 	return
 }
 
 // Delete делает токен обновления невалидным и записывает время окончания сессии.
 func (ss *SessionService) Delete(ctx context.Context, refreshToken string) error {
-	token, _ := ss.session.PopByRefreshToken(ctx, refreshToken)
-	err := ss.session.EndSession(ctx, token.SessionID)
+	ctx, span := tracing.Start(ctx, "SessionService.Delete")
+	defer span.End()
+	token, err := ss.session.PopByRefreshToken(ctx, refreshToken)
+	if err != nil {
+		return fmt.Errorf("pop the refresh token %v: %w", refreshToken, err)
+	}
+	err = ss.session.EndSession(ctx, token.SessionID)
 	if err != nil {
 		return fmt.Errorf("end the session %v: %w", token.SessionID, err)
 	}
-	return err
+	auditCtx := ctx
+	if user, err := ss.session.GetUserFromSession(ctx, token.SessionID); err == nil {
+		auditCtx = withActorUser(ctx, user.ID)
+	}
+	recordAudit(auditCtx, ss.audit, model.AuditUpdate, auditSession, token.SessionID,
+		sessionEndAudit{}, sessionEndAudit{Ended: true, Reason: sessionLoggedOut})
+	return nil
+}
+
+// Причины завершения сессии в журнале аудита.
+const (
+	sessionLoggedOut = "logout"  // пользователь вышел из системы
+	sessionEvicted   = "evicted" // при входе превышено максимальное количество сессий пользователя
+)
+
+// sessionEndAudit представляет завершение сессии в журнале аудита. Обновление токенов сессии
+// в журнал не записывается: время последнего использования хранится в самой сессии.
+type sessionEndAudit struct {
+	Ended  bool   `json:"ended"`            // сессия завершена
+	Reason string `json:"reason,omitempty"` // причина завершения
+}
+
+// DeleteExpiredTokens удаляет истекшие токены обновления и возвращает их количество или ошибку.
+func (ss *SessionService) DeleteExpiredTokens(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "SessionService.DeleteExpiredTokens")
+	defer span.End()
+	deleted, err := ss.session.DeleteExpiredTokens(ctx, int(time.Now().Unix()))
+	if err != nil {
+		return 0, fmt.Errorf("delete expired tokens: %w", err)
+	}
+	return deleted, nil
+}
+
+// EndStaleSessions завершает сессии, которые уже нельзя продлить: без действительного токена обновления,
+// а также простаивающие и превысившие максимальную длительность по политике сессий.
+// Возвращает количество завершенных сессий или ошибку.
+func (ss *SessionService) EndStaleSessions(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "SessionService.EndStaleSessions")
+	defer span.End()
+	now := time.Now()
+	// Нулевое время означает, что ограничение не действует: раньше него сессии не начинаются.
+	var usedBefore, startedBefore time.Time
+	if ss.policy.IdleTimeout > 0 {
+		usedBefore = now.Add(-ss.policy.IdleTimeout)
+	}
+	if ss.policy.MaxAge > 0 {
+		startedBefore = now.Add(-ss.policy.MaxAge)
+	}
+	ended, err := ss.session.EndStaleSessions(ctx, int(now.Unix()), usedBefore, startedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("end stale sessions: %w", err)
+	}
+	return ended, nil
+}
+
+// logins считает попытки входа по их результату.
+var logins = metrics.Default.NewCounter("elib_logins_total", "Total number of login attempts by result.", "result")
+
+// loginResult возвращает результат попытки входа, завершившейся ошибкой err, для метрики [logins].
+func loginResult(err error) string {
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, errs.InvalidLogin):
+		return "invalid_login"
+	case errors.Is(err, errs.InvalidPassword):
+		return "invalid_password"
+	case errors.Is(err, errs.Deactivated):
+		return "deactivated"
+	default:
+		return "error"
+	}
+}
+
+// endSession завершает сессию sessionID, которую не удалось продлить.
+// Ошибка завершения только записывается в журнал, так как клиенту возвращается исходная ошибка.
+func (ss *SessionService) endSession(ctx context.Context, sessionID int) {
+	if err := ss.session.EndSession(ctx, sessionID); err != nil {
+		slog.LogAttrs(ctx, slog.LevelWarn, "couldn't end session", slog.Int("session_id", sessionID), logging.Err(err))
+	}
 }
 
 // getRoleFromName возвращает роль исходя из ее названия.
@@ -164,26 +410,28 @@ func hashPassword(password string) string {
 	return hex.EncodeToString(hasher.Sum(nil))
 }
 
-// createJWT создает новый jwt токен пользователя с его ролью
+// createJWT создает новый jwt токен пользователя с его ролью и сроком действия ttl
 // и подписывает его при помощи переданого ключа алгоритмом sha-256.
-func createJWT(userID int, roleName string, signingKey []byte) (string, error) {
+// Если пароль пользователя одноразовый (mustChangePassword), то токен действует только для его смены.
+func createJWT(userID int, roleName string, mustChangePassword bool, signingKey []byte, ttl time.Duration) (string, error) {
 	role := getRoleFromName(roleName)
 	claims := &model.JWTClaims{
-		Role: role,
+		Role:               role,
+		MustChangePassword: mustChangePassword,
 		RegisteredClaims: jwt.RegisteredClaims{
-			Subject: strconv.Itoa(userID),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
+			Subject:   strconv.Itoa(userID),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
 		},
 	}
 
 	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(signingKey)
 }
 
-// createNewToken создает новый токен обновления со сроком действия в месяц.
-func createNewToken() *model.NewToken {
+// createNewToken создает новый токен обновления, истекающий в момент expiresAt.
+func createNewToken(expiresAt time.Time) *model.NewToken {
 	return &model.NewToken{
 		RefreshToken: generateRefreshToken(),
-		ExpiresAt: int(time.Now().Add(30 * 24 * time.Hour).Unix()),
+		ExpiresAt:    int(expiresAt.Unix()),
 	}
 }
 
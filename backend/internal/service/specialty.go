@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/foreverd34d/aumsu-elib/internal/model"
+	"github.com/foreverd34d/aumsu-elib/internal/tracing"
 )
 
 // SpecialtyRepo определяет методы хранилища специальностей.
@@ -22,36 +23,49 @@ type SpecialtyRepo interface {
 
 	// Update обновляет специальность по номеру и возвращает ее с номером или ошибку.
 	// Если специальность с таким номером не нашлась, то возращается ошибка [errs.NotFound].
-	Update(ctx context.Context, ID int, update *model.NewSpecialty) (*model.Specialty, error)
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Update(ctx context.Context, ID, version int, update *model.NewSpecialty) (*model.Specialty, error)
 
-	// Delete удаляет специальность по номеру и возвращает ошибку, если удаления не произошло.
+	// Patch изменяет указанные в патче поля специальности по номеру и возвращает ее с номером или ошибку.
 	// Если специальность с таким номером не нашлась, то возращается ошибка [errs.NotFound].
-	Delete(ctx context.Context, ID int) error
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Patch(ctx context.Context, ID, version int, patch *model.SpecialtyPatch) (*model.Specialty, error)
+
+	// Delete удаляет специальность по номеру в корзину и возвращает ошибку, если удаления не произошло.
+	// Если специальность с таким номером не нашлась, то возращается ошибка [errs.NotFound].
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Delete(ctx context.Context, ID, version int) error
 }
 
 // SpecialtyService реализует методы для работы со специальностями
 // и реализует интерфейс [handler.SpecialtyService].
 type SpecialtyService struct {
-	repo SpecialtyRepo
+	repo  SpecialtyRepo
+	audit AuditRepo
 }
 
 // NewSpecialtyService возвращает новый экземпляр [SpecialtyService].
-func NewSpecialtyService(repo SpecialtyRepo) *SpecialtyService {
-	return &SpecialtyService{repo}
+func NewSpecialtyService(repo SpecialtyRepo, audit AuditRepo) *SpecialtyService {
+	return &SpecialtyService{repo, audit}
 }
 
 // Create создает новую специальность и возвращает ее с номером или ошибку.
 func (ss *SpecialtyService) Create(ctx context.Context, input *model.NewSpecialty) (*model.Specialty, error) {
+	ctx, span := tracing.Start(ctx, "SpecialtyService.Create")
+	defer span.End()
 	specialty, err := ss.repo.Create(ctx, input)
 	if err != nil {
 		return nil, fmt.Errorf("create new specialty: %w", err)
 	}
+	recordAudit(ctx, ss.audit, model.AuditCreate, auditSpecialty, specialty.ID, nil, specialty)
 	return specialty, nil
 }
 
 // GetAll возвращает слайс всех специальностей или ошибку.
 // Если специальностей нет, то возвращается ошибка [errs.Empty].
 func (ss *SpecialtyService) GetAll(ctx context.Context) ([]model.Specialty, error) {
+	ctx, span := tracing.Start(ctx, "SpecialtyService.GetAll")
+	defer span.End()
 	specialties, err := ss.repo.GetAll(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("get all specialties: %w", err)
@@ -62,6 +76,8 @@ func (ss *SpecialtyService) GetAll(ctx context.Context) ([]model.Specialty, erro
 // Get возвращает специальность по номеру или ошибку.
 // Если специальность с таким номером не нашлась, то возвращается ошибка [errs.NotFound].
 func (ss *SpecialtyService) Get(ctx context.Context, ID int) (*model.Specialty, error) {
+	ctx, span := tracing.Start(ctx, "SpecialtyService.Get")
+	defer span.End()
 	specialty, err := ss.repo.Get(ctx, ID)
 	if err != nil {
 		return nil, fmt.Errorf("get specialty with ID %v: %w", ID, err)
@@ -71,19 +87,53 @@ func (ss *SpecialtyService) Get(ctx context.Context, ID int) (*model.Specialty,
 
 // Update обновляет специальность по номеру и возвращает ее с номером или ошибку.
 // Если специальность с таким номером не нашлась, то возращается ошибка [errs.NotFound].
-func (ss *SpecialtyService) Update(ctx context.Context, ID int, update *model.NewSpecialty) (*model.Specialty, error) {
-	specialty, err := ss.repo.Update(ctx, ID, update)
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (ss *SpecialtyService) Update(ctx context.Context, ID, version int, update *model.NewSpecialty) (*model.Specialty, error) {
+	ctx, span := tracing.Start(ctx, "SpecialtyService.Update")
+	defer span.End()
+	before, err := ss.repo.Get(ctx, ID)
+	if err != nil {
+		return nil, fmt.Errorf("get the specialty with ID %v: %w", ID, err)
+	}
+	specialty, err := ss.repo.Update(ctx, ID, version, update)
 	if err != nil {
 		return nil, fmt.Errorf("update the specialty with ID %v: %w", ID, err)
 	}
+	recordAudit(ctx, ss.audit, model.AuditUpdate, auditSpecialty, ID, before, specialty)
+	return specialty, nil
+}
+
+// Patch изменяет указанные в патче поля специальности по номеру и возвращает ее с номером или ошибку.
+// Если специальность с таким номером не нашлась, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (ss *SpecialtyService) Patch(ctx context.Context, ID, version int, patch *model.SpecialtyPatch) (*model.Specialty, error) {
+	ctx, span := tracing.Start(ctx, "SpecialtyService.Patch")
+	defer span.End()
+	before, err := ss.repo.Get(ctx, ID)
+	if err != nil {
+		return nil, fmt.Errorf("get the specialty with ID %v: %w", ID, err)
+	}
+	specialty, err := ss.repo.Patch(ctx, ID, version, patch)
+	if err != nil {
+		return nil, fmt.Errorf("patch the specialty with ID %v: %w", ID, err)
+	}
+	recordAudit(ctx, ss.audit, model.AuditUpdate, auditSpecialty, ID, before, specialty)
 	return specialty, nil
 }
 
-// Delete удаляет специальность по номеру и возвращает ошибку, если удаления не произошло.
+// Delete удаляет специальность по номеру в корзину и возвращает ошибку, если удаления не произошло.
 // Если специальность с таким номером не нашлась, то возращается ошибка [errs.NotFound].
-func (ss *SpecialtyService) Delete(ctx context.Context, ID int) error {
-	if err := ss.repo.Delete(ctx, ID); err != nil {
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (ss *SpecialtyService) Delete(ctx context.Context, ID, version int) error {
+	ctx, span := tracing.Start(ctx, "SpecialtyService.Delete")
+	defer span.End()
+	before, err := ss.repo.Get(ctx, ID)
+	if err != nil {
+		return fmt.Errorf("get the specialty with ID %v: %w", ID, err)
+	}
+	if err := ss.repo.Delete(ctx, ID, version); err != nil {
 		return fmt.Errorf("delete the specialty with ID %v: %w", ID, err)
 	}
+	recordAudit(ctx, ss.audit, model.AuditDelete, auditSpecialty, ID, before, nil)
 	return nil
 }
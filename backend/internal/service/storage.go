@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"github.com/foreverd34d/aumsu-elib/internal/logging"
+)
+
+// FileStorage определяет методы файлового хранилища.
+type FileStorage interface {
+	// Save сохраняет содержимое r в файл с именем name, перезаписывая его, если он существует.
+	Save(ctx context.Context, name string, r io.Reader) error
+
+	// Open открывает файл с именем name для чтения.
+	// Если файл не существует, то возвращается ошибка [errs.NotFound].
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+
+	// Delete удаляет файл с именем name. Отсутствие файла ошибкой не считается.
+	Delete(ctx context.Context, name string) error
+}
+
+// removeFile удаляет файл с именем name, который больше не нужен, из хранилища storage.
+// Ошибка удаления только записывается в журнал, так как запрос уже выполнен.
+func removeFile(ctx context.Context, storage FileStorage, name string) {
+	if err := storage.Delete(ctx, name); err != nil {
+		slog.LogAttrs(ctx, slog.LevelWarn, "couldn't delete unused file", slog.String("path", name), logging.Err(err))
+	}
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/foreverd34d/aumsu-elib/internal/model"
+	"github.com/foreverd34d/aumsu-elib/internal/tracing"
+)
+
+// TrashRepo определяет методы хранилища записей в корзине.
+type TrashRepo interface {
+	// GetAll возвращает слайс всех записей в корзине или ошибку.
+	// Записи упорядочены от удаленных последними к удаленным первыми.
+	GetAll(ctx context.Context) ([]model.TrashItem, error)
+
+	// Restore возвращает запись сущности entity с номером ID из корзины.
+	// Если записи нет в корзине или сущность не удаляется в корзину, то возвращается ошибка [errs.NotFound].
+	// Если запись ссылается на запись, которая сама находится в корзине, то возвращается ошибка [errs.InvalidReference].
+	Restore(ctx context.Context, entity string, ID int) error
+
+	// Purge окончательно удаляет записи, попавшие в корзину раньше момента before, и возвращает их количество или ошибку.
+	// Записи, на которые еще ссылаются другие записи, остаются в корзине до удаления ссылающихся записей.
+	Purge(ctx context.Context, before time.Time) (int, error)
+}
+
+// TrashService реализует методы для работы с корзиной удаленных записей
+// и реализует интерфейс [handler.TrashService].
+type TrashService struct {
+	repo      TrashRepo
+	audit     AuditRepo
+	retention time.Duration
+}
+
+// NewTrashService возвращает новый экземпляр [TrashService].
+// Записи хранятся в корзине в течение retention, после чего удаляются окончательно при вызове [TrashService.Purge].
+func NewTrashService(repo TrashRepo, audit AuditRepo, retention time.Duration) *TrashService {
+	return &TrashService{
+		repo:      repo,
+		audit:     audit,
+		retention: retention,
+	}
+}
+
+// trashAudit представляет нахождение записи в корзине в журнале аудита.
+type trashAudit struct {
+	Deleted bool `json:"deleted"`
+}
+
+// GetAll возвращает слайс всех записей в корзине или ошибку.
+func (ts *TrashService) GetAll(ctx context.Context) ([]model.TrashItem, error) {
+	ctx, span := tracing.Start(ctx, "TrashService.GetAll")
+	defer span.End()
+	items, err := ts.repo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("repo: get trash: %w", err)
+	}
+	return items, nil
+}
+
+// Restore возвращает запись сущности entity с номером ID из корзины.
+// Если записи нет в корзине, то возвращается ошибка [errs.NotFound].
+// Если запись ссылается на запись, которая сама находится в корзине, то возвращается ошибка [errs.InvalidReference].
+func (ts *TrashService) Restore(ctx context.Context, entity string, ID int) error {
+	ctx, span := tracing.Start(ctx, "TrashService.Restore")
+	defer span.End()
+	if err := ts.repo.Restore(ctx, entity, ID); err != nil {
+		return fmt.Errorf("repo: restore %v with ID %v: %w", entity, ID, err)
+	}
+	recordAudit(ctx, ts.audit, model.AuditUpdate, entity, ID, trashAudit{true}, trashAudit{false})
+	return nil
+}
+
+// Purge окончательно удаляет записи, которые находятся в корзине дольше срока хранения,
+// и возвращает их количество или ошибку.
+func (ts *TrashService) Purge(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "TrashService.Purge")
+	defer span.End()
+	purged, err := ts.repo.Purge(ctx, time.Now().Add(-ts.retention))
+	if err != nil {
+		return 0, fmt.Errorf("repo: purge trash: %w", err)
+	}
+	if purged > 0 {
+		slog.InfoContext(ctx, "trash purged", slog.Int("purged", purged))
+	}
+	return purged, nil
+}
@@ -5,17 +5,22 @@ import (
 	"fmt"
 
 	"github.com/foreverd34d/aumsu-elib/internal/model"
+	"github.com/foreverd34d/aumsu-elib/internal/tracing"
 )
 
-
 // UserRepo определяет методы хранилища пользователей и данными для их входа.
 type UserRepo interface {
 	// Create создает нового пользователя и его данные для входа и возвращает пользователя с номером или ошибку.
 	Create(ctx context.Context, input *model.NewUser) (*model.User, error)
 
-	// GetAll возвращает слайс всех пользователей или ошибку.
+	// CreateBatch создает нескольких пользователей и их данные для входа в одной транзакции
+	// и возвращает пользователей с номерами в том же порядке или ошибку.
+	// Если создать не удалось хотя бы одного пользователя, то не создается ни один.
+	CreateBatch(ctx context.Context, inputs []model.NewUser) ([]model.User, error)
+
+	// GetAll возвращает слайс пользователей, удовлетворяющих фильтру, или ошибку.
 	// Если база данных пуста, то возвращается ошибка [errs.Empty].
-	GetAll(ctx context.Context) ([]model.User, error)
+	GetAll(ctx context.Context, filter *model.UserFilter) ([]model.User, error)
 
 	// GetByID возвращает пользователя по номеру или ошибку.
 	// Если пользователь с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
@@ -25,46 +30,92 @@ type UserRepo interface {
 	// Если пользователь с таким логином не нашелся, то возвращается ошибка [errs.InvalidLogin].
 	GetCredentialsByLogin(ctx context.Context, login string) (*model.UserCredentials, error)
 
+	// LoginTaken сообщает, занят ли логин пользователем, в том числе пользователем в корзине, или возвращает ошибку.
+	LoginTaken(ctx context.Context, login string) (bool, error)
+
+	// GetCredentialsByUserID возвращает данные пользователя для входа по номеру пользователя или ошибку.
+	// Если пользователь с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
+	GetCredentialsByUserID(ctx context.Context, userID int) (*model.UserCredentials, error)
+
+	// UpdatePassword записывает новый хэш пароля пользователя и признак одноразового пароля.
+	// Если пользователь с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
+	UpdatePassword(ctx context.Context, userID int, passwordHash string, mustChange bool) error
+
+	// GetRoleByName возвращает роль по ее названию или ошибку.
+	// Если роль с таким названием не нашлась, то возвращается ошибка [errs.NotFound].
+	GetRoleByName(ctx context.Context, name string) (*model.Role, error)
+
 	// GetRole возвращает название роли пользователя или ошибку.
 	// Если пользователь с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
 	GetRole(ctx context.Context, ID int) (string, error)
 
 	// Update обновляет пользователя и его данные для входа по номеру и возвращает его или ошибку.
 	// Если пользователь с таким номером не нашелся, то возращается ошибка [errs.NotFound].
-	Update(ctx context.Context, ID int, update *model.NewUser) (*model.User, error)
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Update(ctx context.Context, ID, version int, update *model.NewUser) (*model.User, error)
+
+	// Patch изменяет указанные в патче поля пользователя и его данных для входа по номеру и возвращает его или ошибку.
+	// Если пользователь с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Patch(ctx context.Context, ID, version int, patch *model.UserPatch) (*model.User, error)
+
+	// Delete удаляет пользователя по номеру в корзину и возвращает ошибку, если удаления не произошло.
+	// Если пользователь с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+	// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+	Delete(ctx context.Context, ID, version int) error
+
+	// GetProfile возвращает профиль пользователя по номеру или ошибку.
+	// Если пользователь с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
+	GetProfile(ctx context.Context, ID int) (*model.Profile, error)
+
+	// UpdateProfile обновляет заполненные поля профиля пользователя по номеру и возвращает его или ошибку.
+	// Пустая строка удаляет значение поля.
+	// Если пользователь с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+	UpdateProfile(ctx context.Context, ID int, update *model.ProfileUpdate) (*model.User, error)
 
-	// Delete удаляет пользователя по номеру и возвращает ошибку, если удаления не произошло.
+	// UpdatePhoto записывает путь к фотографии профиля пользователя по номеру и возвращает его или ошибку.
+	// Если путь равен nil, то фотография удаляется из профиля.
 	// Если пользователь с таким номером не нашелся, то возращается ошибка [errs.NotFound].
-	Delete(ctx context.Context, ID int) error
+	UpdatePhoto(ctx context.Context, ID int, photoPath *string) (*model.User, error)
 }
 
 // UserService реализует методы для работы с пользователями и их данными для входа
 // и реализует интерфейс [handler.UserService].
 type UserService struct {
-	repo UserRepo
+	repo    UserRepo
+	audit   AuditRepo
+	storage FileStorage
 }
 
 // NewUserService возвращает новый экземпляр [UserService].
-func NewUserService(repo UserRepo) *UserService {
+// В storage хранятся фотографии профилей пользователей.
+func NewUserService(repo UserRepo, audit AuditRepo, storage FileStorage) *UserService {
 	return &UserService{
-		repo: repo,
+		repo:    repo,
+		audit:   audit,
+		storage: storage,
 	}
 }
 
 // Create создает нового пользователя и его данные для входа и возвращает пользователя с номером или ошибку.
 func (us *UserService) Create(ctx context.Context, input *model.NewUser) (*model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.Create")
+	defer span.End()
 	input.Password = hashPassword(input.Password)
 	user, err := us.repo.Create(ctx, input)
 	if err != nil {
 		return nil, fmt.Errorf("repo: create user: %w", err)
 	}
+	recordAudit(ctx, us.audit, model.AuditCreate, auditUser, user.ID, nil, user)
 	return user, nil
 }
 
-// GetAll возвращает слайс всех пользователей или ошибку.
+// GetAll возвращает слайс пользователей, удовлетворяющих фильтру, или ошибку.
 // Если пользователей нет, то возвращается ошибка [errs.Empty].
-func (us *UserService) GetAll(ctx context.Context) ([]model.User, error) {
-	users, err := us.repo.GetAll(ctx)
+func (us *UserService) GetAll(ctx context.Context, filter *model.UserFilter) ([]model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetAll")
+	defer span.End()
+	users, err := us.repo.GetAll(ctx, filter)
 	if err != nil {
 		return nil, fmt.Errorf("repo: get all users: %w", err)
 	}
@@ -74,6 +125,8 @@ func (us *UserService) GetAll(ctx context.Context) ([]model.User, error) {
 // Get возвращает пользователя по номеру или ошибку.
 // Если пользователь с таким номером не нашелся, то возвращается ошибка [errs.NotFound].
 func (us *UserService) Get(ctx context.Context, ID int) (*model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.Get")
+	defer span.End()
 	user, err := us.repo.GetByID(ctx, ID)
 	if err != nil {
 		return nil, fmt.Errorf("repo: get user with ID %v: %w", ID, err)
@@ -83,21 +136,58 @@ func (us *UserService) Get(ctx context.Context, ID int) (*model.User, error) {
 
 // Update обновляет пользователя и его данные для входа по номеру и возвращает его или ошибку.
 // Если пользователь с таким номером не нашелся, то возращается ошибка [errs.NotFound].
-func (us *UserService) Update(ctx context.Context, ID int, update *model.NewUser) (*model.User, error) {
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (us *UserService) Update(ctx context.Context, ID, version int, update *model.NewUser) (*model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.Update")
+	defer span.End()
+	before, err := us.repo.GetByID(ctx, ID)
+	if err != nil {
+		return nil, fmt.Errorf("repo: get user with ID %v: %w", ID, err)
+	}
 	update.Password = hashPassword(update.Password)
-	user, err := us.repo.Update(ctx, ID, update)
+	user, err := us.repo.Update(ctx, ID, version, update)
 	if err != nil {
 		return nil, fmt.Errorf("repo: update the user with ID %v: %w", ID, err)
 	}
+	recordAudit(ctx, us.audit, model.AuditUpdate, auditUser, ID, before, user)
 	return user, nil
 }
 
-// Delete удаляет пользователя по номеру и возвращает ошибку, если удаления не произошло.
+// Patch изменяет указанные в патче поля пользователя и его данных для входа по номеру и возвращает его или ошибку.
 // Если пользователь с таким номером не нашелся, то возращается ошибка [errs.NotFound].
-func (us *UserService) Delete(ctx context.Context, ID int) error {
-	err := us.repo.Delete(ctx, ID)
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (us *UserService) Patch(ctx context.Context, ID, version int, patch *model.UserPatch) (*model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.Patch")
+	defer span.End()
+	before, err := us.repo.GetByID(ctx, ID)
+	if err != nil {
+		return nil, fmt.Errorf("repo: get user with ID %v: %w", ID, err)
+	}
+	if patch.Password.Set {
+		patch.Password.Value = hashPassword(patch.Password.Value)
+	}
+	user, err := us.repo.Patch(ctx, ID, version, patch)
+	if err != nil {
+		return nil, fmt.Errorf("repo: patch the user with ID %v: %w", ID, err)
+	}
+	recordAudit(ctx, us.audit, model.AuditUpdate, auditUser, ID, before, user)
+	return user, nil
+}
+
+// Delete удаляет пользователя по номеру в корзину и возвращает ошибку, если удаления не произошло.
+// Если пользователь с таким номером не нашелся, то возращается ошибка [errs.NotFound].
+// Если версия version отлична от 0 и не совпадает с текущей, то возвращается ошибка [errs.VersionMismatch].
+func (us *UserService) Delete(ctx context.Context, ID, version int) error {
+	ctx, span := tracing.Start(ctx, "UserService.Delete")
+	defer span.End()
+	before, err := us.repo.GetByID(ctx, ID)
+	if err != nil {
+		return fmt.Errorf("repo: get user with ID %v: %w", ID, err)
+	}
+	err = us.repo.Delete(ctx, ID, version)
 	if err != nil {
 		return fmt.Errorf("repo: delete the user with ID %v: %w", ID, err)
 	}
+	recordAudit(ctx, us.audit, model.AuditDelete, auditUser, ID, before, nil)
 	return nil
 }
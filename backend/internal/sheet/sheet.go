@@ -0,0 +1,148 @@
+// Пакет sheet предоставляет чтение и запись табличных файлов в форматах CSV и XLSX.
+package sheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Format представляет формат табличного файла.
+type Format string
+
+const (
+	CSV  Format = "csv"  // значения, разделенные запятыми или точками с запятой
+	XLSX Format = "xlsx" // книга Microsoft Excel
+)
+
+// ErrUnknownFormat возвращается при попытке прочитать или записать файл неизвестного формата.
+var ErrUnknownFormat = errors.New("unknown sheet format")
+
+// MIMEType возвращает тип содержимого файла данного формата.
+func (f Format) MIMEType() string {
+	switch f {
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// FormatFromName определяет формат файла по расширению его имени.
+// Если расширение не поддерживается, то возвращается ошибка [ErrUnknownFormat].
+func FormatFromName(name string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return CSV, nil
+	case ".xlsx":
+		return XLSX, nil
+	}
+	return "", fmt.Errorf("file %q: %w", name, ErrUnknownFormat)
+}
+
+// Read читает строки первого листа табличного файла.
+// Пробелы по краям значений удаляются, полностью пустые строки сохраняются,
+// чтобы номера строк совпадали с номерами в исходном файле.
+func Read(r io.Reader, format Format) ([][]string, error) {
+	var (
+		rows [][]string
+		err  error
+	)
+	switch format {
+	case CSV:
+		rows, err = readCSV(r)
+	case XLSX:
+		rows, err = readXLSX(r)
+	default:
+		return nil, fmt.Errorf("read %q: %w", format, ErrUnknownFormat)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		for i := range row {
+			row[i] = strings.TrimSpace(row[i])
+		}
+	}
+	return rows, nil
+}
+
+// Write записывает строки в табличный файл данного формата.
+func Write(w io.Writer, format Format, rows [][]string) error {
+	switch format {
+	case CSV:
+		writer := csv.NewWriter(w)
+		if err := writer.WriteAll(rows); err != nil {
+			return fmt.Errorf("write csv: %w", err)
+		}
+		return nil
+	case XLSX:
+		return writeXLSX(w, rows)
+	}
+	return fmt.Errorf("write %q: %w", format, ErrUnknownFormat)
+}
+
+// readCSV читает строки CSV файла. Разделитель (запятая или точка с запятой)
+// определяется по первой строке, так как Excel с русской локалью сохраняет CSV через точку с запятой.
+func readCSV(r io.Reader) ([][]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read csv: %w", err)
+	}
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	reader := csv.NewReader(bytes.NewReader(data))
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parse csv: %w", err)
+	}
+	return rows, nil
+}
+
+// readXLSX читает строки первого листа XLSX файла.
+func readXLSX(r io.Reader) ([][]string, error) {
+	book, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("open xlsx: %w", err)
+	}
+	defer book.Close()
+	rows, err := book.GetRows(book.GetSheetName(0))
+	if err != nil {
+		return nil, fmt.Errorf("read xlsx rows: %w", err)
+	}
+	return rows, nil
+}
+
+// writeXLSX записывает строки на первый лист новой XLSX книги.
+func writeXLSX(w io.Writer, rows [][]string) error {
+	book := excelize.NewFile()
+	defer book.Close()
+	sheetName := book.GetSheetName(0)
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return fmt.Errorf("xlsx cell name: %w", err)
+		}
+		values := make([]any, len(row))
+		for j, value := range row {
+			values[j] = value
+		}
+		if err := book.SetSheetRow(sheetName, cell, &values); err != nil {
+			return fmt.Errorf("write xlsx row %v: %w", i+1, err)
+		}
+	}
+	if err := book.Write(w); err != nil {
+		return fmt.Errorf("write xlsx: %w", err)
+	}
+	return nil
+}
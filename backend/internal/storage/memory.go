@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+)
+
+// Memory представляет файловое хранилище в памяти процесса.
+// Используется в тестах и при локальном запуске без диска.
+type Memory struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+// NewMemory возвращает новый экземпляр пустого [Memory].
+func NewMemory() *Memory {
+	return &Memory{files: make(map[string][]byte)}
+}
+
+// Save сохраняет содержимое r в файл с именем name, перезаписывая его, если он существует.
+func (m *Memory) Save(ctx context.Context, name string, r io.Reader) error {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("read file %v: %w: %w", name, errs.Internal, err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[name] = content
+	return nil
+}
+
+// Open открывает файл с именем name для чтения.
+// Если файл не существует, то возвращается ошибка [errs.NotFound].
+func (m *Memory) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	content, ok := m.files[name]
+	if !ok {
+		return nil, fmt.Errorf("open file %v: %w", name, errs.NotFound)
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+// Delete удаляет файл с именем name. Отсутствие файла ошибкой не считается.
+func (m *Memory) Delete(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.files, name)
+	return nil
+}
+
+// Len возвращает количество файлов в хранилище.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.files)
+}
+
+// Check проверяет доступность хранилища. Хранилище в памяти доступно всегда.
+func (m *Memory) Check(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,102 @@
+// Пакет storage предоставляет файловые хранилища для загружаемых пользователями файлов.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/foreverd34d/aumsu-elib/internal/errs"
+)
+
+// Disk представляет файловое хранилище в директории на локальном диске.
+// Имена файлов задаются относительно корневой директории хранилища.
+type Disk struct {
+	root string
+}
+
+// NewDisk создает корневую директорию хранилища, если она не существует,
+// и возвращает новый экземпляр [Disk] или ошибку.
+func NewDisk(dir string) (*Disk, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create storage dir %v: %w", dir, err)
+	}
+	return &Disk{dir}, nil
+}
+
+// path возвращает путь к файлу с именем name на диске.
+// Имена, выходящие за пределы корневой директории, считаются некорректными.
+func (d *Disk) path(name string) (string, error) {
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("invalid file name %q: %w", name, errs.Internal)
+	}
+	return filepath.Join(d.root, name), nil
+}
+
+// Save сохраняет содержимое r в файл с именем name, перезаписывая его, если он существует.
+func (d *Disk) Save(ctx context.Context, name string, r io.Reader) error {
+	path, err := d.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("create dir for %v: %w: %w", name, errs.Internal, err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create file %v: %w: %w", name, errs.Internal, err)
+	}
+	defer file.Close()
+	if _, err := io.Copy(file, r); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("write file %v: %w: %w", name, errs.Internal, err)
+	}
+	return nil
+}
+
+// Open открывает файл с именем name для чтения.
+// Если файл не существует, то возвращается ошибка [errs.NotFound].
+func (d *Disk) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	path, err := d.path(name)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		baseErr := errs.Internal
+		if errors.Is(err, fs.ErrNotExist) {
+			baseErr = errs.NotFound
+		}
+		return nil, fmt.Errorf("open file %v: %w: %w", name, baseErr, err)
+	}
+	return file, nil
+}
+
+// Delete удаляет файл с именем name. Отсутствие файла ошибкой не считается.
+func (d *Disk) Delete(ctx context.Context, name string) error {
+	path, err := d.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("remove file %v: %w: %w", name, errs.Internal, err)
+	}
+	return nil
+}
+
+// Check проверяет, что корневая директория хранилища существует и в нее можно записать файл.
+func (d *Disk) Check(ctx context.Context) error {
+	probe, err := os.CreateTemp(d.root, ".health-*")
+	if err != nil {
+		return fmt.Errorf("write to storage dir %v: %w", d.root, err)
+	}
+	probe.Close()
+	if err := os.Remove(probe.Name()); err != nil {
+		return fmt.Errorf("remove probe file %v: %w", probe.Name(), err)
+	}
+	return nil
+}
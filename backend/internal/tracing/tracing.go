@@ -0,0 +1,102 @@
+// Пакет tracing настраивает трассировку запросов OpenTelemetry.
+//
+// Функция [Setup] задает глобальные провайдер трассировки и пропагатор W3C Trace Context,
+// после чего хэндлеры, сервисы и репозитории начинают span функцией [Start].
+// Пока трассировка не настроена, span ничего не записывают.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName — название трассировщика приложения.
+const InstrumentationName = "github.com/foreverd34d/aumsu-elib"
+
+// Виды экспорта span.
+const (
+	ExporterNone   = "none"   // трассировка выключена
+	ExporterOTLP   = "otlp"   // экспорт по протоколу OTLP/HTTP
+	ExporterStdout = "stdout" // вывод в стандартный вывод для локальной отладки
+)
+
+// ErrUnknownExporter возвращается [Setup], если вид экспорта не поддерживается.
+var ErrUnknownExporter = errors.New("unknown trace exporter")
+
+// Config представляет параметры трассировки.
+type Config struct {
+	Exporter    string  // вид экспорта: none, otlp или stdout (пусто — none)
+	Endpoint    string  // адрес приемника OTLP; если пуст, то берется из переменных окружения OTEL_EXPORTER_OTLP_*
+	ServiceName string  // название сервиса в ресурсе span
+	SampleRatio float64 // доля записываемых трасс без родительского span (0 — все трассы)
+}
+
+// Setup задает глобальный пропагатор W3C Trace Context и провайдер трассировки с экспортом по cfg.
+// Возвращается функция, которая отправляет оставшиеся span и останавливает провайдер, или ошибка.
+// Если экспорт выключен, то пропагатор все равно задается, чтобы контекст трассировки передавался дальше.
+func Setup(ctx context.Context, cfg Config) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownExporter, cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create %v trace exporter: %w", cfg.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(cfg.ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("create trace resource: %w", err)
+	}
+	sampler := sdktrace.AlwaysSample()
+	if cfg.SampleRatio > 0 && cfg.SampleRatio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(cfg.SampleRatio)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Start начинает span с названием name, дочерний по отношению к span из контекста ctx,
+// и возвращает контекст с новым span. Span нужно завершить методом End.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(InstrumentationName).Start(ctx, name, opts...)
+}
+
+// RecordError записывает в span ошибку err и помечает span как завершившийся ошибкой.
+// Если err равна nil, то ничего не делается.
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}